@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -11,28 +12,54 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Set at build time through -ldflags "-X main.version=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildTime = "unknown"
+)
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Panic(err)
 	}
 
-	config.StartEnvironment()
+	if err := config.StartEnvironment(); err != nil {
+		log.Panic(err)
+	}
 
-	storage, err := storage.GetInstance()
+	logger, err := common.NewZapLogger()
 	if err != nil {
 		log.Panic(err)
 	}
 
-	if err := storage.Init(); err != nil {
-		log.Panic(err)
+	buildInfo := config.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
 	}
 
-	logger, err := common.NewZapLogger()
+	if config.Relay.Enabled() {
+		relayApp, err := api.NewRelayApp(os.Getenv("PORT"), logger, buildInfo, config.Relay)
+		if err != nil {
+			log.Panic(err)
+		}
+		relayApp.StartWorkers(context.Background())
+
+		log.Panic(relayApp.Listen())
+	}
+
+	storage, err := storage.GetInstance()
 	if err != nil {
 		log.Panic(err)
 	}
 
-	app := api.NewApp(os.Getenv("PORT"), storage, logger)
+	if err := storage.Init(); err != nil {
+		log.Panic(err)
+	}
+
+	app := api.NewApp(os.Getenv("PORT"), storage, logger, buildInfo)
+	app.StartWorkers(context.Background())
 
 	log.Panic(app.Listen())
 }
@@ -0,0 +1,45 @@
+package analytics
+
+import (
+	"time"
+)
+
+// Evaluation is one flag value served to one context. Its fields are flat so
+// batches load straight into a warehouse table.
+type Evaluation struct {
+	OrganizationID string `json:"organization_id"`
+	FeatureFlagID  string `json:"feature_flag_id"`
+	FeatureFlag    string `json:"feature_flag"`
+	Environment    string `json:"environment"`
+	ContextKey     string `json:"context_key"`
+	Value          string `json:"value"`
+	// RuleID is the rule that served Value, if any.
+	RuleID string `json:"rule_id,omitempty"`
+	// ShadowRevisionID is the revision the flag was evaluated with in shadow
+	// mode, if any, and ShadowValue the value it would have served instead of
+	// Value, through ShadowRuleID.
+	ShadowRevisionID string    `json:"shadow_revision_id,omitempty"`
+	ShadowValue      string    `json:"shadow_value,omitempty"`
+	ShadowRuleID     string    `json:"shadow_rule_id,omitempty"`
+	EvaluatedAt      time.Time `json:"evaluated_at"`
+}
+
+// AnalyticsSink receives every evaluation served. Record is called while the
+// request is being served, so it must not block on I/O.
+type AnalyticsSink interface {
+	Record(evaluation Evaluation)
+}
+
+// NopSink drops every evaluation. It is the sink when analytics are off.
+type NopSink struct{}
+
+func (NopSink) Record(Evaluation) {}
+
+// MultiSink records every evaluation to each of its sinks.
+type MultiSink []AnalyticsSink
+
+func (ms MultiSink) Record(evaluation Evaluation) {
+	for _, sink := range ms {
+		sink.Record(evaluation)
+	}
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// QueuedBatches is how many full batches can wait for the destination. When
+// it falls further behind, new batches are dropped instead of piling up in
+// memory or slowing down evaluations.
+const QueuedBatches = 8
+
+// FinalFlushTimeout bounds the flush of what's left when the sink stops.
+const FinalFlushTimeout = 10 * time.Second
+
+// BatchSink buffers evaluations and writes them to its destination as
+// newline-delimited JSON, once batchSize of them are pending or every
+// flushInterval, whichever comes first. Batches are only written while Run
+// is running.
+type BatchSink struct {
+	destination   Destination
+	logger        *zap.Logger
+	batchSize     int
+	flushInterval time.Duration
+	full          chan []Evaluation
+
+	mu      sync.Mutex
+	pending []Evaluation
+	dropped int
+}
+
+func NewBatchSink(
+	destination Destination,
+	logger *zap.Logger,
+	batchSize int,
+	flushInterval time.Duration,
+) *BatchSink {
+	return &BatchSink{
+		destination:   destination,
+		logger:        logger,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		full:          make(chan []Evaluation, QueuedBatches),
+		pending:       make([]Evaluation, 0, batchSize),
+	}
+}
+
+func (bs *BatchSink) Record(evaluation Evaluation) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.pending = append(bs.pending, evaluation)
+	if len(bs.pending) < bs.batchSize {
+		return
+	}
+
+	select {
+	case bs.full <- bs.pending:
+	default:
+		bs.dropped += len(bs.pending)
+	}
+	bs.pending = make([]Evaluation, 0, bs.batchSize)
+}
+
+// Run writes batches until ctx is done, then writes whatever is left.
+func (bs *BatchSink) Run(ctx context.Context) {
+	ticker := time.NewTicker(bs.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), FinalFlushTimeout)
+			defer cancel()
+
+			for {
+				select {
+				case batch := <-bs.full:
+					bs.write(flushCtx, batch)
+				default:
+					bs.write(flushCtx, bs.take())
+					return
+				}
+			}
+		case batch := <-bs.full:
+			bs.write(ctx, batch)
+		case <-ticker.C:
+			bs.write(ctx, bs.take())
+		}
+	}
+}
+
+// take empties the partial batch.
+func (bs *BatchSink) take() []Evaluation {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.dropped > 0 {
+		bs.logger.Warn("Analytics evaluations dropped",
+			zap.Int("evaluations", bs.dropped),
+		)
+		bs.dropped = 0
+	}
+
+	batch := bs.pending
+	bs.pending = make([]Evaluation, 0, bs.batchSize)
+
+	return batch
+}
+
+func (bs *BatchSink) write(ctx context.Context, batch []Evaluation) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, evaluation := range batch {
+		if err := encoder.Encode(evaluation); err != nil {
+			bs.logger.Error("Analytics error",
+				zap.String("cause", err.Error()),
+			)
+			return
+		}
+	}
+
+	if err := bs.destination.Write(ctx, body.Bytes()); err != nil {
+		bs.logger.Error("Analytics error",
+			zap.String("cause", err.Error()),
+			zap.Int("evaluations", len(batch)),
+		)
+	}
+}
@@ -0,0 +1,118 @@
+package analytics_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// memoryDestination keeps every batch written to it.
+type memoryDestination struct {
+	mu      sync.Mutex
+	batches [][]analytics.Evaluation
+}
+
+func (md *memoryDestination) Write(_ context.Context, batch []byte) error {
+	evaluations := make([]analytics.Evaluation, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(batch))
+	for scanner.Scan() {
+		var evaluation analytics.Evaluation
+		if err := json.Unmarshal(scanner.Bytes(), &evaluation); err != nil {
+			return err
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	md.batches = append(md.batches, evaluations)
+	return nil
+}
+
+func (md *memoryDestination) sizes() []int {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	sizes := make([]int, 0, len(md.batches))
+	for _, batch := range md.batches {
+		sizes = append(sizes, len(batch))
+	}
+
+	return sizes
+}
+
+func evaluation(flag string) analytics.Evaluation {
+	return analytics.Evaluation{
+		FeatureFlag: flag,
+		ContextKey:  "user-1",
+		Value:       "true",
+		EvaluatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBatchSinkWritesFullBatches(t *testing.T) {
+	destination := &memoryDestination{}
+	sink := analytics.NewBatchSink(destination, zap.NewNop(), 2, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sink.Run(ctx)
+		close(done)
+	}()
+
+	sink.Record(evaluation("checkout"))
+	sink.Record(evaluation("search"))
+	sink.Record(evaluation("invoices"))
+
+	assert.Eventually(t, func() bool {
+		return len(destination.sizes()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, []int{2, 1}, destination.sizes())
+	assert.Equal(t, evaluation("checkout"), destination.batches[0][0])
+	assert.Equal(t, evaluation("invoices"), destination.batches[1][0])
+}
+
+func TestBatchSinkFlushesOnInterval(t *testing.T) {
+	destination := &memoryDestination{}
+	sink := analytics.NewBatchSink(destination, zap.NewNop(), 100, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.Run(ctx)
+
+	sink.Record(evaluation("checkout"))
+
+	assert.Eventually(t, func() bool {
+		sizes := destination.sizes()
+		return len(sizes) == 1 && sizes[0] == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBatchSinkDropsBatchesWhenDestinationFallsBehind(t *testing.T) {
+	destination := &memoryDestination{}
+	sink := analytics.NewBatchSink(destination, zap.NewNop(), 1, time.Hour)
+
+	for index := 0; index < analytics.QueuedBatches+3; index++ {
+		sink.Record(evaluation("checkout"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sink.Run(ctx)
+
+	assert.Len(t, destination.sizes(), analytics.QueuedBatches)
+}
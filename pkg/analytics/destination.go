@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NDJSONContentType is the media type of the batches handed to destinations.
+const NDJSONContentType = "application/x-ndjson"
+
+var ErrDestinationStatus = errors.New("unexpected status from analytics destination")
+
+// Destination stores one batch of newline-delimited JSON evaluations.
+type Destination interface {
+	Write(ctx context.Context, batch []byte) error
+}
+
+// FileDestination writes every batch to its own file in dir, named after the
+// time it was flushed. Pointing dir at a bucket mount, or syncing it to one,
+// gives the warehouse an object storage prefix to load from.
+type FileDestination struct {
+	dir string
+}
+
+func NewFileDestination(dir string) *FileDestination {
+	return &FileDestination{dir: dir}
+}
+
+// Write goes through a temporary file so loaders never pick up half a batch.
+func (fd *FileDestination) Write(_ context.Context, batch []byte) error {
+	name := fmt.Sprintf("evaluations-%d.ndjson", time.Now().UTC().UnixNano())
+	temporary := filepath.Join(fd.dir, "."+name)
+	if err := os.WriteFile(temporary, batch, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, filepath.Join(fd.dir, name))
+}
+
+// HTTPDestination posts every batch to url, e.g. a Kafka REST proxy or a
+// warehouse's streaming ingestion endpoint.
+type HTTPDestination struct {
+	client *http.Client
+	url    string
+}
+
+func NewHTTPDestination(client *http.Client, url string) *HTTPDestination {
+	return &HTTPDestination{
+		client: client,
+		url:    url,
+	}
+}
+
+func (hd *HTTPDestination) Write(ctx context.Context, batch []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, hd.url, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", NDJSONContentType)
+
+	response, err := hd.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", ErrDestinationStatus, response.StatusCode)
+	}
+
+	return nil
+}
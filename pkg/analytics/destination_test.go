@@ -0,0 +1,66 @@
+package analytics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDestinationWritesOneFilePerBatch(t *testing.T) {
+	dir := t.TempDir()
+	destination := analytics.NewFileDestination(dir)
+
+	assert.NoError(t, destination.Write(context.Background(), []byte("{\"value\":\"true\"}\n")))
+	assert.NoError(t, destination.Write(context.Background(), []byte("{\"value\":\"false\"}\n")))
+
+	files, err := filepath.Glob(filepath.Join(dir, "evaluations-*.ndjson"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	hidden, err := filepath.Glob(filepath.Join(dir, ".*"))
+	assert.NoError(t, err)
+	assert.Empty(t, hidden)
+
+	body, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(body), "\n"))
+}
+
+func TestHTTPDestinationPostsBatches(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, analytics.NDJSONContentType, r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received = string(body)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	destination := analytics.NewHTTPDestination(server.Client(), server.URL)
+
+	assert.NoError(t, destination.Write(context.Background(), []byte("{\"value\":\"true\"}\n")))
+	assert.Equal(t, "{\"value\":\"true\"}\n", received)
+}
+
+func TestHTTPDestinationReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	destination := analytics.NewHTTPDestination(server.Client(), server.URL)
+
+	assert.ErrorIs(t, destination.Write(context.Background(), []byte("{}\n")), analytics.ErrDestinationStatus)
+}
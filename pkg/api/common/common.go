@@ -8,6 +8,8 @@ type AuthResponse struct {
 	FirstName string             `json:"first_name,omitempty" `
 	LastName  string             `json:"last_name,omitempty" `
 	Token     string             `json:"token"`
+	CreatedAt primitive.DateTime `json:"created_at,omitempty"`
+	UpdatedAt primitive.DateTime `json:"updated_at,omitempty"`
 }
 
 type Tuple[T comparable, U comparable] struct {
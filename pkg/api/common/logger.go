@@ -7,11 +7,9 @@ import (
 )
 
 func NewZapLogger() (*zap.Logger, error) {
-	var level zapcore.Level
-	if config.Environment == config.DevEnvironment || config.Environment == "" {
-		level = zap.DebugLevel
-	} else {
-		level = zap.InfoLevel
+	level, err := logLevel()
+	if err != nil {
+		return nil, err
 	}
 
 	config := zap.Config{
@@ -40,3 +38,15 @@ func NewZapLogger() (*zap.Logger, error) {
 
 	return logger, nil
 }
+
+func logLevel() (zapcore.Level, error) {
+	if config.LogLevel != "" {
+		return zapcore.ParseLevel(config.LogLevel)
+	}
+
+	if config.Environment == config.DevEnvironment || config.Environment == "" {
+		return zap.DebugLevel, nil
+	}
+
+	return zap.InfoLevel, nil
+}
@@ -1,6 +1,8 @@
 package apierrors
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -9,12 +11,66 @@ import (
 type ErrorMessage = string
 
 const (
-	NotFoundError       ErrorMessage = "record not found"
-	InternalServerError ErrorMessage = "internal server error"
-	EmailConflictError  ErrorMessage = "email already in use"
-	UnauthorizedError   ErrorMessage = "user lacks valid authentication credentials"
-	BadRequestError     ErrorMessage = "malformed request"
-	ForbiddenError      ErrorMessage = "forbidden action"
+	NotFoundError                 ErrorMessage = "record not found"
+	InternalServerError           ErrorMessage = "internal server error"
+	EmailConflictError            ErrorMessage = "email already in use"
+	UnauthorizedError             ErrorMessage = "user lacks valid authentication credentials"
+	BadRequestError               ErrorMessage = "malformed request"
+	ForbiddenError                ErrorMessage = "forbidden action"
+	FlagNameConflictError         ErrorMessage = "feature flag name already in use in this namespace"
+	EnvKeyConflictError           ErrorMessage = "feature flag name has the same env key as another flag"
+	PrerequisiteNotFoundError     ErrorMessage = "prerequisite feature flag not found"
+	FlagHasDependentsError        ErrorMessage = "feature flag is a prerequisite of other feature flags"
+	ReadOnlyModeError             ErrorMessage = "service is in read-only mode"
+	NoLiveRevisionError           ErrorMessage = "feature flag has no live revision"
+	RuleOrderMismatchError        ErrorMessage = "rule ids must list every rule of the live revision exactly once"
+	RuleNotFoundError             ErrorMessage = "rule not found in the live revision"
+	DuplicateRuleIDError          ErrorMessage = "rule ids must be unique within a revision"
+	InvalidContextKindError       ErrorMessage = "context kind must start with a lowercase letter followed by lowercase letters, digits, - or _"
+	FlagValueTypeError            ErrorMessage = "value doesn't match the feature flag type"
+	RevisionNotDraftError         ErrorMessage = "only draft revisions can be approved"
+	RevisionNotPreviewableError   ErrorMessage = "only draft revisions can be previewed"
+	RevisionNotDeletableError     ErrorMessage = "only draft revisions can be deleted"
+	ShadowRevisionNotDraftError   ErrorMessage = "only draft revisions can be shadowed"
+	RelayNotSyncedError           ErrorMessage = "relay hasn't synced flags from the central server yet"
+	RelayUserListError            ErrorMessage = "relay can't evaluate flags that target user lists, ask the central server"
+	ImpersonationForbiddenError   ErrorMessage = "account settings can't be changed while impersonating"
+	TokenScopeMismatchError       ErrorMessage = "token issuer or audience isn't accepted by this organization"
+	FlagQuotaExceededError        ErrorMessage = "organization reached its feature flag limit"
+	EnvironmentQuotaExceededError ErrorMessage = "organization reached its environment limit"
+	MemberQuotaExceededError      ErrorMessage = "organization reached its member limit"
+	LastAdminError                ErrorMessage = "organization must keep at least one admin"
+	InvalidTagError               ErrorMessage = "tags start with a letter or digit followed by letters, digits, _, ., : or -"
+	TooManyFlagsError             ErrorMessage = "too many feature flags for one request"
+	TooManyRulesError             ErrorMessage = "revision has more rules than allowed"
+	PredicateTooLongError         ErrorMessage = "rule predicate is longer than allowed"
+	ConditionTooDeepError         ErrorMessage = "rule condition is nested deeper than allowed"
+	InvalidConditionError         ErrorMessage = "rule needs a predicate or a condition with non-empty and/or groups"
+	JSONValueTooLargeError        ErrorMessage = "json flag value is bigger than allowed"
+	RequestTimeoutError           ErrorMessage = "request took longer than allowed"
+	EvaluationBatchTooLargeError  ErrorMessage = "evaluation batch has more contexts than allowed"
+	SelfApprovalForbiddenError    ErrorMessage = "organization requires revisions to be approved by someone other than their author"
+	PasswordReusedError           ErrorMessage = "password was used recently"
+	FlagValueNotAllowedError      ErrorMessage = "value isn't one of the values the feature flag allows"
+	FlagValueOutOfRangeError      ErrorMessage = "value is outside the range the feature flag allows"
+	InvalidValueConstraintsError  ErrorMessage = "value constraints don't fit the feature flag type"
+	InvalidNamePatternError       ErrorMessage = "name pattern isn't a valid regular expression"
+	FlagNamePatternError          ErrorMessage = "feature flag name doesn't match the template's name pattern"
+	AutomationPausedError         ErrorMessage = "organization's automation is already paused"
+	AutomationNotPausedError      ErrorMessage = "organization's automation isn't paused"
+	EnvironmentCycleError         ErrorMessage = "environment can't inherit from itself"
+	InvalidUserListError          ErrorMessage = "user list must be a csv file with one user id per line"
+	UserListTooLargeError         ErrorMessage = "user list has more user ids than allowed"
+	UserListNotFoundError         ErrorMessage = "rule targets a user list that doesn't exist"
+	UserListInUseError            ErrorMessage = "user list is targeted by feature flag rules or segment overrides"
+	SegmentNotFoundError          ErrorMessage = "segment override targets a user list that doesn't exist"
+	DuplicateSegmentPriorityError ErrorMessage = "segment overrides must have unique priorities"
+	InvalidUsageWindowError       ErrorMessage = "usage window must be one of 24h, 7d or 30d"
+	RateLimitExceededError        ErrorMessage = "rate limit exceeded, retry later"
+	FallbackChainTooLongError     ErrorMessage = "evaluation has more fallback environments than allowed"
+	InvalidObjectIDError          ErrorMessage = "id in the path must be a 24 character hex object id"
+	ForbiddenWebhookURLError      ErrorMessage = "webhook url must be http(s) and not loopback, private or link-local"
+	FlagChangedError              ErrorMessage = "feature flag changed while the request was handled, retry it"
 )
 
 type Error struct {
@@ -22,7 +78,16 @@ type Error struct {
 	Message ErrorMessage `json:"message"`
 }
 
+// CustomError writes message with httpStatus. A server error on a request
+// that ran out of time is most likely a query cut short by the deadline, so
+// it is reported as the timeout it is.
 func CustomError(c echo.Context, httpStatus int, message ErrorMessage) error {
+	if httpStatus == http.StatusInternalServerError &&
+		errors.Is(c.Request().Context().Err(), context.DeadlineExceeded) {
+		httpStatus = http.StatusServiceUnavailable
+		message = RequestTimeoutError
+	}
+
 	newError := Error{
 		Error:   http.StatusText(httpStatus),
 		Message: message,
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	readOnly *middlewares.ReadOnlyMode
+	logger   *zap.Logger
+}
+
+func NewAdminHandler(readOnly *middlewares.ReadOnlyMode, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		readOnly: readOnly,
+		logger:   logger,
+	}
+}
+
+type ReadOnlyRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type ReadOnlyResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (ah *AdminHandler) GetReadOnly(c echo.Context) error {
+	return c.JSON(http.StatusOK, ReadOnlyResponse{
+		Enabled: ah.readOnly.Enabled(),
+	})
+}
+
+func (ah *AdminHandler) PutReadOnly(c echo.Context) error {
+	request := new(ReadOnlyRequest)
+	if err := c.Bind(request); err != nil {
+		ah.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if request.Enabled == nil {
+		ah.logger.Debug("Client error",
+			zap.String("cause", "missing enabled field"),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	ah.readOnly.Set(*request.Enabled)
+	ah.logger.Info("Read-only mode changed",
+		zap.Bool("enabled", *request.Enabled),
+	)
+
+	return c.JSON(http.StatusOK, ReadOnlyResponse{
+		Enabled: ah.readOnly.Enabled(),
+	})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewAPIKeyHandler(db *mongo.Database, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// APIKeyRequest may set environment in DefaultContext to pick the environment
+// evaluations made with the key default to.
+type APIKeyRequest struct {
+	Name           string            `json:"name" validate:"required,max=100"`
+	DefaultContext map[string]string `json:"default_context" validate:"max=50"`
+}
+
+// APIKeyResponse is the only time the key itself is returned.
+type APIKeyResponse struct {
+	models.APIKeyRecord
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	Data []models.APIKeyRecord `json:"data"`
+}
+
+func (akh *APIKeyHandler) PostAPIKey(c echo.Context) error {
+	userID, organizationID, err := akh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	request := new(APIKeyRequest)
+	if err := c.Bind(request); err != nil {
+		akh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		akh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	record, key, err := models.NewAPIKeyRecord(organizationID, request.Name, request.DefaultContext)
+	if err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewAPIKeyModel(akh.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	akh.recordAudit(c, organizationID, userID, models.CreateAPIKeyAction, map[string]any{
+		"api_key_id": record.ID.Hex(),
+		"name":       record.Name,
+	})
+
+	return c.JSON(http.StatusCreated, APIKeyResponse{
+		APIKeyRecord: *record,
+		Key:          key,
+	})
+}
+
+func (akh *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	_, organizationID, err := akh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	model := models.NewAPIKeyModel(akh.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListAPIKeysResponse{Data: records})
+}
+
+func (akh *APIKeyHandler) DeleteAPIKey(c echo.Context) error {
+	userID, organizationID, err := akh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	apiKeyID := apiutils.ObjectIDParam(c, "apiKeyID")
+
+	model := models.NewAPIKeyModel(akh.db)
+	found, err := model.DeleteOne(c.Request().Context(), organizationID, apiKeyID)
+	if err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		akh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	akh.recordAudit(c, organizationID, userID, models.DeleteAPIKeyAction, map[string]any{
+		"api_key_id": apiKeyID.Hex(),
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorize returns the caller and the organization in the path when the
+// caller is one of its admins, as API keys can read every flag. The
+// organization id is zero, and the error response already written, when they
+// aren't.
+func (akh *APIKeyHandler) authorize(c echo.Context) (primitive.ObjectID, primitive.ObjectID, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		akh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+
+	organizationModel := models.NewOrganizationModel(akh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, primitive.NilObjectID, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Admin)
+	if !permission {
+		akh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, primitive.NilObjectID, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return userID, organizationID, nil
+}
+
+// recordAudit adds an entry about the organization's keys to its audit log.
+// The change already happened, so a failure is only logged.
+func (akh *APIKeyHandler) recordAudit(
+	c echo.Context,
+	organizationID,
+	userID primitive.ObjectID,
+	action models.AuditAction,
+	details map[string]any,
+) {
+	entry := models.NewAuditEntry(organizationID, primitive.NilObjectID, userID, action, details)
+	entry.ImpersonationID = apiutils.GetImpersonationIDFromContext(c)
+
+	model := models.NewAuditLogModel(akh.db)
+	if _, err := model.InsertOne(context.Background(), entry); err != nil {
+		akh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+			zap.String("action", action),
+		)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type AuditLogHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewAuditLogHandler(db *mongo.Database, logger *zap.Logger) *AuditLogHandler {
+	return &AuditLogHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type ListAuditLogResponse = PaginatedResponse[models.AuditEntry]
+
+// ListAuditLog pages through the organization's audit log, newest first. It
+// can be narrowed with ?action=, ?actor= (user id), ?flag= (feature flag id)
+// and an RFC 3339 ?from=/?to= range.
+func (alh *AuditLogHandler) ListAuditLog(c echo.Context) error {
+	page, limit := apiutils.GetPaginationParams(c.QueryParam("page"), c.QueryParam("page_size"))
+
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		alh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(alh.db)
+	organization, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		alh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organization, models.Admin)
+	if !permission {
+		alh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	filter, err := auditLogFilter(c)
+	if err != nil {
+		alh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	model := models.NewAuditLogModel(alh.db)
+	entries, err := model.FindMany(c.Request().Context(), organizationID, filter, page, limit)
+	if err != nil {
+		alh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	total, err := model.CountMany(c.Request().Context(), organizationID, filter)
+	if err != nil {
+		alh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, NewPaginatedResponse(entries, page, limit, total))
+}
+
+func auditLogFilter(c echo.Context) (bson.D, error) {
+	filter := bson.D{}
+	if action := c.QueryParam("action"); action != "" {
+		filter = append(filter, bson.E{Key: "action", Value: action})
+	}
+
+	if actor := c.QueryParam("actor"); actor != "" {
+		actorID, err := primitive.ObjectIDFromHex(actor)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{Key: "user_id", Value: actorID})
+	}
+
+	if flag := c.QueryParam("flag"); flag != "" {
+		featureFlagID, err := primitive.ObjectIDFromHex(flag)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{Key: "feature_flag_id", Value: featureFlagID})
+	}
+
+	createdAt := bson.M{}
+	if from := c.QueryParam("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, err
+		}
+		createdAt["$gte"] = primitive.NewDateTimeFromTime(fromTime)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, err
+		}
+		createdAt["$lte"] = primitive.NewDateTimeFromTime(toTime)
+	}
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	return filter, nil
+}
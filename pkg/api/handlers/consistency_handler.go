@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/consistency"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type ConsistencyHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewConsistencyHandler(db *mongo.Database, logger *zap.Logger) *ConsistencyHandler {
+	return &ConsistencyHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ConsistencyCheckRequest only reports problems unless Repair is set.
+type ConsistencyCheckRequest struct {
+	Repair bool `json:"repair"`
+}
+
+// ConsistencyReport lists every problem found among FeatureFlags flags, of
+// every organization. Repaired counts the flags saved with their repairable
+// problems fixed.
+type ConsistencyReport struct {
+	CheckedAt    time.Time             `json:"checked_at"`
+	FeatureFlags int                   `json:"feature_flags"`
+	Repaired     int                   `json:"repaired"`
+	Problems     []consistency.Problem `json:"problems"`
+}
+
+// PostConsistencyCheck scans every flag for documents that drifted from what
+// the code assumes, see package consistency, and optionally repairs them.
+// Each repair is recorded in the audit log of the flag's organization. A flag
+// changed while it was being checked isn't repaired; checking again picks it
+// up.
+func (ch *ConsistencyHandler) PostConsistencyCheck(c echo.Context) error {
+	request := new(ConsistencyCheckRequest)
+	if err := c.Bind(request); err != nil {
+		ch.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	ctx := c.Request().Context()
+	model := models.NewFeatureFlagModel(ch.db)
+	organizations, err := model.FindFlagOrganizations(ctx)
+	if err != nil {
+		ch.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	report := ConsistencyReport{
+		CheckedAt: time.Now().UTC(),
+		Problems:  make([]consistency.Problem, 0),
+	}
+	err = model.ForEach(ctx, func(featureFlag *models.FeatureFlagRecord) error {
+		report.FeatureFlags++
+		problems := consistency.Check(featureFlag, organizations)
+		if request.Repair && consistency.Repair(featureFlag, organizations, problems) {
+			repaired, err := ch.saveRepair(ctx, featureFlag, problems)
+			if err != nil {
+				return err
+			}
+			if repaired {
+				report.Repaired++
+			}
+		}
+
+		report.Problems = append(report.Problems, problems...)
+		return nil
+	})
+	if err != nil {
+		ch.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	ch.logger.Info("Consistency check",
+		zap.Int("feature_flags", report.FeatureFlags),
+		zap.Int("problems", len(report.Problems)),
+		zap.Int("repaired", report.Repaired),
+	)
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// saveRepair saves featureFlag along with an audit entry of its repaired
+// problems. When the flag changed since it was read, nothing is saved and
+// the problems are marked unrepaired again.
+func (ch *ConsistencyHandler) saveRepair(
+	ctx context.Context,
+	featureFlag *models.FeatureFlagRecord,
+	problems []consistency.Problem,
+) (bool, error) {
+	model := models.NewFeatureFlagModel(ch.db)
+	saved, err := model.SaveRepair(ctx, featureFlag)
+	if err != nil {
+		return false, err
+	}
+
+	repaired := make([]string, 0, len(problems))
+	for index := range problems {
+		if !problems[index].Repaired {
+			continue
+		}
+		if !saved {
+			problems[index].Repaired = false
+			continue
+		}
+		repaired = append(repaired, problems[index].Kind+": "+problems[index].Detail)
+	}
+	if !saved {
+		return false, nil
+	}
+
+	auditLogModel := models.NewAuditLogModel(ch.db)
+	_, err = auditLogModel.InsertOne(ctx, models.NewAuditEntry(
+		featureFlag.OrganizationID,
+		featureFlag.ID,
+		primitive.NilObjectID,
+		models.RepairAction,
+		map[string]any{"problems": repaired},
+	))
+
+	return true, err
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type ContextSampleSetHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewContextSampleSetHandler(db *mongo.Database, logger *zap.Logger) *ContextSampleSetHandler {
+	return &ContextSampleSetHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ContextSampleSetRequest caps the number of contexts so previews stay cheap to compute.
+type ContextSampleSetRequest struct {
+	Name     string              `json:"name" validate:"required,max=100"`
+	Contexts []map[string]string `json:"contexts" validate:"required,min=1,max=1000"`
+}
+
+type ListContextSampleSetsResponse struct {
+	Data []models.ContextSampleSetRecord `json:"data"`
+}
+
+func (cssh *ContextSampleSetHandler) PostContextSampleSet(c echo.Context) error {
+	organizationID, err := cssh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	request, err := cssh.bindRequest(c)
+	if request == nil {
+		return err
+	}
+
+	record := models.NewContextSampleSetRecord(organizationID, request.Name, request.Contexts)
+	model := models.NewContextSampleSetModel(cssh.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, record)
+}
+
+func (cssh *ContextSampleSetHandler) ListContextSampleSets(c echo.Context) error {
+	organizationID, err := cssh.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	model := models.NewContextSampleSetModel(cssh.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListContextSampleSetsResponse{Data: records})
+}
+
+func (cssh *ContextSampleSetHandler) GetContextSampleSet(c echo.Context) error {
+	organizationID, err := cssh.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	sampleSetID := apiutils.ObjectIDParam(c, "sampleSetID")
+
+	model := models.NewContextSampleSetModel(cssh.db)
+	record, err := model.FindByID(c.Request().Context(), organizationID, sampleSetID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			cssh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+// PutContextSampleSet replaces the name and every context of the sample set.
+func (cssh *ContextSampleSetHandler) PutContextSampleSet(c echo.Context) error {
+	organizationID, err := cssh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	sampleSetID := apiutils.ObjectIDParam(c, "sampleSetID")
+
+	request, err := cssh.bindRequest(c)
+	if request == nil {
+		return err
+	}
+
+	model := models.NewContextSampleSetModel(cssh.db)
+	found, err := model.ReplaceContexts(
+		c.Request().Context(),
+		organizationID,
+		sampleSetID,
+		request.Name,
+		request.Contexts,
+	)
+	if err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	record, err := model.FindByID(c.Request().Context(), organizationID, sampleSetID)
+	if err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+func (cssh *ContextSampleSetHandler) DeleteContextSampleSet(c echo.Context) error {
+	organizationID, err := cssh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	sampleSetID := apiutils.ObjectIDParam(c, "sampleSetID")
+
+	model := models.NewContextSampleSetModel(cssh.db)
+	found, err := model.DeleteOne(c.Request().Context(), organizationID, sampleSetID)
+	if err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorize returns the organization in the path when the caller has at
+// least permissionLevel in it. The id is zero, and the error response already
+// written, when they don't.
+func (cssh *ContextSampleSetHandler) authorize(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, err
+	}
+
+	organizationModel := models.NewOrganizationModel(cssh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		cssh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, permissionLevel)
+	if !permission {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return organizationID, nil
+}
+
+// bindRequest returns nil, with the error response already written, when
+// the body isn't a valid sample set.
+func (cssh *ContextSampleSetHandler) bindRequest(c echo.Context) (*ContextSampleSetRequest, error) {
+	request := new(ContextSampleSetRequest)
+	if err := c.Bind(request); err != nil {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		cssh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	return request, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+)
+
+// UserIDAttribute is the context attribute per-user overrides are matched against.
+const UserIDAttribute = evaluation.UserIDAttribute
+
+// IPAttribute holds the client IP in the evaluation context. It is always
+// taken from the request, so clients can't target themselves as another IP.
+const IPAttribute = "ip"
+
+// contextWarnings checks attributes against the organization's context schema
+// and describes every attribute that isn't declared or doesn't parse as its
+// declared type. The key of every kind, user id included, is always accepted.
+// Without a schema nothing is checked.
+func contextWarnings(schema map[string]models.AttributeType, attributes map[string]string) []string {
+	warnings := make([]string, 0)
+	if len(schema) == 0 {
+		return warnings
+	}
+
+	for attribute, value := range attributes {
+		if attribute == IPAttribute || attribute == evaluation.KeyAttributeOf(evaluation.AttributeKind(attribute)) {
+			continue
+		}
+
+		attributeType, ok := schema[attribute]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown attribute %q", attribute))
+			continue
+		}
+
+		if !validAttributeValue(attributeType, value) {
+			warnings = append(warnings, fmt.Sprintf("attribute %q should be a %s, got %q", attribute, attributeType, value))
+		}
+	}
+	sort.Strings(warnings)
+
+	return warnings
+}
+
+func validAttributeValue(attributeType models.AttributeType, value string) bool {
+	switch attributeType {
+	case models.NumberAttribute:
+		_, err := flagvalue.CoerceNumber(value)
+		return err == nil
+	case models.BooleanAttribute:
+		_, err := flagvalue.CoerceBool(value)
+		return err == nil
+	}
+
+	return true
+}
+
+// missingKindWarnings describes every context kind targeted by flags, mapped
+// to their names, that the context lacks.
+func missingKindWarnings(missing map[string][]string) []string {
+	warnings := make([]string, 0, len(missing))
+	for kind, featureFlags := range missing {
+		sort.Strings(featureFlags)
+		warnings = append(warnings, fmt.Sprintf(
+			"context has no %q kind, targeted by %s",
+			kind,
+			strings.Join(featureFlags, ", "),
+		))
+	}
+	sort.Strings(warnings)
+
+	return warnings
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// OrganizationQueryParam restricts the listed flags to some organizations,
+// either comma separated or repeated.
+const OrganizationQueryParam = "organization_id"
+
+// AccessibleFeatureFlag is a flag along with the organization it belongs to
+// and the caller's permission level there.
+type AccessibleFeatureFlag struct {
+	models.FeatureFlagRecord
+	OrganizationName string                     `json:"organization_name"`
+	PermissionLevel  models.PermissionLevelEnum `json:"permission_level"`
+}
+
+type ListAccessibleFeatureFlagsResponse = PaginatedResponse[AccessibleFeatureFlag]
+
+// ListAccessibleFeatureFlags pages through the flags of every organization
+// the caller can read flags of, sorted by organization. An organization
+// counts only when the caller is a member with read access and the token
+// has the scope the organization requires, the same checks its own routes
+// make, see middlewares.TokenScopeMiddleware. Organizations named in
+// OrganizationQueryParam that the caller can't access list nothing.
+func (ffh *FeatureFlagHandler) ListAccessibleFeatureFlags(c echo.Context) error {
+	page, limit := apiutils.GetPaginationParams(c.QueryParam("page"), c.QueryParam("page_size"))
+	if page < 1 || limit < 1 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", "invalid pagination parameters"),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	requested, err := requestedOrganizations(c.QueryParams()[OrganizationQueryParam])
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	contextUser, ok := c.Get("user").(apiutils.ContextUser)
+	if !ok {
+		ffh.logger.Error("Server error",
+			zap.String("cause", apiutils.ErrContextUserTypeAssertion.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	ctx := c.Request().Context()
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizations, err := organizationModel.FindAllActiveByMember(ctx, contextUser.ID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	accessible := make(map[primitive.ObjectID]*models.OrganizationRecord, len(organizations))
+	organizationIDs := make([]primitive.ObjectID, 0, len(organizations))
+	for index := range organizations {
+		organization := &organizations[index]
+		if !canListFlagsOf(contextUser, organization) {
+			continue
+		}
+		if requested != nil && !requested[organization.ID] {
+			continue
+		}
+
+		accessible[organization.ID] = organization
+		organizationIDs = append(organizationIDs, organization.ID)
+	}
+
+	archived := c.QueryParam(ArchivedQueryParam) == "true"
+	filter := bson.D{{Key: "archived_at", Value: bson.M{"$exists": archived}}}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindManyInOrganizations(ctx, organizationIDs, filter, page, limit)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	total, err := model.CountManyInOrganizations(ctx, organizationIDs, filter)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	data := make([]AccessibleFeatureFlag, 0, len(featureFlags))
+	for index := range featureFlags {
+		organization, ok := accessible[featureFlags[index].OrganizationID]
+		if !ok {
+			// Never returned by the query, but a leak is worse than a short page.
+			continue
+		}
+
+		permissionLevel, _ := apiutils.UserPermissionLevel(contextUser.ID, organization)
+		data = append(data, AccessibleFeatureFlag{
+			FeatureFlagRecord: featureFlags[index],
+			OrganizationName:  organization.Name,
+			PermissionLevel:   permissionLevel,
+		})
+	}
+
+	return c.JSON(http.StatusOK, NewPaginatedResponse(data, page, limit, total))
+}
+
+// canListFlagsOf reports whether contextUser may read the flags of
+// organization.
+func canListFlagsOf(contextUser apiutils.ContextUser, organization *models.OrganizationRecord) bool {
+	if !apiutils.UserHasPermission(contextUser.ID, organization, models.ReadOnly) {
+		return false
+	}
+
+	expected := apiutils.NewTokenScope(organization.Settings.TokenIssuer, organization.Settings.TokenAudience)
+
+	return contextUser.Scope.Matches(expected)
+}
+
+// requestedOrganizations collects the organization ids passed to
+// OrganizationQueryParam, or nil when there are none.
+func requestedOrganizations(values []string) (map[primitive.ObjectID]bool, error) {
+	var requested map[primitive.ObjectID]bool
+	for _, value := range values {
+		for _, hex := range strings.Split(value, ",") {
+			if hex = strings.TrimSpace(hex); hex == "" {
+				continue
+			}
+
+			organizationID, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				return nil, err
+			}
+			if requested == nil {
+				requested = make(map[primitive.ObjectID]bool)
+			}
+			requested[organizationID] = true
+		}
+	}
+
+	return requested, nil
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type ApplyAction = string
+
+const (
+	ApplyCreate ApplyAction = "create"
+	ApplyUpdate ApplyAction = "update"
+	ApplyDelete ApplyAction = "delete"
+)
+
+type ApplyPlanStep struct {
+	Action  ApplyAction `json:"action"`
+	Name    string      `json:"name"`
+	Changes []string    `json:"changes,omitempty"`
+}
+
+type ApplyFeatureFlagsResponse struct {
+	DryRun bool            `json:"dry_run"`
+	Plan   []ApplyPlanStep `json:"plan"`
+}
+
+// ApplyFeatureFlags reconciles the flags of an organization with a declarative spec document.
+// Missing flags are created and changed ones get a new Live revision. Flags absent from the
+// document are only deleted with ?prune=true, and ?dry_run=true returns the plan without applying it.
+func (ffh *FeatureFlagHandler) ApplyFeatureFlags(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	document, err := bindFeatureFlagSpecDocument(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	prune := c.QueryParam("prune") == "true"
+	dryRun := c.QueryParam("dry_run") == "true"
+
+	existing := make(map[string]*models.FeatureFlagRecord, len(featureFlags))
+	for index := range featureFlags {
+		existing[featureFlags[index].QualifiedName()] = &featureFlags[index]
+	}
+	declared := make(map[string]FeatureFlagSpec, len(document.FeatureFlags))
+	for _, spec := range document.FeatureFlags {
+		declared[spec.QualifiedName()] = spec
+	}
+
+	for _, spec := range document.FeatureFlags {
+		for _, prerequisite := range spec.Prerequisites {
+			_, exists := existing[prerequisite.Name]
+			if _, isDeclared := declared[prerequisite.Name]; !isDeclared && (!exists || prune) {
+				ffh.logger.Debug("Client error",
+					zap.String("cause", apierrors.PrerequisiteNotFoundError),
+					zap.String("prerequisite", prerequisite.Name),
+				)
+				return apierrors.CustomError(
+					c,
+					http.StatusBadRequest,
+					apierrors.PrerequisiteNotFoundError,
+				)
+			}
+		}
+	}
+
+	plan := planFeatureFlagSpecDocument(document, featureFlags, prune)
+
+	// Updates promote their revision right away, which would let its author
+	// skip the separate approver the organization requires.
+	if organizationRecord.Settings.RequireSeparateApprover {
+		for _, step := range plan {
+			if step.Action == ApplyUpdate && promotesRevision(step) {
+				ffh.logger.Debug("Client error",
+					zap.String("cause", apierrors.SelfApprovalForbiddenError),
+					zap.String("feature_flag", step.Name),
+				)
+				return apierrors.CustomError(
+					c,
+					http.StatusForbidden,
+					apierrors.SelfApprovalForbiddenError,
+				)
+			}
+		}
+	}
+
+	// Pruned flags make room for the ones created in the same apply
+	newFlags := 0
+	createdNames := make([]string, 0)
+	pruned := make(map[string]bool)
+	for _, step := range plan {
+		switch step.Action {
+		case ApplyCreate:
+			newFlags++
+			createdNames = append(createdNames, step.Name)
+		case ApplyDelete:
+			newFlags--
+			pruned[step.Name] = true
+		}
+	}
+	kept := make([]models.FeatureFlagRecord, 0, len(featureFlags))
+	for index := range featureFlags {
+		if !pruned[featureFlags[index].QualifiedName()] {
+			kept = append(kept, featureFlags[index])
+		}
+	}
+	if ok, err := ffh.enforceEnvKeys(c, kept, createdNames); !ok {
+		return err
+	}
+	declaredRules := make([]models.Rule, 0)
+	for _, spec := range document.FeatureFlags {
+		if ok, err := ffh.enforceRuleLimits(c, spec.rules()); !ok {
+			return err
+		}
+		// Updated flags keep their constraints, created ones have none
+		var constraints *models.ValueConstraints
+		if featureFlag, exists := existing[spec.QualifiedName()]; exists {
+			constraints = featureFlag.Constraints
+		}
+		if ok, err := ffh.enforceValueLimits(c, spec.Type, constraints, spec.DefaultValue, spec.rules()); !ok {
+			return err
+		}
+		declaredRules = append(declaredRules, spec.rules()...)
+	}
+	if ok, err := ffh.enforceUserLists(c, organizationID, declaredRules); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceQuota(c, organizationRecord, newFlags, declaredRules); !ok {
+		return err
+	}
+
+	if dryRun {
+		return c.JSON(http.StatusOK, ApplyFeatureFlagsResponse{
+			DryRun: true,
+			Plan:   plan,
+		})
+	}
+
+	flagIDs := make(map[string]primitive.ObjectID, len(featureFlags)+len(document.FeatureFlags))
+	for name, featureFlag := range existing {
+		flagIDs[name] = featureFlag.ID
+	}
+
+	// Flags are created first so updated and created flags can reference them as prerequisites
+	for _, step := range plan {
+		if step.Action != ApplyCreate {
+			continue
+		}
+
+		spec := declared[step.Name]
+		record := models.NewFeatureFlagRecord(
+			spec.Name,
+			spec.Namespace,
+			spec.DefaultValue,
+			spec.Type,
+			spec.rules(),
+			organizationID,
+			userID,
+		)
+		awaitApproval(organizationRecord, record)
+		id, err := model.InsertOne(c.Request().Context(), record)
+		if mongo.IsDuplicateKeyError(err) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusConflict,
+				apierrors.FlagNameConflictError,
+			)
+		}
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+		flagIDs[step.Name] = id
+		ffh.publishChange(record, nil)
+	}
+
+	for _, step := range plan {
+		var filters, update bson.D
+		var promoted *models.FeatureFlagRecord
+
+		switch step.Action {
+		case ApplyCreate:
+			spec := declared[step.Name]
+			if len(spec.Prerequisites) == 0 {
+				continue
+			}
+
+			filters = bson.D{{Key: "_id", Value: flagIDs[step.Name]}}
+			update = bson.D{{Key: "$set", Value: bson.M{
+				"prerequisites": resolvePrerequisiteSpecs(spec.Prerequisites, flagIDs),
+			}}}
+		case ApplyUpdate:
+			spec := declared[step.Name]
+			record := existing[step.Name]
+			newValues := bson.D{
+				{Key: "prerequisites", Value: resolvePrerequisiteSpecs(spec.Prerequisites, flagIDs)},
+				{Key: "updated_by", Value: userID},
+			}
+			if promotesRevision(step) {
+				promoted = new(models.FeatureFlagRecord)
+				*promoted = *record
+				promoted.Type = spec.Type
+				promoted.Version = record.Version + 1
+				promoted.UpdatedBy = userID
+				promoted.Revisions = promoteSpecRevision(record, spec, userID)
+
+				newValues = append(newValues,
+					bson.E{Key: "type", Value: promoted.Type},
+					bson.E{Key: "version", Value: promoted.Version},
+					bson.E{Key: "revisions", Value: promoted.Revisions},
+				)
+			}
+
+			filters = unchangedSpecFilter(record)
+			update = bson.D{{Key: "$set", Value: newValues}}
+		case ApplyDelete:
+			record := existing[step.Name]
+			if err := model.DetachPrerequisite(c.Request().Context(), record.ID); err != nil {
+				ffh.logger.Error("Server error",
+					zap.String("cause", err.Error()),
+				)
+				return apierrors.CustomError(
+					c,
+					http.StatusInternalServerError,
+					apierrors.InternalServerError,
+				)
+			}
+
+			filters = unchangedSpecFilter(record)
+			update = bson.D{{Key: "$set", Value: bson.D{
+				{Key: "deleted_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+			}}}
+		}
+
+		saved, err := model.UpdateOne(c.Request().Context(), filters, update)
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+		// The plan was made from the flags as read, so one changed or
+		// deleted since can't take it anymore.
+		if !saved {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.FlagChangedError),
+			)
+			return apierrors.CustomError(c,
+				http.StatusConflict,
+				apierrors.FlagChangedError,
+			)
+		}
+
+		if promoted != nil {
+			ffh.publishChange(promoted, snapshotLiveRevision(existing[step.Name]))
+		}
+	}
+
+	ffh.logger.Info("Applied feature flag spec",
+		zap.String("organization_id", organizationID.Hex()),
+		zap.Int("steps", len(plan)),
+	)
+	return c.JSON(http.StatusOK, ApplyFeatureFlagsResponse{
+		DryRun: false,
+		Plan:   plan,
+	})
+}
+
+// planFeatureFlagSpecDocument lists the steps needed to make featureFlags match document,
+// in document order followed by the deletions.
+func planFeatureFlagSpecDocument(
+	document *FeatureFlagSpecDocument,
+	featureFlags []models.FeatureFlagRecord,
+	prune bool,
+) []ApplyPlanStep {
+	names := make(map[primitive.ObjectID]string, len(featureFlags))
+	existing := make(map[string]FeatureFlagSpec, len(featureFlags))
+	for _, featureFlag := range featureFlags {
+		names[featureFlag.ID] = featureFlag.QualifiedName()
+	}
+	for index := range featureFlags {
+		existing[featureFlags[index].QualifiedName()] = newFeatureFlagSpec(&featureFlags[index], names)
+	}
+
+	plan := make([]ApplyPlanStep, 0)
+	declared := make(map[string]bool, len(document.FeatureFlags))
+	for _, spec := range document.FeatureFlags {
+		name := spec.QualifiedName()
+		declared[name] = true
+
+		current, exists := existing[name]
+		if !exists {
+			plan = append(plan, ApplyPlanStep{Action: ApplyCreate, Name: name})
+			continue
+		}
+
+		if changes := diffFeatureFlagSpecs(current, spec); len(changes) > 0 {
+			plan = append(plan, ApplyPlanStep{Action: ApplyUpdate, Name: name, Changes: changes})
+		}
+	}
+
+	if prune {
+		for _, featureFlag := range featureFlags {
+			if !declared[featureFlag.QualifiedName()] {
+				plan = append(plan, ApplyPlanStep{Action: ApplyDelete, Name: featureFlag.QualifiedName()})
+			}
+		}
+	}
+
+	return plan
+}
+
+func diffFeatureFlagSpecs(current, desired FeatureFlagSpec) []string {
+	changes := make([]string, 0)
+	if current.Type != desired.Type {
+		changes = append(changes, "type")
+	}
+	if current.DefaultValue != desired.DefaultValue {
+		changes = append(changes, "default_value")
+	}
+	if len(current.Rules) != len(desired.Rules) ||
+		(len(desired.Rules) > 0 && !reflect.DeepEqual(current.Rules, desired.Rules)) {
+		changes = append(changes, "rules")
+	}
+	if len(current.Prerequisites) != len(desired.Prerequisites) ||
+		(len(desired.Prerequisites) > 0 && !reflect.DeepEqual(current.Prerequisites, desired.Prerequisites)) {
+		changes = append(changes, "prerequisites")
+	}
+
+	return changes
+}
+
+// promotesRevision reports whether an update step needs a new live revision.
+// A prerequisite change alone doesn't.
+func promotesRevision(step ApplyPlanStep) bool {
+	return len(step.Changes) > 1 || step.Changes[0] != "prerequisites"
+}
+
+// promoteSpecRevision archives the Live revision of record and appends a Live one built from spec.
+func promoteSpecRevision(
+	record *models.FeatureFlagRecord,
+	spec FeatureFlagSpec,
+	userID primitive.ObjectID,
+) []models.Revision {
+	revision := models.NewRevisionRecord(spec.DefaultValue, spec.rules(), userID)
+	revision.Approve(userID, time.Now())
+
+	revisions := make([]models.Revision, 0, len(record.Revisions)+1)
+	for _, existing := range record.Revisions {
+		if existing.Status == models.Live {
+			existing.Status = models.Archived
+			revision.LastRevisionID = existing.ID
+		}
+		revisions = append(revisions, existing)
+	}
+
+	return append(revisions, *revision)
+}
+
+// unchangedSpecFilter matches record as long as it is at the version it was
+// planned against and wasn't deleted.
+func unchangedSpecFilter(record *models.FeatureFlagRecord) bson.D {
+	return bson.D{
+		{Key: "_id", Value: record.ID},
+		{Key: "version", Value: record.Version},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ArchivedQueryParam lists archived flags instead of active ones when set to true.
+const ArchivedQueryParam = "archived"
+
+// ArchiveFeatureFlag hides a flag that is no longer used from the default
+// listing and makes it serve its default value. Unlike deletion, the flag
+// stays readable and can be unarchived.
+func (ffh *FeatureFlagHandler) ArchiveFeatureFlag(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if featureFlagRecord.IsArchived() {
+		return c.JSON(http.StatusOK, featureFlagRecord)
+	}
+
+	featureFlagRecord.ArchivedAt = primitive.NewDateTimeFromTime(time.Now().UTC())
+	saved, err := ffh.saveArchivedAt(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$set", Value: bson.M{"archived_at": featureFlagRecord.ArchivedAt}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, featureFlagRecord)
+}
+
+func (ffh *FeatureFlagHandler) UnarchiveFeatureFlag(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if !featureFlagRecord.IsArchived() {
+		return c.JSON(http.StatusOK, featureFlagRecord)
+	}
+
+	featureFlagRecord.ArchivedAt = 0
+	saved, err := ffh.saveArchivedAt(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$unset", Value: bson.M{"archived_at": ""}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, featureFlagRecord)
+}
+
+func (ffh *FeatureFlagHandler) saveArchivedAt(ctx context.Context, featureFlagID primitive.ObjectID, update bson.D) (bool, error) {
+	model := models.NewFeatureFlagModel(ffh.db)
+	return model.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: featureFlagID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		update,
+	)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	CompareFromQueryParam = "from"
+	CompareToQueryParam   = "to"
+)
+
+// CompareRevisionsResponse is what changed going from one revision of a flag
+// to another.
+type CompareRevisionsResponse struct {
+	From primitive.ObjectID  `json:"from"`
+	To   primitive.ObjectID  `json:"to"`
+	Diff models.RevisionDiff `json:"diff"`
+}
+
+// CompareRevisions diffs any two revisions of a flag, given by the from and
+// to query params, e.g. a draft against the live revision. Rules are matched
+// by id like in the diffs sent to webhooks, see models.DiffRevisions.
+func (ffh *FeatureFlagHandler) CompareRevisions(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	fromID, err := primitive.ObjectIDFromHex(c.QueryParam(CompareFromQueryParam))
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	toID, err := primitive.ObjectIDFromHex(c.QueryParam(CompareToQueryParam))
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	from, to := featureFlagRecord.FindRevision(fromID), featureFlagRecord.FindRevision(toID)
+	if from == nil || to == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, CompareRevisionsResponse{
+		From: fromID,
+		To:   toID,
+		Diff: models.DiffRevisions(from, to),
+	})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// DryRunRequest is who to evaluate the flag for: the attributes of a saved
+// test identity, Attributes, or both, with Attributes winning over the
+// identity's. RevisionID evaluates that revision as if it were live instead
+// of the live one. Environment defaults to the organization's.
+type DryRunRequest struct {
+	Environment    string             `json:"environment"`
+	TestIdentityID primitive.ObjectID `json:"test_identity_id"`
+	RevisionID     primitive.ObjectID `json:"revision_id"`
+	Attributes     map[string]string  `json:"attributes"`
+}
+
+// DryRunResponse echoes the context the flag was evaluated for, so the
+// result can be told apart from the persona's attributes changing.
+type DryRunResponse struct {
+	FeatureFlagID string            `json:"feature_flag_id"`
+	RevisionID    string            `json:"revision_id,omitempty"`
+	Environment   string            `json:"environment"`
+	Attributes    map[string]string `json:"attributes"`
+	Value         string            `json:"value"`
+	Reason        string            `json:"reason"`
+}
+
+// DryRunFeatureFlag evaluates the flag in the path without serving it: the
+// evaluation isn't counted in the flag's stats nor sent to analytics.
+func (ffh *FeatureFlagHandler) DryRunFeatureFlag(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	featureFlagID := apiutils.ObjectIDParam(c, "featureFlagID")
+
+	request := new(DryRunRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	attributes := make(map[string]string)
+	if !request.TestIdentityID.IsZero() {
+		testIdentityModel := models.NewTestIdentityModel(ffh.db)
+		testIdentity, err := testIdentityModel.FindByID(c.Request().Context(), organizationID, request.TestIdentityID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				ffh.logger.Debug("Client error",
+					zap.String("cause", err.Error()),
+				)
+				return apierrors.CustomError(c,
+					http.StatusNotFound,
+					apierrors.NotFoundError,
+				)
+			}
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+		for key, value := range testIdentity.Attributes {
+			attributes[key] = value
+		}
+	}
+	for key, value := range request.Attributes {
+		attributes[key] = value
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(c.Request().Context(), organizationID, models.UserListIDs(featureFlags))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	flagIndex := -1
+	for index := range featureFlags {
+		if featureFlags[index].ID == featureFlagID {
+			flagIndex = index
+			break
+		}
+	}
+	if flagIndex == -1 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	if !request.RevisionID.IsZero() {
+		revisionIndex := -1
+		for index := range featureFlags[flagIndex].Revisions {
+			if featureFlags[flagIndex].Revisions[index].ID == request.RevisionID {
+				revisionIndex = index
+				break
+			}
+		}
+		if revisionIndex == -1 {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.NotFoundError),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		featureFlags[flagIndex] = withLiveRevision(featureFlags[flagIndex], revisionIndex)
+	}
+
+	environment := request.Environment
+	if environment == "" {
+		environment = organizationRecord.Settings.DefaultEnvironment
+	}
+	featureFlag := &featureFlags[flagIndex]
+	result, err := evaluation.NewEvaluator(featureFlags, evaluation.Context{
+		Environment:        environment,
+		Attributes:         attributes,
+		EnvironmentParents: organizationRecord.Settings.EnvironmentParents,
+		UserLists:          userLists,
+	}).Evaluate(featureFlag)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	response := DryRunResponse{
+		FeatureFlagID: featureFlag.ID.Hex(),
+		Environment:   environment,
+		Attributes:    attributes,
+		Value:         result.Value,
+		Reason:        result.Code(),
+	}
+	if revision := featureFlag.LiveRevision(); revision != nil {
+		response.RevisionID = revision.ID.Hex()
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
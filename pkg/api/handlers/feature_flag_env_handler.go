@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	EnvPrefixQueryParam      = "prefix"
+	EnvEnvironmentQueryParam = "environment"
+	EnvExplainQueryParam     = "explain"
+	EnvFlagsQueryParam       = "flags"
+	EnvReasonsQueryParam     = "reasons"
+)
+
+// GetFeatureFlagEnv serves the evaluated value of every flag of the organization
+// as dotenv lines (KEY=value), so they can be sourced as environment variables.
+// Query params other than prefix, environment, explain, flags and reasons make up the
+// evaluation context, along with the client IP as ip. With explain=true the
+// context is checked against the organization's schema and for the context
+// kinds flags target, and problems are reported as leading comment lines.
+// flags takes a comma separated list of qualified flag names to restrict the
+// output to; names that match no flag are reported as "# missing:" lines.
+// With reasons=true every line ends with a comment giving the reason code of
+// its value, e.g. "CHECKOUT=true # RULE_MATCH:<rule id>". Deprecated flags
+// that are served are reported as "# deprecated:" lines so SDKs can warn.
+func (ffh *FeatureFlagHandler) GetFeatureFlagEnv(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(c.Request().Context(), organizationRecord.ID, models.UserListIDs(featureFlags))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return serveEnv(
+		c,
+		featureFlags,
+		organizationRecord.ContextSchema,
+		withDefaultEnvironment(nil, organizationRecord.Settings),
+		organizationRecord.Settings.EnvironmentParents,
+		userLists,
+		ffh.analytics,
+	)
+}
+
+// GetAPIKeyEnv is GetFeatureFlagEnv for SDKs authenticated by an API key
+// rather than a user. The evaluation context starts from the key's default
+// context; see evaluationContext for how the request is merged into it.
+func (ffh *FeatureFlagHandler) GetAPIKeyEnv(c echo.Context) error {
+	apiKey, ok := c.Get(middlewares.APIKeyContextKey).(models.APIKeyRecord)
+	if !ok {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apiutils.ErrNotAuthenticated.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), apiKey.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), apiKey.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(c.Request().Context(), organizationRecord.ID, models.UserListIDs(featureFlags))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return serveEnv(
+		c,
+		featureFlags,
+		organizationRecord.ContextSchema,
+		withDefaultEnvironment(apiKey.DefaultContext, organizationRecord.Settings),
+		organizationRecord.Settings.EnvironmentParents,
+		userLists,
+		ffh.analytics,
+	)
+}
+
+// ListAPIKeyFeatureFlags is ListFeatureFlags for the organization of the API
+// key the request was made with, so long-running services such as relays
+// can mirror flags without a user's session. Pages also carry the
+// organization's environment inheritance, see APIKeyFeatureFlagsResponse.
+func (ffh *FeatureFlagHandler) ListAPIKeyFeatureFlags(c echo.Context) error {
+	apiKey, ok := c.Get(middlewares.APIKeyContextKey).(models.APIKeyRecord)
+	if !ok {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apiutils.ErrNotAuthenticated.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), apiKey.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return ffh.listFeatureFlags(c, apiKey.OrganizationID, organizationRecord.Settings.EnvironmentParents)
+}
+
+// serveEnv evaluates featureFlags for the request's context and writes them
+// as dotenv lines. schema is only used to explain the context and may be nil,
+// as may defaults, environmentParents and userLists. Every value served is recorded in
+// sink.
+func serveEnv(
+	c echo.Context,
+	featureFlags []models.FeatureFlagRecord,
+	schema map[string]models.AttributeType,
+	defaults map[string]string,
+	environmentParents map[string]string,
+	userLists map[primitive.ObjectID]models.UserSet,
+	sink analytics.AnalyticsSink,
+) error {
+	environment, attributes := evaluationContext(c, defaults)
+
+	prefix := c.QueryParam(EnvPrefixQueryParam)
+	flagContext := evaluation.Context{
+		Environment:        environment,
+		Attributes:         attributes,
+		EnvironmentParents: environmentParents,
+		UserLists:          userLists,
+	}
+	evaluator := evaluation.NewEvaluator(featureFlags, flagContext)
+
+	requested := requestedFlagNames(c.QueryParams()[EnvFlagsQueryParam])
+	withReasons := c.QueryParam(EnvReasonsQueryParam) == "true"
+	explain := c.QueryParam(EnvExplainQueryParam) == "true"
+	evaluatedAt := time.Now().UTC()
+
+	lines := make([]string, 0, len(featureFlags))
+	deprecated := make([]string, 0)
+	missingKinds := make(map[string][]string)
+	for index := range featureFlags {
+		if requested != nil {
+			if _, ok := requested[featureFlags[index].QualifiedName()]; !ok {
+				continue
+			}
+			requested[featureFlags[index].QualifiedName()] = true
+		}
+
+		// Flags that were never released stay out of the file, so the
+		// application's own defaults apply until they are.
+		result, err := evaluator.Evaluate(&featureFlags[index])
+		if err != nil || result.Reason == evaluation.NoLiveRevisionReason {
+			continue
+		}
+		value := result.Value
+		sink.Record(servedEvaluation(evaluator, &featureFlags[index], flagContext, result, evaluatedAt))
+
+		if featureFlags[index].LifecycleStage() == models.Deprecated {
+			deprecated = append(deprecated, featureFlags[index].QualifiedName())
+		}
+		if explain {
+			rulesEnvironment := flagContext.RulesEnvironment(featureFlags[index].LiveRevision())
+			for _, kind := range evaluation.ReferencedKinds(&featureFlags[index], rulesEnvironment) {
+				if !flagContext.HasKind(kind) {
+					missingKinds[kind] = append(missingKinds[kind], featureFlags[index].QualifiedName())
+				}
+			}
+		}
+
+		line := envKey(prefix, featureFlags[index].QualifiedName()) + "=" + envValue(featureFlags[index].Type, value)
+		if withReasons {
+			line += " # " + result.Code()
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	sort.Strings(deprecated)
+
+	var body strings.Builder
+	if explain {
+		warnings := append(contextWarnings(schema, attributes), missingKindWarnings(missingKinds)...)
+		for _, warning := range warnings {
+			body.WriteString("# warning: ")
+			body.WriteString(warning)
+			body.WriteString("\n")
+		}
+	}
+	for _, name := range missingFlagNames(requested) {
+		body.WriteString("# missing: ")
+		body.WriteString(name)
+		body.WriteString("\n")
+	}
+	for _, name := range deprecated {
+		body.WriteString("# deprecated: ")
+		body.WriteString(name)
+		body.WriteString("\n")
+	}
+	for _, line := range lines {
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+
+	return conditionalBlob(
+		c,
+		echo.MIMETextPlainCharsetUTF8,
+		[]byte(body.String()),
+		apiutils.ETag([]byte(body.String())),
+		lastModifiedIn(featureFlags, environment, environmentParents),
+	)
+}
+
+// withDefaultEnvironment adds the organization's default environment to
+// defaults, unless they already name one.
+func withDefaultEnvironment(defaults map[string]string, settings models.OrganizationSettings) map[string]string {
+	if settings.DefaultEnvironment == "" {
+		return defaults
+	}
+	if _, ok := defaults[EnvEnvironmentQueryParam]; ok {
+		return defaults
+	}
+
+	merged := make(map[string]string, len(defaults)+1)
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	merged[EnvEnvironmentQueryParam] = settings.DefaultEnvironment
+
+	return merged
+}
+
+// evaluationContext merges the request's query params over defaults, so a
+// param always wins over a default of the same name. The environment default
+// is used when the request names none; every other default is an attribute.
+// ip is always the client IP, whatever either of them says.
+func evaluationContext(c echo.Context, defaults map[string]string) (string, map[string]string) {
+	environment := defaults[EnvEnvironmentQueryParam]
+	attributes := make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		if key == EnvEnvironmentQueryParam {
+			continue
+		}
+		attributes[key] = value
+	}
+
+	for key, values := range c.QueryParams() {
+		if key == EnvPrefixQueryParam || key == EnvExplainQueryParam ||
+			key == EnvFlagsQueryParam || key == EnvReasonsQueryParam || len(values) == 0 {
+			continue
+		}
+		if key == EnvEnvironmentQueryParam {
+			environment = values[0]
+			continue
+		}
+		attributes[key] = values[0]
+	}
+	attributes[IPAttribute] = c.RealIP()
+
+	return environment, attributes
+}
+
+// requestedFlagNames collects the names passed to flags, either comma separated
+// or repeated, mapped to whether a flag was found for them. It returns nil
+// when no names were requested, meaning every flag is evaluated.
+func requestedFlagNames(values []string) map[string]bool {
+	var requested map[string]bool
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if requested == nil {
+				requested = make(map[string]bool)
+			}
+			requested[name] = false
+		}
+	}
+
+	return requested
+}
+
+func missingFlagNames(requested map[string]bool) []string {
+	missing := make([]string, 0)
+	for name, found := range requested {
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
+
+// envKey upper-cases the qualified flag name and replaces anything that isn't
+// a letter or a digit with an underscore, e.g. "billing/new-checkout" becomes
+// "BILLING_NEW_CHECKOUT".
+func envKey(prefix, qualifiedName string) string {
+	key := strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, qualifiedName)
+
+	return prefix + key
+}
+
+// enforceEnvKeys checks that every flag of names gets an env key no flag of
+// existing, nor another of names, already has. It returns false, with the
+// error response already written, otherwise.
+func (ffh *FeatureFlagHandler) enforceEnvKeys(
+	c echo.Context,
+	existing []models.FeatureFlagRecord,
+	names []string,
+) (bool, error) {
+	name, conflict := envKeyConflict(existing, names)
+	if !conflict {
+		return true, nil
+	}
+
+	ffh.logger.Debug("Client error",
+		zap.String("cause", apierrors.EnvKeyConflictError),
+		zap.String("feature_flag", name),
+	)
+	return false, apierrors.CustomError(c,
+		http.StatusConflict,
+		apierrors.EnvKeyConflictError,
+	)
+}
+
+// envKeyConflict returns the first of names whose env key belongs to another
+// flag.
+func envKeyConflict(existing []models.FeatureFlagRecord, names []string) (string, bool) {
+	owners := make(map[string]string, len(existing)+len(names))
+	for index := range existing {
+		owners[envKey("", existing[index].QualifiedName())] = existing[index].QualifiedName()
+	}
+	for _, name := range names {
+		key := envKey("", name)
+		if owner, ok := owners[key]; ok && owner != name {
+			return name, true
+		}
+		owners[key] = name
+	}
+
+	return "", false
+}
+
+// envValue normalizes booleans and numbers and quotes anything a shell
+// wouldn't read back verbatim.
+func envValue(flagType models.FlagType, value string) string {
+	switch flagType {
+	case models.Boolean:
+		if parsed, err := flagvalue.CoerceBool(value); err == nil {
+			value = strconv.FormatBool(parsed)
+		}
+	case models.Number:
+		if parsed, err := flagvalue.CoerceNumber(value); err == nil {
+			value = strconv.FormatFloat(parsed, 'f', -1, 64)
+		}
+	case models.JSON:
+		compacted := new(bytes.Buffer)
+		if err := json.Compact(compacted, []byte(value)); err == nil {
+			value = compacted.String()
+		}
+	}
+
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"'`#$\\=") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
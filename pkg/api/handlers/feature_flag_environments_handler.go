@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// FeatureFlagEnvironment is what a flag's live revision serves in a single
+// environment: its default value and the rules that apply there. InheritedFrom
+// is the ancestor those rules target when the environment has none of its
+// own, and empty when they are the environment's. LastChangedAt and
+// LastChangedBy come from the approval that last changed either of them, or
+// the flag's creation when none did.
+type FeatureFlagEnvironment struct {
+	Environment   string             `json:"environment"`
+	InheritedFrom string             `json:"inherited_from,omitempty"`
+	RevisionID    primitive.ObjectID `json:"revision_id"`
+	Version       int                `json:"version"`
+	DefaultValue  string             `json:"default_value"`
+	Rules         []models.Rule      `json:"rules"`
+	LastChangedAt primitive.DateTime `json:"last_changed_at"`
+	LastChangedBy primitive.ObjectID `json:"last_changed_by"`
+}
+
+type FeatureFlagEnvironmentsResponse struct {
+	FeatureFlagID primitive.ObjectID       `json:"feature_flag_id"`
+	Environments  []FeatureFlagEnvironment `json:"environments"`
+}
+
+// ListFeatureFlagEnvironments lists every environment of the organization
+// with what the flag serves in it, so the environments can be compared
+// before a promotion. Environments are the ones rules of any flag target,
+// along with those of the organization's environment inheritance.
+func (ffh *FeatureFlagHandler) ListFeatureFlagEnvironments(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	liveRevision := featureFlagRecord.LiveRevision()
+	if liveRevision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NoLiveRevisionError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), featureFlagRecord.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	environments, err := model.FindEnvironments(c.Request().Context(), featureFlagRecord.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	parents := organizationRecord.Settings.EnvironmentParents
+	environments = withParentEnvironments(environments, parents)
+
+	response := FeatureFlagEnvironmentsResponse{
+		FeatureFlagID: featureFlagRecord.ID,
+		Environments:  make([]FeatureFlagEnvironment, 0, len(environments)),
+	}
+	for _, environment := range environments {
+		rulesEnvironment := liveRevision.InheritedEnvironment(environment, parents)
+		inheritedFrom := ""
+		if rulesEnvironment != environment {
+			inheritedFrom = rulesEnvironment
+		}
+
+		changed := featureFlagRecord.LastChangedIn(environment, parents)
+		changedAt, changedBy := changed.ApprovedAt, changed.ApprovedBy
+		if changedAt == 0 {
+			changedAt, changedBy = featureFlagRecord.CreatedAt, changed.UserID
+		}
+
+		response.Environments = append(response.Environments, FeatureFlagEnvironment{
+			Environment:   environment,
+			InheritedFrom: inheritedFrom,
+			RevisionID:    liveRevision.ID,
+			Version:       featureFlagRecord.Version,
+			DefaultValue:  liveRevision.DefaultValue,
+			Rules:         liveRevision.EnvironmentRules(rulesEnvironment),
+			LastChangedAt: changedAt,
+			LastChangedBy: changedBy,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// withParentEnvironments adds the environments of parents missing from the
+// sorted environments, keeping them sorted.
+func withParentEnvironments(environments []string, parents map[string]string) []string {
+	known := make(map[string]bool, len(environments))
+	for _, environment := range environments {
+		known[environment] = true
+	}
+
+	for child, parent := range parents {
+		for _, environment := range []string{child, parent} {
+			if !known[environment] {
+				known[environment] = true
+				environments = append(environments, environment)
+			}
+		}
+	}
+	sort.Strings(environments)
+
+	return environments
+}
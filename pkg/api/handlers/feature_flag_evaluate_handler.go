@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MaxEvaluationBatchSize is how many contexts a single batch evaluation can
+// hold.
+const MaxEvaluationBatchSize = 1000
+
+// MaxFallbackEnvironments is how many environments an evaluation can fall
+// back to.
+const MaxFallbackEnvironments = 10
+
+// EvaluateFallbackQueryParam takes the comma separated fallback environments
+// of EvaluateByID, e.g. fallback=canary,production.
+const EvaluateFallbackQueryParam = "fallback"
+
+// EvaluateBatchRequest lists the contexts to evaluate a flag for. Every
+// context is a map of attributes, evaluated in Environment, or in the
+// organization's default environment when it's empty. When the flag has no
+// rules in that environment, FallbackEnvironments are tried in order, see
+// evaluation.Context.RulesEnvironment.
+type EvaluateBatchRequest struct {
+	Environment          string              `json:"environment"`
+	FallbackEnvironments []string            `json:"fallback_environments" validate:"dive,min=1,max=64"`
+	Contexts             []map[string]string `json:"contexts" validate:"required,min=1"`
+}
+
+type BatchEvaluation struct {
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// EvaluateBatchResponse holds one evaluation per context of the request, in
+// the same order. RulesEnvironment is the environment whose rules were
+// tried, empty when the flag has no live revision.
+type EvaluateBatchResponse struct {
+	FeatureFlag      string            `json:"feature_flag"`
+	Environment      string            `json:"environment"`
+	RulesEnvironment string            `json:"rules_environment"`
+	Results          []BatchEvaluation `json:"results"`
+}
+
+// EvaluateBatch evaluates the flag named in the path for every context of
+// the request, e.g. to precompute the assignments of a list of users. The
+// name of a namespaced flag is path escaped: billing%2Fnew-invoice.
+func (ffh *FeatureFlagHandler) EvaluateBatch(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	flagName, err := url.PathUnescape(c.Param("flagName"))
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	request := new(EvaluateBatchRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if len(request.Contexts) > MaxEvaluationBatchSize {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.EvaluationBatchTooLargeError),
+		)
+		return c.JSON(http.StatusBadRequest, RuleLimitResponse{
+			Error:   http.StatusText(http.StatusBadRequest),
+			Message: apierrors.EvaluationBatchTooLargeError,
+			Limit:   MaxEvaluationBatchSize,
+		})
+	}
+	if len(request.FallbackEnvironments) > MaxFallbackEnvironments {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FallbackChainTooLongError),
+		)
+		return c.JSON(http.StatusBadRequest, RuleLimitResponse{
+			Error:   http.StatusText(http.StatusBadRequest),
+			Message: apierrors.FallbackChainTooLongError,
+			Limit:   MaxFallbackEnvironments,
+		})
+	}
+	if request.Environment == "" {
+		request.Environment = organizationRecord.Settings.DefaultEnvironment
+	}
+
+	// Prerequisites are resolved against the rest of the organization's flags.
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(c.Request().Context(), organizationID, models.UserListIDs(featureFlags))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	var featureFlag *models.FeatureFlagRecord
+	for index := range featureFlags {
+		if featureFlags[index].QualifiedName() == flagName {
+			featureFlag = &featureFlags[index]
+			break
+		}
+	}
+	if featureFlag == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	flagContext := evaluation.Context{
+		Environment:          request.Environment,
+		EnvironmentParents:   organizationRecord.Settings.EnvironmentParents,
+		UserLists:            userLists,
+		FallbackEnvironments: request.FallbackEnvironments,
+	}
+	evaluatedAt := time.Now().UTC()
+	results := make([]BatchEvaluation, 0, len(request.Contexts))
+	for _, attributes := range request.Contexts {
+		flagContext.Attributes = attributes
+		result, err := ffh.evaluateFlag(featureFlags, featureFlag, flagContext, evaluatedAt)
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	return c.JSON(http.StatusOK, EvaluateBatchResponse{
+		FeatureFlag:      featureFlag.QualifiedName(),
+		Environment:      request.Environment,
+		RulesEnvironment: rulesEnvironment(featureFlag, flagContext),
+		Results:          results,
+	})
+}
+
+// EvaluateResponse is the value of a flag for the context of the request.
+// RulesEnvironment is the environment whose rules were tried, empty when the
+// flag has no live revision.
+type EvaluateResponse struct {
+	FeatureFlagID    string `json:"feature_flag_id"`
+	FeatureFlag      string `json:"feature_flag"`
+	Environment      string `json:"environment"`
+	RulesEnvironment string `json:"rules_environment"`
+	Value            string `json:"value"`
+	Reason           string `json:"reason"`
+}
+
+// EvaluateByID evaluates one flag, found by id, for clients that store ids
+// rather than names. The context is read from the query params like the env
+// endpoint does: environment plus any attribute, except fallback, which lists
+// the environments to fall back to.
+func (ffh *FeatureFlagHandler) EvaluateByID(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	featureFlagID := apiutils.ObjectIDParam(c, "featureFlagID")
+
+	fallbacks := fallbackEnvironments(c.QueryParams()[EvaluateFallbackQueryParam])
+	if len(fallbacks) > MaxFallbackEnvironments {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FallbackChainTooLongError),
+		)
+		return c.JSON(http.StatusBadRequest, RuleLimitResponse{
+			Error:   http.StatusText(http.StatusBadRequest),
+			Message: apierrors.FallbackChainTooLongError,
+			Limit:   MaxFallbackEnvironments,
+		})
+	}
+
+	// Deleted flags aren't part of the organization's flags, so they are
+	// reported as not found like unknown ids.
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(c.Request().Context(), organizationID, models.UserListIDs(featureFlags))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	var featureFlag *models.FeatureFlagRecord
+	for index := range featureFlags {
+		if featureFlags[index].ID == featureFlagID {
+			featureFlag = &featureFlags[index]
+			break
+		}
+	}
+	if featureFlag == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	environment, attributes := evaluationContext(c, withDefaultEnvironment(nil, organizationRecord.Settings))
+	delete(attributes, EvaluateFallbackQueryParam)
+	flagContext := evaluation.Context{
+		Environment:          environment,
+		Attributes:           attributes,
+		EnvironmentParents:   organizationRecord.Settings.EnvironmentParents,
+		UserLists:            userLists,
+		FallbackEnvironments: fallbacks,
+	}
+	result, err := ffh.evaluateFlag(featureFlags, featureFlag, flagContext, time.Now().UTC())
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, EvaluateResponse{
+		FeatureFlagID:    featureFlag.ID.Hex(),
+		FeatureFlag:      featureFlag.QualifiedName(),
+		Environment:      environment,
+		RulesEnvironment: rulesEnvironment(featureFlag, flagContext),
+		Value:            result.Value,
+		Reason:           result.Reason,
+	})
+}
+
+// fallbackEnvironments collects the environments passed to fallback, either
+// comma separated or repeated, in order.
+func fallbackEnvironments(values []string) []string {
+	var environments []string
+	for _, value := range values {
+		for _, environment := range strings.Split(value, ",") {
+			if environment = strings.TrimSpace(environment); environment != "" {
+				environments = append(environments, environment)
+			}
+		}
+	}
+
+	return environments
+}
+
+// rulesEnvironment is the environment whose rules featureFlag tries in
+// flagContext, or empty when it has no live revision.
+func rulesEnvironment(featureFlag *models.FeatureFlagRecord, flagContext evaluation.Context) string {
+	revision := featureFlag.LiveRevision()
+	if revision == nil {
+		return ""
+	}
+
+	return flagContext.RulesEnvironment(revision)
+}
+
+// evaluateFlag evaluates featureFlag, resolving its prerequisites against
+// featureFlags, and records the evaluation.
+func (ffh *FeatureFlagHandler) evaluateFlag(
+	featureFlags []models.FeatureFlagRecord,
+	featureFlag *models.FeatureFlagRecord,
+	flagContext evaluation.Context,
+	evaluatedAt time.Time,
+) (BatchEvaluation, error) {
+	evaluator := evaluation.NewEvaluator(featureFlags, flagContext)
+	result, err := evaluator.Evaluate(featureFlag)
+	if err != nil {
+		return BatchEvaluation{}, err
+	}
+
+	ffh.analytics.Record(servedEvaluation(evaluator, featureFlag, flagContext, result, evaluatedAt))
+
+	return BatchEvaluation{
+		Value:  result.Value,
+		Reason: result.Code(),
+	}, nil
+}
+
+// servedEvaluation is the analytics record of featureFlag serving result in
+// flagContext. When the flag has a shadow revision, evaluator also evaluates
+// it so the record tells what it would have served instead.
+func servedEvaluation(
+	evaluator *evaluation.Evaluator,
+	featureFlag *models.FeatureFlagRecord,
+	flagContext evaluation.Context,
+	result evaluation.Result,
+	evaluatedAt time.Time,
+) analytics.Evaluation {
+	served := analytics.Evaluation{
+		OrganizationID: featureFlag.OrganizationID.Hex(),
+		FeatureFlagID:  featureFlag.ID.Hex(),
+		FeatureFlag:    featureFlag.QualifiedName(),
+		Environment:    flagContext.Environment,
+		ContextKey:     flagContext.Attributes[UserIDAttribute],
+		Value:          result.Value,
+		RuleID:         result.MatchedRule(),
+		EvaluatedAt:    evaluatedAt,
+	}
+	if shadow, ok := evaluator.EvaluateShadow(featureFlag); ok {
+		served.ShadowRevisionID = featureFlag.Shadow.RevisionID.Hex()
+		served.ShadowValue = shadow.Value
+		served.ShadowRuleID = shadow.MatchedRule()
+	}
+
+	return served
+}
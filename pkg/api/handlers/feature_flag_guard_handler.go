@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PutGuardRequest struct {
+	MaxErrorRate float64 `json:"max_error_rate" validate:"gt=0,lte=1"`
+	Window       string  `json:"window" validate:"required"`
+	MinRequests  int     `json:"min_requests" validate:"min=0"`
+}
+
+type PostErrorSignalRequest struct {
+	Requests int `json:"requests" validate:"min=1"`
+	Errors   int `json:"errors" validate:"min=0,ltefield=Requests"`
+}
+
+type ErrorSignalResponse struct {
+	Requests   int  `json:"requests"`
+	Errors     int  `json:"errors"`
+	RolledBack bool `json:"rolled_back"`
+}
+
+// PutGuard arms automatic rollback for the flag.
+func (ffh *FeatureFlagHandler) PutGuard(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PutGuardRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	window, err := time.ParseDuration(request.Window)
+	if err != nil || window <= 0 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", "invalid guard window"),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	guard := &models.RollbackGuard{
+		MaxErrorRate: request.MaxErrorRate,
+		Window:       window,
+		MinRequests:  request.MinRequests,
+	}
+	saved, err := ffh.saveGuard(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$set", Value: bson.M{"guard": guard}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, guard)
+}
+
+func (ffh *FeatureFlagHandler) DeleteGuard(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if featureFlagRecord.Guard == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	saved, err := ffh.saveGuard(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$unset", Value: bson.M{"guard": ""}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostErrorSignal lets clients report how many requests they served with the
+// flag's live revision and how many of them failed. When the flag has a
+// guard and the error rate over its window crosses the threshold, the flag is
+// rolled back to the previous revision and any rollout ramp is stopped. As a
+// signal can roll the flag back, it takes the permission a rollback does.
+func (ffh *FeatureFlagHandler) PostErrorSignal(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.Collaborator)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PostErrorSignalRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	liveRevision := featureFlagRecord.LiveRevision()
+	if liveRevision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NoLiveRevisionError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	now := time.Now().UTC()
+	signalModel := models.NewErrorSignalModel(ffh.db)
+	_, err = signalModel.InsertOne(c.Request().Context(), &models.ErrorSignal{
+		OrganizationID: featureFlagRecord.OrganizationID,
+		FeatureFlagID:  featureFlagRecord.ID,
+		RevisionID:     liveRevision.ID,
+		Requests:       request.Requests,
+		Errors:         request.Errors,
+		CreatedAt:      primitive.NewDateTimeFromTime(now),
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	if featureFlagRecord.Guard == nil {
+		return c.JSON(http.StatusOK, ErrorSignalResponse{
+			Requests: request.Requests,
+			Errors:   request.Errors,
+		})
+	}
+
+	requests, errorCount, err := signalModel.SumSince(
+		c.Request().Context(),
+		liveRevision.ID,
+		now.Add(-featureFlagRecord.Guard.Window),
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	response := ErrorSignalResponse{Requests: requests, Errors: errorCount}
+	if !featureFlagRecord.Guard.Tripped(requests, errorCount) || liveRevision.LastRevisionID.IsZero() {
+		return c.JSON(http.StatusOK, response)
+	}
+
+	rolledBack, err := ffh.autoRollback(featureFlagRecord, requests, errorCount)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	response.RolledBack = rolledBack
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// autoRollback restores the revision before the live one. It reports false,
+// and records nothing, when a concurrent write changed the flag first.
+func (ffh *FeatureFlagHandler) autoRollback(
+	featureFlag *models.FeatureFlagRecord,
+	requests,
+	errorCount int,
+) (bool, error) {
+	liveRevision := featureFlag.LiveRevision()
+	fromRevisionID, toRevisionID := liveRevision.ID, liveRevision.LastRevisionID
+	previousVersion := featureFlag.Version
+	rollbackRevisions(featureFlag)
+
+	set := bson.D{
+		{Key: "version", Value: featureFlag.Version},
+		{Key: "revisions", Value: featureFlag.Revisions},
+	}
+	if featureFlag.Rollout != nil && featureFlag.Rollout.Ramp != nil {
+		featureFlag.Rollout.Ramp = nil
+		set = append(set, bson.E{Key: "rollout", Value: featureFlag.Rollout})
+	}
+
+	// Matching the flag as read keeps a concurrent write from being overwritten.
+	model := models.NewFeatureFlagModel(ffh.db)
+	saved, err := model.SaveRollback(context.Background(), featureFlag.ID, previousVersion, featureFlag.Writes, set)
+	if err != nil || !saved {
+		return false, err
+	}
+
+	auditLogModel := models.NewAuditLogModel(ffh.db)
+	_, err = auditLogModel.InsertOne(context.Background(), models.NewAuditEntry(
+		featureFlag.OrganizationID,
+		featureFlag.ID,
+		primitive.NilObjectID,
+		models.AutoRollbackAction,
+		map[string]any{
+			"from_revision_id": fromRevisionID,
+			"to_revision_id":   toRevisionID,
+			"requests":         requests,
+			"errors":           errorCount,
+			"max_error_rate":   featureFlag.Guard.MaxErrorRate,
+		},
+	))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ffh *FeatureFlagHandler) saveGuard(ctx context.Context, featureFlagID primitive.ObjectID, update bson.D) (bool, error) {
+	model := models.NewFeatureFlagModel(ffh.db)
+	return model.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: featureFlagID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		update,
+	)
+}
@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
 	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,42 +24,123 @@ import (
 )
 
 type FeatureFlagHandler struct {
-	db     *mongo.Database
-	logger *zap.Logger
+	db        *mongo.Database
+	logger    *zap.Logger
+	analytics analytics.AnalyticsSink
+	changes   webhooks.Publisher
 }
 
 func NewFeatureFlagHandler(db *mongo.Database, logger *zap.Logger) *FeatureFlagHandler {
 	return &FeatureFlagHandler{
-		db:     db,
-		logger: logger,
+		db:        db,
+		logger:    logger,
+		analytics: analytics.NopSink{},
+		changes:   webhooks.NopPublisher{},
 	}
 }
 
+// WithAnalytics sends the evaluations the handler serves to sink.
+func (ffh *FeatureFlagHandler) WithAnalytics(sink analytics.AnalyticsSink) *FeatureFlagHandler {
+	ffh.analytics = sink
+	return ffh
+}
+
+// WithChangePublisher sends every change to the revision a flag serves to publisher.
+func (ffh *FeatureFlagHandler) WithChangePublisher(publisher webhooks.Publisher) *FeatureFlagHandler {
+	ffh.changes = publisher
+	return ffh
+}
+
 type PostFeatureFlagRequest struct {
-	Name         string          `json:"name" validate:"required"`
-	Type         models.FlagType `json:"type" validate:"required,oneof=boolean json string number"`
-	DefaultValue string          `json:"default_value" validate:"required"`
-	Rules        []models.Rule   `json:"rules" validate:"dive,required"`
+	Name          string                `json:"name" validate:"required,excludes=/"`
+	Namespace     string                `json:"namespace"`
+	Description   string                `json:"description" validate:"max=500"`
+	Owner         string                `json:"owner" validate:"max=100"`
+	Type          models.FlagType       `json:"type" validate:"required,oneof=boolean json string number"`
+	DefaultValue  string                `json:"default_value" validate:"required"`
+	Rules         []models.Rule         `json:"rules" validate:"dive,required"`
+	Prerequisites []models.Prerequisite `json:"prerequisites" validate:"dive"`
+	// Constraints optionally limit the values the flag serves.
+	Constraints *models.ValueConstraints `json:"constraints"`
 }
 
+// PostBooleanFeatureFlagRequest is the shorthand for an on/off flag: the
+// initial state becomes the default value of its live revision.
+type PostBooleanFeatureFlagRequest struct {
+	Name        string `json:"name" validate:"required,excludes=/"`
+	Namespace   string `json:"namespace"`
+	Description string `json:"description" validate:"max=500"`
+	Owner       string `json:"owner" validate:"max=100"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// PatchFeatureFlagRequest proposes a new draft revision. Description, Owner,
+// Lifecycle and Tags describe the flag itself, so they're applied right away
+// and a request carrying only them doesn't create a revision.
 type PatchFeatureFlagRequest struct {
 	DefaultValue string        `json:"default_value"`
 	Rules        []models.Rule `json:"rules" validate:"dive,required"`
+	Description  *string       `json:"description,omitempty" validate:"omitempty,max=500"`
+	Owner        *string       `json:"owner,omitempty" validate:"omitempty,max=100"`
+	Lifecycle    *string       `json:"lifecycle,omitempty" validate:"omitempty,oneof=development in_rollout stable deprecated"`
+	// Tags replace the flag's tags.
+	Tags *[]string `json:"tags,omitempty" validate:"omitempty,max=20"`
+	// Constraints replace the flag's value constraints; empty ones remove them.
+	Constraints *models.ValueConstraints `json:"constraints,omitempty"`
 }
 
-type ListFeatureFlagResponse struct {
-	Data     []models.FeatureFlagRecord `json:"data"`
-	Page     int                        `json:"page"`
-	PageSize int                        `json:"page_size"`
-	Total    int                        `json:"total"`
+func (pffr *PatchFeatureFlagRequest) onlyMetadata() bool {
+	return pffr.DefaultValue == "" && pffr.Rules == nil &&
+		(pffr.Description != nil || pffr.Owner != nil || pffr.Lifecycle != nil || pffr.Constraints != nil ||
+			pffr.Tags != nil)
 }
 
-func (ffh *FeatureFlagHandler) ListFeatureFlags(c echo.Context) error {
-	pageQuery := c.QueryParam("page")
-	limitQuery := c.QueryParam("page_size")
+func (pffr *PatchFeatureFlagRequest) metadata() bson.D {
+	metadata := bson.D{}
+	if pffr.Description != nil {
+		metadata = append(metadata, bson.E{Key: "description", Value: *pffr.Description})
+	}
+	if pffr.Owner != nil {
+		metadata = append(metadata, bson.E{Key: "owner", Value: *pffr.Owner})
+	}
+	if pffr.Lifecycle != nil {
+		metadata = append(metadata, bson.E{Key: "lifecycle", Value: *pffr.Lifecycle})
+	}
+	if pffr.Constraints != nil {
+		metadata = append(metadata, bson.E{Key: "constraints", Value: pffr.constraints()})
+	}
+	if pffr.Tags != nil {
+		metadata = append(metadata, bson.E{Key: "tags", Value: *pffr.Tags})
+	}
+	return metadata
+}
 
-	page, limit := apiutils.GetPaginationParams(pageQuery, limitQuery)
+// constraints are the ones the request sets, nil when it removes them.
+func (pffr *PatchFeatureFlagRequest) constraints() *models.ValueConstraints {
+	if pffr.Constraints.IsEmpty() {
+		return nil
+	}
+	return pffr.Constraints
+}
+
+// LifecycleQueryParam restricts the listed flags to one lifecycle stage.
+const LifecycleQueryParam = "lifecycle"
+
+// TagQueryParam restricts the listed flags to those with a tag.
+const TagQueryParam = "tag"
 
+type ListFeatureFlagResponse = PaginatedResponse[models.FeatureFlagRecord]
+
+// APIKeyFeatureFlagsResponse is a page of flags along with the organization's
+// environment inheritance, which relays need to evaluate them as the central
+// server does. Being part of the body, a change to the inheritance changes
+// every page's ETag.
+type APIKeyFeatureFlagsResponse struct {
+	ListFeatureFlagResponse
+	EnvironmentParents map[string]string `json:"environment_parents,omitempty"`
+}
+
+func (ffh *FeatureFlagHandler) ListFeatureFlags(c echo.Context) error {
 	userID, organizationID, err := getIDsFromContext(c)
 	if err != nil {
 		ffh.logger.Debug("Client error",
@@ -63,9 +150,9 @@ func (ffh *FeatureFlagHandler) ListFeatureFlags(c echo.Context) error {
 	}
 
 	organizationModel := models.NewOrganizationModel(ffh.db)
-	organization, err := organizationModel.FindByID(context.Background(), organizationID)
+	organization, err := organizationModel.FindByID(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -87,11 +174,66 @@ func (ffh *FeatureFlagHandler) ListFeatureFlags(c echo.Context) error {
 		)
 	}
 
+	return ffh.listFeatureFlags(c, organizationID, nil)
+}
+
+// listFeatureFlags serves a page of the organization's flags, filtered by
+// the query, once the caller was found allowed to read them. The response
+// only carries environmentParents when there are some.
+func (ffh *FeatureFlagHandler) listFeatureFlags(
+	c echo.Context,
+	organizationID primitive.ObjectID,
+	environmentParents map[string]string,
+) error {
+	pageQuery := c.QueryParam("page")
+	limitQuery := c.QueryParam("page_size")
+
+	page, limit := apiutils.GetPaginationParams(pageQuery, limitQuery)
+
+	filter := bson.D{}
+	if namespace := c.QueryParam("namespace"); namespace != "" {
+		filter = append(filter, bson.E{Key: "namespace", Value: namespace})
+	}
+	if lifecycle := c.QueryParam(LifecycleQueryParam); lifecycle != "" {
+		switch lifecycle {
+		case models.Development:
+			// Flags created before lifecycle stages existed have none and are in development.
+			filter = append(filter, bson.E{Key: "lifecycle", Value: bson.M{"$in": bson.A{lifecycle, nil}}})
+		case models.InRollout, models.Stable, models.Deprecated:
+			filter = append(filter, bson.E{Key: "lifecycle", Value: lifecycle})
+		default:
+			ffh.logger.Debug("Client error",
+				zap.String("cause", "unknown lifecycle stage"),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+	}
+	if tag := c.QueryParam(TagQueryParam); tag != "" {
+		tag, err := models.NormalizeTag(tag)
+		if err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.InvalidTagError,
+			)
+		}
+		filter = append(filter, bson.E{Key: "tags", Value: tag})
+	}
+	archived := c.QueryParam(ArchivedQueryParam) == "true"
+	filter = append(filter, bson.E{Key: "archived_at", Value: bson.M{"$exists": archived}})
+
 	model := models.NewFeatureFlagModel(ffh.db)
 
-	featureFlags, err := model.FindMany(context.Background(), organizationID, page, limit)
+	featureFlags, err := model.FindMany(c.Request().Context(), organizationID, filter, page, limit)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -101,12 +243,66 @@ func (ffh *FeatureFlagHandler) ListFeatureFlags(c echo.Context) error {
 		)
 	}
 
-	return c.JSON(http.StatusOK, ListFeatureFlagResponse{
-		Data:     featureFlags,
-		Page:     page,
-		PageSize: limit,
-		Total:    len(featureFlags),
-	})
+	total, err := model.CountMany(c.Request().Context(), organizationID, filter)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	response := APIKeyFeatureFlagsResponse{
+		ListFeatureFlagResponse: NewPaginatedResponse(featureFlags, page, limit, total),
+		EnvironmentParents:      environmentParents,
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	etag, err := listETag(response)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return conditionalBlob(c, echo.MIMEApplicationJSONCharsetUTF8, body, etag, lastModified(featureFlags))
+}
+
+// listETag is the ETag of a flag list, computed as if no flag in it was ever
+// evaluated: evaluations move last_evaluated_at without changing the flags,
+// and pollers shouldn't download the list again for them.
+func listETag(response APIKeyFeatureFlagsResponse) (string, error) {
+	featureFlags := make([]models.FeatureFlagRecord, len(response.Data))
+	copy(featureFlags, response.Data)
+	for index := range featureFlags {
+		featureFlags[index].LastEvaluatedAt = 0
+	}
+	response.Data = featureFlags
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return apiutils.ETag(body), nil
 }
 
 func (ffh *FeatureFlagHandler) PostFeatureFlag(c echo.Context) error {
@@ -119,9 +315,9 @@ func (ffh *FeatureFlagHandler) PostFeatureFlag(c echo.Context) error {
 	}
 
 	organizationModel := models.NewOrganizationModel(ffh.db)
-	organizationRecord, err := organizationModel.FindByID(context.Background(), organizationID)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -166,19 +362,25 @@ func (ffh *FeatureFlagHandler) PostFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	featureFlagModel := models.NewFeatureFlagModel(ffh.db)
-	featureFlagRecord := models.NewFeatureFlagRecord(
-		request.Name,
-		request.DefaultValue,
-		request.Type,
-		request.Rules,
-		organizationID,
-		userID,
-	)
+	return ffh.createFeatureFlag(c, userID, organizationRecord, request)
+}
+
+// ValidateFeatureFlag runs a create request through every check
+// PostFeatureFlag makes and answers with the flag it would create, without
+// inserting it.
+func (ffh *FeatureFlagHandler) ValidateFeatureFlag(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
 
-	_, err = featureFlagModel.InsertOne(context.Background(), featureFlagRecord)
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -187,10 +389,51 @@ func (ffh *FeatureFlagHandler) PostFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	return c.JSON(http.StatusCreated, featureFlagRecord)
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	request := new(PostFeatureFlagRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	featureFlagRecord, ok, err := ffh.prepareFeatureFlag(c, userID, organizationRecord, request)
+	if !ok {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, featureFlagRecord)
 }
 
-func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
+func (ffh *FeatureFlagHandler) PostBooleanFeatureFlag(c echo.Context) error {
 	userID, organizationID, err := getIDsFromContext(c)
 	if err != nil {
 		ffh.logger.Debug("Client error",
@@ -200,9 +443,9 @@ func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
 	}
 
 	organizationModel := models.NewOrganizationModel(ffh.db)
-	organizationRecord, err := organizationModel.FindByID(context.Background(), organizationID)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -223,8 +466,8 @@ func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	featureFlagID, err := primitive.ObjectIDFromHex(c.Param("featureFlagID"))
-	if err != nil {
+	request := new(PostBooleanFeatureFlagRequest)
+	if err := c.Bind(request); err != nil {
 		ffh.logger.Debug("Client error",
 			zap.String("cause", err.Error()),
 		)
@@ -235,32 +478,55 @@ func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	request := new(PatchFeatureFlagRequest)
-	if err := c.Bind(request); err != nil {
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
 		ffh.logger.Debug("Client error",
 			zap.String("cause", err.Error()),
 		)
-		return apierrors.CustomError(
-			c,
+		return apierrors.CustomError(c,
 			http.StatusBadRequest,
 			apierrors.BadRequestError,
 		)
 	}
 
-	model := models.NewFeatureFlagModel(ffh.db)
+	return ffh.createFeatureFlag(c, userID, organizationRecord, &PostFeatureFlagRequest{
+		Name:         request.Name,
+		Namespace:    request.Namespace,
+		Description:  request.Description,
+		Owner:        request.Owner,
+		Type:         models.Boolean,
+		DefaultValue: strconv.FormatBool(request.Enabled),
+	})
+}
 
-	revision := models.NewRevisionRecord(
-		request.DefaultValue,
-		request.Rules,
-		userID,
-	)
-	_, err = model.UpdateOne(
-		context.Background(),
-		bson.D{{Key: "_id", Value: featureFlagID}},
-		bson.D{{Key: "$push", Value: bson.M{"revisions": revision}}},
-	)
+// createFeatureFlag inserts a validated request as a new flag with a live
+// revision.
+func (ffh *FeatureFlagHandler) createFeatureFlag(
+	c echo.Context,
+	userID primitive.ObjectID,
+	organization *models.OrganizationRecord,
+	request *PostFeatureFlagRequest,
+) error {
+	featureFlagRecord, ok, err := ffh.prepareFeatureFlag(c, userID, organization, request)
+	if !ok {
+		return err
+	}
+
+	featureFlagModel := models.NewFeatureFlagModel(ffh.db)
+	_, err = featureFlagModel.InsertOne(c.Request().Context(), featureFlagRecord)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request created the name after the check above.
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.FlagNameConflictError,
+		)
+	}
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -269,22 +535,287 @@ func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	return c.JSON(http.StatusOK, revision)
+	ffh.publishChange(featureFlagRecord, nil)
+
+	return c.JSON(http.StatusCreated, featureFlagRecord)
 }
 
-func (ffh *FeatureFlagHandler) ApproveRevision(c echo.Context) error {
-	userID, organizationID, err := getIDsFromContext(c)
-	if err != nil {
+// prepareFeatureFlag builds the flag a validated request would create,
+// rejecting name and env key conflicts, unknown prerequisites and flags over the
+// organization's limits or quota. When it reports false, the error response
+// has already been written.
+func (ffh *FeatureFlagHandler) prepareFeatureFlag(
+	c echo.Context,
+	userID primitive.ObjectID,
+	organization *models.OrganizationRecord,
+	request *PostFeatureFlagRequest,
+) (*models.FeatureFlagRecord, bool, error) {
+	organizationID := organization.ID
+	featureFlagModel := models.NewFeatureFlagModel(ffh.db)
+	qualifiedName := request.Name
+	if request.Namespace != "" {
+		qualifiedName = request.Namespace + models.NamespaceSeparator + request.Name
+	}
+
+	_, err := featureFlagModel.FindByName(c.Request().Context(), organizationID, qualifiedName)
+	if err == nil {
 		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FlagNameConflictError),
+		)
+		return nil, false, apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.FlagNameConflictError,
+		)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
+		return nil, false, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	featureFlags, err := featureFlagModel.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, false, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if ok, err := ffh.enforceEnvKeys(c, featureFlags, []string{qualifiedName}); !ok {
+		return nil, false, err
+	}
+
+	for _, prerequisite := range request.Prerequisites {
+		prerequisiteRecord, err := featureFlagModel.FindByID(c.Request().Context(), prerequisite.FeatureFlagID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return nil, false, apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+
+		if err != nil || prerequisiteRecord.OrganizationID != organizationID {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.PrerequisiteNotFoundError),
+			)
+			return nil, false, apierrors.CustomError(c,
+				http.StatusBadRequest,
+				apierrors.PrerequisiteNotFoundError,
+			)
+		}
+	}
+
+	if ok, err := ffh.enforceRuleLimits(c, request.Rules); !ok {
+		return nil, false, err
+	}
+	if ok, err := ffh.enforceUserLists(c, organizationID, request.Rules); !ok {
+		return nil, false, err
+	}
+	constraints := request.Constraints
+	if constraints.IsEmpty() {
+		constraints = nil
+	}
+	if ok, err := ffh.enforceConstraints(c, request.Type, constraints, nil); !ok {
+		return nil, false, err
+	}
+	if ok, err := ffh.enforceValueLimits(c, request.Type, constraints, request.DefaultValue, request.Rules); !ok {
+		return nil, false, err
+	}
+	if ok, err := ffh.enforceQuota(c, organization, 1, request.Rules); !ok {
+		return nil, false, err
+	}
+
+	featureFlagRecord := models.NewFeatureFlagRecord(
+		request.Name,
+		request.Namespace,
+		request.DefaultValue,
+		request.Type,
+		request.Rules,
+		organizationID,
+		userID,
+	)
+	awaitApproval(organization, featureFlagRecord)
+	featureFlagRecord.Prerequisites = request.Prerequisites
+	featureFlagRecord.Description = request.Description
+	featureFlagRecord.Owner = request.Owner
+	featureFlagRecord.Constraints = constraints
+
+	return featureFlagRecord, true, nil
+}
+
+func (ffh *FeatureFlagHandler) PatchFeatureFlag(c echo.Context) error {
+	userID, organizationRecord, featureFlagRecord, err := ffh.findFeatureFlagInOrganization(c, models.Collaborator)
+	if featureFlagRecord == nil {
 		return err
 	}
+	organizationID := organizationRecord.ID
+	featureFlagID := featureFlagRecord.ID
 
-	organizationModel := models.NewOrganizationModel(ffh.db)
-	organizationRecord, err := organizationModel.FindByID(context.Background(), organizationID)
+	model := models.NewFeatureFlagModel(ffh.db)
+
+	request := new(PatchFeatureFlagRequest)
+	if apiutils.IsJSONPatchMediaType(c.Request().Header.Get(echo.HeaderContentType)) {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+
+		request, err = patchLiveRevision(featureFlagRecord, body)
+		if err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			switch {
+			case errors.Is(err, errNoLiveRevision):
+				return apierrors.CustomError(c,
+					http.StatusConflict,
+					apierrors.NoLiveRevisionError,
+				)
+			case errors.Is(err, errFlagValueType):
+				return apierrors.CustomError(c,
+					http.StatusBadRequest,
+					apierrors.FlagValueTypeError,
+				)
+			}
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+	} else {
+		if err := c.Bind(request); err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(request); err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+	}
+
+	if request.Tags != nil {
+		tags, err := models.NormalizeTags(*request.Tags)
+		if err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusBadRequest,
+				apierrors.InvalidTagError,
+			)
+		}
+		request.Tags = &tags
+	}
+
+	constraints := featureFlagRecord.Constraints
+	if request.Constraints != nil {
+		constraints = request.constraints()
+		if ok, err := ffh.enforceConstraints(c, featureFlagRecord.Type, constraints, featureFlagRecord); !ok {
+			return err
+		}
+	}
+
+	filters := bson.D{
+		{Key: "_id", Value: featureFlagID},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+
+	if request.onlyMetadata() {
+		set := append(request.metadata(), bson.E{Key: "updated_by", Value: userID})
+		saved, err := model.UpdateOne(c.Request().Context(), filters, bson.D{{Key: "$set", Value: set}})
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+		if !saved {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.NotFoundError),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+
+		if request.Description != nil {
+			featureFlagRecord.Description = *request.Description
+		}
+		if request.Owner != nil {
+			featureFlagRecord.Owner = *request.Owner
+		}
+		if request.Lifecycle != nil {
+			featureFlagRecord.Lifecycle = *request.Lifecycle
+		}
+		if request.Tags != nil {
+			featureFlagRecord.Tags = *request.Tags
+		}
+		featureFlagRecord.Constraints = constraints
+		featureFlagRecord.UpdatedBy = userID
+		return c.JSON(http.StatusOK, featureFlagRecord)
+	}
+
+	if ok, err := ffh.enforceRuleLimits(c, request.Rules); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceUserLists(c, organizationID, request.Rules); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceValueLimits(c, featureFlagRecord.Type, constraints, request.DefaultValue, request.Rules); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceQuota(c, organizationRecord, 0, request.Rules); !ok {
+		return err
+	}
+
+	revision := models.NewRevisionRecord(
+		request.DefaultValue,
+		request.Rules,
+		userID,
+	)
+	newValues := bson.D{
+		{Key: "$push", Value: bson.M{"revisions": revision}},
+		{Key: "$set", Value: append(request.metadata(), bson.E{Key: "updated_by", Value: userID})},
+	}
+	saved, err := model.UpdateOne(c.Request().Context(), filters, newValues)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -292,81 +823,121 @@ func (ffh *FeatureFlagHandler) ApproveRevision(c echo.Context) error {
 			apierrors.InternalServerError,
 		)
 	}
-
-	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
-	if !permission {
-		ffh.logger.Debug("Server error",
-			zap.String("cause", apierrors.UnauthorizedError),
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
 		)
-		return apierrors.CustomError(
-			c,
-			http.StatusUnauthorized,
-			apierrors.UnauthorizedError,
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
 		)
 	}
 
-	featureFlagID, err := primitive.ObjectIDFromHex(c.Param("featureFlagID"))
-	if err != nil {
+	ffh.recordAudit(c, organizationID, featureFlagID, userID, models.PatchAction, map[string]any{
+		"revision_id": revision.ID,
+	})
+
+	c.Response().Header().Set(echo.HeaderLocation, revisionLocation(organizationID, featureFlagID, revision.ID))
+	return c.JSON(http.StatusOK, revision)
+}
+
+// revisionLocation is the path revisionID is approved at.
+func revisionLocation(organizationID, featureFlagID, revisionID primitive.ObjectID) string {
+	return fmt.Sprintf("/organizations/%s/feature-flags/%s/revisions/%s",
+		organizationID.Hex(),
+		featureFlagID.Hex(),
+		revisionID.Hex(),
+	)
+}
+
+func (ffh *FeatureFlagHandler) ApproveRevision(c echo.Context) error {
+	userID, organizationRecord, featureFlagRecord, err := ffh.findFeatureFlagInOrganization(c, models.Collaborator)
+	if featureFlagRecord == nil {
+		return err
+	}
+	organizationID := organizationRecord.ID
+	featureFlagID := featureFlagRecord.ID
+
+	model := models.NewFeatureFlagModel(ffh.db)
+
+	revisionID := apiutils.ObjectIDParam(c, "revisionID")
+
+	// The target is checked before anything is demoted so a bad request
+	// can't leave the flag without a live revision.
+	targetIndex := -1
+	for index, revision := range featureFlagRecord.Revisions {
+		if revision.ID == revisionID {
+			targetIndex = index
+			break
+		}
+	}
+	if targetIndex == -1 {
 		ffh.logger.Debug("Client error",
-			zap.String("cause", err.Error()),
+			zap.String("cause", "revision not found"),
 		)
 		return apierrors.CustomError(
 			c,
-			http.StatusBadRequest,
-			apierrors.BadRequestError,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
 		)
 	}
-
-	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionID"))
-	if err != nil {
+	// Approving the live revision again changes nothing, so it doesn't bump the version.
+	if featureFlagRecord.Revisions[targetIndex].Status == models.Live {
+		return c.JSON(http.StatusOK, featureFlagRecord)
+	}
+	if featureFlagRecord.Revisions[targetIndex].Status != models.Draft {
 		ffh.logger.Debug("Client error",
-			zap.String("cause", err.Error()),
+			zap.String("cause", apierrors.RevisionNotDraftError),
 		)
 		return apierrors.CustomError(
 			c,
-			http.StatusBadRequest,
-			apierrors.BadRequestError,
+			http.StatusConflict,
+			apierrors.RevisionNotDraftError,
 		)
 	}
-
-	model := models.NewFeatureFlagModel(ffh.db)
-	featureFlagRecord, err := model.FindByID(context.Background(), featureFlagID)
-	if err != nil {
-		ffh.logger.Debug("Server error",
-			zap.String("cause", err.Error()),
+	if organizationRecord.Settings.RequireSeparateApprover &&
+		featureFlagRecord.Revisions[targetIndex].UserID == userID {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.SelfApprovalForbiddenError),
 		)
 		return apierrors.CustomError(
 			c,
-			http.StatusInternalServerError,
-			apierrors.InternalServerError,
+			http.StatusForbidden,
+			apierrors.SelfApprovalForbiddenError,
 		)
 	}
 
+	previous := snapshotLiveRevision(featureFlagRecord)
+	previousVersion := featureFlagRecord.Version
 	var lastRevisionID primitive.ObjectID
 	for index, revision := range featureFlagRecord.Revisions {
 		if revision.Status == models.Live {
 			featureFlagRecord.Revisions[index].Status = models.Archived
 			lastRevisionID = revision.ID
 		}
-		if revision.ID == revisionID && revision.Status == models.Draft {
-			featureFlagRecord.Revisions[index].Status = models.Live
-			featureFlagRecord.Revisions[index].LastRevisionID = lastRevisionID
-		}
 	}
+	featureFlagRecord.Revisions[targetIndex].Approve(userID, time.Now())
+	featureFlagRecord.Revisions[targetIndex].LastRevisionID = lastRevisionID
 	featureFlagRecord.Version++
+	featureFlagRecord.UpdatedBy = userID
 
-	filters := bson.D{{Key: "_id", Value: featureFlagID}}
-	newValues := bson.D{
-		{
-			Key: "$set", Value: bson.D{
-				{Key: "version", Value: featureFlagRecord.Version},
-				{Key: "revisions", Value: featureFlagRecord.Revisions},
-			},
-		},
+	// The whole revisions array is written back, so it is only saved while
+	// the flag is as it was read: a revision pushed or approved meanwhile
+	// would be lost.
+	set := bson.D{
+		{Key: "version", Value: featureFlagRecord.Version},
+		{Key: "revisions", Value: featureFlagRecord.Revisions},
+		{Key: "updated_by", Value: featureFlagRecord.UpdatedBy},
 	}
-	_, err = model.UpdateOne(context.Background(), filters, newValues)
+	saved, err := model.ApproveRevision(
+		c.Request().Context(),
+		featureFlagID,
+		previousVersion,
+		featureFlagRecord.Writes,
+		set,
+	)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -374,21 +945,71 @@ func (ffh *FeatureFlagHandler) ApproveRevision(c echo.Context) error {
 			apierrors.InternalServerError,
 		)
 	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FlagChangedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.FlagChangedError,
+		)
+	}
+
+	ffh.recordAudit(c, organizationID, featureFlagID, userID, models.ApproveAction, map[string]any{
+		"revision_id":      revisionID,
+		"last_revision_id": lastRevisionID,
+	})
+	ffh.publishChange(featureFlagRecord, previous)
 
 	return c.JSON(http.StatusOK, featureFlagRecord)
 }
 
 func (ffh *FeatureFlagHandler) RollbackFeatureFlagVersion(c echo.Context) error {
-	userID, organizationID, err := getIDsFromContext(c)
-	if err != nil {
-		log.Println(apiutils.HandlerErrorLogMessage(err, c))
+	userID, organizationRecord, featureFlagRecord, err := ffh.findFeatureFlagInOrganization(c, models.Collaborator)
+	if featureFlagRecord == nil {
 		return err
 	}
+	organizationID := organizationRecord.ID
+	featureFlagID := featureFlagRecord.ID
 
-	organizationModel := models.NewOrganizationModel(ffh.db)
-	organizationRecord, err := organizationModel.FindByID(context.Background(), organizationID)
+	model := models.NewFeatureFlagModel(ffh.db)
+
+	// Rolling back makes the previous revision live again, which its author
+	// can't do on their own any more than approve it.
+	if live := featureFlagRecord.LiveRevision(); live != nil && organizationRecord.Settings.RequireSeparateApprover {
+		restored := featureFlagRecord.FindRevision(live.LastRevisionID)
+		if restored != nil && restored.UserID == userID {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.SelfApprovalForbiddenError),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusForbidden,
+				apierrors.SelfApprovalForbiddenError,
+			)
+		}
+	}
+
+	previous := snapshotLiveRevision(featureFlagRecord)
+	previousVersion := featureFlagRecord.Version
+	rollbackRevisions(featureFlagRecord)
+	featureFlagRecord.UpdatedBy = userID
+
+	// Like an approval, the rollback writes back the whole revisions array.
+	set := bson.D{
+		{Key: "version", Value: featureFlagRecord.Version},
+		{Key: "revisions", Value: featureFlagRecord.Revisions},
+		{Key: "updated_by", Value: featureFlagRecord.UpdatedBy},
+	}
+	saved, err := model.SaveRollback(
+		c.Request().Context(),
+		featureFlagID,
+		previousVersion,
+		featureFlagRecord.Writes,
+		set,
+	)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -396,35 +1017,43 @@ func (ffh *FeatureFlagHandler) RollbackFeatureFlagVersion(c echo.Context) error
 			apierrors.InternalServerError,
 		)
 	}
-
-	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
-	if !permission {
-		ffh.logger.Debug("Server error",
-			zap.String("cause", apierrors.ForbiddenError),
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FlagChangedError),
 		)
-		return apierrors.CustomError(
-			c,
-			http.StatusForbidden,
-			apierrors.ForbiddenError,
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.FlagChangedError,
 		)
 	}
 
-	featureFlagID, err := primitive.ObjectIDFromHex(c.Param("featureFlagID"))
-	if err != nil {
-		ffh.logger.Debug("Client error",
-			zap.String("cause", err.Error()),
-		)
-		return apierrors.CustomError(
-			c,
-			http.StatusBadRequest,
-			apierrors.BadRequestError,
-		)
+	ffh.recordAudit(c, organizationID, featureFlagID, userID, models.RollbackAction, nil)
+	ffh.publishChange(featureFlagRecord, previous)
+
+	return c.JSON(http.StatusOK, featureFlagRecord)
+}
+
+type DeleteFeatureFlagConflictResponse struct {
+	Error      string                 `json:"error"`
+	Message    apierrors.ErrorMessage `json:"message"`
+	Dependents []DependencyNode       `json:"dependents"`
+}
+
+// DeleteFeatureFlag refuses to delete a flag other flags depend on,
+// unless ?force=true is given, in which case the dependents are detached first.
+func (ffh *FeatureFlagHandler) DeleteFeatureFlag(c echo.Context) error {
+	userID, organizationRecord, featureFlagRecord, err := ffh.findFeatureFlagInOrganization(c, models.Collaborator)
+	if featureFlagRecord == nil {
+		return err
 	}
+	organizationID := organizationRecord.ID
+	featureFlagID := featureFlagRecord.ID
 
 	model := models.NewFeatureFlagModel(ffh.db)
-	featureFlagRecord, err := model.FindByID(context.Background(), featureFlagID)
+
+	dependents, err := model.FindDependents(c.Request().Context(), featureFlagID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -434,45 +1063,99 @@ func (ffh *FeatureFlagHandler) RollbackFeatureFlagVersion(c echo.Context) error
 		)
 	}
 
-	var newRevisionID primitive.ObjectID
-	for index, revision := range featureFlagRecord.Revisions {
-		if revision.Status == models.Live {
-			featureFlagRecord.Revisions[index].Status = models.Draft
-			newRevisionID = revision.LastRevisionID
-			featureFlagRecord.Revisions[index].LastRevisionID = primitive.NilObjectID
+	if len(dependents) > 0 {
+		if c.QueryParam("force") != "true" {
+			nodes := make([]DependencyNode, 0, len(dependents))
+			for _, dependent := range dependents {
+				nodes = append(nodes, DependencyNode{ID: dependent.ID, Name: dependent.QualifiedName()})
+			}
+
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.FlagHasDependentsError),
+			)
+			return c.JSON(http.StatusConflict, DeleteFeatureFlagConflictResponse{
+				Error:      http.StatusText(http.StatusConflict),
+				Message:    apierrors.FlagHasDependentsError,
+				Dependents: nodes,
+			})
 		}
-	}
-	for index, revision := range featureFlagRecord.Revisions {
-		if revision.ID == newRevisionID && revision.Status == models.Archived {
-			featureFlagRecord.Revisions[index].Status = models.Live
+
+		if err := model.DetachPrerequisite(c.Request().Context(), featureFlagID); err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
 		}
+
+		ffh.logger.Info("Detached feature flag from its dependents",
+			zap.String("_id", featureFlagID.Hex()),
+			zap.Int("dependents", len(dependents)),
+		)
 	}
-	featureFlagRecord.Version--
 
-	filters := bson.D{{Key: "_id", Value: featureFlagID}}
-	newValues := bson.D{
-		{
-			Key: "$set", Value: bson.D{
-				{Key: "version", Value: featureFlagRecord.Version},
-				{Key: "revisions", Value: featureFlagRecord.Revisions},
-			},
+	saved, err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{
+			{Key: "_id", Value: featureFlagID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
 		},
-	}
-	_, err = model.UpdateOne(context.Background(), filters, newValues)
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{
+					Key:   "deleted_at",
+					Value: primitive.NewDateTimeFromTime(time.Now().UTC()),
+				},
+			}},
+		},
+	)
+
 	if err != nil {
-		ffh.logger.Debug("Server error",
-			zap.String("cause", err.Error()),
-		)
-		return apierrors.CustomError(c,
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()))
+		return apierrors.CustomError(
+			c,
 			http.StatusInternalServerError,
 			apierrors.InternalServerError,
 		)
 	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
 
-	return c.JSON(http.StatusOK, featureFlagRecord)
+	ffh.logger.Info("Soft deleted feature flag",
+		zap.String("_id", featureFlagID.Hex()))
+	ffh.recordAudit(c, organizationID, featureFlagID, userID, models.DeleteAction, map[string]any{
+		"detached_dependents": len(dependents),
+	})
+	return c.NoContent(http.StatusNoContent)
 }
 
-func (ffh *FeatureFlagHandler) DeleteFeatureFlag(c echo.Context) error {
+// DependencyNode is marked Missing when a flag references a prerequisite that no longer exists.
+type DependencyNode struct {
+	ID      primitive.ObjectID `json:"_id"`
+	Name    string             `json:"name"`
+	Missing bool               `json:"missing,omitempty"`
+}
+
+type FeatureFlagDependenciesResponse struct {
+	FeatureFlag DependencyNode          `json:"feature_flag"`
+	Upstream    []DependencyNode        `json:"upstream"`
+	Downstream  []DependencyNode        `json:"downstream"`
+	Edges       []models.DependencyEdge `json:"edges"`
+	Cycles      [][]primitive.ObjectID  `json:"cycles"`
+}
+
+func (ffh *FeatureFlagHandler) GetFeatureFlagDependencies(c echo.Context) error {
 	userID, organizationID, err := getIDsFromContext(c)
 	if err != nil {
 		ffh.logger.Debug("Client error",
@@ -482,9 +1165,9 @@ func (ffh *FeatureFlagHandler) DeleteFeatureFlag(c echo.Context) error {
 	}
 
 	organizationModel := models.NewOrganizationModel(ffh.db)
-	organizationRecord, err := organizationModel.FindByID(context.Background(), organizationID)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -493,9 +1176,9 @@ func (ffh *FeatureFlagHandler) DeleteFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
 	if !permission {
-		ffh.logger.Debug("Server error",
+		ffh.logger.Debug("Client error",
 			zap.String("cause", apierrors.ForbiddenError),
 		)
 		return apierrors.CustomError(
@@ -505,66 +1188,259 @@ func (ffh *FeatureFlagHandler) DeleteFeatureFlag(c echo.Context) error {
 		)
 	}
 
-	featureFlagID, err := primitive.ObjectIDFromHex(c.Param("featureFlagID"))
+	featureFlagID := apiutils.ObjectIDParam(c, "featureFlagID")
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
 	if err != nil {
-		ffh.logger.Debug("Client error",
+		ffh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
 			c,
-			http.StatusBadRequest,
-			apierrors.BadRequestError,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
 		)
 	}
 
-	model := models.NewFeatureFlagModel(ffh.db)
+	graph := models.NewDependencyGraph(featureFlags)
+	featureFlag := graph.Flag(featureFlagID)
+	if featureFlag == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
 
-	objectID, err := model.UpdateOne(
-		context.Background(),
-		bson.D{{Key: "_id", Value: featureFlagID}},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{
-					Key:   "deleted_at",
-					Value: primitive.NewDateTimeFromTime(time.Now().UTC()),
-				},
-			}},
-		},
-	)
+	upstream := graph.Upstream(featureFlagID)
+	downstream := graph.Downstream(featureFlagID)
+	related := append([]primitive.ObjectID{featureFlagID}, upstream...)
+	related = append(related, downstream...)
 
+	toNodes := func(ids []primitive.ObjectID) []DependencyNode {
+		nodes := make([]DependencyNode, 0, len(ids))
+		for _, id := range ids {
+			record := graph.Flag(id)
+			if record == nil {
+				nodes = append(nodes, DependencyNode{ID: id, Missing: true})
+				continue
+			}
+			nodes = append(nodes, DependencyNode{ID: id, Name: record.QualifiedName()})
+		}
+		return nodes
+	}
+
+	return c.JSON(http.StatusOK, FeatureFlagDependenciesResponse{
+		FeatureFlag: DependencyNode{ID: featureFlag.ID, Name: featureFlag.QualifiedName()},
+		Upstream:    toNodes(upstream),
+		Downstream:  toNodes(downstream),
+		Edges:       graph.Edges(related),
+		Cycles:      graph.Cycles(related),
+	})
+}
+
+// findEditableFeatureFlag returns the caller's id along with the flag in the path.
+// The flag is nil, and the error response already written, when the caller can't edit it.
+func (ffh *FeatureFlagHandler) findEditableFeatureFlag(
+	c echo.Context,
+) (primitive.ObjectID, *models.FeatureFlagRecord, error) {
+	return ffh.findFeatureFlag(c, models.Collaborator)
+}
+
+// findFeatureFlag is findEditableFeatureFlag for callers needing permission
+// instead of collaborator access.
+func (ffh *FeatureFlagHandler) findFeatureFlag(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, *models.FeatureFlagRecord, error) {
+	userID, _, featureFlagRecord, err := ffh.findFeatureFlagInOrganization(c, permissionLevel)
+	return userID, featureFlagRecord, err
+}
+
+// findFeatureFlagInOrganization is findFeatureFlag for callers that also need
+// the organization in the path.
+func (ffh *FeatureFlagHandler) findFeatureFlagInOrganization(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, *models.OrganizationRecord, *models.FeatureFlagRecord, error) {
+	userID, organizationRecord, err := ffh.findOrganization(c, permissionLevel)
+	if organizationRecord == nil {
+		return primitive.NilObjectID, nil, nil, err
+	}
+
+	featureFlagID := apiutils.ObjectIDParam(c, "featureFlagID")
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlagRecord, err := model.FindByID(c.Request().Context(), featureFlagID)
 	if err != nil {
-		ffh.logger.Debug("Server error",
-			zap.String("cause", err.Error()))
-		return apierrors.CustomError(
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return primitive.NilObjectID, nil, nil, apierrors.CustomError(
+				c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, nil, nil, apierrors.CustomError(
 			c,
 			http.StatusInternalServerError,
 			apierrors.InternalServerError,
 		)
 	}
 
-	ffh.logger.Info("Soft deleted feature flag",
-		zap.String("_id", objectID.Hex()))
-	return c.JSON(http.StatusNoContent, nil)
+	if featureFlagRecord.OrganizationID != organizationRecord.ID {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", "feature flag belongs to another organization"),
+		)
+		return primitive.NilObjectID, nil, nil, apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return userID, organizationRecord, featureFlagRecord, nil
 }
 
-func getIDsFromContext(c echo.Context) (primitive.ObjectID, primitive.ObjectID, error) {
-	userID, err := apiutils.GetObjectIDFromContext(c)
+// recordAudit adds an entry to the audit log. The change it describes already
+// happened, so a failure is logged rather than turned into an error response.
+// Changes made while impersonating are tagged with the impersonation session.
+func (ffh *FeatureFlagHandler) recordAudit(
+	c echo.Context,
+	organizationID,
+	featureFlagID,
+	userID primitive.ObjectID,
+	action models.AuditAction,
+	details map[string]any,
+) {
+	entry := models.NewAuditEntry(organizationID, featureFlagID, userID, action, details)
+	entry.ImpersonationID = apiutils.GetImpersonationIDFromContext(c)
+
+	model := models.NewAuditLogModel(ffh.db)
+	_, err := model.InsertOne(context.Background(), entry)
 	if err != nil {
-		return primitive.NilObjectID, primitive.NilObjectID, apierrors.CustomError(
-			c,
-			http.StatusUnauthorized,
-			apierrors.UnauthorizedError,
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+			zap.String("action", action),
 		)
 	}
+}
+
+// awaitApproval leaves the first revision of a new flag as a draft when the
+// organization requires a separate approver, so the flag only serves it once
+// someone other than its author approves it.
+func awaitApproval(organization *models.OrganizationRecord, featureFlag *models.FeatureFlagRecord) {
+	if organization.Settings.RequireSeparateApprover {
+		featureFlag.Revisions[0].Status = models.Draft
+	}
+}
+
+// publishChange tells subscribers featureFlag now serves its live revision
+// instead of previous, nil for a new flag.
+func (ffh *FeatureFlagHandler) publishChange(featureFlag *models.FeatureFlagRecord, previous *models.Revision) {
+	current := featureFlag.LiveRevision()
+	if current == nil {
+		return
+	}
+
+	ffh.changes.Publish(webhooks.NewFeatureFlagUpdatedPayload(featureFlag, previous, current, time.Now()))
+}
+
+// snapshotLiveRevision copies the live revision of featureFlag so it survives
+// the revisions being updated in place.
+func snapshotLiveRevision(featureFlag *models.FeatureFlagRecord) *models.Revision {
+	live := featureFlag.LiveRevision()
+	if live == nil {
+		return nil
+	}
+
+	snapshot := *live
+	return &snapshot
+}
+
+// rollbackRevisions demotes the live revision of featureFlag back to draft and
+// restores the revision it replaced.
+func rollbackRevisions(featureFlag *models.FeatureFlagRecord) {
+	var newRevisionID primitive.ObjectID
+	for index, revision := range featureFlag.Revisions {
+		if revision.Status == models.Live {
+			featureFlag.Revisions[index].Status = models.Draft
+			newRevisionID = revision.LastRevisionID
+			featureFlag.Revisions[index].LastRevisionID = primitive.NilObjectID
+			featureFlag.Revisions[index].ApprovedBy = primitive.NilObjectID
+			featureFlag.Revisions[index].ApprovedAt = 0
+		}
+	}
+	for index, revision := range featureFlag.Revisions {
+		if revision.ID == newRevisionID && revision.Status == models.Archived {
+			featureFlag.Revisions[index].Status = models.Live
+		}
+	}
+	featureFlag.Version--
+}
+
+// conditionalBlob serves body along with the validators polling clients need
+// to revalidate it, and answers 304 without the body when their copy is current.
+func conditionalBlob(c echo.Context, contentType string, body []byte, etag string, lastModified time.Time) error {
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, apiutils.RevalidateCacheControl)
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if apiutils.NotModified(c.Request(), etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, contentType, body)
+}
 
-	organizationID, err := primitive.ObjectIDFromHex(c.Param("organizationID"))
+// lastModified returns the latest updated_at among featureFlags. A deleted
+// flag doesn't move it, so clients should prefer the ETag to revalidate.
+func lastModified(featureFlags []models.FeatureFlagRecord) time.Time {
+	var latest time.Time
+	for index := range featureFlags {
+		if updatedAt := featureFlags[index].UpdatedAt.Time(); updatedAt.After(latest) {
+			latest = updatedAt
+		}
+	}
+
+	return latest
+}
+
+// lastModifiedIn is lastModified for what featureFlags serve in environment,
+// so approving a revision that only changes another environment doesn't move
+// it.
+func lastModifiedIn(featureFlags []models.FeatureFlagRecord, environment string, parents map[string]string) time.Time {
+	var latest time.Time
+	for index := range featureFlags {
+		if modifiedAt := featureFlags[index].LastModifiedIn(environment, parents); modifiedAt.After(latest) {
+			latest = modifiedAt
+		}
+	}
+
+	return latest
+}
+
+func getIDsFromContext(c echo.Context) (primitive.ObjectID, primitive.ObjectID, error) {
+	userID, err := apiutils.GetObjectIDFromContext(c)
 	if err != nil {
 		return primitive.NilObjectID, primitive.NilObjectID, apierrors.CustomError(
 			c,
-			http.StatusBadRequest,
-			apierrors.BadRequestError,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
 		)
 	}
 
-	return userID, organizationID, err
+	return userID, apiutils.ObjectIDParam(c, "organizationID"), nil
 }
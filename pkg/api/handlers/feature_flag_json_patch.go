@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+	jsonpatch "github.com/Roll-Play/togglelabs/pkg/utils/json_patch"
+	"github.com/go-playground/validator/v10"
+)
+
+var errNoLiveRevision = errors.New("feature flag has no live revision")
+var errFlagValueType = errors.New("value doesn't match the feature flag type")
+
+// patchLiveRevision applies an RFC 6902 patch to the live revision's default
+// value and rules, the same document a regular PatchFeatureFlagRequest
+// describes. Rules keep their ids unless the patch changes them.
+func patchLiveRevision(featureFlag *models.FeatureFlagRecord, body []byte) (*PatchFeatureFlagRequest, error) {
+	liveRevision := featureFlag.LiveRevision()
+	if liveRevision == nil {
+		return nil, errNoLiveRevision
+	}
+
+	patch, err := jsonpatch.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := liveRevision.Rules
+	if rules == nil {
+		rules = make([]models.Rule, 0)
+	}
+	document, err := json.Marshal(PatchFeatureFlagRequest{
+		DefaultValue: liveRevision.DefaultValue,
+		Rules:        rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := patch.Apply(document)
+	if err != nil {
+		return nil, err
+	}
+
+	request := new(PatchFeatureFlagRequest)
+	if err := json.Unmarshal(patched, request); err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		return nil, err
+	}
+
+	if !flagvalue.Valid(featureFlag.Type, request.DefaultValue) {
+		return nil, errFlagValueType
+	}
+	for _, rule := range request.Rules {
+		if !flagvalue.Valid(featureFlag.Type, rule.Value) {
+			return nil, errFlagValueType
+		}
+	}
+
+	return request, nil
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type PutOverrideRequest struct {
+	Value string `json:"value" validate:"required"`
+}
+
+type OverridesResponse struct {
+	Overrides []models.Override `json:"overrides"`
+}
+
+// PutOverride forces a value for a single end-user id. Overrides live on the
+// flag itself, so they take effect right away without a new revision. The
+// value must fit the flag's type and constraints like any value it serves.
+func (ffh *FeatureFlagHandler) PutOverride(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PutOverrideRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if ok, err := ffh.enforceValueConstraints(c, featureFlagRecord.Type, featureFlagRecord.Constraints, request.Value); !ok {
+		return err
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	overrides, err := model.SaveOverride(c.Request().Context(), featureFlagRecord.ID, models.Override{
+		UserID: c.Param("userID"),
+		Value:  request.Value,
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, OverridesResponse{Overrides: overrides})
+}
+
+func (ffh *FeatureFlagHandler) DeleteOverride(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	deleted, err := model.DeleteOverride(c.Request().Context(), featureFlagRecord.ID, c.Param("userID"))
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	if !deleted {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type PutSegmentOverridesRequest struct {
+	SegmentOverrides []models.SegmentOverride `json:"segment_overrides" validate:"max=100,dive"`
+}
+
+type SegmentOverridesResponse struct {
+	SegmentOverrides []models.SegmentOverride `json:"segment_overrides"`
+}
+
+// PutSegmentOverrides replaces every segment override of the flag at once,
+// so priorities are always checked against each other. Like per-user
+// overrides, they take effect right away without a new revision; an empty
+// list removes them.
+func (ffh *FeatureFlagHandler) PutSegmentOverrides(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PutSegmentOverridesRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	overrides := request.SegmentOverrides
+	if overrides == nil {
+		overrides = make([]models.SegmentOverride, 0)
+	}
+	if !models.SortSegmentOverrides(overrides) {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.DuplicateSegmentPriorityError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.DuplicateSegmentPriorityError,
+		)
+	}
+
+	for _, override := range overrides {
+		if ok, err := ffh.enforceValueConstraints(c, featureFlagRecord.Type, featureFlagRecord.Constraints, override.Value); !ok {
+			return err
+		}
+	}
+
+	if ok, err := ffh.enforceUserListIDs(
+		c,
+		featureFlagRecord.OrganizationID,
+		models.SegmentUserListIDs(overrides),
+		apierrors.SegmentNotFoundError,
+	); !ok {
+		return err
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	saved, err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{
+			{Key: "_id", Value: featureFlagRecord.ID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		bson.D{{Key: "$set", Value: bson.M{"segment_overrides": overrides}}},
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, SegmentOverridesResponse{SegmentOverrides: overrides})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"net/http"
+	"sort"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	PreviewSampleSetQueryParam   = "sample_set"
+	PreviewEnvironmentQueryParam = "environment"
+)
+
+// PreviewOutcome is how many sampled contexts were served Value.
+type PreviewOutcome struct {
+	Value      string  `json:"value"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+type PreviewRevisionResponse struct {
+	SampleSize        int              `json:"sample_size"`
+	Current           []PreviewOutcome `json:"current"`
+	Proposed          []PreviewOutcome `json:"proposed"`
+	Changed           int              `json:"changed"`
+	ChangedPercentage float64          `json:"changed_percentage"`
+}
+
+// PreviewRevision evaluates a draft revision against every context of a
+// sample set, as if it had been approved, and compares what is served with
+// the live revision. Current is empty when the flag has no live revision.
+func (ffh *FeatureFlagHandler) PreviewRevision(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	revisionID := apiutils.ObjectIDParam(c, "revisionID")
+
+	sampleSetID, err := primitive.ObjectIDFromHex(c.QueryParam(PreviewSampleSetQueryParam))
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	revisionIndex := -1
+	for index := range featureFlagRecord.Revisions {
+		if featureFlagRecord.Revisions[index].ID == revisionID {
+			revisionIndex = index
+			break
+		}
+	}
+	if revisionIndex == -1 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	if featureFlagRecord.Revisions[revisionIndex].Status != models.Draft {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.RevisionNotPreviewableError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.RevisionNotPreviewableError,
+		)
+	}
+
+	sampleSetModel := models.NewContextSampleSetModel(ffh.db)
+	sampleSet, err := sampleSetModel.FindByID(c.Request().Context(), featureFlagRecord.OrganizationID, sampleSetID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	currentFlags, err := model.FindAllByOrganization(c.Request().Context(), featureFlagRecord.OrganizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	userListModel := models.NewUserListModel(ffh.db)
+	userLists, err := userListModel.FindSets(
+		c.Request().Context(),
+		featureFlagRecord.OrganizationID,
+		models.UserListIDs(currentFlags),
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	flagIndex := -1
+	for index := range currentFlags {
+		if currentFlags[index].ID == featureFlagRecord.ID {
+			flagIndex = index
+			break
+		}
+	}
+	if flagIndex == -1 {
+		currentFlags = append(currentFlags, *featureFlagRecord)
+		flagIndex = len(currentFlags) - 1
+	}
+
+	proposedFlags := make([]models.FeatureFlagRecord, len(currentFlags))
+	copy(proposedFlags, currentFlags)
+	proposedFlags[flagIndex] = withLiveRevision(*featureFlagRecord, revisionIndex)
+
+	environment := c.QueryParam(PreviewEnvironmentQueryParam)
+	current := make(map[string]int)
+	proposed := make(map[string]int)
+	changed := 0
+	for _, attributes := range sampleSet.Contexts {
+		sampleContext := evaluation.Context{Environment: environment, Attributes: attributes, UserLists: userLists}
+		currentResult, currentErr := evaluation.NewEvaluator(currentFlags, sampleContext).
+			Evaluate(&currentFlags[flagIndex])
+		proposedResult, _ := evaluation.NewEvaluator(proposedFlags, sampleContext).
+			Evaluate(&proposedFlags[flagIndex])
+
+		if currentErr == nil {
+			current[currentResult.Value]++
+		}
+		proposed[proposedResult.Value]++
+		if currentErr != nil || currentResult.Value != proposedResult.Value {
+			changed++
+		}
+	}
+
+	sampleSize := len(sampleSet.Contexts)
+	return c.JSON(http.StatusOK, PreviewRevisionResponse{
+		SampleSize:        sampleSize,
+		Current:           previewOutcomes(current, sampleSize),
+		Proposed:          previewOutcomes(proposed, sampleSize),
+		Changed:           changed,
+		ChangedPercentage: percentage(changed, sampleSize),
+	})
+}
+
+// withLiveRevision returns a copy of featureFlag serving the revision at
+// revisionIndex, the way approving it would.
+func withLiveRevision(featureFlag models.FeatureFlagRecord, revisionIndex int) models.FeatureFlagRecord {
+	revisions := make([]models.Revision, len(featureFlag.Revisions))
+	copy(revisions, featureFlag.Revisions)
+	for index := range revisions {
+		if revisions[index].Status == models.Live {
+			revisions[index].Status = models.Archived
+		}
+	}
+	revisions[revisionIndex].Status = models.Live
+	featureFlag.Revisions = revisions
+
+	return featureFlag
+}
+
+// previewOutcomes sorts outcomes from the most to the least served value.
+func previewOutcomes(counts map[string]int, sampleSize int) []PreviewOutcome {
+	outcomes := make([]PreviewOutcome, 0, len(counts))
+	for value, count := range counts {
+		outcomes = append(outcomes, PreviewOutcome{
+			Value:      value,
+			Count:      count,
+			Percentage: percentage(count, sampleSize),
+		})
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		if outcomes[i].Count != outcomes[j].Count {
+			return outcomes[i].Count > outcomes[j].Count
+		}
+		return outcomes[i].Value < outcomes[j].Value
+	})
+
+	return outcomes
+}
+
+func percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) * 100 / float64(total)
+}
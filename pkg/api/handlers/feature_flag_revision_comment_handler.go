@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PostRevisionCommentRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// CommentAuthor is what's shown of a comment's author. It is left with only
+// the ID when the user no longer exists.
+type CommentAuthor struct {
+	ID        primitive.ObjectID `json:"_id"`
+	Email     string             `json:"email,omitempty"`
+	FirstName string             `json:"first_name,omitempty"`
+	LastName  string             `json:"last_name,omitempty"`
+}
+
+type RevisionCommentResponse struct {
+	ID        primitive.ObjectID `json:"_id"`
+	Body      string             `json:"body"`
+	Author    CommentAuthor      `json:"author"`
+	CreatedAt primitive.DateTime `json:"created_at"`
+}
+
+type ListRevisionCommentsResponse = PaginatedResponse[RevisionCommentResponse]
+
+func newCommentAuthor(user *models.UserRecord) CommentAuthor {
+	return CommentAuthor{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+}
+
+// PostRevisionComment adds a comment to a revision of the flag, for reviewers
+// to discuss it before it's approved.
+func (ffh *FeatureFlagHandler) PostRevisionComment(c echo.Context) error {
+	userID, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	revisionID := apiutils.ObjectIDParam(c, "revisionID")
+
+	if featureFlagRecord.FindRevision(revisionID) == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	request := new(PostRevisionCommentRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userModel := models.NewUserModel(ffh.db)
+	user, err := userModel.FindByID(c.Request().Context(), userID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	comment := models.NewRevisionCommentRecord(featureFlagRecord.ID, revisionID, userID, request.Body)
+	model := models.NewRevisionCommentModel(ffh.db)
+	if _, err := model.InsertOne(c.Request().Context(), comment); err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, RevisionCommentResponse{
+		ID:        comment.ID,
+		Body:      comment.Body,
+		Author:    newCommentAuthor(user),
+		CreatedAt: comment.CreatedAt,
+	})
+}
+
+// ListRevisionComments pages through the comments on a revision, newest
+// first, with their authors' names.
+func (ffh *FeatureFlagHandler) ListRevisionComments(c echo.Context) error {
+	page, limit := apiutils.GetPaginationParams(c.QueryParam("page"), c.QueryParam("page_size"))
+	if page < 1 || limit < 1 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", "invalid pagination parameters"),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	revisionID := apiutils.ObjectIDParam(c, "revisionID")
+
+	if featureFlagRecord.FindRevision(revisionID) == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	model := models.NewRevisionCommentModel(ffh.db)
+	comments, err := model.FindManyByRevision(c.Request().Context(), featureFlagRecord.ID, revisionID, page, limit)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	total, err := model.CountByRevision(c.Request().Context(), featureFlagRecord.ID, revisionID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	authorIDs := make([]primitive.ObjectID, 0, len(comments))
+	for _, comment := range comments {
+		authorIDs = append(authorIDs, comment.UserID)
+	}
+	userModel := models.NewUserModel(ffh.db)
+	users, err := userModel.FindManyByIDs(c.Request().Context(), authorIDs)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	authors := make(map[primitive.ObjectID]CommentAuthor, len(users))
+	for index := range users {
+		authors[users[index].ID] = newCommentAuthor(&users[index])
+	}
+
+	data := make([]RevisionCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		author, ok := authors[comment.UserID]
+		if !ok {
+			author = CommentAuthor{ID: comment.UserID}
+		}
+		data = append(data, RevisionCommentResponse{
+			ID:        comment.ID,
+			Body:      comment.Body,
+			Author:    author,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, NewPaginatedResponse(data, page, limit, total))
+}
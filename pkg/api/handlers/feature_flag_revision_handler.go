@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// DeleteRevision removes a draft revision proposed by mistake. Live and
+// archived revisions are the flag's history and can't be deleted.
+func (ffh *FeatureFlagHandler) DeleteRevision(c echo.Context) error {
+	userID, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	revisionID := apiutils.ObjectIDParam(c, "revisionID")
+
+	revisionIndex := -1
+	for index := range featureFlagRecord.Revisions {
+		if featureFlagRecord.Revisions[index].ID == revisionID {
+			revisionIndex = index
+			break
+		}
+	}
+	if revisionIndex == -1 {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	if featureFlagRecord.Revisions[revisionIndex].Status != models.Draft {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.RevisionNotDeletableError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.RevisionNotDeletableError,
+		)
+	}
+
+	// Matching the draft status keeps a revision approved in the meantime
+	// from being pulled.
+	model := models.NewFeatureFlagModel(ffh.db)
+	saved, err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{
+			{Key: "_id", Value: featureFlagRecord.ID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+			{Key: "revisions", Value: bson.M{"$elemMatch": bson.M{
+				"_id":    revisionID,
+				"status": models.Draft,
+			}}},
+		},
+		bson.D{
+			{Key: "$pull", Value: bson.M{"revisions": bson.M{"_id": revisionID}}},
+			{Key: "$set", Value: bson.M{"updated_by": userID}},
+		},
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FlagChangedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.FlagChangedError,
+		)
+	}
+
+	ffh.recordAudit(
+		c,
+		featureFlagRecord.OrganizationID,
+		featureFlagRecord.ID,
+		userID,
+		models.DeleteRevisionAction,
+		map[string]any{"revision_id": revisionID},
+	)
+
+	return c.NoContent(http.StatusNoContent)
+}
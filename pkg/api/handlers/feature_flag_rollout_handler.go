@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// MinRolloutRampInterval keeps ramps from outpacing the worker that advances them.
+const MinRolloutRampInterval = time.Minute
+
+type RolloutRampRequest struct {
+	Step     int    `json:"step" validate:"min=1,max=100"`
+	Interval string `json:"interval" validate:"required"`
+}
+
+type PutRolloutRequest struct {
+	Percentage int                 `json:"percentage" validate:"min=0,max=100"`
+	Value      string              `json:"value" validate:"required"`
+	Kind       string              `json:"kind"`
+	Ramp       *RolloutRampRequest `json:"ramp"`
+}
+
+// PutRollout serves Value to a percentage of users that no rule matched. With
+// a ramp, the percentage is raised by Step every Interval until it reaches
+// 100%; the first step happens one interval from now. With a kind, contexts
+// of that kind are bucketed by their key instead of users.
+func (ffh *FeatureFlagHandler) PutRollout(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PutRolloutRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if ok, err := ffh.enforceValueConstraints(c, featureFlagRecord.Type, featureFlagRecord.Constraints, request.Value); !ok {
+		return err
+	}
+
+	if request.Kind != "" && !evaluation.ValidKind(request.Kind) {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.InvalidContextKindError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.InvalidContextKindError,
+		)
+	}
+
+	rollout := &models.Rollout{
+		Percentage: request.Percentage,
+		Value:      request.Value,
+		Kind:       request.Kind,
+	}
+	if request.Ramp != nil && request.Percentage < models.MaxRolloutPercentage {
+		interval, err := time.ParseDuration(request.Ramp.Interval)
+		if err != nil || interval < MinRolloutRampInterval {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", "invalid rollout ramp interval"),
+			)
+			return apierrors.CustomError(c,
+				http.StatusBadRequest,
+				apierrors.BadRequestError,
+			)
+		}
+
+		rollout.Ramp = &models.RolloutRamp{
+			Step:       request.Ramp.Step,
+			Interval:   interval,
+			NextStepAt: primitive.NewDateTimeFromTime(time.Now().UTC().Add(interval)),
+		}
+	}
+
+	saved, err := ffh.saveRollout(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$set", Value: bson.M{"rollout": rollout}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, rollout)
+}
+
+func (ffh *FeatureFlagHandler) DeleteRollout(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if featureFlagRecord.Rollout == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	saved, err := ffh.saveRollout(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$unset", Value: bson.M{"rollout": ""}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (ffh *FeatureFlagHandler) saveRollout(ctx context.Context, featureFlagID primitive.ObjectID, update bson.D) (bool, error) {
+	model := models.NewFeatureFlagModel(ffh.db)
+	return model.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: featureFlagID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		update,
+	)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type ToggleRuleRequest struct {
+	IsEnabled *bool `json:"is_enabled" validate:"required"`
+}
+
+type ReorderRulesRequest struct {
+	RuleIDs []primitive.ObjectID `json:"rule_ids" validate:"required"`
+}
+
+// ReorderRules proposes a new Draft revision with the live rules in the
+// requested order. Rule ids are kept, so the draft can be reviewed and
+// approved like any other revision.
+func (ffh *FeatureFlagHandler) ReorderRules(c echo.Context) error {
+	userID, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(ReorderRulesRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	liveRevision := featureFlagRecord.LiveRevision()
+	if liveRevision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NoLiveRevisionError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	rules, ok := reorderRules(liveRevision.Rules, request.RuleIDs)
+	if !ok {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.RuleOrderMismatchError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.RuleOrderMismatchError,
+		)
+	}
+
+	revision := models.NewRevisionRecord(liveRevision.DefaultValue, rules, userID)
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	saved, err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{
+			{Key: "_id", Value: featureFlagRecord.ID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		bson.D{
+			{Key: "$push", Value: bson.M{"revisions": revision}},
+			{Key: "$set", Value: bson.M{"updated_by": userID}},
+		},
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	c.Response().Header().Set(
+		echo.HeaderLocation,
+		revisionLocation(featureFlagRecord.OrganizationID, featureFlagRecord.ID, revision.ID),
+	)
+	return c.JSON(http.StatusOK, revision)
+}
+
+// ToggleRule proposes a new Draft revision with one live rule turned on or
+// off. A disabled rule keeps its place and configuration, so turning it back
+// on restores it as it was.
+func (ffh *FeatureFlagHandler) ToggleRule(c echo.Context) error {
+	userID, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	ruleID := apiutils.ObjectIDParam(c, "ruleID")
+
+	request := new(ToggleRuleRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	liveRevision := featureFlagRecord.LiveRevision()
+	if liveRevision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NoLiveRevisionError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	rules, ok := toggleRule(liveRevision.Rules, ruleID, *request.IsEnabled)
+	if !ok {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.RuleNotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.RuleNotFoundError,
+		)
+	}
+
+	revision := models.NewRevisionRecord(liveRevision.DefaultValue, rules, userID)
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	saved, err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{
+			{Key: "_id", Value: featureFlagRecord.ID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		bson.D{
+			{Key: "$push", Value: bson.M{"revisions": revision}},
+			{Key: "$set", Value: bson.M{"updated_by": userID}},
+		},
+	)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	c.Response().Header().Set(
+		echo.HeaderLocation,
+		revisionLocation(featureFlagRecord.OrganizationID, featureFlagRecord.ID, revision.ID),
+	)
+	return c.JSON(http.StatusOK, revision)
+}
+
+// toggleRule returns a copy of rules with the one with id set to enabled.
+func toggleRule(rules []models.Rule, id primitive.ObjectID, enabled bool) ([]models.Rule, bool) {
+	if id.IsZero() {
+		return nil, false
+	}
+
+	toggled := make([]models.Rule, len(rules))
+	copy(toggled, rules)
+	for index := range toggled {
+		if toggled[index].ID == id {
+			toggled[index].IsEnabled = enabled
+			return toggled, true
+		}
+	}
+
+	return nil, false
+}
+
+// reorderRules returns rules sorted as ids, which must name each rule exactly once.
+func reorderRules(rules []models.Rule, ids []primitive.ObjectID) ([]models.Rule, bool) {
+	if len(rules) != len(ids) {
+		return nil, false
+	}
+
+	byID := make(map[primitive.ObjectID]models.Rule, len(rules))
+	for _, rule := range rules {
+		byID[rule.ID] = rule
+	}
+
+	reordered := make([]models.Rule, 0, len(ids))
+	for _, id := range ids {
+		rule, ok := byID[id]
+		if !ok || id.IsZero() {
+			return nil, false
+		}
+		delete(byID, id)
+		reordered = append(reordered, rule)
+	}
+
+	return reordered, true
+}
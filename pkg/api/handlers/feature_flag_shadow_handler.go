@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type PutShadowRequest struct {
+	RevisionID primitive.ObjectID `json:"revision_id" validate:"required"`
+}
+
+// ShadowResponse compares the shadow revision with the live one. Active is
+// false once the revision stopped being a draft, and so being evaluated.
+type ShadowResponse struct {
+	models.Shadow
+	Active             bool    `json:"active"`
+	MismatchPercentage float64 `json:"mismatch_percentage"`
+}
+
+// PutShadow starts evaluating a draft revision of the flag in the shadow of
+// its live one: clients keep being served the live revision, while every
+// evaluation records what the draft would have served. Shadowing another
+// revision resets the comparison.
+func (ffh *FeatureFlagHandler) PutShadow(c echo.Context) error {
+	userID, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	request := new(PutShadowRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	revision := featureFlagRecord.FindRevision(request.RevisionID)
+	if revision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+	if revision.Status != models.Draft {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ShadowRevisionNotDraftError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.ShadowRevisionNotDraftError,
+		)
+	}
+
+	shadow := &models.Shadow{
+		RevisionID: revision.ID,
+		StartedBy:  userID,
+		StartedAt:  primitive.NewDateTimeFromTime(time.Now().UTC()),
+	}
+	saved, err := ffh.saveShadow(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$set", Value: bson.M{"shadow": shadow}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ShadowResponse{Shadow: *shadow, Active: true})
+}
+
+// GetShadow reports how often the shadow revision disagreed with the value
+// served. Counts are saved in batches like the flag's stats, so they trail
+// evaluations by up to a minute.
+func (ffh *FeatureFlagHandler) GetShadow(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if featureFlagRecord.Shadow == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	shadow := featureFlagRecord.Shadow
+	return c.JSON(http.StatusOK, ShadowResponse{
+		Shadow:             *shadow,
+		Active:             featureFlagRecord.ShadowRevision() != nil,
+		MismatchPercentage: percentage(int(shadow.Mismatches), int(shadow.Evaluations)),
+	})
+}
+
+// DeleteShadow stops the comparison and drops its counts.
+func (ffh *FeatureFlagHandler) DeleteShadow(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findEditableFeatureFlag(c)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	if featureFlagRecord.Shadow == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	saved, err := ffh.saveShadow(c.Request().Context(), featureFlagRecord.ID, bson.D{
+		{Key: "$unset", Value: bson.M{"shadow": ""}},
+	})
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !saved {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (ffh *FeatureFlagHandler) saveShadow(ctx context.Context, featureFlagID primitive.ObjectID, update bson.D) (bool, error) {
+	model := models.NewFeatureFlagModel(ffh.db)
+	return model.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: featureFlagID},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		},
+		update,
+	)
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// PrerequisiteSpec references the prerequisite flag by its qualified name.
+type PrerequisiteSpec struct {
+	Name  string `json:"name" yaml:"name" validate:"required"`
+	Value string `json:"value" yaml:"value" validate:"required"`
+}
+
+// RuleSpec is a rule without its id, which only makes sense inside one organization.
+// UserListID is the hex id of the user list the rule targets. User lists
+// belong to their organization, so documents with such rules only apply to it.
+type RuleSpec struct {
+	Predicate  string            `json:"predicate,omitempty" yaml:"predicate,omitempty" validate:"required_without_all=Condition UserListID"`
+	Condition  *models.Condition `json:"condition,omitempty" yaml:"condition,omitempty"`
+	UserListID string            `json:"user_list_id,omitempty" yaml:"user_list_id,omitempty" validate:"omitempty,mongodb"`
+	Value      string            `json:"value" yaml:"value" validate:"required"`
+	Env        string            `json:"env" yaml:"env" validate:"required"`
+	IsEnabled  bool              `json:"is_enabled" yaml:"is_enabled" validate:"boolean"`
+}
+
+// UnmarshalJSON enables rules that leave is_enabled out, like
+// models.Rule.UnmarshalJSON.
+func (rs *RuleSpec) UnmarshalJSON(data []byte) error {
+	type ruleSpec RuleSpec
+	decoded := ruleSpec{IsEnabled: true}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*rs = RuleSpec(decoded)
+
+	return nil
+}
+
+// UnmarshalYAML is UnmarshalJSON for YAML documents.
+func (rs *RuleSpec) UnmarshalYAML(value *yaml.Node) error {
+	type ruleSpec RuleSpec
+	decoded := ruleSpec{IsEnabled: true}
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+	*rs = RuleSpec(decoded)
+
+	return nil
+}
+
+// FeatureFlagSpec is the portable description of a flag used by import and export.
+type FeatureFlagSpec struct {
+	Name          string             `json:"name" yaml:"name" validate:"required,excludes=/"`
+	Namespace     string             `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Type          models.FlagType    `json:"type" yaml:"type" validate:"required,oneof=boolean json string number"`
+	DefaultValue  string             `json:"default_value" yaml:"default_value" validate:"required"`
+	Rules         []RuleSpec         `json:"rules" yaml:"rules" validate:"dive,required"`
+	Prerequisites []PrerequisiteSpec `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty" validate:"dive"`
+}
+
+func (ffs FeatureFlagSpec) QualifiedName() string {
+	if ffs.Namespace == "" {
+		return ffs.Name
+	}
+
+	return ffs.Namespace + models.NamespaceSeparator + ffs.Name
+}
+
+func (ffs FeatureFlagSpec) rules() []models.Rule {
+	rules := make([]models.Rule, 0, len(ffs.Rules))
+	for _, rule := range ffs.Rules {
+		// Validated along with the document.
+		userListID, _ := primitive.ObjectIDFromHex(rule.UserListID)
+		rules = append(rules, models.Rule{
+			Predicate:  rule.Predicate,
+			Condition:  rule.Condition,
+			UserListID: userListID,
+			Value:      rule.Value,
+			Env:        rule.Env,
+			IsEnabled:  rule.IsEnabled,
+		})
+	}
+
+	return rules
+}
+
+type FeatureFlagSpecDocument struct {
+	FeatureFlags []FeatureFlagSpec `json:"feature_flags" yaml:"feature_flags" validate:"dive"`
+}
+
+type ImportFeatureFlagsResponse struct {
+	Created []string `json:"created"`
+	Skipped []string `json:"skipped"`
+}
+
+func (ffh *FeatureFlagHandler) ExportFeatureFlags(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	document := newFeatureFlagSpecDocument(featureFlags)
+
+	if apiutils.IsYAMLMediaType(c.Request().Header.Get(echo.HeaderAccept)) {
+		body, err := yaml.Marshal(document)
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+
+		return c.Blob(http.StatusOK, apiutils.MIMEApplicationYAML, body)
+	}
+
+	return c.JSON(http.StatusOK, document)
+}
+
+// ImportFeatureFlags creates the flags of a spec document that don't exist yet.
+// Flags whose qualified name is already in use are reported as skipped and left untouched.
+func (ffh *FeatureFlagHandler) ImportFeatureFlags(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Collaborator)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	document, err := bindFeatureFlagSpecDocument(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	flagIDs := make(map[string]primitive.ObjectID, len(featureFlags))
+	for _, featureFlag := range featureFlags {
+		flagIDs[featureFlag.QualifiedName()] = featureFlag.ID
+	}
+
+	declared := make(map[string]bool, len(document.FeatureFlags))
+	for _, spec := range document.FeatureFlags {
+		declared[spec.QualifiedName()] = true
+	}
+
+	for _, spec := range document.FeatureFlags {
+		for _, prerequisite := range spec.Prerequisites {
+			if _, exists := flagIDs[prerequisite.Name]; !exists && !declared[prerequisite.Name] {
+				ffh.logger.Debug("Client error",
+					zap.String("cause", apierrors.PrerequisiteNotFoundError),
+					zap.String("prerequisite", prerequisite.Name),
+				)
+				return apierrors.CustomError(
+					c,
+					http.StatusBadRequest,
+					apierrors.PrerequisiteNotFoundError,
+				)
+			}
+		}
+	}
+
+	newFlags := 0
+	newNames := make([]string, 0)
+	newRules := make([]models.Rule, 0)
+	for _, spec := range document.FeatureFlags {
+		if ok, err := ffh.enforceRuleLimits(c, spec.rules()); !ok {
+			return err
+		}
+		if ok, err := ffh.enforceValueLimits(c, spec.Type, nil, spec.DefaultValue, spec.rules()); !ok {
+			return err
+		}
+		if _, exists := flagIDs[spec.QualifiedName()]; !exists {
+			newFlags++
+			newNames = append(newNames, spec.QualifiedName())
+			newRules = append(newRules, spec.rules()...)
+		}
+	}
+	if ok, err := ffh.enforceEnvKeys(c, featureFlags, newNames); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceUserLists(c, organizationID, newRules); !ok {
+		return err
+	}
+	if ok, err := ffh.enforceQuota(c, organizationRecord, newFlags, newRules); !ok {
+		return err
+	}
+
+	response := ImportFeatureFlagsResponse{
+		Created: make([]string, 0),
+		Skipped: make([]string, 0),
+	}
+	created := make([]FeatureFlagSpec, 0)
+	for _, spec := range document.FeatureFlags {
+		if _, exists := flagIDs[spec.QualifiedName()]; exists {
+			response.Skipped = append(response.Skipped, spec.QualifiedName())
+			continue
+		}
+
+		record := models.NewFeatureFlagRecord(
+			spec.Name,
+			spec.Namespace,
+			spec.DefaultValue,
+			spec.Type,
+			spec.rules(),
+			organizationID,
+			userID,
+		)
+		awaitApproval(organizationRecord, record)
+		id, err := model.InsertOne(c.Request().Context(), record)
+		if mongo.IsDuplicateKeyError(err) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusConflict,
+				apierrors.FlagNameConflictError,
+			)
+		}
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+
+		flagIDs[spec.QualifiedName()] = id
+		created = append(created, spec)
+		response.Created = append(response.Created, spec.QualifiedName())
+	}
+
+	// Prerequisites are resolved once every flag of the document exists,
+	// so a flag may depend on one declared after it.
+	for _, spec := range created {
+		if len(spec.Prerequisites) == 0 {
+			continue
+		}
+
+		saved, err := model.UpdateOne(
+			c.Request().Context(),
+			bson.D{{Key: "_id", Value: flagIDs[spec.QualifiedName()]}},
+			bson.D{{Key: "$set", Value: bson.M{
+				"prerequisites": resolvePrerequisiteSpecs(spec.Prerequisites, flagIDs),
+			}}},
+		)
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+		if !saved {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.FlagChangedError),
+			)
+			return apierrors.CustomError(c,
+				http.StatusConflict,
+				apierrors.FlagChangedError,
+			)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func resolvePrerequisiteSpecs(
+	specs []PrerequisiteSpec,
+	flagIDs map[string]primitive.ObjectID,
+) []models.Prerequisite {
+	prerequisites := make([]models.Prerequisite, 0, len(specs))
+	for _, prerequisite := range specs {
+		prerequisites = append(prerequisites, models.Prerequisite{
+			FeatureFlagID: flagIDs[prerequisite.Name],
+			Value:         prerequisite.Value,
+		})
+	}
+
+	return prerequisites
+}
+
+func newFeatureFlagSpecDocument(featureFlags []models.FeatureFlagRecord) FeatureFlagSpecDocument {
+	names := make(map[primitive.ObjectID]string, len(featureFlags))
+	for _, featureFlag := range featureFlags {
+		names[featureFlag.ID] = featureFlag.QualifiedName()
+	}
+
+	document := FeatureFlagSpecDocument{
+		FeatureFlags: make([]FeatureFlagSpec, 0, len(featureFlags)),
+	}
+	for index := range featureFlags {
+		document.FeatureFlags = append(document.FeatureFlags, newFeatureFlagSpec(&featureFlags[index], names))
+	}
+
+	return document
+}
+
+func newFeatureFlagSpec(featureFlag *models.FeatureFlagRecord, names map[primitive.ObjectID]string) FeatureFlagSpec {
+	spec := FeatureFlagSpec{
+		Name:      featureFlag.Name,
+		Namespace: featureFlag.Namespace,
+		Type:      featureFlag.Type,
+		Rules:     make([]RuleSpec, 0),
+	}
+
+	if revision := featureFlag.LiveRevision(); revision != nil {
+		spec.DefaultValue = revision.DefaultValue
+		for _, rule := range revision.Rules {
+			ruleSpec := RuleSpec{
+				Predicate: rule.Predicate,
+				Condition: rule.Condition,
+				Value:     rule.Value,
+				Env:       rule.Env,
+				IsEnabled: rule.IsEnabled,
+			}
+			if !rule.UserListID.IsZero() {
+				ruleSpec.UserListID = rule.UserListID.Hex()
+			}
+			spec.Rules = append(spec.Rules, ruleSpec)
+		}
+	}
+
+	for _, prerequisite := range featureFlag.Prerequisites {
+		if name, ok := names[prerequisite.FeatureFlagID]; ok {
+			spec.Prerequisites = append(spec.Prerequisites, PrerequisiteSpec{
+				Name:  name,
+				Value: prerequisite.Value,
+			})
+		}
+	}
+
+	return spec
+}
+
+func bindFeatureFlagSpecDocument(c echo.Context) (*FeatureFlagSpecDocument, error) {
+	document := new(FeatureFlagSpecDocument)
+
+	if apiutils.IsYAMLMediaType(c.Request().Header.Get(echo.HeaderContentType)) {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(body, document); err != nil {
+			return nil, err
+		}
+	} else if err := c.Bind(document); err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// RuleStats is how many evaluations a rule of the live revision served. A
+// rule that never matches is most likely misconfigured.
+type RuleStats struct {
+	RuleID    primitive.ObjectID `json:"rule_id"`
+	Env       string             `json:"env"`
+	Predicate string             `json:"predicate,omitempty"`
+	IsEnabled bool               `json:"is_enabled"`
+	Matches   int64              `json:"matches"`
+}
+
+type FeatureFlagStatsResponse struct {
+	FeatureFlagID   primitive.ObjectID `json:"feature_flag_id"`
+	RevisionID      primitive.ObjectID `json:"revision_id"`
+	Evaluations     int64              `json:"evaluations"`
+	LastEvaluatedAt primitive.DateTime `json:"last_evaluated_at,omitempty"`
+	Rules           []RuleStats        `json:"rules"`
+}
+
+// GetFeatureFlagStats reports how often the flag was evaluated and how often
+// each rule of its live revision matched. Counts are saved in batches, so
+// they trail evaluations by up to a minute.
+func (ffh *FeatureFlagHandler) GetFeatureFlagStats(c echo.Context) error {
+	_, featureFlagRecord, err := ffh.findFeatureFlag(c, models.ReadOnly)
+	if featureFlagRecord == nil {
+		return err
+	}
+
+	liveRevision := featureFlagRecord.LiveRevision()
+	if liveRevision == nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NoLiveRevisionError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.NoLiveRevisionError,
+		)
+	}
+
+	response := FeatureFlagStatsResponse{
+		FeatureFlagID:   featureFlagRecord.ID,
+		RevisionID:      liveRevision.ID,
+		Evaluations:     featureFlagRecord.Evaluations,
+		LastEvaluatedAt: featureFlagRecord.LastEvaluatedAt,
+		Rules:           make([]RuleStats, 0, len(liveRevision.Rules)),
+	}
+	for _, rule := range liveRevision.Rules {
+		response.Rules = append(response.Rules, RuleStats{
+			RuleID:    rule.ID,
+			Env:       rule.Env,
+			Predicate: rule.Predicate,
+			IsEnabled: rule.IsEnabled,
+			Matches:   featureFlagRecord.RuleMatches[rule.ID.Hex()],
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
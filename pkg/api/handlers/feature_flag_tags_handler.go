@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// MaxBulkTagFlags is how many flags one bulk tag request can change.
+const MaxBulkTagFlags = 1000
+
+type BulkTagStatus = string
+
+const (
+	TagAdded        BulkTagStatus = "added"
+	TagRemoved      BulkTagStatus = "removed"
+	TagUnchanged    BulkTagStatus = "unchanged"
+	TagLimitReached BulkTagStatus = "tag_limit_reached"
+	FlagNotFound    BulkTagStatus = "not_found"
+)
+
+// BulkTagFilter selects flags like the list endpoint's query params do.
+// Archived flags are only selected with Archived.
+type BulkTagFilter struct {
+	Namespace string `json:"namespace,omitempty"`
+	Lifecycle string `json:"lifecycle,omitempty" validate:"omitempty,oneof=development in_rollout stable deprecated"`
+	Tag       string `json:"tag,omitempty"`
+	Archived  bool   `json:"archived,omitempty"`
+}
+
+// BulkTagRequest names the flags to tag either by id or with a filter, not
+// both.
+type BulkTagRequest struct {
+	Tag            string               `json:"tag" validate:"required"`
+	FeatureFlagIDs []primitive.ObjectID `json:"feature_flag_ids,omitempty" validate:"max=1000"`
+	Filter         *BulkTagFilter       `json:"filter,omitempty"`
+}
+
+type BulkTagResult struct {
+	FeatureFlagID primitive.ObjectID `json:"feature_flag_id"`
+	Name          string             `json:"name,omitempty"`
+	Status        BulkTagStatus      `json:"status"`
+}
+
+type BulkTagResponse struct {
+	Tag     string          `json:"tag"`
+	Results []BulkTagResult `json:"results"`
+}
+
+// PostBulkAddTag tags every flag the request names, so a large catalog can be
+// reorganized without editing each flag. Flags that already have the tag, or
+// MaxTags tags, are reported and left untouched.
+func (ffh *FeatureFlagHandler) PostBulkAddTag(c echo.Context) error {
+	return ffh.bulkTag(c, true)
+}
+
+// PostBulkRemoveTag removes the tag from every flag the request names.
+func (ffh *FeatureFlagHandler) PostBulkRemoveTag(c echo.Context) error {
+	return ffh.bulkTag(c, false)
+}
+
+func (ffh *FeatureFlagHandler) bulkTag(c echo.Context, add bool) error {
+	userID, organizationRecord, err := ffh.findOrganization(c, models.Collaborator)
+	if organizationRecord == nil {
+		return err
+	}
+
+	request := new(BulkTagRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+	if (request.Filter == nil) == (len(request.FeatureFlagIDs) == 0) {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", "bulk tag request needs either feature flag ids or a filter"),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	tag, err := models.NormalizeTag(request.Tag)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.InvalidTagError,
+		)
+	}
+
+	filter, err := bulkTagFilter(request)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.InvalidTagError,
+		)
+	}
+
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindMatching(c.Request().Context(), organizationRecord.ID, filter)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if len(featureFlags) > MaxBulkTagFlags {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.TooManyFlagsError),
+			zap.Int("feature_flags", len(featureFlags)),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.TooManyFlagsError,
+		)
+	}
+
+	results, changed := bulkTagResults(request.FeatureFlagIDs, featureFlags, tag, add)
+	if len(changed) > 0 {
+		if add {
+			_, err = model.AddTag(c.Request().Context(), changed, tag, userID)
+		} else {
+			_, err = model.RemoveTag(c.Request().Context(), changed, tag, userID)
+		}
+		if err != nil {
+			ffh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+	}
+
+	return c.JSON(http.StatusOK, BulkTagResponse{Tag: tag, Results: results})
+}
+
+// bulkTagFilter matches the flags request names.
+func bulkTagFilter(request *BulkTagRequest) (bson.D, error) {
+	if request.Filter == nil {
+		return bson.D{{Key: "_id", Value: bson.M{"$in": request.FeatureFlagIDs}}}, nil
+	}
+
+	filter := bson.D{
+		{Key: "archived_at", Value: bson.M{"$exists": request.Filter.Archived}},
+	}
+	if request.Filter.Namespace != "" {
+		filter = append(filter, bson.E{Key: "namespace", Value: request.Filter.Namespace})
+	}
+	switch request.Filter.Lifecycle {
+	case "":
+	case models.Development:
+		// Flags created before lifecycle stages existed have none and are in development.
+		filter = append(filter, bson.E{Key: "lifecycle", Value: bson.M{"$in": bson.A{models.Development, nil}}})
+	default:
+		filter = append(filter, bson.E{Key: "lifecycle", Value: request.Filter.Lifecycle})
+	}
+	if request.Filter.Tag != "" {
+		tag, err := models.NormalizeTag(request.Filter.Tag)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{Key: "tags", Value: tag})
+	}
+
+	return filter, nil
+}
+
+// bulkTagResults tells what adding or removing tag does to each flag, in the
+// order of ids when the request named them, and returns the flags it changes.
+// Ids that match no flag of the organization are reported as not found.
+func bulkTagResults(
+	ids []primitive.ObjectID,
+	featureFlags []models.FeatureFlagRecord,
+	tag string,
+	add bool,
+) ([]BulkTagResult, []primitive.ObjectID) {
+	found := make(map[primitive.ObjectID]*models.FeatureFlagRecord, len(featureFlags))
+	for index := range featureFlags {
+		found[featureFlags[index].ID] = &featureFlags[index]
+	}
+	if len(ids) == 0 {
+		ids = make([]primitive.ObjectID, 0, len(featureFlags))
+		for index := range featureFlags {
+			ids = append(ids, featureFlags[index].ID)
+		}
+	}
+
+	results := make([]BulkTagResult, 0, len(ids))
+	changed := make([]primitive.ObjectID, 0, len(ids))
+	reported := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if reported[id] {
+			continue
+		}
+		reported[id] = true
+
+		featureFlag, ok := found[id]
+		if !ok {
+			results = append(results, BulkTagResult{FeatureFlagID: id, Status: FlagNotFound})
+			continue
+		}
+
+		status := TagUnchanged
+		switch {
+		case add && !featureFlag.HasTag(tag) && len(featureFlag.Tags) >= models.MaxTags:
+			status = TagLimitReached
+		case add && !featureFlag.HasTag(tag):
+			status = TagAdded
+		case !add && featureFlag.HasTag(tag):
+			status = TagRemoved
+		}
+		if status == TagAdded || status == TagRemoved {
+			changed = append(changed, id)
+		}
+
+		results = append(results, BulkTagResult{
+			FeatureFlagID: id,
+			Name:          featureFlag.QualifiedName(),
+			Status:        status,
+		})
+	}
+
+	return results, changed
+}
@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// FeatureFlagTemplateRequest takes the same fields as PostFeatureFlagRequest,
+// except the flag name, which NamePattern can constrain instead.
+type FeatureFlagTemplateRequest struct {
+	Name         string                   `json:"name" validate:"required,max=100"`
+	NamePattern  string                   `json:"name_pattern" validate:"max=200"`
+	Namespace    string                   `json:"namespace"`
+	Description  string                   `json:"description" validate:"max=500"`
+	Owner        string                   `json:"owner" validate:"max=100"`
+	Type         models.FlagType          `json:"type" validate:"required,oneof=boolean json string number"`
+	DefaultValue string                   `json:"default_value" validate:"required"`
+	Rules        []models.Rule            `json:"rules" validate:"dive,required"`
+	Constraints  *models.ValueConstraints `json:"constraints"`
+}
+
+func (fftr *FeatureFlagTemplateRequest) record(organizationID primitive.ObjectID) *models.FeatureFlagTemplateRecord {
+	rules := fftr.Rules
+	if rules == nil {
+		rules = make([]models.Rule, 0)
+	}
+	constraints := fftr.Constraints
+	if constraints.IsEmpty() {
+		constraints = nil
+	}
+
+	return &models.FeatureFlagTemplateRecord{
+		OrganizationID: organizationID,
+		Name:           fftr.Name,
+		NamePattern:    fftr.NamePattern,
+		Namespace:      fftr.Namespace,
+		Description:    fftr.Description,
+		Owner:          fftr.Owner,
+		Type:           fftr.Type,
+		DefaultValue:   fftr.DefaultValue,
+		Rules:          rules,
+		Constraints:    constraints,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}
+}
+
+// PostFeatureFlagFromTemplateRequest overrides the template's fields it sets.
+// Rules and constraints replace the template's as a whole; empty constraints
+// create the flag without any.
+type PostFeatureFlagFromTemplateRequest struct {
+	Name         string                   `json:"name"`
+	Namespace    *string                  `json:"namespace"`
+	Description  *string                  `json:"description"`
+	Owner        *string                  `json:"owner"`
+	Type         *models.FlagType         `json:"type"`
+	DefaultValue *string                  `json:"default_value"`
+	Rules        []models.Rule            `json:"rules"`
+	Constraints  *models.ValueConstraints `json:"constraints"`
+}
+
+// apply returns the create request for template with pfftr's overrides.
+func (pfftr *PostFeatureFlagFromTemplateRequest) apply(
+	template *models.FeatureFlagTemplateRecord,
+) *PostFeatureFlagRequest {
+	request := &PostFeatureFlagRequest{
+		Name:         pfftr.Name,
+		Namespace:    template.Namespace,
+		Description:  template.Description,
+		Owner:        template.Owner,
+		Type:         template.Type,
+		DefaultValue: template.DefaultValue,
+		Rules:        template.Rules,
+		Constraints:  template.Constraints,
+	}
+	if pfftr.Namespace != nil {
+		request.Namespace = *pfftr.Namespace
+	}
+	if pfftr.Description != nil {
+		request.Description = *pfftr.Description
+	}
+	if pfftr.Owner != nil {
+		request.Owner = *pfftr.Owner
+	}
+	if pfftr.Type != nil {
+		request.Type = *pfftr.Type
+	}
+	if pfftr.DefaultValue != nil {
+		request.DefaultValue = *pfftr.DefaultValue
+	}
+	if pfftr.Rules != nil {
+		request.Rules = pfftr.Rules
+	}
+	if pfftr.Constraints != nil {
+		request.Constraints = pfftr.Constraints
+	}
+
+	return request
+}
+
+type ListFeatureFlagTemplatesResponse struct {
+	Data []models.FeatureFlagTemplateRecord `json:"data"`
+}
+
+func (ffh *FeatureFlagHandler) PostFeatureFlagTemplate(c echo.Context) error {
+	_, organization, err := ffh.findOrganization(c, models.Admin)
+	if organization == nil {
+		return err
+	}
+
+	record, err := ffh.bindTemplateRequest(c, organization.ID)
+	if record == nil {
+		return err
+	}
+
+	model := models.NewFeatureFlagTemplateModel(ffh.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, record)
+}
+
+func (ffh *FeatureFlagHandler) ListFeatureFlagTemplates(c echo.Context) error {
+	_, organization, err := ffh.findOrganization(c, models.ReadOnly)
+	if organization == nil {
+		return err
+	}
+
+	model := models.NewFeatureFlagTemplateModel(ffh.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organization.ID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListFeatureFlagTemplatesResponse{Data: records})
+}
+
+func (ffh *FeatureFlagHandler) GetFeatureFlagTemplate(c echo.Context) error {
+	_, organization, err := ffh.findOrganization(c, models.ReadOnly)
+	if organization == nil {
+		return err
+	}
+
+	record, err := ffh.findTemplate(c, organization.ID)
+	if record == nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+// PutFeatureFlagTemplate replaces every field of the template. Flags already
+// created from it are left as they are.
+func (ffh *FeatureFlagHandler) PutFeatureFlagTemplate(c echo.Context) error {
+	_, organization, err := ffh.findOrganization(c, models.Admin)
+	if organization == nil {
+		return err
+	}
+
+	templateID := apiutils.ObjectIDParam(c, "templateID")
+
+	record, err := ffh.bindTemplateRequest(c, organization.ID)
+	if record == nil {
+		return err
+	}
+
+	model := models.NewFeatureFlagTemplateModel(ffh.db)
+	found, err := model.ReplaceOne(c.Request().Context(), organization.ID, templateID, record)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	updated, err := model.FindByID(c.Request().Context(), organization.ID, templateID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (ffh *FeatureFlagHandler) DeleteFeatureFlagTemplate(c echo.Context) error {
+	_, organization, err := ffh.findOrganization(c, models.Admin)
+	if organization == nil {
+		return err
+	}
+
+	templateID := apiutils.ObjectIDParam(c, "templateID")
+
+	model := models.NewFeatureFlagTemplateModel(ffh.db)
+	found, err := model.DeleteOne(c.Request().Context(), organization.ID, templateID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostFeatureFlagFromTemplate creates a flag the way PostFeatureFlag does,
+// with every field the body leaves out taken from the template. The name is
+// always required and has to match the template's name pattern.
+func (ffh *FeatureFlagHandler) PostFeatureFlagFromTemplate(c echo.Context) error {
+	userID, organization, err := ffh.findOrganization(c, models.Collaborator)
+	if organization == nil {
+		return err
+	}
+
+	template, err := ffh.findTemplate(c, organization.ID)
+	if template == nil {
+		return err
+	}
+
+	overrides := new(PostFeatureFlagFromTemplateRequest)
+	if err := c.Bind(overrides); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	request := overrides.apply(template)
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	matcher, err := template.NameMatcher()
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+			zap.String("template_id", template.ID.Hex()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if matcher != nil && !matcher.MatchString(request.Name) {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.FlagNamePatternError),
+			zap.String("name_pattern", template.NamePattern),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.FlagNamePatternError,
+		)
+	}
+
+	return ffh.createFeatureFlag(c, userID, organization, request)
+}
+
+// findOrganization returns the caller and the organization in the path when
+// the caller has at least permissionLevel in it. The organization is nil, and
+// the error response already written, when they don't.
+func (ffh *FeatureFlagHandler) findOrganization(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, *models.OrganizationRecord, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, nil, err
+	}
+
+	organizationModel := models.NewOrganizationModel(ffh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, nil, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, permissionLevel)
+	if !permission {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, nil, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return userID, organizationRecord, nil
+}
+
+// findTemplate returns the template in the path, or nil with the error
+// response already written when organizationID has no such template.
+func (ffh *FeatureFlagHandler) findTemplate(
+	c echo.Context,
+	organizationID primitive.ObjectID,
+) (*models.FeatureFlagTemplateRecord, error) {
+	templateID := apiutils.ObjectIDParam(c, "templateID")
+
+	model := models.NewFeatureFlagTemplateModel(ffh.db)
+	record, err := model.FindByID(c.Request().Context(), organizationID, templateID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return nil, apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return record, nil
+}
+
+// bindTemplateRequest returns the template the body describes, or nil with
+// the error response already written when it isn't one a flag could be
+// created from: templates are held to the same rule and value limits as flags.
+func (ffh *FeatureFlagHandler) bindTemplateRequest(
+	c echo.Context,
+	organizationID primitive.ObjectID,
+) (*models.FeatureFlagTemplateRecord, error) {
+	request := new(FeatureFlagTemplateRequest)
+	if err := c.Bind(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	record := request.record(organizationID)
+	if _, err := record.NameMatcher(); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.InvalidNamePatternError,
+		)
+	}
+
+	if ok, err := ffh.enforceRuleLimits(c, record.Rules); !ok {
+		return nil, err
+	}
+	if ok, err := ffh.enforceConstraints(c, record.Type, record.Constraints, nil); !ok {
+		return nil, err
+	}
+	if ok, err := ffh.enforceValueLimits(c, record.Type, record.Constraints, record.DefaultValue, record.Rules); !ok {
+		return nil, err
+	}
+
+	return record, nil
+}
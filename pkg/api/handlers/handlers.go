@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/workers"
 	"github.com/labstack/echo/v4"
 )
 
@@ -15,3 +18,76 @@ func HealthHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, r)
 }
+
+type VersionHandler struct {
+	buildInfo config.BuildInfo
+}
+
+func NewVersionHandler(buildInfo config.BuildInfo) *VersionHandler {
+	return &VersionHandler{
+		buildInfo: buildInfo,
+	}
+}
+
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+func (vh *VersionHandler) GetVersion(c echo.Context) error {
+	return c.JSON(http.StatusOK, VersionResponse{
+		Version:   vh.buildInfo.Version,
+		Commit:    vh.buildInfo.Commit,
+		BuildTime: vh.buildInfo.BuildTime,
+	})
+}
+
+type WorkersHandler struct {
+	registry *workers.Registry
+}
+
+func NewWorkersHandler(registry *workers.Registry) *WorkersHandler {
+	return &WorkersHandler{
+		registry: registry,
+	}
+}
+
+type WorkerStatus struct {
+	Name     string    `json:"name"`
+	Interval string    `json:"interval"`
+	LastRun  time.Time `json:"last_run"`
+	Stuck    bool      `json:"stuck"`
+}
+
+type WorkersStatusResponse struct {
+	Healthy bool           `json:"healthy"`
+	Workers []WorkerStatus `json:"workers"`
+}
+
+// GetStatus answers 503 as soon as one worker missed too many heartbeats,
+// so it can be used as a readiness probe.
+func (wh *WorkersHandler) GetStatus(c echo.Context) error {
+	response := WorkersStatusResponse{
+		Healthy: true,
+		Workers: make([]WorkerStatus, 0),
+	}
+
+	for _, status := range wh.registry.Statuses(time.Now().UTC()) {
+		response.Workers = append(response.Workers, WorkerStatus{
+			Name:     status.Name,
+			Interval: status.Interval.String(),
+			LastRun:  status.LastRun,
+			Stuck:    status.Stuck,
+		})
+		if status.Stuck {
+			response.Healthy = false
+		}
+	}
+
+	if !response.Healthy {
+		return c.JSON(http.StatusServiceUnavailable, response)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// ImpersonationTTL is how long an impersonation token lasts. It can't be
+// renewed; support asks for a new one, with a new reason, instead.
+const ImpersonationTTL = 15 * time.Minute
+
+type ImpersonationHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewImpersonationHandler(db *mongo.Database, logger *zap.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type ImpersonationRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Reason string `json:"reason" validate:"required,min=10,max=500"`
+}
+
+type ImpersonationResponse struct {
+	ImpersonationID primitive.ObjectID `json:"impersonation_id"`
+	Token           string             `json:"token"`
+	ExpiresAt       time.Time          `json:"expires_at"`
+}
+
+// PostImpersonation issues a short-lived token acting as another user. The
+// session is written to the audit log of every organization the user belongs
+// to before the token is handed out, and every change made with the token is
+// tagged with the session id.
+func (ih *ImpersonationHandler) PostImpersonation(c echo.Context) error {
+	request := new(ImpersonationRequest)
+	if err := c.Bind(request); err != nil {
+		ih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		ih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userID, err := primitive.ObjectIDFromHex(request.UserID)
+	if err != nil {
+		ih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userModel := models.NewUserModel(ih.db)
+	user, err := userModel.FindByID(c.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ih.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	organizationModel := models.NewOrganizationModel(ih.db)
+	organizations, err := organizationModel.FindAllByMember(c.Request().Context(), user.ID)
+	if err != nil {
+		ih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	impersonationID := primitive.NewObjectID()
+	expiresAt := time.Now().UTC().Add(ImpersonationTTL)
+
+	// No token is issued unless the session made it to every audit log.
+	auditLogModel := models.NewAuditLogModel(ih.db)
+	for _, organization := range organizations {
+		entry := models.NewAuditEntry(
+			organization.ID,
+			primitive.NilObjectID,
+			user.ID,
+			models.ImpersonateAction,
+			map[string]any{
+				"reason":     request.Reason,
+				"expires_at": primitive.NewDateTimeFromTime(expiresAt),
+			},
+		)
+		entry.ImpersonationID = impersonationID
+		if _, err := auditLogModel.InsertOne(c.Request().Context(), entry); err != nil {
+			ih.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+	}
+
+	token, err := apiutils.CreateImpersonationJWT(user.ID, user.TokenVersion, impersonationID, ImpersonationTTL)
+	if err != nil {
+		ih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	ih.logger.Info("User impersonated",
+		zap.String("_id", user.ID.Hex()),
+		zap.String("impersonation_id", impersonationID.Hex()),
+		zap.String("reason", request.Reason),
+		zap.Time("expires_at", expiresAt),
+	)
+	return c.JSON(http.StatusCreated, ImpersonationResponse{
+		ImpersonationID: impersonationID,
+		Token:           token,
+		ExpiresAt:       expiresAt,
+	})
+}
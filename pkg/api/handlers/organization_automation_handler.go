@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// PauseAutomation stops the changes the system makes to the organization's
+// flags on its own, like rollout ramp steps, until ResumeAutomation is
+// called. It is meant for incidents, so nothing moves while people are
+// firefighting.
+func (oh *OrganizationHandler) PauseAutomation(c echo.Context) error {
+	userID, organizationRecord, err := oh.findOrganization(c, models.Admin)
+	if organizationRecord == nil {
+		return err
+	}
+
+	pausedAt := time.Now().UTC()
+	model := models.NewOrganizationModel(oh.db)
+	paused, err := model.PauseAutomation(c.Request().Context(), organizationRecord.ID, userID, pausedAt)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !paused {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.AutomationPausedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.AutomationPausedError,
+		)
+	}
+
+	oh.recordAudit(c, organizationRecord.ID, userID, models.PauseAutomationAction, nil)
+
+	organizationRecord.Settings.AutomationPausedAt = primitive.NewDateTimeFromTime(pausedAt)
+	organizationRecord.Settings.AutomationPausedBy = userID
+	return c.JSON(http.StatusOK, newOrganizationSettingsResponse(organizationRecord))
+}
+
+// ResumeAutomation lifts the pause. Ramp steps that came due while paused
+// don't all fire at once: every ramp is pushed back by the pause's length.
+func (oh *OrganizationHandler) ResumeAutomation(c echo.Context) error {
+	userID, organizationRecord, err := oh.findOrganization(c, models.Admin)
+	if organizationRecord == nil {
+		return err
+	}
+
+	pausedAt := organizationRecord.Settings.AutomationPausedAt
+	if !organizationRecord.Settings.AutomationPaused() {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.AutomationNotPausedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.AutomationNotPausedError,
+		)
+	}
+
+	// Ramps are moved before the pause is lifted, so the worker can't step
+	// them in between.
+	pausedFor := time.Since(pausedAt.Time())
+	featureFlagModel := models.NewFeatureFlagModel(oh.db)
+	delayed, err := featureFlagModel.DelayRolloutRamps(c.Request().Context(), organizationRecord.ID, pausedFor)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	resumed, err := model.ResumeAutomation(c.Request().Context(), organizationRecord.ID, pausedAt)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !resumed {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.AutomationNotPausedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.AutomationNotPausedError,
+		)
+	}
+
+	oh.recordAudit(c, organizationRecord.ID, userID, models.ResumeAutomationAction, map[string]any{
+		"paused_at":      pausedAt,
+		"paused_by":      organizationRecord.Settings.AutomationPausedBy,
+		"delayed_ramps":  delayed,
+		"paused_seconds": int64(pausedFor.Seconds()),
+	})
+
+	organizationRecord.Settings.AutomationPausedAt = 0
+	organizationRecord.Settings.AutomationPausedBy = primitive.NilObjectID
+	return c.JSON(http.StatusOK, newOrganizationSettingsResponse(organizationRecord))
+}
+
+// findOrganization returns the caller and the organization in the path, or
+// a nil organization with the error response already written.
+func (oh *OrganizationHandler) findOrganization(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, *models.OrganizationRecord, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, nil, err
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organizationRecord, err := model.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return primitive.NilObjectID, nil, apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, nil, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, permissionLevel)
+	if !permission {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, nil, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return userID, organizationRecord, nil
+}
+
+// recordAudit adds an entry about the organization itself to the audit log.
+// Like the flag handlers', it logs failures instead of failing the request.
+func (oh *OrganizationHandler) recordAudit(
+	c echo.Context,
+	organizationID,
+	userID primitive.ObjectID,
+	action models.AuditAction,
+	details map[string]any,
+) {
+	entry := models.NewAuditEntry(organizationID, primitive.NilObjectID, userID, action, details)
+	entry.ImpersonationID = apiutils.GetImpersonationIDFromContext(c)
+
+	model := models.NewAuditLogModel(oh.db)
+	_, err := model.InsertOne(context.Background(), entry)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+			zap.String("action", action),
+		)
+	}
+}
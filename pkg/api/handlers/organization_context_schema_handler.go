@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+type PutContextSchemaRequest struct {
+	Attributes map[string]models.AttributeType `json:"attributes" validate:"dive,keys,required,endkeys,oneof=string number boolean"`
+}
+
+type ContextSchemaResponse struct {
+	Attributes map[string]models.AttributeType `json:"attributes"`
+}
+
+// PutContextSchema replaces the evaluation context attributes the
+// organization expects. An empty schema turns context validation off.
+func (oh *OrganizationHandler) PutContextSchema(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organizationRecord, err := model.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Admin)
+	if !permission {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	request := new(PutContextSchemaRequest)
+	if err := c.Bind(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if request.Attributes == nil {
+		request.Attributes = make(map[string]models.AttributeType)
+	}
+
+	err = model.UpdateOne(
+		c.Request().Context(),
+		bson.D{{Key: "_id", Value: organizationID}},
+		bson.D{{Key: "$set", Value: bson.M{"context_schema": request.Attributes}}},
+	)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ContextSchemaResponse{Attributes: request.Attributes})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// DashboardAuditEntries is how many of the latest audit entries the
+// dashboard shows.
+const DashboardAuditEntries = 10
+
+// DashboardChangesWindow is how far back flags count as recently changed.
+const DashboardChangesWindow = 7 * 24 * time.Hour
+
+// DashboardResponse is everything the organization's landing page shows.
+// RecentAuditEntries is only filled for admins, like the audit log itself.
+type DashboardResponse struct {
+	FeatureFlags        *models.FeatureFlagCounts `json:"feature_flags"`
+	ChangedFeatureFlags int64                     `json:"changed_feature_flags"`
+	Members             int                       `json:"members"`
+	RecentAuditEntries  []models.AuditEntry       `json:"recent_audit_entries,omitempty"`
+}
+
+// GetDashboard gathers the organization's flag counts, how many flags
+// changed in the last DashboardChangesWindow, its member count and its
+// latest audit entries in one call. The queries run concurrently.
+func (oh *OrganizationHandler) GetDashboard(c echo.Context) error {
+	userID, organizationRecord, err := oh.findOrganization(c, models.ReadOnly)
+	if organizationRecord == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	featureFlagModel := models.NewFeatureFlagModel(oh.db)
+	response := DashboardResponse{Members: len(organizationRecord.Members)}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		response.FeatureFlags, errs[0] = featureFlagModel.CountByStatusAndType(ctx, organizationRecord.ID)
+	}()
+	go func() {
+		defer wg.Done()
+		since := primitive.NewDateTimeFromTime(time.Now().UTC().Add(-DashboardChangesWindow))
+		response.ChangedFeatureFlags, errs[1] = featureFlagModel.CountMany(ctx, organizationRecord.ID, bson.D{
+			{Key: "updated_at", Value: bson.M{"$gte": since}},
+		})
+	}()
+	if apiutils.UserHasPermission(userID, organizationRecord, models.Admin) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			auditLogModel := models.NewAuditLogModel(oh.db)
+			response.RecentAuditEntries, errs[2] = auditLogModel.FindMany(
+				ctx,
+				organizationRecord.ID,
+				nil,
+				1,
+				DashboardAuditEntries,
+			)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			oh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,14 +16,25 @@ import (
 )
 
 type OrganizationHandler struct {
-	db     *mongo.Database
-	logger *zap.Logger
+	db      *mongo.Database
+	logger  *zap.Logger
+	changes webhooks.Publisher
 }
 
 type OrganizationPostRequest struct {
 	Name string `json:"name" validate:"required"`
 }
 
+// OrganizationMembership is an organization as one of its members sees it
+// in their organization list.
+type OrganizationMembership struct {
+	ID              string                     `json:"_id"`
+	Name            string                     `json:"name"`
+	PermissionLevel models.PermissionLevelEnum `json:"permission_level"`
+}
+
+type ListOrganizationsResponse = PaginatedResponse[OrganizationMembership]
+
 func (oh *OrganizationHandler) PostOrganization(c echo.Context) error {
 	request := new(OrganizationPostRequest)
 	if err := c.Bind(request); err != nil {
@@ -61,7 +73,7 @@ func (oh *OrganizationHandler) PostOrganization(c echo.Context) error {
 			)
 		}
 
-		oh.logger.Debug("Server error",
+		oh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -71,9 +83,9 @@ func (oh *OrganizationHandler) PostOrganization(c echo.Context) error {
 	}
 
 	userModel := models.NewUserModel(oh.db)
-	user, err := userModel.FindByID(context.Background(), userID)
+	user, err := userModel.FindByID(c.Request().Context(), userID)
 	if err != nil {
-		oh.logger.Debug("Server error",
+		oh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -92,10 +104,10 @@ func (oh *OrganizationHandler) PostOrganization(c echo.Context) error {
 
 	model := models.NewOrganizationModel(oh.db)
 
-	_, err = model.InsertOne(context.Background(), organization)
+	_, err = model.InsertOne(c.Request().Context(), organization)
 
 	if err != nil {
-		oh.logger.Debug("Server error",
+		oh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -104,12 +116,94 @@ func (oh *OrganizationHandler) PostOrganization(c echo.Context) error {
 		)
 	}
 
+	oh.changes.PublishOrganizationEvent(webhooks.NewOrganizationCreatedPayload(organization, time.Now()))
+
 	return c.JSON(http.StatusCreated, organization)
 }
 
+// ListOrganizations pages through the organizations the caller belongs to,
+// with their permission level in each.
+func (oh *OrganizationHandler) ListOrganizations(c echo.Context) error {
+	page, limit := apiutils.GetPaginationParams(c.QueryParam("page"), c.QueryParam("page_size"))
+	if page < 1 || limit < 1 {
+		oh.logger.Debug("Client error",
+			zap.String("cause", "invalid pagination parameters"),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userID, err := apiutils.GetObjectIDFromContext(c)
+	if err != nil {
+		// Should never happen but better safe than sorry
+		if errors.Is(err, apiutils.ErrNotAuthenticated) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(
+				c,
+				http.StatusUnauthorized,
+				apierrors.UnauthorizedError,
+			)
+		}
+
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organizations, err := model.FindManyByMember(c.Request().Context(), userID, page, limit)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	total, err := model.CountByMember(c.Request().Context(), userID)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	memberships := make([]OrganizationMembership, 0, len(organizations))
+	for index := range organizations {
+		permissionLevel, _ := apiutils.UserPermissionLevel(userID, &organizations[index])
+		memberships = append(memberships, OrganizationMembership{
+			ID:              organizations[index].ID.Hex(),
+			Name:            organizations[index].Name,
+			PermissionLevel: permissionLevel,
+		})
+	}
+
+	return c.JSON(http.StatusOK, NewPaginatedResponse(memberships, page, limit, total))
+}
+
 func NewOrganizationHandler(db *mongo.Database, logger *zap.Logger) *OrganizationHandler {
 	return &OrganizationHandler{
-		db:     db,
-		logger: logger,
+		db:      db,
+		logger:  logger,
+		changes: webhooks.NopPublisher{},
 	}
 }
+
+// WithChangePublisher sends organization and member changes to publisher.
+func (oh *OrganizationHandler) WithChangePublisher(publisher webhooks.Publisher) *OrganizationHandler {
+	oh.changes = publisher
+	return oh
+}
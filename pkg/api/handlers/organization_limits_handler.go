@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// PutLimitsRequest leaves a limit at its default when it's zero.
+type PutLimitsRequest struct {
+	MaxFeatureFlags int `json:"max_feature_flags" validate:"min=0"`
+	MaxEnvironments int `json:"max_environments" validate:"min=0"`
+	MaxMembers      int `json:"max_members" validate:"min=0"`
+}
+
+// PutLimits sets the quotas of an organization. It sits behind the admin
+// token rather than organization permissions, as limits come with the plan
+// the organization pays for.
+func (oh *OrganizationHandler) PutLimits(c echo.Context) error {
+	organizationID := apiutils.ObjectIDParam(c, "organizationID")
+
+	request := new(PutLimitsRequest)
+	if err := c.Bind(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	limits := models.OrganizationLimits{
+		MaxFeatureFlags: request.MaxFeatureFlags,
+		MaxEnvironments: request.MaxEnvironments,
+		MaxMembers:      request.MaxMembers,
+	}.WithDefaults()
+
+	model := models.NewOrganizationModel(oh.db)
+	if _, err := model.FindByID(c.Request().Context(), organizationID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	err := model.UpdateOne(
+		c.Request().Context(),
+		bson.D{{Key: "_id", Value: organizationID}},
+		bson.D{{Key: "$set", Value: bson.M{"limits": limits}}},
+	)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	oh.logger.Info("Organization limits changed",
+		zap.String("organization_id", organizationID.Hex()),
+		zap.Int("max_feature_flags", limits.MaxFeatureFlags),
+		zap.Int("max_environments", limits.MaxEnvironments),
+		zap.Int("max_members", limits.MaxMembers),
+	)
+	return c.JSON(http.StatusOK, limits)
+}
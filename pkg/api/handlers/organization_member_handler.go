@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type PutMemberRequest struct {
+	PermissionLevel models.PermissionLevelEnum `json:"permission_level" validate:"required,oneof=ADMIN COLLABORATOR READ_ONLY"`
+}
+
+// PutMember adds the user in the path to the organization with the level of
+// the request, or changes the level of a member.
+func (oh *OrganizationHandler) PutMember(c echo.Context) error {
+	actorID, organizationRecord, err := oh.findOrganization(c, models.Admin)
+	if organizationRecord == nil {
+		return err
+	}
+
+	memberID := apiutils.ObjectIDParam(c, "userID")
+
+	request := new(PutMemberRequest)
+	if err := c.Bind(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userModel := models.NewUserModel(oh.db)
+	user, err := userModel.FindByID(c.Request().Context(), memberID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	user.Password = ""
+	user.PasswordHistory = nil
+
+	previous, _ := apiutils.UserPermissionLevel(memberID, organizationRecord)
+	member := models.OrganizationMember{User: *user, PermissionLevel: request.PermissionLevel}
+	if previous == request.PermissionLevel {
+		return c.JSON(http.StatusOK, member)
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	if previous == "" {
+		if len(organizationRecord.Members) >= organizationRecord.Limits.WithDefaults().MaxMembers {
+			oh.logger.Debug("Client error",
+				zap.String("cause", apierrors.MemberQuotaExceededError),
+				zap.String("organization_id", organizationRecord.ID.Hex()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusPaymentRequired,
+				apierrors.MemberQuotaExceededError,
+			)
+		}
+
+		err = model.AddMember(c.Request().Context(), organizationRecord.ID, member)
+	} else {
+		if previous == models.Admin && countAdmins(organizationRecord) == 1 {
+			oh.logger.Debug("Client error",
+				zap.String("cause", apierrors.LastAdminError),
+			)
+			return apierrors.CustomError(c,
+				http.StatusConflict,
+				apierrors.LastAdminError,
+			)
+		}
+
+		err = model.SetMemberPermissionLevel(c.Request().Context(), organizationRecord.ID, memberID, request.PermissionLevel)
+	}
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	oh.changes.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationRecord.ID,
+		memberID,
+		actorID,
+		previous,
+		request.PermissionLevel,
+		time.Now(),
+	))
+
+	return c.JSON(http.StatusOK, member)
+}
+
+// DeleteMember removes the user in the path from the organization.
+func (oh *OrganizationHandler) DeleteMember(c echo.Context) error {
+	actorID, organizationRecord, err := oh.findOrganization(c, models.Admin)
+	if organizationRecord == nil {
+		return err
+	}
+
+	memberID := apiutils.ObjectIDParam(c, "userID")
+
+	previous, isMember := apiutils.UserPermissionLevel(memberID, organizationRecord)
+	if !isMember {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+	if previous == models.Admin && countAdmins(organizationRecord) == 1 {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.LastAdminError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.LastAdminError,
+		)
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	if err := model.RemoveMember(c.Request().Context(), organizationRecord.ID, memberID); err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	oh.changes.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationRecord.ID,
+		memberID,
+		actorID,
+		previous,
+		"",
+		time.Now(),
+	))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func countAdmins(organization *models.OrganizationRecord) int {
+	admins := 0
+	for _, member := range organization.Members {
+		if member.PermissionLevel == models.Admin {
+			admins++
+		}
+	}
+
+	return admins
+}
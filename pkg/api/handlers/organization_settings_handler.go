@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type MaintenanceWindowRequest struct {
+	Start       time.Time `json:"start" validate:"required"`
+	End         time.Time `json:"end" validate:"required,gtfield=Start"`
+	Environment string    `json:"environment" validate:"max=64"`
+}
+
+// PatchSettingsRequest only changes the settings it sets. Maintenance
+// windows and environment parents are replaced as a whole; an empty list or
+// object removes them, like an empty token issuer or audience.
+type PatchSettingsRequest struct {
+	DefaultEnvironment      *string                     `json:"default_environment" validate:"omitempty,max=64"`
+	RequireSeparateApprover *bool                       `json:"require_separate_approver"`
+	MaintenanceWindows      *[]MaintenanceWindowRequest `json:"maintenance_windows" validate:"omitempty,max=50,dive"`
+	EnvironmentParents      *map[string]string          `json:"environment_parents" validate:"omitempty,max=50,dive,keys,min=1,max=64,endkeys,min=1,max=64"`
+	TokenIssuer             *string                     `json:"token_issuer" validate:"omitempty,max=255"`
+	TokenAudience           *string                     `json:"token_audience" validate:"omitempty,max=255"`
+}
+
+// OrganizationSettingsResponse also reports the organization's limits, which
+// only the admin token can change.
+type OrganizationSettingsResponse struct {
+	models.OrganizationSettings
+	Limits models.OrganizationLimits `json:"limits"`
+}
+
+func newOrganizationSettingsResponse(organization *models.OrganizationRecord) OrganizationSettingsResponse {
+	settings := organization.Settings
+	if settings.MaintenanceWindows == nil {
+		settings.MaintenanceWindows = make([]models.MaintenanceWindow, 0)
+	}
+
+	return OrganizationSettingsResponse{
+		OrganizationSettings: settings,
+		Limits:               organization.Limits.WithDefaults(),
+	}
+}
+
+func (oh *OrganizationHandler) GetSettings(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organizationRecord, err := model.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.ReadOnly)
+	if !permission {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, newOrganizationSettingsResponse(organizationRecord))
+}
+
+func (oh *OrganizationHandler) PatchSettings(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organizationRecord, err := model.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Admin)
+	if !permission {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	request := new(PatchSettingsRequest)
+	if err := c.Bind(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if request.EnvironmentParents != nil && models.HasEnvironmentCycle(*request.EnvironmentParents) {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.EnvironmentCycleError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.EnvironmentCycleError,
+		)
+	}
+
+	settings := &organizationRecord.Settings
+	changes := bson.D{}
+	if request.DefaultEnvironment != nil {
+		settings.DefaultEnvironment = *request.DefaultEnvironment
+		changes = append(changes, bson.E{Key: "settings.default_environment", Value: settings.DefaultEnvironment})
+	}
+	if request.RequireSeparateApprover != nil {
+		settings.RequireSeparateApprover = *request.RequireSeparateApprover
+		changes = append(changes, bson.E{
+			Key:   "settings.require_separate_approver",
+			Value: settings.RequireSeparateApprover,
+		})
+	}
+	if request.MaintenanceWindows != nil {
+		settings.MaintenanceWindows = make([]models.MaintenanceWindow, 0, len(*request.MaintenanceWindows))
+		for _, window := range *request.MaintenanceWindows {
+			settings.MaintenanceWindows = append(settings.MaintenanceWindows, models.MaintenanceWindow{
+				Start:       primitive.NewDateTimeFromTime(window.Start.UTC()),
+				End:         primitive.NewDateTimeFromTime(window.End.UTC()),
+				Environment: window.Environment,
+			})
+		}
+		changes = append(changes, bson.E{Key: "settings.maintenance_windows", Value: settings.MaintenanceWindows})
+	}
+	if request.EnvironmentParents != nil {
+		settings.EnvironmentParents = *request.EnvironmentParents
+		changes = append(changes, bson.E{Key: "settings.environment_parents", Value: settings.EnvironmentParents})
+	}
+	if request.TokenIssuer != nil {
+		settings.TokenIssuer = *request.TokenIssuer
+		changes = append(changes, bson.E{Key: "settings.token_issuer", Value: settings.TokenIssuer})
+	}
+	if request.TokenAudience != nil {
+		settings.TokenAudience = *request.TokenAudience
+		changes = append(changes, bson.E{Key: "settings.token_audience", Value: settings.TokenAudience})
+	}
+
+	if len(changes) > 0 {
+		err = model.UpdateOne(
+			c.Request().Context(),
+			bson.D{{Key: "_id", Value: organizationID}},
+			bson.D{{Key: "$set", Value: changes}},
+		)
+		if err != nil {
+			oh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusInternalServerError,
+				apierrors.InternalServerError,
+			)
+		}
+	}
+
+	return c.JSON(http.StatusOK, newOrganizationSettingsResponse(organizationRecord))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// OrganizationTokenResponse is a token scoped to the organization, along
+// with the issuer and audience it was stamped with.
+type OrganizationTokenResponse struct {
+	Token     string    `json:"token"`
+	Issuer    string    `json:"issuer"`
+	Audience  string    `json:"audience,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PostOrganizationToken exchanges the caller's token for one stamped with
+// the issuer and audience the organization requires, the only tokens its
+// routes accept once it configured them. It isn't behind the organization's
+// token scope itself, so members can get a scoped token with their session
+// token. The scoped token expires no later than the one exchanged, so
+// exchanging tokens can't stand in for refreshing them.
+func (oh *OrganizationHandler) PostOrganizationToken(c echo.Context) error {
+	_, organizationRecord, err := oh.findOrganization(c, models.ReadOnly)
+	if organizationRecord == nil {
+		return err
+	}
+
+	contextUser, ok := c.Get("user").(apiutils.ContextUser)
+	if !ok {
+		oh.logger.Error("Server error",
+			zap.String("cause", apiutils.ErrContextUserTypeAssertion.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	scope := apiutils.NewTokenScope(organizationRecord.Settings.TokenIssuer, organizationRecord.Settings.TokenAudience)
+	now := time.Now().UTC()
+	expiresAt := now.Add(config.JWT.AccessTokenTTL)
+	if !contextUser.ExpiresAt.IsZero() && contextUser.ExpiresAt.Before(expiresAt) {
+		expiresAt = contextUser.ExpiresAt
+	}
+	token, err := apiutils.CreateScopedJWT(contextUser, scope, expiresAt.Sub(now))
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, OrganizationTokenResponse{
+		Token:     token,
+		Issuer:    scope.Issuer,
+		Audience:  scope.Audience,
+		ExpiresAt: expiresAt,
+	})
+}
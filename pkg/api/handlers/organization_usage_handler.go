@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// UsageWindowQueryParam picks the span GetUsage covers, one of UsageWindows.
+const UsageWindowQueryParam = "window"
+
+const DefaultUsageWindow = "24h"
+
+// UsageWindows are the spans usage can be read over, with the size of the
+// buckets each one is split in.
+var UsageWindows = map[string]struct {
+	Span       time.Duration
+	BucketSize time.Duration
+}{
+	"24h": {Span: 24 * time.Hour, BucketSize: time.Hour},
+	"7d":  {Span: 7 * 24 * time.Hour, BucketSize: 24 * time.Hour},
+	"30d": {Span: 30 * 24 * time.Hour, BucketSize: 24 * time.Hour},
+}
+
+// UsageStats are the counts of a span of time, along with the average
+// latency of its requests. Start is left out of the totals.
+type UsageStats struct {
+	Start time.Time `json:"start,omitempty"`
+	models.UsageCounts
+	AverageLatencyMillis float64 `json:"average_latency_ms"`
+}
+
+type UsageResponse struct {
+	Window     string       `json:"window"`
+	From       time.Time    `json:"from"`
+	BucketSize string       `json:"bucket_size"`
+	Totals     UsageStats   `json:"totals"`
+	Buckets    []UsageStats `json:"buckets"`
+}
+
+// GetUsage reports the organization's API calls, their errors and latency,
+// and the evaluations it was served over a window, as totals and per bucket,
+// oldest first, the last one being the current one. Buckets without any
+// usage are included, with zero counts. Counts are saved every minute, see
+// workers.UsageWorker, so the latest calls may be missing.
+func (oh *OrganizationHandler) GetUsage(c echo.Context) error {
+	_, organizationRecord, err := oh.findOrganization(c, models.Admin)
+	if organizationRecord == nil {
+		return err
+	}
+
+	windowName := c.QueryParam(UsageWindowQueryParam)
+	if windowName == "" {
+		windowName = DefaultUsageWindow
+	}
+	window, ok := UsageWindows[windowName]
+	if !ok {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.InvalidUsageWindowError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.InvalidUsageWindowError,
+		)
+	}
+
+	bucketCount := int(window.Span / window.BucketSize)
+	from := time.Now().UTC().Truncate(window.BucketSize).Add(-time.Duration(bucketCount-1) * window.BucketSize)
+
+	model := models.NewUsageModel(oh.db)
+	records, err := model.FindSince(c.Request().Context(), organizationRecord.ID, from)
+	if err != nil {
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	response := UsageResponse{
+		Window:     windowName,
+		From:       from,
+		BucketSize: window.BucketSize.String(),
+		Buckets:    make([]UsageStats, bucketCount),
+	}
+	for index := range response.Buckets {
+		response.Buckets[index].Start = from.Add(time.Duration(index) * window.BucketSize)
+	}
+	for _, record := range records {
+		index := int(record.Bucket.Time().Sub(from) / window.BucketSize)
+		if index < 0 || index >= bucketCount {
+			continue
+		}
+		response.Buckets[index].Add(record.UsageCounts)
+		response.Totals.Add(record.UsageCounts)
+	}
+
+	response.Totals.AverageLatencyMillis = averageLatency(response.Totals.UsageCounts)
+	for index := range response.Buckets {
+		response.Buckets[index].AverageLatencyMillis = averageLatency(response.Buckets[index].UsageCounts)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func averageLatency(counts models.UsageCounts) float64 {
+	if counts.Requests == 0 {
+		return 0
+	}
+
+	return float64(counts.LatencyMillis) / float64(counts.Requests)
+}
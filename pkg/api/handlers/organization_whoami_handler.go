@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type OrganizationAction = string
+
+const (
+	ReadFeatureFlagsAction     OrganizationAction = "read_feature_flags"
+	ExportFeatureFlagsAction   OrganizationAction = "export_feature_flags"
+	WriteFeatureFlagsAction    OrganizationAction = "write_feature_flags"
+	ApproveRevisionsAction     OrganizationAction = "approve_revisions"
+	ImportFeatureFlagsAction   OrganizationAction = "import_feature_flags"
+	ManageContextSamplesAction OrganizationAction = "manage_context_samples"
+	ManageTestIdentitiesAction OrganizationAction = "manage_test_identities"
+	ManageUserListsAction      OrganizationAction = "manage_user_lists"
+	ManageAPIKeysAction        OrganizationAction = "manage_api_keys"
+	ReadAuditLogAction         OrganizationAction = "read_audit_log"
+	ReadUsageAction            OrganizationAction = "read_usage"
+	ManageContextSchemaAction  OrganizationAction = "manage_context_schema"
+	ManageSettingsAction       OrganizationAction = "manage_settings"
+	ManageFlagTemplatesAction  OrganizationAction = "manage_flag_templates"
+	PauseAutomationAction      OrganizationAction = "pause_automation"
+	ManageMembersAction        OrganizationAction = "manage_members"
+	ManageWebhooksAction       OrganizationAction = "manage_webhooks"
+)
+
+// organizationActions lists every action with the level the handlers behind
+// it check for. Keep it in step with them.
+var organizationActions = []struct {
+	action          OrganizationAction
+	permissionLevel models.PermissionLevelEnum
+}{
+	{ReadFeatureFlagsAction, models.ReadOnly},
+	{ExportFeatureFlagsAction, models.ReadOnly},
+	{WriteFeatureFlagsAction, models.Collaborator},
+	{ApproveRevisionsAction, models.Collaborator},
+	{ImportFeatureFlagsAction, models.Collaborator},
+	{ManageContextSamplesAction, models.Collaborator},
+	{ManageTestIdentitiesAction, models.Collaborator},
+	{ManageUserListsAction, models.Collaborator},
+	{ManageAPIKeysAction, models.Admin},
+	{ReadAuditLogAction, models.Admin},
+	{ReadUsageAction, models.Admin},
+	{ManageContextSchemaAction, models.Admin},
+	{ManageSettingsAction, models.Admin},
+	{ManageFlagTemplatesAction, models.Admin},
+	{PauseAutomationAction, models.Admin},
+	{ManageMembersAction, models.Admin},
+	{ManageWebhooksAction, models.Admin},
+}
+
+type WhoAmIResponse struct {
+	UserID          string                     `json:"user_id"`
+	OrganizationID  string                     `json:"organization_id"`
+	PermissionLevel models.PermissionLevelEnum `json:"permission_level"`
+	Actions         []OrganizationAction       `json:"actions"`
+}
+
+// GetWhoAmI tells the caller what they can do in the organization, so
+// frontends don't have to guess which controls to show.
+func (oh *OrganizationHandler) GetWhoAmI(c echo.Context) error {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		oh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	model := models.NewOrganizationModel(oh.db)
+	organization, err := model.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			oh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		oh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permissionLevel, member := apiutils.UserPermissionLevel(userID, organization)
+	if !member {
+		oh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	actions := make([]OrganizationAction, 0, len(organizationActions))
+	for _, action := range organizationActions {
+		if apiutils.UserHasPermission(userID, organization, action.permissionLevel) {
+			actions = append(actions, action.action)
+		}
+	}
+
+	return c.JSON(http.StatusOK, WhoAmIResponse{
+		UserID:          userID.Hex(),
+		OrganizationID:  organizationID.Hex(),
+		PermissionLevel: permissionLevel,
+		Actions:         actions,
+	})
+}
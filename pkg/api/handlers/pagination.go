@@ -0,0 +1,26 @@
+package handlers
+
+// PaginatedResponse is the body of every list endpoint: a page of Data out
+// of Total items, split in TotalPages pages of PageSize.
+type PaginatedResponse[T any] struct {
+	Data       []T `json:"data"`
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+func NewPaginatedResponse[T any](data []T, page, pageSize int, total int64) PaginatedResponse[T] {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	return PaginatedResponse[T]{
+		Data:       data,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}
+}
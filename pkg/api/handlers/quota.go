@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// enforceQuota checks that the organization can take newFlags more flags and
+// the environments named by rules. It returns false, with the error response
+// already written, when it can't.
+func (ffh *FeatureFlagHandler) enforceQuota(
+	c echo.Context,
+	organization *models.OrganizationRecord,
+	newFlags int,
+	rules []models.Rule,
+) (bool, error) {
+	model := models.NewFeatureFlagModel(ffh.db)
+	featureFlags, err := model.FindAllByOrganization(c.Request().Context(), organization.ID)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return false, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	if message := quotaError(organization.Limits.WithDefaults(), featureFlags, newFlags, rules); message != "" {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", message),
+			zap.String("organization_id", organization.ID.Hex()),
+		)
+		return false, apierrors.CustomError(c,
+			http.StatusPaymentRequired,
+			message,
+		)
+	}
+
+	return true, nil
+}
+
+// quotaError returns the message of the limit the organization would go over
+// by adding newFlags flags and the environments of rules, or "" when it stays
+// within its limits. Organizations already over a limit can still work with
+// what they have; they just can't add to it.
+func quotaError(
+	limits models.OrganizationLimits,
+	featureFlags []models.FeatureFlagRecord,
+	newFlags int,
+	rules []models.Rule,
+) apierrors.ErrorMessage {
+	if newFlags > 0 && len(featureFlags)+newFlags > limits.MaxFeatureFlags {
+		return apierrors.FlagQuotaExceededError
+	}
+
+	environments := make(map[string]bool)
+	for _, featureFlag := range featureFlags {
+		for _, revision := range featureFlag.Revisions {
+			for _, rule := range revision.Rules {
+				environments[rule.Env] = true
+			}
+		}
+	}
+
+	added := 0
+	for _, rule := range rules {
+		if !environments[rule.Env] {
+			environments[rule.Env] = true
+			added++
+		}
+	}
+	if added > 0 && len(environments) > limits.MaxEnvironments {
+		return apierrors.EnvironmentQuotaExceededError
+	}
+
+	return ""
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/relay"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// RelayHandler serves evaluations from a relay's in-memory copy of one
+// organization's flags. The relay can't see the organization's members, so
+// any authenticated user is served; deploy it where only the organization's
+// own services can reach it.
+type RelayHandler struct {
+	store          *relay.Store
+	organizationID primitive.ObjectID
+	logger         *zap.Logger
+	analytics      analytics.AnalyticsSink
+}
+
+func NewRelayHandler(store *relay.Store, organizationID primitive.ObjectID, logger *zap.Logger) *RelayHandler {
+	return &RelayHandler{
+		store:          store,
+		organizationID: organizationID,
+		logger:         logger,
+		analytics:      analytics.NopSink{},
+	}
+}
+
+// WithAnalytics sends the evaluations the relay serves to sink.
+func (rh *RelayHandler) WithAnalytics(sink analytics.AnalyticsSink) *RelayHandler {
+	rh.analytics = sink
+	return rh
+}
+
+// GetFeatureFlagEnv behaves like the central endpoint of the same name. The
+// relay doesn't mirror the context schema, so explain=true reports nothing.
+// Nor does it mirror user lists, so it refuses to serve flags whose rules or
+// segment overrides target one rather than serve them as if nobody was on it.
+func (rh *RelayHandler) GetFeatureFlagEnv(c echo.Context) error {
+	_, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		rh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return err
+	}
+
+	if organizationID != rh.organizationID {
+		rh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	if !rh.store.Synced() {
+		rh.logger.Error("Server error",
+			zap.String("cause", apierrors.RelayNotSyncedError),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusServiceUnavailable,
+			apierrors.RelayNotSyncedError,
+		)
+	}
+
+	featureFlags := rh.store.Flags()
+	if names := userListFlagNames(c, featureFlags); len(names) > 0 {
+		rh.logger.Error("Server error",
+			zap.String("cause", apierrors.RelayUserListError),
+			zap.Strings("flags", names),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusServiceUnavailable,
+			apierrors.RelayUserListError,
+		)
+	}
+
+	return serveEnv(c, featureFlags, nil, nil, rh.store.EnvironmentParents(), nil, rh.analytics)
+}
+
+// userListFlagNames lists the flags the request asks for that target a user
+// list.
+func userListFlagNames(c echo.Context, featureFlags []models.FeatureFlagRecord) []string {
+	requested := requestedFlagNames(c.QueryParams()[EnvFlagsQueryParam])
+	names := make([]string, 0)
+	for index := range featureFlags {
+		name := featureFlags[index].QualifiedName()
+		if _, ok := requested[name]; requested != nil && !ok {
+			continue
+		}
+		if len(models.UserListIDs(featureFlags[index:index+1])) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
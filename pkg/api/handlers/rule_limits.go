@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// RuleLimitResponse is sent when a revision goes over one of the limits in
+// config.RuleLimits. Limit is the value it went over.
+type RuleLimitResponse struct {
+	Error   string                 `json:"error"`
+	Message apierrors.ErrorMessage `json:"message"`
+	Limit   int                    `json:"limit"`
+}
+
+// enforceRuleLimits checks the rules of a single revision. It returns false,
+// with the error response already written, when a condition tree is malformed,
+// two rules share an id or they go over a limit.
+func (ffh *FeatureFlagHandler) enforceRuleLimits(c echo.Context, rules []models.Rule) (bool, error) {
+	for index := range rules {
+		if err := rules[index].Validate(); err != nil {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return false, apierrors.CustomError(c,
+				http.StatusBadRequest,
+				apierrors.InvalidConditionError,
+			)
+		}
+	}
+
+	// Rules without an id get one when saved.
+	ruleIDs := make(map[primitive.ObjectID]bool, len(rules))
+	for index := range rules {
+		if rules[index].ID.IsZero() {
+			continue
+		}
+		if ruleIDs[rules[index].ID] {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.DuplicateRuleIDError),
+			)
+			return false, apierrors.CustomError(c,
+				http.StatusBadRequest,
+				apierrors.DuplicateRuleIDError,
+			)
+		}
+		ruleIDs[rules[index].ID] = true
+	}
+
+	message, limit := ruleLimitError(config.RuleLimits, rules)
+	if message == "" {
+		return true, nil
+	}
+
+	ffh.logger.Debug("Client error",
+		zap.String("cause", message),
+		zap.Int("limit", limit),
+	)
+	return false, c.JSON(http.StatusBadRequest, RuleLimitResponse{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: message,
+		Limit:   limit,
+	})
+}
+
+// enforceUserLists checks that every user list rules target is one of the
+// organization's. It returns false, with the error response already written,
+// otherwise.
+func (ffh *FeatureFlagHandler) enforceUserLists(
+	c echo.Context,
+	organizationID primitive.ObjectID,
+	rules []models.Rule,
+) (bool, error) {
+	return ffh.enforceUserListIDs(c, organizationID, models.RuleUserListIDs(rules), apierrors.UserListNotFoundError)
+}
+
+// enforceUserListIDs checks that ids, without duplicates, are user lists of
+// the organization, and answers notFound otherwise.
+func (ffh *FeatureFlagHandler) enforceUserListIDs(
+	c echo.Context,
+	organizationID primitive.ObjectID,
+	ids []primitive.ObjectID,
+	notFound apierrors.ErrorMessage,
+) (bool, error) {
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	model := models.NewUserListModel(ffh.db)
+	found, err := model.CountMany(c.Request().Context(), organizationID, ids)
+	if err != nil {
+		ffh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return false, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if found != int64(len(ids)) {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", notFound),
+		)
+		return false, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			notFound,
+		)
+	}
+
+	return true, nil
+}
+
+// ruleLimitError returns the message and value of the limit rules go over,
+// or "" when they stay within limits. Every predicate of a compound condition
+// is held to the same length as a simple one.
+func ruleLimitError(limits config.RuleLimitsConfig, rules []models.Rule) (apierrors.ErrorMessage, int) {
+	if len(rules) > limits.MaxRules {
+		return apierrors.TooManyRulesError, limits.MaxRules
+	}
+
+	for index := range rules {
+		if condition := rules[index].Condition; condition != nil && condition.Depth() > limits.MaxConditionDepth {
+			return apierrors.ConditionTooDeepError, limits.MaxConditionDepth
+		}
+
+		for _, predicate := range rules[index].Predicates() {
+			if utf8.RuneCountInString(predicate) > limits.MaxPredicateLength {
+				return apierrors.PredicateTooLongError, limits.MaxPredicateLength
+			}
+		}
+	}
+
+	return "", 0
+}
+
+// enforceValueLimits checks the values a revision of a flag of flagType
+// serves. It returns false, with the error response already written, when a
+// json value is bigger than config.RuleLimits allows or a value doesn't fit
+// the flag's type or constraints.
+func (ffh *FeatureFlagHandler) enforceValueLimits(
+	c echo.Context,
+	flagType models.FlagType,
+	constraints *models.ValueConstraints,
+	defaultValue string,
+	rules []models.Rule,
+) (bool, error) {
+	if flagType == models.JSON {
+		maxSize := config.RuleLimits.MaxJSONValueSize
+		tooLarge := len(defaultValue) > maxSize
+		for index := range rules {
+			tooLarge = tooLarge || len(rules[index].Value) > maxSize
+		}
+		if tooLarge {
+			ffh.logger.Debug("Client error",
+				zap.String("cause", apierrors.JSONValueTooLargeError),
+				zap.Int("limit", maxSize),
+			)
+			return false, c.JSON(http.StatusBadRequest, RuleLimitResponse{
+				Error:   http.StatusText(http.StatusBadRequest),
+				Message: apierrors.JSONValueTooLargeError,
+				Limit:   maxSize,
+			})
+		}
+	}
+
+	if ok, err := ffh.enforceValueConstraints(c, flagType, constraints, defaultValue); !ok {
+		return false, err
+	}
+	for index := range rules {
+		if ok, err := ffh.enforceValueConstraints(c, flagType, constraints, rules[index].Value); !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// enforceValueConstraints checks a single value a flag of flagType would
+// serve. It returns false, with the error response already written, when
+// flagvalue.Check rejects it.
+func (ffh *FeatureFlagHandler) enforceValueConstraints(
+	c echo.Context,
+	flagType models.FlagType,
+	constraints *models.ValueConstraints,
+	value string,
+) (bool, error) {
+	err := flagvalue.Check(flagType, constraints, value)
+	if err == nil {
+		return true, nil
+	}
+
+	message := apierrors.FlagValueTypeError
+	switch {
+	case errors.Is(err, flagvalue.ErrNotAllowed):
+		message = apierrors.FlagValueNotAllowedError
+	case errors.Is(err, flagvalue.ErrOutOfRange):
+		message = apierrors.FlagValueOutOfRangeError
+	}
+
+	ffh.logger.Debug("Client error",
+		zap.String("cause", err.Error()),
+	)
+	return false, apierrors.CustomError(c,
+		http.StatusBadRequest,
+		message,
+	)
+}
+
+// enforceConstraints checks that constraints fit a flag of flagType and that
+// everything featureFlag already serves, when it exists, stays within them.
+// It returns false, with the error response already written, otherwise.
+func (ffh *FeatureFlagHandler) enforceConstraints(
+	c echo.Context,
+	flagType models.FlagType,
+	constraints *models.ValueConstraints,
+	featureFlag *models.FeatureFlagRecord,
+) (bool, error) {
+	if err := flagvalue.CheckConstraints(flagType, constraints); err != nil {
+		ffh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return false, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.InvalidValueConstraintsError,
+		)
+	}
+	if featureFlag == nil || constraints == nil {
+		return true, nil
+	}
+
+	for index := range featureFlag.Revisions {
+		revision := &featureFlag.Revisions[index]
+		if revision.Status == models.Archived {
+			continue
+		}
+		if ok, err := ffh.enforceValueLimits(c, flagType, constraints, revision.DefaultValue, revision.Rules); !ok {
+			return false, err
+		}
+	}
+	for _, override := range featureFlag.Overrides {
+		if ok, err := ffh.enforceValueConstraints(c, flagType, constraints, override.Value); !ok {
+			return false, err
+		}
+	}
+	if featureFlag.Rollout != nil {
+		return ffh.enforceValueConstraints(c, flagType, constraints, featureFlag.Rollout.Value)
+	}
+
+	return true, nil
+}
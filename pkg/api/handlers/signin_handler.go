@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"net/http"
 
@@ -35,8 +34,8 @@ func (sh *SignInHandler) PostSignIn(c echo.Context) error {
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
-			http.StatusInternalServerError,
-			apierrors.InternalServerError,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
 		)
 	}
 
@@ -54,7 +53,7 @@ func (sh *SignInHandler) PostSignIn(c echo.Context) error {
 
 	model := models.NewUserModel(sh.db)
 
-	ur, err := model.FindByEmail(context.Background(), request.Email)
+	ur, err := model.FindByEmail(c.Request().Context(), request.Email)
 
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -67,7 +66,7 @@ func (sh *SignInHandler) PostSignIn(c echo.Context) error {
 			)
 		}
 
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -88,9 +87,19 @@ func (sh *SignInHandler) PostSignIn(c echo.Context) error {
 		)
 	}
 
-	token, err := apiutils.CreateJWT(ur.ID, config.JWTExpireTime)
+	// Hashes made before the cost was raised are upgraded while the
+	// password is at hand. Failing to do so doesn't keep the user out.
+	if ur.NeedsRehash() {
+		if err := model.UpgradePasswordHash(c.Request().Context(), ur.ID, request.Password); err != nil {
+			sh.logger.Error("Server error",
+				zap.String("cause", err.Error()),
+			)
+		}
+	}
+
+	token, err := apiutils.CreateVersionedJWT(ur.ID, ur.TokenVersion, config.JWT.AccessTokenTTL)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -108,6 +117,8 @@ func (sh *SignInHandler) PostSignIn(c echo.Context) error {
 		FirstName: ur.FirstName,
 		LastName:  ur.LastName,
 		Token:     token,
+		CreatedAt: ur.CreatedAt,
+		UpdatedAt: ur.UpdatedAt,
 	})
 }
 
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/Roll-Play/togglelabs/pkg/api/common"
@@ -29,7 +28,7 @@ func NewSignUpHandler(db *mongo.Database, logger *zap.Logger) *SignUpHandler {
 
 type SignUpRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"gte=8"`
+	Password string `json:"password" validate:"required"`
 }
 
 func (sh *SignUpHandler) PostUser(c echo.Context) error {
@@ -56,8 +55,18 @@ func (sh *SignUpHandler) PostUser(c echo.Context) error {
 		)
 	}
 
+	if err := apiutils.ValidatePassword(request.Password, config.Password); err != nil {
+		sh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			err.Error(),
+		)
+	}
+
 	model := models.NewUserModel(sh.db)
-	_, err := model.FindByEmail(context.Background(), request.Email)
+	_, err := model.FindByEmail(c.Request().Context(), request.Email)
 	if err == nil {
 		sh.logger.Debug("Client error",
 			zap.String("cause", apierrors.EmailConflictError),
@@ -70,7 +79,7 @@ func (sh *SignUpHandler) PostUser(c echo.Context) error {
 
 	ur, err := models.NewUserRecord(request.Email, request.Password, "", "")
 	if err != nil {
-		sh.logger.Debug("Client error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -79,9 +88,9 @@ func (sh *SignUpHandler) PostUser(c echo.Context) error {
 		)
 	}
 
-	objectID, err := model.InsertOne(context.Background(), ur)
+	objectID, err := model.InsertOne(c.Request().Context(), ur)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -90,9 +99,9 @@ func (sh *SignUpHandler) PostUser(c echo.Context) error {
 		)
 	}
 
-	token, err := apiutils.CreateJWT(objectID, config.JWTExpireTime)
+	token, err := apiutils.CreateJWT(objectID, config.JWT.AccessTokenTTL)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -109,5 +118,7 @@ func (sh *SignUpHandler) PostUser(c echo.Context) error {
 		FirstName: ur.FirstName,
 		LastName:  ur.LastName,
 		Token:     token,
+		CreatedAt: ur.CreatedAt,
+		UpdatedAt: ur.UpdatedAt,
 	})
 }
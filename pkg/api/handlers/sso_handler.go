@@ -7,13 +7,16 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/Roll-Play/togglelabs/pkg/api/common"
 	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
 	"github.com/Roll-Play/togglelabs/pkg/config"
 	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/storage"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
@@ -61,7 +64,7 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 	userDataBytes, err := sh.getUserOAuthData(state, code)
 
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -73,7 +76,7 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 	userData := new(models.UserRecord)
 	err = json.Unmarshal(userDataBytes, userData)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -84,11 +87,11 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 	}
 
 	model := models.NewUserModel(sh.db)
-	foundRecord, err := model.FindByEmail(context.Background(), userData.Email)
+	foundRecord, err := model.FindByEmail(c.Request().Context(), userData.Email)
 	if err == nil {
-		token, err := apiutils.CreateJWT(foundRecord.ID, config.JWTExpireTime)
+		token, err := apiutils.CreateVersionedJWT(foundRecord.ID, foundRecord.TokenVersion, config.JWT.AccessTokenTTL)
 		if err != nil {
-			sh.logger.Debug("Server error",
+			sh.logger.Error("Server error",
 				zap.String("cause", err.Error()),
 			)
 			return apierrors.CustomError(
@@ -104,12 +107,18 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 			FirstName: foundRecord.FirstName,
 			LastName:  foundRecord.LastName,
 			Token:     token,
+			CreatedAt: foundRecord.CreatedAt,
+			UpdatedAt: foundRecord.UpdatedAt,
 		})
 	}
 
-	objectID, err := model.InsertOne(context.Background(), userData)
+	userData.Timestamps = storage.Timestamps{
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+	}
+	objectID, err := model.InsertOne(c.Request().Context(), userData)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -119,9 +128,9 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 		)
 	}
 
-	token, err := apiutils.CreateJWT(objectID, config.JWTExpireTime)
+	token, err := apiutils.CreateJWT(objectID, config.JWT.AccessTokenTTL)
 	if err != nil {
-		sh.logger.Debug("Server error",
+		sh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -135,9 +144,11 @@ func (sh *SsoHandler) Callback(c echo.Context) error {
 		zap.String("_id", objectID.Hex()),
 	)
 	return c.JSON(http.StatusCreated, common.AuthResponse{
-		ID:    userData.ID,
-		Email: userData.Email,
-		Token: token,
+		ID:        userData.ID,
+		Email:     userData.Email,
+		Token:     token,
+		CreatedAt: userData.CreatedAt,
+		UpdatedAt: userData.UpdatedAt,
 	})
 }
 
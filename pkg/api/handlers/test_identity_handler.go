@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type TestIdentityHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewTestIdentityHandler(db *mongo.Database, logger *zap.Logger) *TestIdentityHandler {
+	return &TestIdentityHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type TestIdentityRequest struct {
+	Name       string            `json:"name" validate:"required,max=100"`
+	Attributes map[string]string `json:"attributes" validate:"required,min=1,max=100"`
+}
+
+type ListTestIdentitiesResponse struct {
+	Data []models.TestIdentityRecord `json:"data"`
+}
+
+func (tih *TestIdentityHandler) PostTestIdentity(c echo.Context) error {
+	organizationID, err := tih.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	request, err := tih.bindRequest(c)
+	if request == nil {
+		return err
+	}
+
+	record := models.NewTestIdentityRecord(organizationID, request.Name, request.Attributes)
+	model := models.NewTestIdentityModel(tih.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, record)
+}
+
+func (tih *TestIdentityHandler) ListTestIdentities(c echo.Context) error {
+	organizationID, err := tih.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	model := models.NewTestIdentityModel(tih.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListTestIdentitiesResponse{Data: records})
+}
+
+func (tih *TestIdentityHandler) GetTestIdentity(c echo.Context) error {
+	organizationID, err := tih.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	testIdentityID := apiutils.ObjectIDParam(c, "testIdentityID")
+
+	model := models.NewTestIdentityModel(tih.db)
+	record, err := model.FindByID(c.Request().Context(), organizationID, testIdentityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			tih.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+// PutTestIdentity replaces the name and every attribute of the test identity.
+func (tih *TestIdentityHandler) PutTestIdentity(c echo.Context) error {
+	organizationID, err := tih.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	testIdentityID := apiutils.ObjectIDParam(c, "testIdentityID")
+
+	request, err := tih.bindRequest(c)
+	if request == nil {
+		return err
+	}
+
+	model := models.NewTestIdentityModel(tih.db)
+	found, err := model.ReplaceAttributes(
+		c.Request().Context(),
+		organizationID,
+		testIdentityID,
+		request.Name,
+		request.Attributes,
+	)
+	if err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		tih.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	record, err := model.FindByID(c.Request().Context(), organizationID, testIdentityID)
+	if err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+func (tih *TestIdentityHandler) DeleteTestIdentity(c echo.Context) error {
+	organizationID, err := tih.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	testIdentityID := apiutils.ObjectIDParam(c, "testIdentityID")
+
+	model := models.NewTestIdentityModel(tih.db)
+	found, err := model.DeleteOne(c.Request().Context(), organizationID, testIdentityID)
+	if err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		tih.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorize returns the organization in the path when the caller has at
+// least permissionLevel in it. The id is zero, and the error response already
+// written, when they don't.
+func (tih *TestIdentityHandler) authorize(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		tih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, err
+	}
+
+	organizationModel := models.NewOrganizationModel(tih.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		tih.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, permissionLevel)
+	if !permission {
+		tih.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return organizationID, nil
+}
+
+// bindRequest returns nil, with the error response already written, when
+// the body isn't a valid test identity.
+func (tih *TestIdentityHandler) bindRequest(c echo.Context) (*TestIdentityRequest, error) {
+	request := new(TestIdentityRequest)
+	if err := c.Bind(request); err != nil {
+		tih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		tih.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	return request, nil
+}
@@ -0,0 +1,172 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	testutils "github.com/Roll-Play/togglelabs/pkg/utils/test_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const adminTestToken = "super-secret-admin-token"
+
+type AdminHandlerTestSuite struct {
+	testutils.DefaultTestSuite
+	readOnly *middlewares.ReadOnlyMode
+}
+
+func (suite *AdminHandlerTestSuite) SetupTest() {
+	suite.Server = echo.New()
+	suite.readOnly = middlewares.NewReadOnlyMode(false)
+
+	h := handlers.NewAdminHandler(suite.readOnly, zap.NewNop())
+	adminMiddleware := middlewares.AdminMiddleware(adminTestToken)
+
+	suite.Server.Use(middlewares.ReadOnlyMiddleware(suite.readOnly, "/admin/read-only"))
+	suite.Server.GET("/admin/read-only", h.GetReadOnly, adminMiddleware)
+	suite.Server.PUT("/admin/read-only", h.PutReadOnly, adminMiddleware)
+	suite.Server.GET("/resources", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	suite.Server.POST("/resources", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+	suite.Server.PATCH("/resources", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	suite.Server.DELETE("/resources", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+}
+
+func (suite *AdminHandlerTestSuite) setReadOnly(enabled bool, token string) *httptest.ResponseRecorder {
+	requestBody, err := json.Marshal(map[string]bool{"enabled": enabled})
+	assert.NoError(suite.T(), err)
+
+	request := httptest.NewRequest(http.MethodPut, "/admin/read-only", bytes.NewBuffer(requestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(middlewares.AdminTokenHeader, token)
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *AdminHandlerTestSuite) TestReadOnlyModeRejectsWrites() {
+	t := suite.T()
+
+	recorder := suite.setReadOnly(true, adminTestToken)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, suite.readOnly.Enabled())
+
+	for _, method := range []string{http.MethodPost, http.MethodPatch, http.MethodDelete} {
+		request := httptest.NewRequest(method, "/resources", nil)
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response apierrors.Error
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, method)
+		assert.Equal(t, apierrors.ReadOnlyModeError, response.Message)
+		assert.NotEmpty(t, recorder.Header().Get(echo.HeaderRetryAfter))
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/resources", nil)
+	recorder = httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func (suite *AdminHandlerTestSuite) TestReadOnlyModeCanBeDisabled() {
+	t := suite.T()
+
+	suite.readOnly.Set(true)
+
+	recorder := suite.setReadOnly(false, adminTestToken)
+
+	var response handlers.ReadOnlyResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.False(t, response.Enabled)
+
+	request := httptest.NewRequest(http.MethodPost, "/resources", nil)
+	recorder = httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
+
+func (suite *AdminHandlerTestSuite) TestGetReadOnly() {
+	t := suite.T()
+
+	suite.readOnly.Set(true)
+
+	request := httptest.NewRequest(http.MethodGet, "/admin/read-only", nil)
+	request.Header.Set(middlewares.AdminTokenHeader, adminTestToken)
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.ReadOnlyResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, response.Enabled)
+}
+
+func (suite *AdminHandlerTestSuite) TestReadOnlyRequiresAdminToken() {
+	t := suite.T()
+
+	recorder := suite.setReadOnly(true, "wrong-token")
+
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, apierrors.UnauthorizedError, response.Message)
+	assert.False(t, suite.readOnly.Enabled())
+}
+
+func (suite *AdminHandlerTestSuite) TestReadOnlyMissingEnabled() {
+	t := suite.T()
+
+	request := httptest.NewRequest(http.MethodPut, "/admin/read-only", bytes.NewBufferString("{}"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(middlewares.AdminTokenHeader, adminTestToken)
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func (suite *AdminHandlerTestSuite) TestAdminEndpointsDisabledWithoutToken() {
+	t := suite.T()
+
+	h := handlers.NewAdminHandler(suite.readOnly, zap.NewNop())
+	suite.Server.GET("/disabled/read-only", h.GetReadOnly, middlewares.AdminMiddleware(""))
+
+	request := httptest.NewRequest(http.MethodGet, "/disabled/read-only", nil)
+	request.Header.Set(middlewares.AdminTokenHeader, "")
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestAdminHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminHandlerTestSuite))
+}
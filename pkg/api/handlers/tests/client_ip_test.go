@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPExtractor(t *testing.T) {
+	_, loadBalancers, err := net.ParseCIDR("10.0.0.0/8")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		remoteAddr     string
+		forwardedFor   string
+		expected       string
+	}{
+		{
+			name:         "forwarded header is ignored without trusted proxies",
+			remoteAddr:   "198.51.100.10:4000",
+			forwardedFor: "203.0.113.7",
+			expected:     "198.51.100.10",
+		},
+		{
+			name:           "forwarded header from a trusted proxy",
+			trustedProxies: []*net.IPNet{loadBalancers},
+			remoteAddr:     "10.0.0.5:4000",
+			forwardedFor:   "203.0.113.7",
+			expected:       "203.0.113.7",
+		},
+		{
+			name:           "spoofed hops left of the client are skipped",
+			trustedProxies: []*net.IPNet{loadBalancers},
+			remoteAddr:     "10.0.0.5:4000",
+			forwardedFor:   "1.2.3.4, 203.0.113.7, 10.0.0.9",
+			expected:       "203.0.113.7",
+		},
+		{
+			name:           "forwarded header from an untrusted peer",
+			trustedProxies: []*net.IPNet{loadBalancers},
+			remoteAddr:     "198.51.100.10:4000",
+			forwardedFor:   "203.0.113.7",
+			expected:       "198.51.100.10",
+		},
+		{
+			name:           "private peers aren't trusted unless listed",
+			trustedProxies: []*net.IPNet{loadBalancers},
+			remoteAddr:     "192.168.1.1:4000",
+			forwardedFor:   "203.0.113.7",
+			expected:       "192.168.1.1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := echo.New()
+			server.IPExtractor = middlewares.ClientIPExtractor(testCase.trustedProxies)
+			server.GET("/ip", func(c echo.Context) error {
+				return c.String(http.StatusOK, c.RealIP())
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			request.RemoteAddr = testCase.remoteAddr
+			request.Header.Set(echo.HeaderXForwardedFor, testCase.forwardedFor)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.expected, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	large := []byte(strings.Repeat(`{"name":"feature","value":"true"},`, 100))
+	small := []byte(`{"name":"feature"}`)
+
+	server := echo.New()
+	// Stands in for a handler serving its body with conditionalBlob.
+	serve := func(body []byte) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			etag := apiutils.ETag(body)
+			c.Response().Header().Set("ETag", etag)
+			if apiutils.NotModified(c.Request(), etag, time.Time{}) {
+				return c.NoContent(http.StatusNotModified)
+			}
+
+			return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, body)
+		}
+	}
+	server.GET("/large", serve(large), middlewares.Compress(1024))
+	server.GET("/small", serve(small), middlewares.Compress(1024))
+
+	request := httptest.NewRequest(http.MethodGet, "/large", nil)
+	request.Header.Set(echo.HeaderAcceptEncoding, "gzip, deflate")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "gzip", recorder.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, echo.HeaderAcceptEncoding, recorder.Header().Get(echo.HeaderVary))
+	assert.Less(t, recorder.Body.Len(), len(large))
+	etag := recorder.Header().Get("ETag")
+	assert.Equal(t, "W/"+apiutils.ETag(large), etag)
+
+	reader, err := gzip.NewReader(bytes.NewReader(recorder.Body.Bytes()))
+	assert.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, large, body)
+
+	request = httptest.NewRequest(http.MethodGet, "/large", nil)
+	request.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	request.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+
+	request = httptest.NewRequest(http.MethodGet, "/small", nil)
+	request.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, echo.HeaderAcceptEncoding, recorder.Header().Get(echo.HeaderVary))
+	assert.Equal(t, apiutils.ETag(small), recorder.Header().Get("ETag"))
+	assert.Equal(t, small, recorder.Body.Bytes())
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/large", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, apiutils.ETag(large), recorder.Header().Get("ETag"))
+	assert.Equal(t, large, recorder.Body.Bytes())
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) listAccessibleFeatureFlags(
+	token,
+	query string,
+) (*httptest.ResponseRecorder, handlers.ListAccessibleFeatureFlagsResponse) {
+	request := httptest.NewRequest(http.MethodGet, "/users/me/feature-flags?"+query, nil)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.ListAccessibleFeatureFlagsResponse
+	if recorder.Code == http.StatusOK {
+		assert.NoError(suite.T(), json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+
+	return recorder, response
+}
+
+func accessibleFlagNames(response handlers.ListAccessibleFeatureFlagsResponse) []string {
+	names := make([]string, 0, len(response.Data))
+	for _, featureFlag := range response.Data {
+		names = append(names, featureFlag.OrganizationName+"/"+featureFlag.Name)
+	}
+
+	return names
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestListAccessibleFeatureFlags() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	stranger := fixtures.CreateUser("", "", "", "", suite.db)
+	payments := fixtures.CreateOrganization("payments", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](user, models.ReadOnly),
+	}, suite.db)
+	growth := fixtures.CreateOrganization("growth", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](user, models.Admin),
+	}, suite.db)
+	secret := fixtures.CreateOrganization("secret", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](stranger, models.Admin),
+	}, suite.db)
+
+	fixtures.CreateFeatureFlag(user.ID, payments.ID, "checkout", 1, models.Boolean, nil, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, payments.ID, "refunds", 1, models.Boolean, nil, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, growth.ID, "onboarding", 1, models.Boolean, nil, suite.db)
+	fixtures.CreateFeatureFlag(stranger.ID, secret.ID, "launch", 1, models.Boolean, nil, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	// Flags of organizations the user isn't a member of never show up.
+	recorder, response := suite.listAccessibleFeatureFlags(token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 3, response.Total)
+	assert.Equal(t, []string{
+		payments.Name + "/checkout",
+		payments.Name + "/refunds",
+		growth.Name + "/onboarding",
+	}, accessibleFlagNames(response))
+	assert.Equal(t, models.ReadOnly, response.Data[0].PermissionLevel)
+	assert.Equal(t, models.Admin, response.Data[2].PermissionLevel)
+
+	recorder, response = suite.listAccessibleFeatureFlags(token, "page=2&page_size=2")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 3, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.Equal(t, []string{growth.Name + "/onboarding"}, accessibleFlagNames(response))
+
+	recorder, response = suite.listAccessibleFeatureFlags(token, "organization_id="+growth.ID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{growth.Name + "/onboarding"}, accessibleFlagNames(response))
+
+	// Asking for an organization the user can't access lists nothing of it.
+	recorder, response = suite.listAccessibleFeatureFlags(token,
+		"organization_id="+secret.ID.Hex()+","+growth.ID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, []string{growth.Name + "/onboarding"}, accessibleFlagNames(response))
+
+	recorder, _ = suite.listAccessibleFeatureFlags(token, "organization_id=nope")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Organizations requiring a scope the token lacks are left out, as their
+	// own routes would reject it.
+	_, err = suite.db.Collection(models.OrganizationCollectionName).UpdateOne(context.Background(),
+		bson.D{{Key: "_id", Value: payments.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"settings.token_issuer": "https://sso.payments.example"}}},
+	)
+	assert.NoError(t, err)
+
+	recorder, response = suite.listAccessibleFeatureFlags(token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{growth.Name + "/onboarding"}, accessibleFlagNames(response))
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestArchiveFeatureFlag() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "old-checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false", models.Rule{
+			Predicate: "country: br",
+			Value:     "true",
+			Env:       "prd",
+			IsEnabled: true,
+		}), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/organizations/"+organization.ID.Hex()+path, nil)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+	listed := func(query string) []models.FeatureFlagRecord {
+		recorder := send(http.MethodGet, "/feature-flags"+query)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response handlers.ListFeatureFlagResponse
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	recorder := send(http.MethodPatch, "/feature-flags/"+featureFlag.ID.Hex()+"/archive")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.True(t, response.IsArchived())
+
+	assert.Empty(t, listed(""))
+	archived := listed("?archived=true")
+	assert.Len(t, archived, 1)
+	assert.Equal(t, featureFlag.ID, archived[0].ID)
+
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=br")
+	assert.Equal(t, "OLD_CHECKOUT=false\n", recorder.Body.String())
+
+	recorder = send(http.MethodPatch, "/feature-flags/"+featureFlag.ID.Hex()+"/unarchive")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	assert.Len(t, listed(""), 1)
+	assert.Empty(t, listed("?archived=true"))
+
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=br")
+	assert.Equal(t, "OLD_CHECKOUT=true\n", recorder.Body.String())
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) compareRevisions(
+	organizationID,
+	featureFlagID primitive.ObjectID,
+	token,
+	query string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID.Hex()+"/revisions/compare?"+query,
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestCompareRevisions() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	pix := models.Rule{ID: primitive.NewObjectID(), Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true}
+	boleto := models.Rule{ID: primitive.NewObjectID(), Predicate: "country: BR", Value: "boleto", Env: "stg", IsEnabled: true}
+	card := models.Rule{ID: primitive.NewObjectID(), Predicate: "country: US", Value: "card", Env: "prd", IsEnabled: true}
+	pixDisabled := pix
+	pixDisabled.IsEnabled = false
+
+	archived := models.Revision{
+		ID: primitive.NewObjectID(), UserID: user.ID, Status: models.Archived,
+		DefaultValue: "legacy", Rules: []models.Rule{pix, boleto},
+	}
+	live := models.Revision{
+		ID: primitive.NewObjectID(), UserID: user.ID, Status: models.Live,
+		DefaultValue: "legacy", Rules: []models.Rule{boleto, pixDisabled}, LastRevisionID: archived.ID,
+	}
+	draft := models.Revision{
+		ID: primitive.NewObjectID(), UserID: user.ID, Status: models.Draft,
+		DefaultValue: "redirect", Rules: []models.Rule{pixDisabled, card}, LastRevisionID: live.ID,
+	}
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 3, models.String,
+		[]models.Revision{archived, live, draft}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	// Revisions don't have to be adjacent.
+	recorder := suite.compareRevisions(organization.ID, featureFlag.ID, token,
+		"from="+archived.ID.Hex()+"&to="+draft.ID.Hex())
+
+	var response handlers.CompareRevisionsResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, archived.ID, response.From)
+	assert.Equal(t, draft.ID, response.To)
+	assert.Equal(t, &models.FieldChange{From: "legacy", To: "redirect"}, response.Diff.DefaultValue)
+	assert.Equal(t, &models.FieldChange{From: models.Archived, To: models.Draft}, response.Diff.Status)
+	assert.Equal(t, []models.RuleChange{
+		{RuleID: pix.ID, Type: models.RuleChanged, Before: &pix, After: &pixDisabled},
+		{RuleID: card.ID, Type: models.RuleAdded, After: &card},
+		{RuleID: boleto.ID, Type: models.RuleRemoved, Before: &boleto},
+	}, response.Diff.Rules)
+
+	recorder = suite.compareRevisions(organization.ID, featureFlag.ID, token,
+		"from="+archived.ID.Hex()+"&to="+live.ID.Hex())
+	response = handlers.CompareRevisionsResponse{}
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Nil(t, response.Diff.DefaultValue)
+	assert.Equal(t, []models.RuleChange{
+		{RuleID: boleto.ID, Type: models.RuleMoved},
+		{RuleID: pix.ID, Type: models.RuleChanged, Before: &pix, After: &pixDisabled},
+	}, response.Diff.Rules)
+
+	recorder = suite.compareRevisions(organization.ID, featureFlag.ID, token,
+		"from="+live.ID.Hex()+"&to="+live.ID.Hex())
+	response = handlers.CompareRevisionsResponse{}
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.True(t, response.Diff.Empty())
+
+	recorder = suite.compareRevisions(organization.ID, featureFlag.ID, token,
+		"from="+live.ID.Hex()+"&to="+primitive.NewObjectID().Hex())
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = suite.compareRevisions(organization.ID, featureFlag.ID, token, "from="+live.ID.Hex())
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestCompareRevisionsForbidden() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", nil, suite.db)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy"), suite.db)
+	revisionID := featureFlag.Revisions[0].ID.Hex()
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.compareRevisions(organization.ID, featureFlag.ID, token, "from="+revisionID+"&to="+revisionID)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
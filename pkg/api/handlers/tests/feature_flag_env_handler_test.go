@@ -0,0 +1,407 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func liveRevision(userID primitive.ObjectID, defaultValue string, rules ...models.Rule) []models.Revision {
+	revision := fixtures.CreateRevision(userID, models.Live, primitive.NilObjectID)
+	revision.DefaultValue = defaultValue
+	revision.Rules = rules
+
+	return []models.Revision{*revision}
+}
+
+func (suite *FeatureFlagHandlerTestSuite) getEnv(organizationID primitive.ObjectID, token, query string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/env?"+query,
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvCoercesValues() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "dark-mode", 1,
+		models.Boolean, liveRevision(user.ID, "TRUE"), suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "max_items", 1,
+		models.Number, liveRevision(user.ID, "10.50"), suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "banner", 1,
+		models.String, liveRevision(user.ID, "hello world"), suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "limits", 1,
+		models.JSON, liveRevision(user.ID, `{ "max": 3 }`), suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "unreleased", 1,
+		models.Boolean, nil, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(organization.ID, token, "prefix=FF_")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, echo.MIMETextPlainCharsetUTF8, recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "FF_BANNER=\"hello world\"\n"+
+		"FF_DARK_MODE=true\n"+
+		"FF_LIMITS=\"{\\\"max\\\":3}\"\n"+
+		"FF_MAX_ITEMS=10.5\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvEvaluatesRules() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String, liveRevision(user.ID, "legacy",
+		models.Rule{Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: BR", Value: "disabled", Env: "prd", IsEnabled: false},
+		models.Rule{Predicate: "country: US", Value: "card", Env: "prd", IsEnabled: true},
+	), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"environment=prd&country=BR", "CHECKOUT=pix\n"},
+		{"environment=prd&country=US", "CHECKOUT=card\n"},
+		{"environment=prd&country=AR", "CHECKOUT=legacy\n"},
+		{"environment=stg&country=BR", "CHECKOUT=legacy\n"},
+		{"country=BR", "CHECKOUT=legacy\n"},
+	}
+
+	for _, tc := range testCases {
+		recorder := suite.getEnv(organization.ID, token, tc.query)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.query)
+		assert.Equal(t, tc.expected, recorder.Body.String(), tc.query)
+	}
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvValidatorsArePerEnvironment() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	live := liveRevision(user.ID, "legacy",
+		models.Rule{Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: BR", Value: "boleto", Env: "stg", IsEnabled: true},
+	)
+	draft := fixtures.CreateRevision(user.ID, models.Draft, primitive.NilObjectID)
+	draft.DefaultValue = "legacy"
+	draft.Rules = []models.Rule{
+		{Predicate: "country: BR", Value: "card", Env: "prd", IsEnabled: true},
+		{Predicate: "country: BR", Value: "boleto", Env: "stg", IsEnabled: true},
+	}
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, append(live, *draft), suite.db)
+
+	// Last-Modified has a one second resolution, so the flag is made older
+	// than the approval below.
+	anHourAgo := primitive.NewDateTimeFromTime(time.Now().UTC().Add(-time.Hour))
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: featureFlag.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"updated_at": anHourAgo, "shared_changed_at": anHourAgo}}},
+	)
+	assert.NoError(t, err)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	conditionalEnv := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(
+			http.MethodGet,
+			"/organizations/"+organization.ID.Hex()+"/env?"+query,
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	validators := make(map[string]map[string]string)
+	for _, environment := range []string{"prd", "stg"} {
+		recorder := conditionalEnv("environment="+environment+"&country=BR", nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		validators[environment] = map[string]string{
+			"ETag":              recorder.Header().Get("ETag"),
+			"If-Modified-Since": recorder.Header().Get(echo.HeaderLastModified),
+		}
+	}
+	assert.NotEqual(t, validators["prd"]["ETag"], validators["stg"]["ETag"])
+
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+featureFlag.ID.Hex()+
+			"/revisions/"+draft.ID.Hex(),
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// The approval only changed prd, so stg clients keep getting 304s with
+	// either validator.
+	recorder = conditionalEnv("environment=stg&country=BR", map[string]string{
+		"If-None-Match": validators["stg"]["ETag"],
+	})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	recorder = conditionalEnv("environment=stg&country=BR", map[string]string{
+		"If-Modified-Since": validators["stg"]["If-Modified-Since"],
+	})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Equal(t, validators["stg"]["If-Modified-Since"], recorder.Header().Get(echo.HeaderLastModified))
+
+	recorder = conditionalEnv("environment=prd&country=BR", map[string]string{
+		"If-None-Match": validators["prd"]["ETag"],
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "CHECKOUT=card\n", recorder.Body.String())
+	recorder = conditionalEnv("environment=prd&country=BR", map[string]string{
+		"If-Modified-Since": validators["prd"]["If-Modified-Since"],
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvEvaluatesCompoundConditions() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	// (plan: pro OR plan: enterprise) AND region: us
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean,
+		liveRevision(user.ID, "false", models.Rule{
+			Condition: &models.Condition{
+				Operator: models.AndOperator,
+				Conditions: []models.Condition{
+					{
+						Operator: models.OrOperator,
+						Conditions: []models.Condition{
+							{Predicate: "plan: pro"},
+							{Predicate: "plan: enterprise"},
+						},
+					},
+					{Predicate: "region: us"},
+				},
+			},
+			Value:     "true",
+			Env:       "prd",
+			IsEnabled: true,
+		}), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	testCases := map[string]string{
+		"environment=prd&plan=enterprise&region=us": "CHECKOUT=true\n",
+		"environment=prd&plan=pro&region=us":        "CHECKOUT=true\n",
+		"environment=prd&plan=pro&region=eu":        "CHECKOUT=false\n",
+		"environment=prd&plan=free&region=us":       "CHECKOUT=false\n",
+		"environment=prd&region=us":                 "CHECKOUT=false\n",
+	}
+	for query, expected := range testCases {
+		recorder := suite.getEnv(organization.ID, token, query)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, expected, recorder.Body.String(), query)
+	}
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvChecksPrerequisites() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "payments", 1, models.Boolean,
+		liveRevision(user.ID, "false",
+			models.Rule{Predicate: "beta: yes", Value: "true", Env: "prd", IsEnabled: true},
+		), suite.db)
+	dependent := fixtures.CreateFeatureFlag(user.ID, organization.ID, "invoices", 1, models.Boolean,
+		liveRevision(user.ID, "false",
+			models.Rule{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+		), suite.db)
+	fixtures.SetPrerequisites(dependent, []primitive.ObjectID{prerequisite.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(organization.ID, token, "environment=prd&plan=pro&beta=yes")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "INVOICES=true\nPAYMENTS=true\n", recorder.Body.String())
+
+	// invoices' own rule matches, but payments doesn't serve "true" so its default is served.
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&plan=pro")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "INVOICES=false\nPAYMENTS=false\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvReportsReasons() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "payments", 1, models.Boolean,
+		liveRevision(user.ID, "false"), suite.db)
+	dependent := fixtures.CreateFeatureFlag(user.ID, organization.ID, "invoices", 1, models.Boolean,
+		liveRevision(user.ID, "false",
+			models.Rule{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+		), suite.db)
+	fixtures.SetPrerequisites(dependent, []primitive.ObjectID{prerequisite.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(organization.ID, token, "environment=prd&plan=pro&reasons=true")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "INVOICES=false # PREREQUISITE_FAILED\nPAYMENTS=false # DEFAULT\n", recorder.Body.String())
+
+	// Reasons are opt-in.
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&plan=pro")
+	assert.Equal(t, "INVOICES=false\nPAYMENTS=false\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvSelectsFlags() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "payments", 1, models.Boolean,
+		liveRevision(user.ID, "true"), suite.db)
+	dependent := fixtures.CreateFeatureFlag(user.ID, organization.ID, "invoices", 1, models.Boolean,
+		liveRevision(user.ID, "true"), suite.db)
+	fixtures.SetPrerequisites(dependent, []primitive.ObjectID{prerequisite.ID}, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "banner", 1, models.String,
+		liveRevision(user.ID, "hello"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	// Prerequisites are still evaluated even when they weren't requested.
+	recorder := suite.getEnv(organization.ID, token, "flags=invoices,ghost&flags=banner")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "# missing: ghost\nBANNER=hello\nINVOICES=true\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvForbidden() {
+	t := suite.T()
+
+	organization := fixtures.CreateOrganization("the company", fixtures.EmptyMemberTupleList, suite.db)
+	outsider := fixtures.CreateUser("", "", "", "", suite.db)
+
+	token, err := apiutils.CreateJWT(outsider.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(organization.ID, token, "prefix=FF_")
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagEnvExplainsContextSchemaProblems() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	err := models.NewOrganizationModel(suite.db).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"context_schema": map[string]models.AttributeType{
+			"plan": models.StringAttribute,
+			"age":  models.NumberAttribute,
+		}}}},
+	)
+	assert.NoError(t, err)
+
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "dark-mode", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	query := "plan=pro&age=ten&paln=pro&user_id=42"
+
+	recorder := suite.getEnv(organization.ID, token, query)
+	assert.Equal(t, "DARK_MODE=false\n", recorder.Body.String())
+
+	recorder = suite.getEnv(organization.ID, token, query+"&explain=true")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "# warning: attribute \"age\" should be a number, got \"ten\"\n"+
+		"# warning: unknown attribute \"paln\"\n"+
+		"DARK_MODE=false\n", recorder.Body.String())
+}
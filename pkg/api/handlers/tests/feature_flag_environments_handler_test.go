@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) listEnvironments(
+	organizationID primitive.ObjectID,
+	token,
+	featureFlagID string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID+"/environments",
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagEnvironments() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	approver := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	devRule := models.Rule{Predicate: "plan: pro", Value: "true", Env: "dev", IsEnabled: true}
+	first := &liveRevision(user.ID, "false",
+		devRule,
+		models.Rule{Predicate: "plan: pro", Value: "false", Env: "prd", IsEnabled: true},
+	)[0]
+	first.Status = models.Archived
+	prdRule := models.Rule{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true}
+	second := &liveRevision(user.ID, "false", devRule, prdRule)[0]
+	second.LastRevisionID = first.ID
+	second.Approve(approver.ID, time.Now())
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 2, models.Boolean,
+		[]models.Revision{*first, *second}, suite.db)
+
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "invoices", 1, models.Boolean,
+		liveRevision(user.ID, "false", models.Rule{Predicate: "plan: pro", Value: "true", Env: "stg", IsEnabled: true}),
+		suite.db)
+	deleted := fixtures.CreateFeatureFlag(user.ID, organization.ID, "gone", 1, models.Boolean,
+		liveRevision(user.ID, "false", models.Rule{Predicate: "plan: pro", Value: "true", Env: "qa", IsEnabled: true}),
+		suite.db)
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: deleted.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"deleted_at": primitive.NewDateTimeFromTime(time.Now().UTC())}}},
+	)
+	assert.NoError(t, err)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.listEnvironments(organization.ID, token, checkout.ID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.FeatureFlagEnvironmentsResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.FeatureFlagEnvironmentsResponse{
+		FeatureFlagID: checkout.ID,
+		Environments: []handlers.FeatureFlagEnvironment{
+			{
+				Environment:   "dev",
+				RevisionID:    second.ID,
+				Version:       2,
+				DefaultValue:  "false",
+				Rules:         []models.Rule{devRule},
+				LastChangedAt: checkout.CreatedAt,
+				LastChangedBy: user.ID,
+			},
+			{
+				Environment:   "prd",
+				RevisionID:    second.ID,
+				Version:       2,
+				DefaultValue:  "false",
+				Rules:         []models.Rule{prdRule},
+				LastChangedAt: second.ApprovedAt,
+				LastChangedBy: approver.ID,
+			},
+			{
+				Environment:   "stg",
+				RevisionID:    second.ID,
+				Version:       2,
+				DefaultValue:  "false",
+				Rules:         []models.Rule{},
+				LastChangedAt: checkout.CreatedAt,
+				LastChangedBy: user.ID,
+			},
+		},
+	}, response)
+
+	noLiveRevision := fixtures.CreateFeatureFlag(user.ID, organization.ID, "drafted", 1, models.Boolean, nil, suite.db)
+	recorder = suite.listEnvironments(organization.ID, token, noLiveRevision.ID.Hex())
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = suite.listEnvironments(organization.ID, token, deleted.ID.Hex())
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagEnvironmentsInherited() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	_, err := suite.db.Collection(models.OrganizationCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{
+			"settings.environment_parents": map[string]string{"stg": "prd", "dev": "stg"},
+		}}},
+	)
+	assert.NoError(t, err)
+
+	prdRule := models.Rule{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true}
+	devRule := models.Rule{Predicate: "plan: pro", Value: "false", Env: "dev", IsEnabled: true}
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean,
+		liveRevision(user.ID, "false", prdRule, devRule), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.listEnvironments(organization.ID, token, checkout.ID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.FeatureFlagEnvironmentsResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	inherited := make(map[string]string)
+	rules := make(map[string][]models.Rule)
+	for _, environment := range response.Environments {
+		inherited[environment.Environment] = environment.InheritedFrom
+		rules[environment.Environment] = environment.Rules
+	}
+	assert.Equal(t, map[string]string{"dev": "", "prd": "", "stg": "prd"}, inherited)
+	assert.Equal(t, []models.Rule{devRule}, rules["dev"])
+	assert.Equal(t, []models.Rule{prdRule}, rules["stg"])
+}
@@ -0,0 +1,301 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) evaluateBatch(
+	organizationID primitive.ObjectID,
+	token,
+	flagName string,
+	body handlers.EvaluateBatchRequest,
+) *httptest.ResponseRecorder {
+	requestBody, err := json.Marshal(body)
+	assert.NoError(suite.T(), err)
+
+	request := httptest.NewRequest(
+		http.MethodPost,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+flagName+"/evaluate-batch",
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestEvaluateBatch() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy",
+			models.Rule{Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true},
+		), suite.db)
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: checkout.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"namespace": "payments"}}},
+	)
+	assert.NoError(t, err)
+	unreleased := fixtures.CreateFeatureFlag(user.ID, organization.ID, "unreleased", 1, models.Boolean, nil, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.evaluateBatch(organization.ID, token, "payments%2Fcheckout", handlers.EvaluateBatchRequest{
+		Environment: "prd",
+		Contexts: []map[string]string{
+			{"user_id": "ana", "country": "BR"},
+			{"user_id": "bob", "country": "US"},
+			{"user_id": "cai"},
+		},
+	})
+
+	var response handlers.EvaluateBatchResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "payments/checkout", response.FeatureFlag)
+	assert.Equal(t, "prd", response.Environment)
+	assert.Len(t, response.Results, 3)
+	assert.Equal(t, "pix", response.Results[0].Value)
+	assert.Contains(t, response.Results[0].Reason, evaluation.RuleMatchReason)
+	assert.Equal(t, handlers.BatchEvaluation{Value: "legacy", Reason: evaluation.DefaultReason}, response.Results[1])
+	assert.Equal(t, handlers.BatchEvaluation{Value: "legacy", Reason: evaluation.DefaultReason}, response.Results[2])
+
+	recorder = suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		Contexts: []map[string]string{{"user_id": "ana"}},
+	})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// A flag that was never approved serves the default it was created with.
+	recorder = suite.evaluateBatch(organization.ID, token, "unreleased", handlers.EvaluateBatchRequest{
+		Contexts: []map[string]string{{"user_id": "ana"}},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []handlers.BatchEvaluation{{
+		Value:  unreleased.Revisions[0].DefaultValue,
+		Reason: evaluation.NoLiveRevisionReason,
+	}}, response.Results)
+
+	recorder = suite.evaluateBatch(organization.ID, token, "payments%2Fcheckout", handlers.EvaluateBatchRequest{})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	tooMany := make([]map[string]string, handlers.MaxEvaluationBatchSize+1)
+	recorder = suite.evaluateBatch(organization.ID, token, "payments%2Fcheckout", handlers.EvaluateBatchRequest{
+		Contexts: tooMany,
+	})
+
+	var limitResponse handlers.RuleLimitResponse
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &limitResponse))
+	assert.Equal(t, apierrors.EvaluationBatchTooLargeError, limitResponse.Message)
+	assert.Equal(t, handlers.MaxEvaluationBatchSize, limitResponse.Limit)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestEvaluateBatchForbidden() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", nil, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		Contexts: []map[string]string{{"user_id": "ana"}},
+	})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestEvaluateBatchFallbackEnvironments() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy",
+			models.Rule{Predicate: "country: BR", Value: "pix", Env: "production", IsEnabled: true},
+		), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		Environment:          "canary",
+		FallbackEnvironments: []string{"staging", "production"},
+		Contexts:             []map[string]string{{"country": "BR"}, {"country": "US"}},
+	})
+
+	var response handlers.EvaluateBatchResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "canary", response.Environment)
+	assert.Equal(t, "production", response.RulesEnvironment)
+	assert.Equal(t, "pix", response.Results[0].Value)
+	assert.Equal(t, handlers.BatchEvaluation{Value: "legacy", Reason: evaluation.DefaultReason}, response.Results[1])
+
+	// No environment of the chain has rules, so the default value is served.
+	recorder = suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		Environment:          "canary",
+		FallbackEnvironments: []string{"staging"},
+		Contexts:             []map[string]string{{"country": "BR"}},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "canary", response.RulesEnvironment)
+	assert.Equal(t, []handlers.BatchEvaluation{{Value: "legacy", Reason: evaluation.DefaultReason}}, response.Results)
+
+	recorder = suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		FallbackEnvironments: make([]string, handlers.MaxFallbackEnvironments+1),
+		Contexts:             []map[string]string{{"country": "BR"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	tooMany := make([]string, handlers.MaxFallbackEnvironments+1)
+	for index := range tooMany {
+		tooMany[index] = fmt.Sprintf("env-%d", index)
+	}
+	recorder = suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		FallbackEnvironments: tooMany,
+		Contexts:             []map[string]string{{"country": "BR"}},
+	})
+
+	var limitResponse handlers.RuleLimitResponse
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &limitResponse))
+	assert.Equal(t, apierrors.FallbackChainTooLongError, limitResponse.Message)
+	assert.Equal(t, handlers.MaxFallbackEnvironments, limitResponse.Limit)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) evaluateByID(
+	organizationID primitive.ObjectID,
+	token,
+	featureFlagID,
+	query string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID+"/evaluate-by-id?"+query,
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestEvaluateByID() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy",
+			models.Rule{Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true},
+		), suite.db)
+	deleted := fixtures.CreateFeatureFlag(user.ID, organization.ID, "gone", 1, models.Boolean,
+		liveRevision(user.ID, "true"), suite.db)
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: deleted.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"deleted_at": primitive.NewDateTimeFromTime(time.Now().UTC())}}},
+	)
+	assert.NoError(t, err)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.evaluateByID(organization.ID, token, checkout.ID.Hex(), "environment=prd&country=BR")
+
+	var response handlers.EvaluateResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, checkout.ID.Hex(), response.FeatureFlagID)
+	assert.Equal(t, "checkout", response.FeatureFlag)
+	assert.Equal(t, "prd", response.Environment)
+	assert.Equal(t, "pix", response.Value)
+	assert.Contains(t, response.Reason, evaluation.RuleMatchReason)
+
+	// The same flag evaluated by name gives the same result.
+	batchRecorder := suite.evaluateBatch(organization.ID, token, "checkout", handlers.EvaluateBatchRequest{
+		Environment: "prd",
+		Contexts:    []map[string]string{{"country": "BR"}},
+	})
+	var batchResponse handlers.EvaluateBatchResponse
+	assert.NoError(t, json.Unmarshal(batchRecorder.Body.Bytes(), &batchResponse))
+	assert.Equal(t, handlers.BatchEvaluation{Value: response.Value, Reason: response.Reason}, batchResponse.Results[0])
+
+	unreleased := fixtures.CreateFeatureFlag(user.ID, organization.ID, "unreleased", 1, models.Boolean,
+		[]models.Revision{*fixtures.CreateRevision(user.ID, models.Draft, primitive.NilObjectID)}, suite.db)
+	recorder = suite.evaluateByID(organization.ID, token, unreleased.ID.Hex(), "environment=prd")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, unreleased.Revisions[0].DefaultValue, response.Value)
+	assert.Equal(t, evaluation.NoLiveRevisionReason, response.Reason)
+
+	recorder = suite.evaluateByID(organization.ID, token, checkout.ID.Hex(), "environment=canary&fallback=qa,prd&country=BR")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "canary", response.Environment)
+	assert.Equal(t, "prd", response.RulesEnvironment)
+	assert.Equal(t, "pix", response.Value)
+
+	recorder = suite.evaluateByID(organization.ID, token, deleted.ID.Hex(), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = suite.evaluateByID(organization.ID, token, primitive.NewObjectID().Hex(), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = suite.evaluateByID(organization.ID, token, "checkout", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
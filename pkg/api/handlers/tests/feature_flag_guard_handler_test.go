@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestErrorSignalsTriggerAutoRollback() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	previousRevision := fixtures.CreateRevision(user.ID, models.Archived, primitive.NilObjectID)
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, previousRevision.ID)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "new-checkout", 2,
+		models.Boolean, []models.Revision{*previousRevision, *liveRevision}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(
+			method,
+			"/organizations/"+organization.ID.Hex()+"/feature-flags/"+featureFlag.ID.Hex()+path,
+			bytes.NewBufferString(body),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodPut, "/guard", `{"max_error_rate": 0.1, "window": "5m", "min_requests": 100}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodPost, "/error-signals", `{"requests": 50, "errors": 40}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.ErrorSignalResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.False(t, response.RolledBack, "not enough requests yet")
+
+	recorder = send(http.MethodPost, "/error-signals", `{"requests": 50, "errors": 0}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.ErrorSignalResponse{Requests: 100, Errors: 40, RolledBack: true}, response)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	saved, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.Version)
+	assert.Equal(t, previousRevision.ID, saved.LiveRevision().ID)
+
+	count, err := suite.db.Collection(models.AuditLogCollectionName).CountDocuments(
+		context.Background(),
+		bson.D{
+			{Key: "feature_flag_id", Value: featureFlag.ID},
+			{Key: "action", Value: models.AutoRollbackAction},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestErrorSignalsRequireCollaborator() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	previousRevision := fixtures.CreateRevision(user.ID, models.Archived, primitive.NilObjectID)
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, previousRevision.ID)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "new-checkout", 2,
+		models.Boolean, []models.Revision{*previousRevision, *liveRevision}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodPost,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags/"+featureFlag.ID.Hex()+"/error-signals",
+		bytes.NewBufferString(`{"requests": 1000, "errors": 1000}`),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	count, err := suite.db.Collection(models.ErrorSignalCollectionName).CountDocuments(
+		context.Background(),
+		bson.D{{Key: "feature_flag_id", Value: featureFlag.ID}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
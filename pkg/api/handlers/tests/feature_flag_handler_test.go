@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,8 +18,10 @@ import (
 	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
 	"github.com/Roll-Play/togglelabs/pkg/config"
 	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/storage"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
 	testutils "github.com/Roll-Play/togglelabs/pkg/utils/test_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -26,6 +29,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type FeatureFlagHandlerTestSuite struct {
@@ -45,22 +51,110 @@ func (suite *FeatureFlagHandlerTestSuite) SetupTest() {
 	logger, _ := common.NewZapLogger()
 	h := handlers.NewFeatureFlagHandler(suite.db, logger)
 
-	testGroup := suite.Server.Group("", middlewares.AuthMiddleware)
+	testGroup := suite.Server.Group(
+		"",
+		middlewares.AuthMiddleware,
+		middlewares.ObjectIDParamsMiddleware(middlewares.ObjectIDParams...),
+	)
 	testGroup.POST("/organizations/:organizationID/feature-flags", h.PostFeatureFlag)
+	testGroup.POST("/organizations/:organizationID/feature-flags/boolean", h.PostBooleanFeatureFlag)
+	testGroup.POST("/organizations/:organizationID/feature-flags/validate", h.ValidateFeatureFlag)
+	testGroup.POST("/organizations/:organizationID/feature-flags/:flagName/evaluate-batch", h.EvaluateBatch)
+	testGroup.GET("/organizations/:organizationID/feature-flags/:featureFlagID/evaluate-by-id", h.EvaluateByID)
 	testGroup.PATCH(
 		"/organizations/:organizationID/feature-flags/:featureFlagID",
 		h.PatchFeatureFlag,
 	)
 	testGroup.GET("/organizations/:organizationID/feature-flags", h.ListFeatureFlags)
+	testGroup.GET("/users/me/feature-flags", h.ListAccessibleFeatureFlags)
 	testGroup.PATCH(
 		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID",
 		h.ApproveRevision,
 	)
+	testGroup.DELETE(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID",
+		h.DeleteRevision,
+	)
+	testGroup.GET(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/compare",
+		h.CompareRevisions,
+	)
+	testGroup.POST(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID/comments",
+		h.PostRevisionComment,
+	)
+	testGroup.GET(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID/comments",
+		h.ListRevisionComments,
+	)
 	testGroup.DELETE("/organizations/:organizationID/feature-flags/:featureFlagID", h.DeleteFeatureFlag)
+	testGroup.PUT(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/rollout",
+		h.PutRollout,
+	)
+	testGroup.DELETE(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/rollout",
+		h.DeleteRollout,
+	)
+	testGroup.PUT(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/guard",
+		h.PutGuard,
+	)
+	testGroup.DELETE(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/guard",
+		h.DeleteGuard,
+	)
+	testGroup.PUT(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/shadow",
+		h.PutShadow,
+	)
+	testGroup.GET(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/shadow",
+		h.GetShadow,
+	)
+	testGroup.DELETE(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/shadow",
+		h.DeleteShadow,
+	)
+	testGroup.POST(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/error-signals",
+		h.PostErrorSignal,
+	)
+	testGroup.PATCH(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/archive",
+		h.ArchiveFeatureFlag,
+	)
+	testGroup.PATCH(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/unarchive",
+		h.UnarchiveFeatureFlag,
+	)
 	testGroup.PATCH(
 		"/organizations/:organizationID/feature-flags/:featureFlagID/rollback",
 		h.RollbackFeatureFlagVersion,
 	)
+	testGroup.GET(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/dependencies",
+		h.GetFeatureFlagDependencies,
+	)
+	testGroup.GET("/organizations/:organizationID/env", h.GetFeatureFlagEnv)
+	testGroup.PATCH(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/rules/order",
+		h.ReorderRules,
+	)
+	testGroup.PATCH(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/rules/:ruleID",
+		h.ToggleRule,
+	)
+	testGroup.PUT(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/overrides/:userID",
+		h.PutOverride,
+	)
+	testGroup.DELETE(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/overrides/:userID",
+		h.DeleteOverride,
+	)
+	testGroup.POST("/organizations/:organizationID/feature-flags/tags/add", h.PostBulkAddTag)
+	testGroup.POST("/organizations/:organizationID/feature-flags/tags/remove", h.PostBulkRemoveTag)
 }
 
 func (suite *FeatureFlagHandlerTestSuite) AfterTest(_, _ string) {
@@ -141,6 +235,276 @@ func (suite *FeatureFlagHandlerTestSuite) TestPostFeatureFlagSuccess() {
 	assert.Equal(t, rule.IsEnabled, responseRule.IsEnabled)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestPostBooleanFeatureFlagSuccess() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	requestBody, err := json.Marshal(handlers.PostBooleanFeatureFlagRequest{
+		Name:      "kill switch",
+		Namespace: "checkout",
+		Enabled:   true,
+	})
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodPost,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags/boolean",
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response models.FeatureFlagRecord
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "checkout/kill switch", response.QualifiedName())
+	assert.Equal(t, models.Boolean, response.Type)
+	assert.Equal(t, organization.ID, response.OrganizationID)
+
+	assert.Len(t, response.Revisions, 1)
+	assert.Equal(t, "true", response.Revisions[0].DefaultValue)
+	assert.Equal(t, models.Live, response.Revisions[0].Status)
+	assert.Empty(t, response.Revisions[0].Rules)
+
+	// The shorthand goes through the same name conflict check.
+	request = httptest.NewRequest(
+		http.MethodPost,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags/boolean",
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder = httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestValidateFeatureFlag() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	postFlag := func(path string, featureFlagRequest handlers.PostFeatureFlagRequest) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(featureFlagRequest)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPost,
+			"/organizations/"+organization.ID.Hex()+path,
+			bytes.NewBuffer(requestBody),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	featureFlagRequest := handlers.PostFeatureFlagRequest{
+		Name:         "new-invoice",
+		Namespace:    "billing",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+		Rules: []models.Rule{
+			{
+				Predicate: "plan: pro",
+				Value:     "true",
+				Env:       "prd",
+				IsEnabled: true,
+			},
+		},
+	}
+
+	recorder := postFlag("/feature-flags/validate", featureFlagRequest)
+
+	var response models.FeatureFlagRecord
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "billing/new-invoice", response.QualifiedName())
+	assert.Equal(t, user.ID, response.UserID)
+	assert.Len(t, response.Revisions, 1)
+	assert.Equal(t, models.Live, response.Revisions[0].Status)
+	assert.Len(t, response.Revisions[0].Rules, 1)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	_, err = model.FindByName(context.Background(), organization.ID, "billing/new-invoice")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	// Validating twice is fine; validating a name that's taken is not.
+	assert.Equal(t, http.StatusOK, postFlag("/feature-flags/validate", featureFlagRequest).Code)
+	assert.Equal(t, http.StatusCreated, postFlag("/feature-flags", featureFlagRequest).Code)
+
+	recorder = postFlag("/feature-flags/validate", featureFlagRequest)
+
+	var errorResponse apierrors.Error
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errorResponse))
+	assert.Equal(t, apierrors.FlagNameConflictError, errorResponse.Message)
+
+	recorder = postFlag("/feature-flags/validate", handlers.PostFeatureFlagRequest{
+		Name: "missing-type",
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPostFeatureFlagNamespaceConflict() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	postFlag := func(namespace string) int {
+		requestBody, err := json.Marshal(handlers.PostFeatureFlagRequest{
+			Name:         "new-invoice",
+			Namespace:    namespace,
+			Type:         models.Boolean,
+			DefaultValue: "false",
+		})
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPost,
+			"/organizations/"+organization.ID.Hex()+"/feature-flags",
+			bytes.NewBuffer(requestBody),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, postFlag("billing"))
+	assert.Equal(t, http.StatusConflict, postFlag("billing"))
+	assert.Equal(t, http.StatusCreated, postFlag("checkout"))
+	assert.Equal(t, http.StatusCreated, postFlag(""))
+	assert.Equal(t, http.StatusConflict, postFlag(""))
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByName(context.Background(), organization.ID, "billing/new-invoice")
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", record.Namespace)
+	assert.Equal(t, "new-invoice", record.Name)
+	assert.Equal(t, "billing/new-invoice", record.QualifiedName())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPostFeatureFlagEnvKeyConflict() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	postFlag := func(namespace, name string) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(handlers.PostFeatureFlagRequest{
+			Name:         name,
+			Namespace:    namespace,
+			Type:         models.Boolean,
+			DefaultValue: "false",
+		})
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPost,
+			"/organizations/"+organization.ID.Hex()+"/feature-flags",
+			bytes.NewBuffer(requestBody),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusCreated, postFlag("billing", "checkout").Code)
+
+	// Both names read BILLING_CHECKOUT in the env export.
+	for _, name := range []string{"billing_checkout", "Billing-Checkout"} {
+		recorder := postFlag("", name)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+
+		var errorResponse apierrors.Error
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errorResponse))
+		assert.Equal(t, apierrors.EnvKeyConflictError, errorResponse.Message)
+	}
+
+	assert.Equal(t, http.StatusCreated, postFlag("billing", "invoice").Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagNamesAreUnique() {
+	t := suite.T()
+	ctx := context.Background()
+
+	assert.NoError(t, storage.EnsureIndexes(ctx, suite.db))
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organizationID := primitive.NewObjectID()
+	record := fixtures.CreateFeatureFlag(user.ID, organizationID, "new-invoice", 1, models.Boolean, nil, suite.db)
+
+	// A request racing past the name check still can't insert a duplicate.
+	model := models.NewFeatureFlagModel(suite.db)
+	duplicate := *record
+	_, err := model.InsertOne(ctx, &duplicate)
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+
+	// The name of a deleted flag can be reused.
+	_, err = model.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: record.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"deleted_at": primitive.NewDateTimeFromTime(time.Now())}}},
+	)
+	assert.NoError(t, err)
+	_, err = model.InsertOne(ctx, &duplicate)
+	assert.NoError(t, err)
+}
+
 func (suite *FeatureFlagHandlerTestSuite) TestPostFeatureFlagUnauthorized() {
 	t := suite.T()
 
@@ -228,6 +592,13 @@ func (suite *FeatureFlagHandlerTestSuite) TestPatchFeatureFlagSuccess() {
 	assert.Equal(t, user.ID, response.UserID)
 	assert.Equal(t, revisionRule.DefaultValue, response.DefaultValue)
 	assert.Equal(t, models.Draft, response.Status)
+	assert.False(t, response.ID.IsZero())
+	assert.Equal(t,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+featureFlagRecord.ID.Hex()+
+			"/revisions/"+response.ID.Hex(),
+		recorder.Header().Get(echo.HeaderLocation),
+	)
 
 	savedFeatureFlag, err := featureFlagModel.FindByID(context.Background(), featureFlagRecord.ID)
 	assert.NoError(t, err)
@@ -247,6 +618,7 @@ func (suite *FeatureFlagHandlerTestSuite) TestPatchFeatureFlagSuccess() {
 	assert.Equal(t, originalRule.IsEnabled, originalSavedRule.IsEnabled)
 	// Check the new revision
 	newSavedRevision := savedRevisions[1]
+	assert.Equal(t, response.ID, newSavedRevision.ID)
 	assert.Equal(t, user.ID, newSavedRevision.UserID)
 	assert.Equal(t, revisionRule.DefaultValue, newSavedRevision.DefaultValue)
 	assert.Equal(t, models.Draft, newSavedRevision.Status)
@@ -330,12 +702,221 @@ func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsAuthorized() {
 			*featureFlag1,
 			*featureFlag2,
 		},
-		Page:     1,
-		PageSize: 10,
-		Total:    2,
+		Page:       1,
+		PageSize:   10,
+		Total:      2,
+		TotalPages: 1,
 	}, response)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagMetadata() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "cool feature",
+		Description:  "Gates the new checkout flow",
+		Owner:        "payments",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	owner := "growth"
+	recorder = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		Owner: &owner,
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	tooLong := strings.Repeat("a", 501)
+	recorder = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		Description: &tooLong,
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodGet, featureFlagsPath, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.ListFeatureFlagResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "Gates the new checkout flow", response.Data[0].Description)
+	assert.Equal(t, "growth", response.Data[0].Owner)
+	// Metadata-only patches don't propose a revision.
+	assert.Len(t, response.Data[0].Revisions, 1)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagLifecycle() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	created := make(map[string]models.FeatureFlagRecord)
+	for _, name := range []string{"checkout", "invoices"} {
+		recorder := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+			Name:         name,
+			Type:         models.Boolean,
+			DefaultValue: "false",
+		})
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+
+		var featureFlag models.FeatureFlagRecord
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &featureFlag))
+		assert.Equal(t, models.Development, featureFlag.Lifecycle)
+		created[name] = featureFlag
+	}
+
+	deprecated := models.Deprecated
+	recorder := send(http.MethodPatch, featureFlagsPath+"/"+created["checkout"].ID.Hex(), handlers.PatchFeatureFlagRequest{
+		Lifecycle: &deprecated,
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	unknown := "retired"
+	recorder = send(http.MethodPatch, featureFlagsPath+"/"+created["checkout"].ID.Hex(), handlers.PatchFeatureFlagRequest{
+		Lifecycle: &unknown,
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodGet, featureFlagsPath+"?lifecycle=deprecated", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.ListFeatureFlagResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "checkout", response.Data[0].Name)
+	// Lifecycle changes don't propose a revision.
+	assert.Len(t, response.Data[0].Revisions, 1)
+
+	recorder = send(http.MethodGet, featureFlagsPath+"?lifecycle=development", nil)
+	response = handlers.ListFeatureFlagResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "invoices", response.Data[0].Name)
+
+	recorder = send(http.MethodGet, featureFlagsPath+"?lifecycle=retired", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.getEnv(organization.ID, token, "")
+	assert.Equal(t, "# deprecated: checkout\nCHECKOUT=false\nINVOICES=false\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsShowsLastEvaluated() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	evaluated := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean, nil, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "invoices", 1, models.Boolean, nil, suite.db)
+
+	list := func(etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(
+			http.MethodGet,
+			"/organizations/"+organization.ID.Hex()+"/feature-flags",
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := list("")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+
+	model := models.NewFeatureFlagModel(suite.db)
+	evaluatedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, model.SaveLastEvaluated(context.Background(), map[primitive.ObjectID]time.Time{
+		evaluated.ID: evaluatedAt,
+	}))
+	// An older time saved late doesn't move it back
+	assert.NoError(t, model.SaveLastEvaluated(context.Background(), map[primitive.ObjectID]time.Time{
+		evaluated.ID: evaluatedAt.Add(-time.Hour),
+	}))
+
+	saved, err := model.FindByID(context.Background(), evaluated.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, primitive.NewDateTimeFromTime(evaluatedAt), saved.LastEvaluatedAt)
+
+	// Evaluating flags doesn't change them, so pollers keep their copy.
+	recorder = list(etag)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = list("")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	var response struct {
+		Data []map[string]any `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "checkout", response.Data[0]["name"])
+	assert.Equal(t, evaluatedAt.Format(time.RFC3339), response.Data[0]["last_evaluated_at"])
+	assert.Equal(t, "invoices", response.Data[1]["name"])
+	assert.NotContains(t, response.Data[1], "last_evaluated_at")
+}
+
 func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsPagination() {
 	t := suite.T()
 
@@ -372,13 +953,14 @@ func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsPagination() {
 		Data: []models.FeatureFlagRecord{
 			*featureFlag,
 		},
-		Page:     1,
-		PageSize: 1,
-		Total:    1,
+		Page:       1,
+		PageSize:   1,
+		Total:      2,
+		TotalPages: 2,
 	}, response)
 }
 
-func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsUnauthorized() {
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsByNamespace() {
 	t := suite.T()
 
 	user := fixtures.CreateUser("", "", "", "", suite.db)
@@ -388,14 +970,54 @@ func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsUnauthorized() {
 			models.Admin,
 		),
 	}, suite.db)
-	user, err := models.NewUserRecord("evildoear97@gmail.com", "trying_to_steal_info", "Evil", "Doer")
-	assert.NoError(t, err)
-
-	userModel := models.NewUserModel(suite.db)
-	userID, err := userModel.InsertOne(context.Background(), user)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
 	assert.NoError(t, err)
 
-	token, err := apiutils.CreateJWT(userID, time.Second*120)
+	model := models.NewFeatureFlagModel(suite.db)
+	billingFlag := models.NewFeatureFlagRecord("new-invoice", "billing", "false", models.Boolean, nil,
+		organization.ID, user.ID)
+	_, err = model.InsertOne(context.Background(), billingFlag)
+	assert.NoError(t, err)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1, models.Boolean, nil, suite.db)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags?namespace=billing",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.ListFeatureFlagResponse
+
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, billingFlag.ID, response.Data[0].ID)
+	assert.Equal(t, "billing", response.Data[0].Namespace)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsUnauthorized() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	user, err := models.NewUserRecord("evildoear97@gmail.com", "trying_to_steal_info", "Evil", "Doer")
+	assert.NoError(t, err)
+
+	userModel := models.NewUserModel(suite.db)
+	userID, err := userModel.InsertOne(context.Background(), user)
+	assert.NoError(t, err)
+
+	token, err := apiutils.CreateJWT(userID, time.Second*120)
 	assert.NoError(t, err)
 
 	request := httptest.NewRequest(
@@ -419,6 +1041,67 @@ func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsUnauthorized() {
 	}, response)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestListFeatureFlagsConditionalRequests() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1,
+		models.Boolean, nil, suite.db)
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	list := func(headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, featureFlagsPath, nil)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := list(nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, apiutils.RevalidateCacheControl, recorder.Header().Get(echo.HeaderCacheControl))
+	assert.NotEmpty(t, recorder.Header().Get(echo.HeaderLastModified))
+	etag := recorder.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	recorder = list(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	recorder = list(map[string]string{"If-Modified-Since": recorder.Header().Get(echo.HeaderLastModified)})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	owner := "growth"
+	requestBody, err := json.Marshal(handlers.PatchFeatureFlagRequest{Owner: &owner})
+	assert.NoError(t, err)
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		featureFlagsPath+"/"+featureFlag.ID.Hex(),
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	suite.Server.ServeHTTP(httptest.NewRecorder(), request)
+
+	recorder = list(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"))
+}
+
 func (suite *FeatureFlagHandlerTestSuite) TestRevisionStatusUpdateSuccess() {
 	t := suite.T()
 	user := fixtures.CreateUser("", "", "", "", suite.db)
@@ -469,10 +1152,383 @@ func (suite *FeatureFlagHandlerTestSuite) TestRevisionStatusUpdateSuccess() {
 	assert.Equal(t, models.Archived, originalRevision.Status)
 	updatedRevision := savedRevisions[1]
 	assert.Equal(t, models.Live, updatedRevision.Status)
+	assert.Equal(t, user.ID, updatedRevision.ApprovedBy)
+	assert.WithinDuration(t, time.Now(), updatedRevision.ApprovedAt.Time(), time.Minute)
 	controlRevision := savedRevisions[2]
 	assert.Equal(t, models.Draft, controlRevision.Status)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestApproveRevisionRequiresSeparateApprover() {
+	t := suite.T()
+
+	author := fixtures.CreateUser("", "", "", "", suite.db)
+	reviewer := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			author,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reviewer,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	organizationModel := models.NewOrganizationModel(suite.db)
+	assert.NoError(t, organizationModel.UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"settings.require_separate_approver": true}}},
+	))
+
+	draft := fixtures.CreateRevision(author.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(author.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{*draft}, suite.db)
+
+	approve := func(userID primitive.ObjectID) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPatch,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlagRecord.ID.Hex()+
+				"/revisions/"+draft.ID.Hex(),
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := approve(author.ID)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), apierrors.SelfApprovalForbiddenError)
+
+	recorder = approve(reviewer.ID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	saved, err := model.FindByID(context.Background(), featureFlagRecord.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, reviewer.ID, saved.Revisions[0].ApprovedBy)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagUpdatedBy() {
+	t := suite.T()
+
+	creator := fixtures.CreateUser("", "", "", "", suite.db)
+	editor := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			creator,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			editor,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	send := func(user *models.UserRecord, method, path string, body any) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+		assert.NoError(t, err)
+
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			method,
+			"/organizations/"+organization.ID.Hex()+path,
+			bytes.NewBuffer(requestBody),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+	listed := func() models.FeatureFlagRecord {
+		recorder := send(creator, http.MethodGet, "/feature-flags", nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response handlers.ListFeatureFlagResponse
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+		return response.Data[0]
+	}
+
+	recorder := send(creator, http.MethodPost, "/feature-flags", handlers.PostFeatureFlagRequest{
+		Name:         "cool feature",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	assert.Equal(t, creator.ID, created.UserID)
+	assert.Equal(t, creator.ID, created.UpdatedBy)
+
+	description := "Shows the cool feature"
+	recorder = send(editor, http.MethodPatch, "/feature-flags/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		Description: &description,
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	featureFlag := listed()
+	assert.Equal(t, creator.ID, featureFlag.UserID)
+	assert.Equal(t, editor.ID, featureFlag.UpdatedBy)
+
+	recorder = send(editor, http.MethodPatch, "/feature-flags/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		DefaultValue: "true",
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var revision models.Revision
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &revision))
+
+	recorder = send(creator, http.MethodPatch, "/feature-flags/"+created.ID.Hex()+"/revisions/"+revision.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	featureFlag = listed()
+	assert.Equal(t, creator.ID, featureFlag.UserID)
+	assert.Equal(t, creator.ID, featureFlag.UpdatedBy)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestDeleteRevision() {
+	t := suite.T()
+
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	readOnly := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			readOnly,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	archivedRevision := fixtures.CreateRevision(collaborator.ID, models.Archived, primitive.NilObjectID)
+	liveRevision := fixtures.CreateRevision(collaborator.ID, models.Live, archivedRevision.ID)
+	draftRevision := fixtures.CreateRevision(collaborator.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(collaborator.ID, organization.ID, "cool feature", 2,
+		models.Boolean, []models.Revision{
+			*archivedRevision,
+			*liveRevision,
+			*draftRevision,
+		}, suite.db)
+
+	deleteRevision := func(user *models.UserRecord, revisionID primitive.ObjectID) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodDelete,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlagRecord.ID.Hex()+
+				"/revisions/"+revisionID.Hex(),
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusForbidden, deleteRevision(readOnly, draftRevision.ID).Code)
+
+	for _, revisionID := range []primitive.ObjectID{liveRevision.ID, archivedRevision.ID} {
+		recorder := deleteRevision(collaborator, revisionID)
+
+		var response apierrors.Error
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, apierrors.RevisionNotDeletableError, response.Message)
+	}
+
+	assert.Equal(t, http.StatusNotFound, deleteRevision(collaborator, primitive.NewObjectID()).Code)
+	assert.Equal(t, http.StatusNoContent, deleteRevision(collaborator, draftRevision.ID).Code)
+	assert.Equal(t, http.StatusNotFound, deleteRevision(collaborator, draftRevision.ID).Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	savedFeatureFlag, err := model.FindByID(context.Background(), featureFlagRecord.ID)
+	assert.NoError(t, err)
+	assert.Len(t, savedFeatureFlag.Revisions, 2)
+	assert.Equal(t, archivedRevision.ID, savedFeatureFlag.Revisions[0].ID)
+	assert.Equal(t, liveRevision.ID, savedFeatureFlag.Revisions[1].ID)
+	assert.Equal(t, models.Live, savedFeatureFlag.Revisions[1].Status)
+	assert.Equal(t, 2, savedFeatureFlag.Version)
+}
+
+// recordingPublisher keeps every payload published to it.
+type recordingPublisher struct {
+	payloads             []webhooks.FeatureFlagPayload
+	organizationPayloads []webhooks.OrganizationPayload
+}
+
+func (rp *recordingPublisher) Publish(payload webhooks.FeatureFlagPayload) {
+	rp.payloads = append(rp.payloads, payload)
+}
+
+func (rp *recordingPublisher) PublishOrganizationEvent(payload webhooks.OrganizationPayload) {
+	rp.organizationPayloads = append(rp.organizationPayloads, payload)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestRevisionApprovalPublishesChange() {
+	t := suite.T()
+
+	publisher := new(recordingPublisher)
+	h := handlers.NewFeatureFlagHandler(suite.db, zap.NewNop()).WithChangePublisher(publisher)
+	server := echo.New()
+	server.PATCH(
+		"/organizations/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID",
+		middlewares.AuthMiddleware(h.ApproveRevision),
+	)
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, primitive.NilObjectID)
+	draftRevision := fixtures.CreateRevision(user.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{
+			*liveRevision,
+			*draftRevision,
+		}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+featureFlagRecord.ID.Hex()+
+			"/revisions/"+draftRevision.ID.Hex(),
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, publisher.payloads, 1)
+	payload := publisher.payloads[0]
+	assert.Equal(t, webhooks.FeatureFlagUpdatedEvent, payload.Event)
+	assert.Equal(t, featureFlagRecord.ID, payload.FeatureFlagID)
+	assert.Equal(t, draftRevision.ID, payload.RevisionID)
+	assert.Equal(t, 2, payload.Version)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestRevisionUpdateIsIdempotent() {
+	t := suite.T()
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, primitive.NilObjectID)
+	draftRevision := fixtures.CreateRevision(user.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{*liveRevision, *draftRevision}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest(
+			http.MethodPatch,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlagRecord.ID.Hex()+
+				"/revisions/"+draftRevision.ID.Hex(),
+			nil,
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response models.FeatureFlagRecord
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Version)
+	}
+
+	model := models.NewFeatureFlagModel(suite.db)
+	savedFeatureFlag, err := model.FindByID(context.Background(), featureFlagRecord.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, savedFeatureFlag.Version)
+	assert.Equal(t, models.Archived, savedFeatureFlag.Revisions[0].Status)
+	assert.Equal(t, models.Live, savedFeatureFlag.Revisions[1].Status)
+	assert.Equal(t, liveRevision.ID, savedFeatureFlag.Revisions[1].LastRevisionID)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestRevisionUpdateUnknownRevisionKeepsLive() {
+	t := suite.T()
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, primitive.NilObjectID)
+	archivedRevision := fixtures.CreateRevision(user.ID, models.Archived, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{*liveRevision, *archivedRevision}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	for revisionID, status := range map[primitive.ObjectID]int{
+		primitive.NewObjectID(): http.StatusNotFound,
+		archivedRevision.ID:     http.StatusConflict,
+	} {
+		request := httptest.NewRequest(
+			http.MethodPatch,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlagRecord.ID.Hex()+
+				"/revisions/"+revisionID.Hex(),
+			nil,
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, status, recorder.Code)
+	}
+
+	model := models.NewFeatureFlagModel(suite.db)
+	savedFeatureFlag, err := model.FindByID(context.Background(), featureFlagRecord.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, savedFeatureFlag.Version)
+	assert.Equal(t, models.Live, savedFeatureFlag.Revisions[0].Status)
+	assert.Equal(t, models.Archived, savedFeatureFlag.Revisions[1].Status)
+}
+
 func (suite *FeatureFlagHandlerTestSuite) TestRevisionUpdateUnauthorized() {
 	t := suite.T()
 
@@ -505,10 +1561,10 @@ func (suite *FeatureFlagHandlerTestSuite) TestRevisionUpdateUnauthorized() {
 	var response apierrors.Error
 
 	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
-	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
 	assert.Equal(t, apierrors.Error{
-		Error:   http.StatusText(http.StatusUnauthorized),
-		Message: apierrors.UnauthorizedError,
+		Error:   http.StatusText(http.StatusForbidden),
+		Message: apierrors.ForbiddenError,
 	}, response)
 }
 
@@ -547,10 +1603,10 @@ func (suite *FeatureFlagHandlerTestSuite) TestRevisionUpdateUnauthorizedMissingP
 	var response apierrors.Error
 
 	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
-	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
 	assert.Equal(t, apierrors.Error{
-		Error:   http.StatusText(http.StatusUnauthorized),
-		Message: apierrors.UnauthorizedError,
+		Error:   http.StatusText(http.StatusForbidden),
+		Message: apierrors.ForbiddenError,
 	}, response)
 }
 
@@ -601,6 +1657,58 @@ func (suite *FeatureFlagHandlerTestSuite) TestRollbackSuccess() {
 	assert.Equal(t, models.Draft, rolledBackRevision.Status)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestRollbackRequiresSeparateApprover() {
+	t := suite.T()
+	author := fixtures.CreateUser("", "", "", "", suite.db)
+	reviewer := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			author,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reviewer,
+			models.Collaborator,
+		),
+	}, suite.db)
+	assert.NoError(t, models.NewOrganizationModel(suite.db).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"settings.require_separate_approver": true}}},
+	))
+
+	revision := fixtures.CreateRevision(author.ID, models.Archived, primitive.NilObjectID)
+	liveRevision := fixtures.CreateRevision(reviewer.ID, models.Live, revision.ID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(author.ID, organization.ID, "cool feature", 2,
+		models.Boolean, []models.Revision{*revision, *liveRevision}, suite.db)
+
+	rollback := func(userID primitive.ObjectID) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPatch,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlagRecord.ID.Hex()+
+				"/rollback",
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// The author of the revision rolled back to can't make it live again.
+	recorder := rollback(author.ID)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), apierrors.SelfApprovalForbiddenError)
+
+	recorder = rollback(reviewer.ID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
 func (suite *FeatureFlagHandlerTestSuite) TestRollbackUnauthorized() {
 	t := suite.T()
 
@@ -724,6 +1832,160 @@ func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagDeletionSuccess() {
 	assert.Equal(t, featureFlagRecord.ID, deletedRecord.ID)
 
 	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+	assert.Empty(t, recorder.Header().Get(echo.HeaderContentType))
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestDeletedFeatureFlagCannotBeChanged() {
+	t := suite.T()
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	draft := fixtures.CreateRevision(user.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(user.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{*draft}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	featureFlagPath := "/organizations/" + organization.ID.Hex() + "/feature-flags/" + featureFlagRecord.ID.Hex()
+
+	request := httptest.NewRequest(http.MethodDelete, featureFlagPath, nil)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"patch", http.MethodPatch, featureFlagPath, `{"default_value": "false"}`},
+		{"approve", http.MethodPatch, featureFlagPath + "/revisions/" + draft.ID.Hex(), ""},
+		{"rollback", http.MethodPatch, featureFlagPath + "/rollback", ""},
+		{"delete", http.MethodDelete, featureFlagPath, ""},
+	}
+
+	for _, tc := range testCases {
+		request := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response apierrors.Error
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), tc.name)
+		assert.Equal(t, http.StatusNotFound, recorder.Code, tc.name)
+		assert.Equal(t, apierrors.NotFoundError, response.Message, tc.name)
+	}
+
+	model := models.NewFeatureFlagModel(suite.db)
+	deletedRecord, err := model.FindOne(context.Background(), bson.D{
+		{Key: "_id", Value: featureFlagRecord.ID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, featureFlagRecord.Version, deletedRecord.Version)
+	assert.Len(t, deletedRecord.Revisions, 1)
+	assert.Equal(t, models.Draft, deletedRecord.Revisions[0].Status)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagDeletionBlockedByDependents() {
+	t := suite.T()
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "prerequisite", 1,
+		models.Boolean, nil, suite.db)
+	dependent := fixtures.CreateFeatureFlag(user.ID, organization.ID, "dependent", 1,
+		models.Boolean, nil, suite.db)
+	fixtures.SetPrerequisites(dependent, []primitive.ObjectID{prerequisite.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodDelete,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+prerequisite.ID.Hex(),
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.DeleteFeatureFlagConflictResponse
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.DeleteFeatureFlagConflictResponse{
+		Error:      http.StatusText(http.StatusConflict),
+		Message:    apierrors.FlagHasDependentsError,
+		Dependents: []handlers.DependencyNode{{ID: dependent.ID, Name: "dependent"}},
+	}, response)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	_, err = model.FindByID(context.Background(), prerequisite.ID)
+	assert.NoError(t, err)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagForcedDeletionDetachesDependents() {
+	t := suite.T()
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "prerequisite", 1,
+		models.Boolean, nil, suite.db)
+	dependent := fixtures.CreateFeatureFlag(user.ID, organization.ID, "dependent", 1,
+		models.Boolean, nil, suite.db)
+	fixtures.SetPrerequisites(dependent, []primitive.ObjectID{prerequisite.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodDelete,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+prerequisite.ID.Hex()+"?force=true",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	_, err = model.FindByID(context.Background(), prerequisite.ID)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	savedDependent, err := model.FindByID(context.Background(), dependent.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, savedDependent.Prerequisites)
 }
 
 func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagDeletionForbidden() {
@@ -763,6 +2025,151 @@ func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagDeletionForbidden() {
 	}, response)
 }
 
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagDependencies() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	base := fixtures.CreateFeatureFlag(user.ID, organization.ID, "base", 1, models.Boolean, nil, suite.db)
+	middle := fixtures.CreateFeatureFlag(user.ID, organization.ID, "middle", 1, models.Boolean, nil, suite.db)
+	top := fixtures.CreateFeatureFlag(user.ID, organization.ID, "top", 1, models.Boolean, nil, suite.db)
+	unrelated := fixtures.CreateFeatureFlag(user.ID, organization.ID, "unrelated", 1, models.Boolean, nil, suite.db)
+	fixtures.SetPrerequisites(middle, []primitive.ObjectID{base.ID}, suite.db)
+	fixtures.SetPrerequisites(top, []primitive.ObjectID{middle.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags/"+middle.ID.Hex()+"/dependencies",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.FeatureFlagDependenciesResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.DependencyNode{ID: middle.ID, Name: "middle"}, response.FeatureFlag)
+	assert.Equal(t, []handlers.DependencyNode{{ID: base.ID, Name: "base"}}, response.Upstream)
+	assert.Equal(t, []handlers.DependencyNode{{ID: top.ID, Name: "top"}}, response.Downstream)
+	assert.ElementsMatch(t, []models.DependencyEdge{
+		{FeatureFlagID: middle.ID, PrerequisiteID: base.ID},
+		{FeatureFlagID: top.ID, PrerequisiteID: middle.ID},
+	}, response.Edges)
+	assert.Empty(t, response.Cycles)
+	assert.NotContains(t, recorder.Body.String(), unrelated.ID.Hex())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagDependenciesReportsCycles() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	first := fixtures.CreateFeatureFlag(user.ID, organization.ID, "first", 1, models.Boolean, nil, suite.db)
+	second := fixtures.CreateFeatureFlag(user.ID, organization.ID, "second", 1, models.Boolean, nil, suite.db)
+	fixtures.SetPrerequisites(first, []primitive.ObjectID{second.ID}, suite.db)
+	fixtures.SetPrerequisites(second, []primitive.ObjectID{first.ID}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags/"+first.ID.Hex()+"/dependencies",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	var response handlers.FeatureFlagDependenciesResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, [][]primitive.ObjectID{{first.ID, second.ID}}, response.Cycles)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestServerErrorsAreLoggedAtErrorLevel() {
+	t := suite.T()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	h := handlers.NewFeatureFlagHandler(suite.db, zap.New(core))
+	server := echo.New()
+	server.GET("/organizations/:organizationID/feature-flags", middlewares.AuthMiddleware(h.ListFeatureFlags))
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	// The organization does not exist so the lookup fails with a server error
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+primitive.NewObjectID().Hex()+"/feature-flags",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	entries := logs.FilterMessage("Server error").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestClientErrorsAreLoggedAtDebugLevel() {
+	t := suite.T()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	h := handlers.NewFeatureFlagHandler(suite.db, zap.New(core))
+	server := echo.New()
+	server.GET("/organizations/:organizationID/feature-flags", middlewares.AuthMiddleware(h.ListFeatureFlags))
+
+	organization := fixtures.CreateOrganization("the company", fixtures.EmptyMemberTupleList, suite.db)
+	outsider := fixtures.CreateUser("", "", "", "", suite.db)
+	token, err := apiutils.CreateJWT(outsider.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags",
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Empty(t, logs.FilterMessage("Server error").All())
+	entries := logs.FilterMessage("Client error").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+}
+
 func TestFeatureFlagHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(FeatureFlagHandlerTestSuite))
 }
@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) jsonPatch(
+	organizationID,
+	featureFlagID primitive.ObjectID,
+	token,
+	patch string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID.Hex(),
+		bytes.NewBufferString(patch),
+	)
+	request.Header.Set(echo.HeaderContentType, apiutils.MIMEApplicationJSONPatch)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPatchFeatureFlagWithJSONPatch() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "true", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: US", Value: "true", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.jsonPatch(organization.ID, featureFlag.ID, token, `[
+		{"op": "test", "path": "/rules/0/predicate", "value": "country: BR"},
+		{"op": "remove", "path": "/rules/0"},
+		{"op": "add", "path": "/rules/-", "value": {
+			"predicate": "plan: pro", "value": "true", "env": "prd", "is_enabled": true
+		}},
+		{"op": "replace", "path": "/default_value", "value": "true"}
+	]`)
+
+	var response models.Revision
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, models.Draft, response.Status)
+	assert.Equal(t, "true", response.DefaultValue)
+	assert.Len(t, response.Rules, 2)
+	assert.Equal(t, rules[1], response.Rules[0])
+	assert.Equal(t, "plan: pro", response.Rules[1].Predicate)
+	assert.False(t, response.Rules[1].ID.IsZero())
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 2)
+	assert.Equal(t, rules, record.LiveRevision().Rules)
+	assert.Equal(t, response.Rules, record.Revisions[1].Rules)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPatchFeatureFlagWithJSONPatchInvalid() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false", orderedRules(
+			models.Rule{Predicate: "country: BR", Value: "true", Env: "prd", IsEnabled: true},
+		)...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		patch   string
+		message apierrors.ErrorMessage
+	}{
+		{
+			"value of the wrong type",
+			`[{"op": "replace", "path": "/rules/0/value", "value": "maybe"}]`,
+			apierrors.FlagValueTypeError,
+		},
+		{
+			"missing path",
+			`[{"op": "remove", "path": "/rules/3"}]`,
+			apierrors.BadRequestError,
+		},
+		{
+			"failed test",
+			`[{"op": "test", "path": "/default_value", "value": "true"}]`,
+			apierrors.BadRequestError,
+		},
+		{
+			"rule missing required fields",
+			`[{"op": "add", "path": "/rules/-", "value": {"value": "true"}}]`,
+			apierrors.BadRequestError,
+		},
+		{
+			"not a patch",
+			`{"default_value": "true"}`,
+			apierrors.BadRequestError,
+		},
+	}
+
+	for _, tc := range testCases {
+		recorder := suite.jsonPatch(organization.ID, featureFlag.ID, token, tc.patch)
+
+		var response apierrors.Error
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), tc.name)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, tc.name)
+		assert.Equal(t, tc.message, response.Message, tc.name)
+	}
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 1)
+}
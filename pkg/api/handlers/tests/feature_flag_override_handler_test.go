@@ -0,0 +1,268 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) override(
+	method string,
+	organizationID,
+	featureFlagID primitive.ObjectID,
+	userID,
+	token,
+	body string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		method,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID.Hex()+"/overrides/"+userID,
+		bytes.NewBufferString(body),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutOverride() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "true"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-2", token, `{"value": "true"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Putting the same user id again replaces its value instead of adding a duplicate.
+	recorder = suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "false"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.OverridesResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	expected := []models.Override{
+		{UserID: "qa-1", Value: "false"},
+		{UserID: "qa-2", Value: "true"},
+	}
+	assert.Equal(t, expected, response.Overrides)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, record.Overrides)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutOverrideMissingValue() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutOverrideChecksFlagType() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.FlagValueTypeError, response.Message)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, record.Overrides)
+}
+
+// Every save only writes its own user's override, so concurrent saves for
+// different users all stick.
+func (suite *FeatureFlagHandlerTestSuite) TestPutOverrideConcurrently() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	const users = 20
+	var wg sync.WaitGroup
+	for index := 0; index < users; index++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, userID, token, `{"value": "true"}`)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		}(fmt.Sprintf("qa-%d", index))
+	}
+	wg.Wait()
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Overrides, users)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutOverrideForbidden() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "true"}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestDeleteOverride() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "true"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = suite.override(http.MethodDelete, organization.ID, featureFlag.ID, "qa-1", token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, record.Overrides)
+
+	recorder = suite.override(http.MethodDelete, organization.ID, featureFlag.ID, "qa-1", token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestOverridesTakePrecedence() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	prerequisite := fixtures.CreateFeatureFlag(user.ID, organization.ID, "payments", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.String,
+		liveRevision(user.ID, "legacy",
+			models.Rule{Predicate: "user_id: qa-1", Value: "ruled", Env: "prd", IsEnabled: true},
+		), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(organization.ID, token, "environment=prd&user_id=qa-1")
+	assert.Equal(t, "CHECKOUT=ruled\nPAYMENTS=false\n", recorder.Body.String())
+
+	recorder = suite.override(http.MethodPut, organization.ID, featureFlag.ID, "qa-1", token, `{"value": "forced"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// The override beats a matching rule.
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&user_id=qa-1")
+	assert.Equal(t, "CHECKOUT=forced\nPAYMENTS=false\n", recorder.Body.String())
+
+	// It also beats an unmet prerequisite.
+	fixtures.SetPrerequisites(featureFlag, []primitive.ObjectID{prerequisite.ID}, suite.db)
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&user_id=qa-1")
+	assert.Equal(t, "CHECKOUT=forced\nPAYMENTS=false\n", recorder.Body.String())
+
+	// Other users keep going through prerequisites and rules.
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&user_id=qa-2")
+	assert.Equal(t, "CHECKOUT=legacy\nPAYMENTS=false\n", recorder.Body.String())
+}
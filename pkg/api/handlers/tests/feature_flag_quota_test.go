@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagQuotas() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	organizationModel := models.NewOrganizationModel(suite.db)
+	err = organizationModel.UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"limits": models.OrganizationLimits{
+			MaxFeatureFlags: 2,
+			MaxEnvironments: 1,
+		}}}},
+	)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) (*httptest.ResponseRecorder, apierrors.Error) {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response apierrors.Error
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+	flag := func(name, env string) handlers.PostFeatureFlagRequest {
+		return handlers.PostFeatureFlagRequest{
+			Name:         name,
+			Type:         models.Boolean,
+			DefaultValue: "false",
+			Rules: []models.Rule{
+				{Predicate: "plan: pro", Value: "true", Env: env, IsEnabled: true},
+			},
+		}
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder, _ := send(http.MethodPost, featureFlagsPath, flag("checkout", "prd"))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	recorder, response := send(http.MethodPost, featureFlagsPath, flag("invoices", "stg"))
+	assert.Equal(t, http.StatusPaymentRequired, recorder.Code)
+	assert.Equal(t, apierrors.EnvironmentQuotaExceededError, response.Message)
+
+	recorder, _ = send(http.MethodPost, featureFlagsPath, flag("invoices", "prd"))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder, response = send(http.MethodPost, featureFlagsPath, flag("search", "prd"))
+	assert.Equal(t, http.StatusPaymentRequired, recorder.Code)
+	assert.Equal(t, apierrors.FlagQuotaExceededError, response.Message)
+
+	patch := handlers.PatchFeatureFlagRequest{
+		DefaultValue: "true",
+		Rules: []models.Rule{
+			{Predicate: "plan: team", Value: "true", Env: "dev", IsEnabled: true},
+		},
+	}
+	recorder, response = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), patch)
+	assert.Equal(t, http.StatusPaymentRequired, recorder.Code)
+	assert.Equal(t, apierrors.EnvironmentQuotaExceededError, response.Message)
+
+	patch.Rules[0].Env = "prd"
+	recorder, _ = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), patch)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
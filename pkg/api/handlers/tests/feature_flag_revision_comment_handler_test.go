@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestRevisionComments() {
+	t := suite.T()
+
+	author := fixtures.CreateUser("", "Ana", "Lima", "", suite.db)
+	reviewer := fixtures.CreateUser("", "Bob", "Reis", "", suite.db)
+	reader := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			author,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reviewer,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reader,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	draft := fixtures.CreateRevision(author.ID, models.Draft, primitive.NilObjectID)
+	featureFlagRecord := fixtures.CreateFeatureFlag(author.ID, organization.ID, "cool feature", 1,
+		models.Boolean, []models.Revision{*draft}, suite.db)
+
+	commentsPath := func(revisionID primitive.ObjectID) string {
+		return "/organizations/" + organization.ID.Hex() +
+			"/feature-flags/" + featureFlagRecord.ID.Hex() +
+			"/revisions/" + revisionID.Hex() + "/comments"
+	}
+	send := func(method string, userID primitive.ObjectID, path, body string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, reader.ID, commentsPath(draft.ID), `{"body": "lgtm"}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodPost, reviewer.ID, commentsPath(primitive.NewObjectID()), `{"body": "lgtm"}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodPost, reviewer.ID, commentsPath(draft.ID), `{"body": ""}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	for _, comment := range []struct {
+		user *models.UserRecord
+		body string
+	}{
+		{reviewer, "why is the default true?"},
+		{author, "it was on for everyone already"},
+		{reviewer, "lgtm"},
+	} {
+		recorder = send(http.MethodPost, comment.user.ID, commentsPath(draft.ID), `{"body": "`+comment.body+`"}`)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+	}
+
+	listComments := func(query string) handlers.ListRevisionCommentsResponse {
+		recorder := send(http.MethodGet, reader.ID, commentsPath(draft.ID)+query, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response handlers.ListRevisionCommentsResponse
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		return response
+	}
+
+	response := listComments("?page_size=2")
+	assert.Equal(t, 3, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "lgtm", response.Data[0].Body)
+	assert.Equal(t, handlers.CommentAuthor{
+		ID:        reviewer.ID,
+		Email:     reviewer.Email,
+		FirstName: "Bob",
+		LastName:  "Reis",
+	}, response.Data[0].Author)
+	assert.Equal(t, "it was on for everyone already", response.Data[1].Body)
+	assert.Equal(t, author.ID, response.Data[1].Author.ID)
+
+	response = listComments("?page=2&page_size=2")
+	assert.Equal(t, 2, response.Page)
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "why is the default true?", response.Data[0].Body)
+
+	recorder = send(http.MethodGet, reader.ID, commentsPath(draft.ID)+"?page=0", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
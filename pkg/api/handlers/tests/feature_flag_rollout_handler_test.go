@@ -0,0 +1,120 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) putRollout(path, token, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutRolloutWithRamp() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "new-checkout", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	path := "/organizations/" + organization.ID.Hex() + "/feature-flags/" + featureFlag.ID.Hex() + "/rollout"
+
+	recorder := suite.putRollout(path, token, `{"percentage": 10, "value": "maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.putRollout(path, token, `{"percentage": 10, "value": "true", "ramp": {"step": 10, "interval": "1s"}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.putRollout(path, token, `{"percentage": 100, "value": "true", "ramp": {"step": 10, "interval": "1h"}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var rollout models.Rollout
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rollout))
+	assert.Nil(t, rollout.Ramp)
+
+	// Every user is included at 100%.
+	recorder = suite.getEnv(organization.ID, token, "user_id=someone")
+	assert.Equal(t, "NEW_CHECKOUT=true\n", recorder.Body.String())
+
+	recorder = suite.putRollout(path, token, `{"percentage": 0, "value": "true", "ramp": {"step": 25, "interval": "1h"}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	saved, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, saved.Rollout.Percentage)
+	assert.Equal(t, 25, saved.Rollout.Ramp.Step)
+	assert.Equal(t, time.Hour, saved.Rollout.Ramp.Interval)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), saved.Rollout.Ramp.NextStepAt.Time(), time.Minute)
+
+	recorder = suite.getEnv(organization.ID, token, "user_id=someone")
+	assert.Equal(t, "NEW_CHECKOUT=false\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPutRolloutByContextKind() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "new-sync", 1,
+		models.Boolean, liveRevision(user.ID, "false"), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	path := "/organizations/" + organization.ID.Hex() + "/feature-flags/" + featureFlag.ID.Hex() + "/rollout"
+
+	recorder := suite.putRollout(path, token, `{"percentage": 100, "value": "true", "kind": "Device"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.InvalidContextKindError, response.Message)
+
+	recorder = suite.putRollout(path, token, `{"percentage": 100, "value": "true", "kind": "device"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var rollout models.Rollout
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rollout))
+	assert.Equal(t, "device", rollout.Kind)
+
+	// Contexts are bucketed by their device, users without one aren't rolled out to.
+	recorder = suite.getEnv(organization.ID, token, "user_id=someone&device.key=phone-1")
+	assert.Equal(t, "NEW_SYNC=true\n", recorder.Body.String())
+
+	recorder = suite.getEnv(organization.ID, token, "user_id=someone&explain=true")
+	assert.Equal(t, "# warning: context has no \"device\" kind, targeted by new-sync\n"+
+		"NEW_SYNC=false\n", recorder.Body.String())
+}
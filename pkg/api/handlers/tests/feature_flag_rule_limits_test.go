@@ -0,0 +1,220 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagRuleLimits() {
+	t := suite.T()
+
+	previous := config.RuleLimits
+	config.RuleLimits = config.RuleLimitsConfig{
+		MaxRules:           2,
+		MaxPredicateLength: 20,
+		MaxConditionDepth:  2,
+		MaxJSONValueSize:   config.DefaultMaxJSONValueSize,
+	}
+	defer func() {
+		config.RuleLimits = previous
+	}()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) (*httptest.ResponseRecorder, handlers.RuleLimitResponse) {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response handlers.RuleLimitResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+	rule := func(predicate string) models.Rule {
+		return models.Rule{Predicate: predicate, Value: "true", Env: "prd", IsEnabled: true}
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder, response := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "checkout",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+		Rules:        []models.Rule{rule("plan: pro"), rule("plan: team"), rule("plan: free")},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.TooManyRulesError, response.Message)
+	assert.Equal(t, 2, response.Limit)
+
+	recorder, _ = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "checkout",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+		Rules:        []models.Rule{rule("plan: pro"), rule("plan: team")},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	recorder, response = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		DefaultValue: "true",
+		Rules:        []models.Rule{rule("country: " + strings.Repeat("B", 20))},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.PredicateTooLongError, response.Message)
+	assert.Equal(t, 20, response.Limit)
+
+	compound := func(condition models.Condition) handlers.PatchFeatureFlagRequest {
+		return handlers.PatchFeatureFlagRequest{
+			DefaultValue: "true",
+			Rules: []models.Rule{
+				{Condition: &condition, Value: "true", Env: "prd", IsEnabled: true},
+			},
+		}
+	}
+	nested := models.Condition{
+		Operator: models.AndOperator,
+		Conditions: []models.Condition{
+			{
+				Operator: models.OrOperator,
+				Conditions: []models.Condition{
+					{Operator: models.AndOperator, Conditions: []models.Condition{{Predicate: "plan: pro"}}},
+				},
+			},
+		},
+	}
+	recorder, response = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), compound(nested))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.ConditionTooDeepError, response.Message)
+	assert.Equal(t, 2, response.Limit)
+
+	recorder, response = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), compound(models.Condition{
+		Operator:   models.OrOperator,
+		Conditions: []models.Condition{},
+	}))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.InvalidConditionError, response.Message)
+
+	recorder, _ = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), compound(models.Condition{
+		Operator:   models.OrOperator,
+		Conditions: []models.Condition{{Predicate: "plan: pro"}, {Predicate: "plan: team"}},
+	}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagJSONValueLimit() {
+	t := suite.T()
+
+	previous := config.RuleLimits
+	config.RuleLimits.MaxJSONValueSize = 16
+	defer func() {
+		config.RuleLimits = previous
+	}()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) (*httptest.ResponseRecorder, handlers.RuleLimitResponse) {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response handlers.RuleLimitResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+	// jsonValue is a json object exactly size bytes long
+	jsonValue := func(size int) string {
+		return `{"a":"` + strings.Repeat("x", size-8) + `"}`
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder, response := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "settings",
+		Type:         models.JSON,
+		DefaultValue: jsonValue(17),
+		Rules:        []models.Rule{},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.JSONValueTooLargeError, response.Message)
+	assert.Equal(t, 16, response.Limit)
+
+	recorder, _ = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "settings",
+		Type:         models.JSON,
+		DefaultValue: jsonValue(16),
+		Rules:        []models.Rule{},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+
+	recorder, response = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		DefaultValue: jsonValue(16),
+		Rules: []models.Rule{
+			{Predicate: "plan: pro", Value: jsonValue(17), Env: "prd", IsEnabled: true},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.JSONValueTooLargeError, response.Message)
+
+	recorder, _ = send(http.MethodPatch, featureFlagsPath+"/"+created.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		DefaultValue: jsonValue(16),
+		Rules: []models.Rule{
+			{Predicate: "plan: pro", Value: jsonValue(16), Env: "prd", IsEnabled: true},
+		},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Only json flags are held to the limit
+	recorder, _ = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "banner",
+		Type:         models.String,
+		DefaultValue: strings.Repeat("x", 17),
+		Rules:        []models.Rule{},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
@@ -0,0 +1,425 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) reorderRules(
+	organizationID,
+	featureFlagID primitive.ObjectID,
+	token string,
+	ruleIDs []primitive.ObjectID,
+) *httptest.ResponseRecorder {
+	requestBody, err := json.Marshal(handlers.ReorderRulesRequest{RuleIDs: ruleIDs})
+	assert.NoError(suite.T(), err)
+
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID.Hex()+"/rules/order",
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func orderedRules(rules ...models.Rule) []models.Rule {
+	for index := range rules {
+		rules[index].ID = primitive.NewObjectID()
+	}
+
+	return rules
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestReorderRules() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "a", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: US", Value: "b", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: AR", Value: "c", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.reorderRules(organization.ID, featureFlag.ID, token,
+		[]primitive.ObjectID{rules[2].ID, rules[0].ID, rules[1].ID})
+
+	var response models.Revision
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, models.Draft, response.Status)
+	assert.Equal(t, "legacy", response.DefaultValue)
+	assert.Equal(t, []models.Rule{rules[2], rules[0], rules[1]}, response.Rules)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 2)
+	assert.Equal(t, rules, record.LiveRevision().Rules)
+	assert.Equal(t, response.ID, record.Revisions[1].ID)
+	assert.Equal(t,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+featureFlag.ID.Hex()+
+			"/revisions/"+response.ID.Hex(),
+		recorder.Header().Get(echo.HeaderLocation),
+	)
+	assert.Equal(t, []models.Rule{rules[2], rules[0], rules[1]}, record.Revisions[1].Rules)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestReorderRulesMismatch() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "a", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "country: US", Value: "b", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	testCases := map[string][]primitive.ObjectID{
+		"missing":   {rules[0].ID},
+		"duplicate": {rules[0].ID, rules[0].ID},
+		"unknown":   {rules[0].ID, primitive.NewObjectID()},
+		"extra":     {rules[0].ID, rules[1].ID, primitive.NewObjectID()},
+	}
+
+	for name, ruleIDs := range testCases {
+		recorder := suite.reorderRules(organization.ID, featureFlag.ID, token, ruleIDs)
+
+		var response apierrors.Error
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), name)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, name)
+		assert.Equal(t, apierrors.RuleOrderMismatchError, response.Message, name)
+	}
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 1)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestRuleOrderDecidesFirstMatch() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "regional", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "plan: pro", Value: "premium", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	// Both rules match, the first one listed wins.
+	recorder := suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=regional\n", recorder.Body.String())
+
+	recorder = suite.reorderRules(organization.ID, featureFlag.ID, token,
+		[]primitive.ObjectID{rules[1].ID, rules[0].ID})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var draft models.Revision
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &draft))
+
+	// The draft isn't served until it is approved.
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=regional\n", recorder.Body.String())
+
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organization.ID.Hex()+
+			"/feature-flags/"+featureFlag.ID.Hex()+
+			"/revisions/"+draft.ID.Hex(),
+		nil,
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder = httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=premium\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) toggleRule(
+	organizationID,
+	featureFlagID primitive.ObjectID,
+	token string,
+	ruleID string,
+	body string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodPatch,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID.Hex()+"/rules/"+ruleID,
+		bytes.NewBufferString(body),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestToggleRule() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "regional", Env: "prd", IsEnabled: true},
+		models.Rule{Predicate: "plan: pro", Value: "premium", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	approve := func(revisionID primitive.ObjectID) {
+		request := httptest.NewRequest(
+			http.MethodPatch,
+			"/organizations/"+organization.ID.Hex()+
+				"/feature-flags/"+featureFlag.ID.Hex()+
+				"/revisions/"+revisionID.Hex(),
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder := suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=regional\n", recorder.Body.String())
+
+	recorder = suite.toggleRule(organization.ID, featureFlag.ID, token, rules[0].ID.Hex(), `{"is_enabled": false}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var draft models.Revision
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &draft))
+	assert.Equal(t, models.Draft, draft.Status)
+	assert.Len(t, draft.Rules, 2)
+	assert.Equal(t, rules[0].ID, draft.Rules[0].ID)
+	assert.False(t, draft.Rules[0].IsEnabled)
+	assert.Equal(t, rules[0].Predicate, draft.Rules[0].Predicate)
+	assert.Equal(t, rules[1], draft.Rules[1])
+
+	// The disabled rule is skipped, the next matching one wins.
+	approve(draft.ID)
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=premium\n", recorder.Body.String())
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR")
+	assert.Equal(t, "CHECKOUT=legacy\n", recorder.Body.String())
+
+	recorder = suite.toggleRule(organization.ID, featureFlag.ID, token, rules[0].ID.Hex(), `{"is_enabled": true}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &draft))
+	approve(draft.ID)
+
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR&plan=pro")
+	assert.Equal(t, "CHECKOUT=regional\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestToggleRuleRejectsBadRequests() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	rules := orderedRules(
+		models.Rule{Predicate: "country: BR", Value: "regional", Env: "prd", IsEnabled: true},
+	)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy", rules...), suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.toggleRule(organization.ID, featureFlag.ID, token, primitive.NewObjectID().Hex(), `{"is_enabled": false}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.RuleNotFoundError, response.Message)
+
+	recorder = suite.toggleRule(organization.ID, featureFlag.ID, token, rules[0].ID.Hex(), `{}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.toggleRule(organization.ID, featureFlag.ID, token, "not-an-id", `{"is_enabled": false}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 1)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPostFeatureFlagWithDisabledRule() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	requestBody, err := json.Marshal(handlers.PostFeatureFlagRequest{
+		Name:         "checkout",
+		Type:         models.String,
+		DefaultValue: "legacy",
+		Rules: []models.Rule{
+			{Predicate: "country: BR", Value: "regional", Env: "prd", IsEnabled: false},
+		},
+	})
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodPost,
+		"/organizations/"+organization.ID.Hex()+"/feature-flags",
+		bytes.NewBuffer(requestBody),
+	)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder = suite.getEnv(organization.ID, token, "environment=prd&country=BR")
+	assert.Equal(t, "CHECKOUT=legacy\n", recorder.Body.String())
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestRulesRejectDuplicateIDs() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	ruleID := primitive.NewObjectID()
+	duplicated := []models.Rule{
+		{ID: ruleID, Predicate: "country: BR", Value: "a", Env: "prd", IsEnabled: true},
+		{ID: ruleID, Predicate: "country: US", Value: "b", Env: "prd", IsEnabled: true},
+	}
+
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "checkout",
+		Type:         models.String,
+		DefaultValue: "legacy",
+		Rules:        duplicated,
+	})
+
+	var response apierrors.Error
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.DuplicateRuleIDError, response.Message)
+
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1,
+		models.String, liveRevision(user.ID, "legacy"), suite.db)
+	recorder = send(http.MethodPatch, featureFlagsPath+"/"+featureFlag.ID.Hex(), handlers.PatchFeatureFlagRequest{
+		DefaultValue: "legacy",
+		Rules:        duplicated,
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.DuplicateRuleIDError, response.Message)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	record, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Len(t, record.Revisions, 1)
+}
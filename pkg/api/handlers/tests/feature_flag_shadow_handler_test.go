@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestShadowRevision() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	liveRevision := fixtures.CreateRevision(user.ID, models.Live, primitive.NilObjectID)
+	draft := fixtures.CreateRevision(user.ID, models.Draft, liveRevision.ID)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "new-checkout", 2,
+		models.Boolean, []models.Revision{*liveRevision, *draft}, suite.db)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(
+			method,
+			"/organizations/"+organization.ID.Hex()+"/feature-flags/"+featureFlag.ID.Hex()+"/shadow",
+			bytes.NewBufferString(body),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodGet, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodPut, fmt.Sprintf(`{"revision_id": %q}`, liveRevision.ID.Hex()))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPut, fmt.Sprintf(`{"revision_id": %q}`, primitive.NewObjectID().Hex()))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodPut, fmt.Sprintf(`{"revision_id": %q}`, draft.ID.Hex()))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Counts of another revision, e.g. saved after the shadow was replaced,
+	// are ignored.
+	model := models.NewFeatureFlagModel(suite.db)
+	assert.NoError(t, model.SaveEvaluationCounts(context.Background(), map[primitive.ObjectID]*models.EvaluationCounts{
+		featureFlag.ID: {Evaluations: 4, ShadowRevisionID: draft.ID, ShadowEvaluations: 4, ShadowMismatches: 1},
+	}))
+	assert.NoError(t, model.SaveEvaluationCounts(context.Background(), map[primitive.ObjectID]*models.EvaluationCounts{
+		featureFlag.ID: {Evaluations: 1, ShadowRevisionID: liveRevision.ID, ShadowEvaluations: 1, ShadowMismatches: 1},
+	}))
+
+	recorder = send(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.ShadowResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, draft.ID, response.RevisionID)
+	assert.Equal(t, user.ID, response.StartedBy)
+	assert.True(t, response.Active)
+	assert.Equal(t, int64(4), response.Evaluations)
+	assert.Equal(t, int64(1), response.Mismatches)
+	assert.Equal(t, 25.0, response.MismatchPercentage)
+
+	recorder = send(http.MethodDelete, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = send(http.MethodGet, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) getStats(
+	organizationID primitive.ObjectID,
+	token,
+	featureFlagID string,
+) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/feature-flags/"+featureFlagID+"/stats",
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestGetFeatureFlagStats() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	proRule := models.Rule{ID: primitive.NewObjectID(), Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true}
+	freeRule := models.Rule{ID: primitive.NewObjectID(), Predicate: "plan: free", Value: "true", Env: "prd", IsEnabled: true}
+	revisions := liveRevision(user.ID, "false", proRule, freeRule)
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean,
+		revisions, suite.db)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	evaluatedAt := time.Now().UTC().Truncate(time.Millisecond)
+	assert.NoError(t, model.SaveLastEvaluated(context.Background(), map[primitive.ObjectID]time.Time{
+		checkout.ID: evaluatedAt,
+	}))
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, model.SaveEvaluationCounts(context.Background(), map[primitive.ObjectID]*models.EvaluationCounts{
+			checkout.ID: {Evaluations: 5, RuleMatches: map[primitive.ObjectID]int64{proRule.ID: 3}},
+		}))
+	}
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.getStats(organization.ID, token, checkout.ID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response handlers.FeatureFlagStatsResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.FeatureFlagStatsResponse{
+		FeatureFlagID:   checkout.ID,
+		RevisionID:      revisions[0].ID,
+		Evaluations:     10,
+		LastEvaluatedAt: primitive.NewDateTimeFromTime(evaluatedAt),
+		Rules: []handlers.RuleStats{
+			{RuleID: proRule.ID, Env: "prd", Predicate: "plan: pro", IsEnabled: true, Matches: 6},
+			{RuleID: freeRule.ID, Env: "prd", Predicate: "plan: free", IsEnabled: true, Matches: 0},
+		},
+	}, response)
+
+	noLiveRevision := fixtures.CreateFeatureFlag(user.ID, organization.ID, "drafted", 1, models.Boolean, nil, suite.db)
+	recorder = suite.getStats(organization.ID, token, noLiveRevision.ID.Hex())
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) sendTags(
+	method,
+	path,
+	token string,
+	body any,
+) *httptest.ResponseRecorder {
+	requestBody, err := json.Marshal(body)
+	assert.NoError(suite.T(), err)
+
+	request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestPatchFeatureFlagNormalizesTags() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+	featureFlag := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean, nil, suite.db)
+	fixtures.CreateFeatureFlag(user.ID, organization.ID, "search", 1, models.Boolean, nil, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	path := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+	recorder := suite.sendTags(http.MethodPatch, path+"/"+featureFlag.ID.Hex(), token, map[string]any{
+		"tags": []string{"team/growth"},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.sendTags(http.MethodPatch, path+"/"+featureFlag.ID.Hex(), token, map[string]any{
+		"tags": []string{" Team:Growth", "q3-cleanup", "team:growth"},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	saved, err := model.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"q3-cleanup", "team:growth"}, saved.Tags)
+	assert.Len(t, saved.Revisions, 1)
+
+	recorder = suite.sendTags(http.MethodGet, path+"?tag=Team:Growth", token, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.ListFeatureFlagResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, featureFlag.ID, response.Data[0].ID)
+}
+
+func (suite *FeatureFlagHandlerTestSuite) TestBulkTags() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	reader := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reader,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	otherOrganization := fixtures.CreateOrganization("the other company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+
+	checkout := fixtures.CreateFeatureFlag(user.ID, organization.ID, "checkout", 1, models.Boolean, nil, suite.db)
+	search := fixtures.CreateFeatureFlag(user.ID, organization.ID, "search", 1, models.Boolean, nil, suite.db)
+	full := fixtures.CreateFeatureFlag(user.ID, organization.ID, "full", 1, models.Boolean, nil, suite.db)
+	foreign := fixtures.CreateFeatureFlag(user.ID, otherOrganization.ID, "foreign", 1, models.Boolean, nil, suite.db)
+
+	model := models.NewFeatureFlagModel(suite.db)
+	tags := make([]string, 0, models.MaxTags)
+	for index := 0; index < models.MaxTags; index++ {
+		tags = append(tags, fmt.Sprintf("tag-%02d", index))
+	}
+	_, err := model.UpdateOne(context.Background(), bson.D{{Key: "_id", Value: full.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"tags": tags}}})
+	assert.NoError(t, err)
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+	readerToken, err := apiutils.CreateJWT(reader.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	path := "/organizations/" + organization.ID.Hex() + "/feature-flags/tags"
+	request := handlers.BulkTagRequest{
+		Tag:            "Cleanup",
+		FeatureFlagIDs: []primitive.ObjectID{checkout.ID, full.ID, foreign.ID},
+	}
+
+	recorder := suite.sendTags(http.MethodPost, path+"/add", readerToken, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = suite.sendTags(http.MethodPost, path+"/add", token, handlers.BulkTagRequest{Tag: "cleanup"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.sendTags(http.MethodPost, path+"/add", token, handlers.BulkTagRequest{
+		Tag:            "not a tag",
+		FeatureFlagIDs: request.FeatureFlagIDs,
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.sendTags(http.MethodPost, path+"/add", token, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.BulkTagResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "cleanup", response.Tag)
+	assert.Equal(t, []handlers.BulkTagResult{
+		{FeatureFlagID: checkout.ID, Name: "checkout", Status: handlers.TagAdded},
+		{FeatureFlagID: full.ID, Name: "full", Status: handlers.TagLimitReached},
+		{FeatureFlagID: foreign.ID, Status: handlers.FlagNotFound},
+	}, response.Results)
+
+	// The filter selects the flags by their current tags.
+	recorder = suite.sendTags(http.MethodPost, path+"/add", token, handlers.BulkTagRequest{
+		Tag:    "payments",
+		Filter: &handlers.BulkTagFilter{Tag: "cleanup"},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []handlers.BulkTagResult{
+		{FeatureFlagID: checkout.ID, Name: "checkout", Status: handlers.TagAdded},
+	}, response.Results)
+
+	recorder = suite.sendTags(http.MethodPost, path+"/remove", token, handlers.BulkTagRequest{
+		Tag:            "cleanup",
+		FeatureFlagIDs: []primitive.ObjectID{checkout.ID, search.ID},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []handlers.BulkTagResult{
+		{FeatureFlagID: checkout.ID, Name: "checkout", Status: handlers.TagRemoved},
+		{FeatureFlagID: search.ID, Name: "search", Status: handlers.TagUnchanged},
+	}, response.Results)
+
+	saved, err := model.FindByID(context.Background(), checkout.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"payments"}, saved.Tags)
+	assert.Equal(t, user.ID, saved.UpdatedBy)
+
+	saved, err = model.FindByID(context.Background(), full.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, tags, saved.Tags)
+
+	saved, err = model.FindByID(context.Background(), foreign.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, saved.Tags)
+}
@@ -0,0 +1,156 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagTemplates() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	send := func(user *models.UserRecord, method, path string, body any) (*httptest.ResponseRecorder, apierrors.Error) {
+		token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+		assert.NoError(t, err)
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, "/organizations/"+organization.ID.Hex()+path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response apierrors.Error
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+
+	killSwitch := handlers.FeatureFlagTemplateRequest{
+		Name:         "kill switch",
+		NamePattern:  `kill-[a-z-]+`,
+		Namespace:    "ops",
+		Owner:        "sre",
+		Type:         models.Boolean,
+		DefaultValue: "true",
+		Rules:        []models.Rule{{Predicate: "region: eu", Value: "false", Env: "prd", IsEnabled: false}},
+	}
+
+	recorder, _ := send(collaborator, http.MethodPost, "/feature-flag-templates", killSwitch)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	invalid := killSwitch
+	invalid.NamePattern = `kill-(`
+	recorder, response := send(admin, http.MethodPost, "/feature-flag-templates", invalid)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.InvalidNamePatternError, response.Message)
+
+	invalid = killSwitch
+	invalid.DefaultValue = "yes"
+	recorder, response = send(admin, http.MethodPost, "/feature-flag-templates", invalid)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueTypeError, response.Message)
+
+	recorder, _ = send(admin, http.MethodPost, "/feature-flag-templates", killSwitch)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var template models.FeatureFlagTemplateRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &template))
+	assert.Equal(t, organization.ID, template.OrganizationID)
+	assert.Equal(t, killSwitch.NamePattern, template.NamePattern)
+	templatePath := "/feature-flag-templates/" + template.ID.Hex()
+
+	recorder, _ = send(collaborator, http.MethodGet, "/feature-flag-templates", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var list handlers.ListFeatureFlagTemplatesResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, template.ID, list.Data[0].ID)
+
+	killSwitch.Description = "Turns a feature off during incidents"
+	recorder, _ = send(admin, http.MethodPut, templatePath, killSwitch)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder, _ = send(collaborator, http.MethodGet, templatePath, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &template))
+	assert.Equal(t, killSwitch.Description, template.Description)
+
+	fromTemplatePath := "/feature-flags/from-template/" + template.ID.Hex()
+	recorder, response = send(collaborator, http.MethodPost, fromTemplatePath, map[string]any{"name": "checkout"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagNamePatternError, response.Message)
+
+	recorder, _ = send(collaborator, http.MethodPost, fromTemplatePath, map[string]any{"name": "kill-checkout"})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var featureFlag models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &featureFlag))
+	assert.Equal(t, "ops/kill-checkout", featureFlag.QualifiedName())
+	assert.Equal(t, "sre", featureFlag.Owner)
+	assert.Equal(t, killSwitch.Description, featureFlag.Description)
+	assert.Equal(t, models.Boolean, featureFlag.Type)
+	assert.Equal(t, "true", featureFlag.Revisions[0].DefaultValue)
+	assert.Len(t, featureFlag.Revisions[0].Rules, 1)
+	assert.Equal(t, "region: eu", featureFlag.Revisions[0].Rules[0].Predicate)
+
+	// Fields set in the body win over the template's
+	recorder, _ = send(collaborator, http.MethodPost, fromTemplatePath, map[string]any{
+		"name":          "kill-invoices",
+		"namespace":     "billing",
+		"default_value": "false",
+		"rules":         []models.Rule{},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var overridden models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &overridden))
+	assert.Equal(t, "billing/kill-invoices", overridden.QualifiedName())
+	assert.Equal(t, "sre", overridden.Owner)
+	assert.Equal(t, "false", overridden.Revisions[0].DefaultValue)
+	assert.Empty(t, overridden.Revisions[0].Rules)
+
+	// Overrides are validated like any other flag
+	recorder, response = send(collaborator, http.MethodPost, fromTemplatePath, map[string]any{
+		"name":          "kill-search",
+		"default_value": "off",
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueTypeError, response.Message)
+
+	recorder, _ = send(collaborator, http.MethodDelete, templatePath, nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder, _ = send(admin, http.MethodDelete, templatePath, nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder, _ = send(collaborator, http.MethodPost, fromTemplatePath, map[string]any{"name": "kill-search"})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
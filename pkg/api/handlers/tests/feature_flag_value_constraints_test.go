@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *FeatureFlagHandlerTestSuite) TestFeatureFlagValueConstraints() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Collaborator,
+		),
+	}, suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	send := func(method, path string, body any) (*httptest.ResponseRecorder, apierrors.Error) {
+		requestBody, err := json.Marshal(body)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(method, path, bytes.NewBuffer(requestBody))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response apierrors.Error
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+	featureFlagsPath := "/organizations/" + organization.ID.Hex() + "/feature-flags"
+
+	// Booleans only take true and false, even where coercion would accept more
+	recorder, response := send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "checkout",
+		Type:         models.Boolean,
+		DefaultValue: "false",
+		Rules:        []models.Rule{{Predicate: "plan: pro", Value: "1", Env: "prd", IsEnabled: true}},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueTypeError, response.Message)
+
+	lower, upper := 1.0, 100.0
+	recorder, response = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "page-size",
+		Type:         models.Number,
+		DefaultValue: "20",
+		Constraints:  &models.ValueConstraints{Min: &upper, Max: &lower},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.InvalidValueConstraintsError, response.Message)
+
+	// Range
+	recorder, _ = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "page-size",
+		Type:         models.Number,
+		DefaultValue: "20",
+		Constraints:  &models.ValueConstraints{Min: &lower, Max: &upper},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var pageSize models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &pageSize))
+	assert.Equal(t, &models.ValueConstraints{Min: &lower, Max: &upper}, pageSize.Constraints)
+
+	pageSizePath := featureFlagsPath + "/" + pageSize.ID.Hex()
+	recorder, response = send(http.MethodPatch, pageSizePath, handlers.PatchFeatureFlagRequest{
+		DefaultValue: "20",
+		Rules:        []models.Rule{{Predicate: "plan: pro", Value: "9999", Env: "prd", IsEnabled: true}},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueOutOfRangeError, response.Message)
+
+	recorder = suite.putRollout(pageSizePath+"/rollout", token, `{"percentage": 10, "value": "9999"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.override(http.MethodPut, organization.ID, pageSize.ID, "qa-1", token, `{"value": "0"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = suite.override(http.MethodPut, organization.ID, pageSize.ID, "qa-1", token, `{"value": "50"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Enum
+	recorder, _ = send(http.MethodPost, featureFlagsPath, handlers.PostFeatureFlagRequest{
+		Name:         "plan",
+		Type:         models.String,
+		DefaultValue: "free",
+		Constraints:  &models.ValueConstraints{Enum: []string{"free", "pro"}},
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var plan models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &plan))
+
+	planPath := featureFlagsPath + "/" + plan.ID.Hex()
+	recorder, response = send(http.MethodPatch, planPath, handlers.PatchFeatureFlagRequest{
+		DefaultValue: "team",
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueNotAllowedError, response.Message)
+
+	// Constraints can't be narrowed past what the flag already serves
+	recorder, response = send(http.MethodPatch, planPath, handlers.PatchFeatureFlagRequest{
+		Constraints: &models.ValueConstraints{Enum: []string{"pro"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, apierrors.FlagValueNotAllowedError, response.Message)
+
+	// Empty constraints remove them
+	recorder, _ = send(http.MethodPatch, planPath, handlers.PatchFeatureFlagRequest{
+		Constraints: &models.ValueConstraints{},
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var updated models.FeatureFlagRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+	assert.Nil(t, updated.Constraints)
+
+	recorder, _ = send(http.MethodPatch, planPath, handlers.PatchFeatureFlagRequest{
+		DefaultValue: "team",
+	})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -32,12 +33,13 @@ func CreateFeatureFlag(
 		}
 	}
 	record := &models.FeatureFlagRecord{
-		OrganizationID: organizationID,
-		UserID:         userID,
-		Version:        version,
-		Name:           name,
-		Type:           flagType,
-		Revisions:      revision,
+		OrganizationID:  organizationID,
+		UserID:          userID,
+		Version:         version,
+		Name:            name,
+		Type:            flagType,
+		Revisions:       revision,
+		SharedChangedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 		Timestamps: storage.Timestamps{
 			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
@@ -72,3 +74,29 @@ func CreateRevision(
 		},
 	}
 }
+
+func SetPrerequisites(
+	record *models.FeatureFlagRecord,
+	prerequisiteIDs []primitive.ObjectID,
+	db *mongo.Database,
+) {
+	prerequisites := make([]models.Prerequisite, 0, len(prerequisiteIDs))
+	for _, prerequisiteID := range prerequisiteIDs {
+		prerequisites = append(prerequisites, models.Prerequisite{
+			FeatureFlagID: prerequisiteID,
+			Value:         "true",
+		})
+	}
+
+	model := models.NewFeatureFlagModel(db)
+	_, err := model.UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: record.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"prerequisites": prerequisites}}},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	record.Prerequisites = prerequisites
+}
@@ -5,9 +5,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/config"
 	testutils "github.com/Roll-Play/togglelabs/pkg/utils/test_utils"
+	"github.com/Roll-Play/togglelabs/pkg/workers"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -37,6 +40,73 @@ func (suite *HandlersSuite) TestHealthHanlderHealthy() {
 	}, response)
 }
 
+func (suite *HandlersSuite) TestVersionHandler() {
+	request := httptest.NewRequest(http.MethodGet, "/version", nil)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	c := suite.Server.NewContext(request, recorder)
+	var response handlers.VersionResponse
+
+	h := handlers.NewVersionHandler(config.BuildInfo{
+		Version:   "v1.2.3",
+		Commit:    "abc1234",
+		BuildTime: "2024-01-01T00:00:00Z",
+	})
+
+	assert.NoError(suite.T(), h.GetVersion(c))
+	assert.NoError(suite.T(), json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(suite.T(), http.StatusOK, recorder.Code)
+	assert.Equal(suite.T(), handlers.VersionResponse{
+		Version:   "v1.2.3",
+		Commit:    "abc1234",
+		BuildTime: "2024-01-01T00:00:00Z",
+	}, response)
+}
+
+func (suite *HandlersSuite) TestWorkersStatusHealthy() {
+	request := httptest.NewRequest(http.MethodGet, "/workers/status", nil)
+	recorder := httptest.NewRecorder()
+
+	c := suite.Server.NewContext(request, recorder)
+	var response handlers.WorkersStatusResponse
+
+	registry := workers.NewRegistry()
+	registry.Register("scheduler", time.Minute)
+	h := handlers.NewWorkersHandler(registry)
+
+	assert.NoError(suite.T(), h.GetStatus(c))
+	assert.NoError(suite.T(), json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(suite.T(), http.StatusOK, recorder.Code)
+	assert.True(suite.T(), response.Healthy)
+	assert.Len(suite.T(), response.Workers, 1)
+	assert.Equal(suite.T(), "scheduler", response.Workers[0].Name)
+	assert.Equal(suite.T(), "1m0s", response.Workers[0].Interval)
+	assert.False(suite.T(), response.Workers[0].Stuck)
+}
+
+func (suite *HandlersSuite) TestWorkersStatusStuck() {
+	request := httptest.NewRequest(http.MethodGet, "/workers/status", nil)
+	recorder := httptest.NewRecorder()
+
+	c := suite.Server.NewContext(request, recorder)
+	var response handlers.WorkersStatusResponse
+
+	registry := workers.NewRegistry()
+	registry.Register("scheduler", time.Minute)
+	registry.Register("flush", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	h := handlers.NewWorkersHandler(registry)
+
+	assert.NoError(suite.T(), h.GetStatus(c))
+	assert.NoError(suite.T(), json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, recorder.Code)
+	assert.False(suite.T(), response.Healthy)
+	assert.Equal(suite.T(), "flush", response.Workers[0].Name)
+	assert.True(suite.T(), response.Workers[0].Stuck)
+	assert.False(suite.T(), response.Workers[1].Stuck)
+}
+
 func TestHandlers(t *testing.T) {
 	suite.Run(t, new(HandlersSuite))
 }
@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestObjectIDParamsMiddleware(t *testing.T) {
+	var organizationID, featureFlagID primitive.ObjectID
+	var overrideUserID string
+	called := false
+
+	server := echo.New()
+	group := server.Group("/organizations", middlewares.ObjectIDParamsMiddleware(middlewares.ObjectIDParams...))
+	group.GET("/:organizationID/feature-flags/:featureFlagID", func(c echo.Context) error {
+		called = true
+		organizationID = apiutils.ObjectIDParam(c, "organizationID")
+		featureFlagID = apiutils.ObjectIDParam(c, "featureFlagID")
+		return c.NoContent(http.StatusOK)
+	})
+	// userID isn't declared, so any user id goes through.
+	group.GET("/:organizationID/feature-flags/:featureFlagID/overrides/:userID", func(c echo.Context) error {
+		overrideUserID = c.Param("userID")
+		return c.NoContent(http.StatusOK)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	expectedOrganizationID, expectedFeatureFlagID := primitive.NewObjectID(), primitive.NewObjectID()
+	recorder := get("/organizations/" + expectedOrganizationID.Hex() + "/feature-flags/" + expectedFeatureFlagID.Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, expectedOrganizationID, organizationID)
+	assert.Equal(t, expectedFeatureFlagID, featureFlagID)
+
+	recorder = get("/organizations/" + expectedOrganizationID.Hex() + "/feature-flags/" +
+		expectedFeatureFlagID.Hex() + "/overrides/ana@example.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "ana@example.com", overrideUserID)
+
+	malformed := map[string]string{
+		"organization id":         "/organizations/acme/feature-flags/" + expectedFeatureFlagID.Hex(),
+		"feature flag id":         "/organizations/" + expectedOrganizationID.Hex() + "/feature-flags/checkout",
+		"too short id":            "/organizations/" + expectedOrganizationID.Hex()[:23] + "/feature-flags/" + expectedFeatureFlagID.Hex(),
+		"id with non hex letters": "/organizations/" + expectedOrganizationID.Hex() + "/feature-flags/zzzzzzzzzzzzzzzzzzzzzzzz",
+	}
+	for name, path := range malformed {
+		t.Run(name, func(t *testing.T) {
+			called = false
+			recorder := get(path)
+
+			var response apierrors.Error
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, apierrors.InvalidObjectIDError, response.Message)
+			assert.False(t, called)
+		})
+	}
+}
+
+func TestObjectIDParamWithoutMiddleware(t *testing.T) {
+	server := echo.New()
+	id := primitive.NewObjectID()
+
+	c := server.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.SetParamNames("featureFlagID")
+	c.SetParamValues(id.Hex())
+	assert.Equal(t, id, apiutils.ObjectIDParam(c, "featureFlagID"))
+
+	// A malformed id the middleware didn't reject matches no document.
+	c.SetParamValues("checkout")
+	assert.Equal(t, primitive.NilObjectID, apiutils.ObjectIDParam(c, "featureFlagID"))
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Roll-Play/togglelabs/pkg/api/common"
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
 	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
 	"github.com/Roll-Play/togglelabs/pkg/api/handlers/tests/fixtures"
 	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
@@ -18,16 +19,20 @@ import (
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
 	testutils "github.com/Roll-Play/togglelabs/pkg/utils/test_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrganizationHandlerTestSuite struct {
 	testutils.DefaultTestSuite
-	db *mongo.Database
+	db        *mongo.Database
+	publisher *recordingPublisher
 }
 
 func (suite *OrganizationHandlerTestSuite) SetupTest() {
@@ -41,8 +46,39 @@ func (suite *OrganizationHandlerTestSuite) SetupTest() {
 
 	logger, _ := common.NewZapLogger()
 
-	h := handlers.NewOrganizationHandler(suite.db, logger)
+	suite.publisher = new(recordingPublisher)
+	h := handlers.NewOrganizationHandler(suite.db, logger).WithChangePublisher(suite.publisher)
+	suite.Server.Use(middlewares.ObjectIDParamsMiddleware(middlewares.ObjectIDParams...))
 	suite.Server.POST("/organizations", middlewares.AuthMiddleware(h.PostOrganization))
+	suite.Server.GET("/organizations", middlewares.AuthMiddleware(h.ListOrganizations))
+	suite.Server.PUT(
+		"/organizations/:organizationID/context-schema",
+		middlewares.AuthMiddleware(h.PutContextSchema),
+	)
+	suite.Server.GET("/organizations/:organizationID/whoami", middlewares.AuthMiddleware(h.GetWhoAmI))
+	suite.Server.GET("/organizations/:organizationID/settings", middlewares.AuthMiddleware(h.GetSettings))
+	suite.Server.PATCH("/organizations/:organizationID/settings", middlewares.AuthMiddleware(h.PatchSettings))
+	suite.Server.GET("/organizations/:organizationID/dashboard", middlewares.AuthMiddleware(h.GetDashboard))
+	suite.Server.GET("/organizations/:organizationID/usage", middlewares.AuthMiddleware(h.GetUsage))
+	suite.Server.POST(
+		"/organizations/:organizationID/automation/pause",
+		middlewares.AuthMiddleware(h.PauseAutomation),
+	)
+	suite.Server.POST(
+		"/organizations/:organizationID/automation/resume",
+		middlewares.AuthMiddleware(h.ResumeAutomation),
+	)
+	memberIDParam := middlewares.ObjectIDParamsMiddleware("userID")
+	suite.Server.PUT(
+		"/organizations/:organizationID/members/:userID",
+		middlewares.AuthMiddleware(h.PutMember),
+		memberIDParam,
+	)
+	suite.Server.DELETE(
+		"/organizations/:organizationID/members/:userID",
+		middlewares.AuthMiddleware(h.DeleteMember),
+		memberIDParam,
+	)
 }
 
 func (suite *OrganizationHandlerTestSuite) AfterTest(_, _ string) {
@@ -89,6 +125,651 @@ func (suite *OrganizationHandlerTestSuite) TestPostOrganizationHandlerSuccess()
 	assert.Equal(t, organization.ID, response.ID)
 	assert.Equal(t, organization.Members, response.Members)
 	assert.Equal(t, organization.Name, response.Name)
+	assert.NotContains(t, recorder.Body.String(), "password")
+
+	assert.Len(t, suite.publisher.organizationPayloads, 1)
+	assert.Equal(t, webhooks.OrganizationCreatedEvent, suite.publisher.organizationPayloads[0].Event)
+	assert.Equal(t, user.ID, suite.publisher.organizationPayloads[0].UserID)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestPutContextSchema() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	putSchema := func(userID primitive.ObjectID, body string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPut,
+			"/organizations/"+organization.ID.Hex()+"/context-schema",
+			bytes.NewBufferString(body),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := putSchema(collaborator.ID, `{"attributes": {"plan": "string"}}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = putSchema(admin.ID, `{"attributes": {"plan": "date"}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = putSchema(admin.ID, `{"attributes": {"plan": "string", "age": "number"}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	model := models.NewOrganizationModel(suite.db)
+	saved, err := model.FindByID(context.Background(), organization.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]models.AttributeType{
+		"plan": models.StringAttribute,
+		"age":  models.NumberAttribute,
+	}, saved.ContextSchema)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestGetWhoAmI() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	readOnly := fixtures.CreateUser("", "", "", "", suite.db)
+	outsider := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			readOnly,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	whoAmI := func(userID primitive.ObjectID) (*httptest.ResponseRecorder, handlers.WhoAmIResponse) {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodGet,
+			"/organizations/"+organization.ID.Hex()+"/whoami",
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response handlers.WhoAmIResponse
+		if recorder.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		}
+		return recorder, response
+	}
+
+	readActions := []string{
+		handlers.ReadFeatureFlagsAction,
+		handlers.ExportFeatureFlagsAction,
+	}
+	collaboratorActions := append(append([]string{}, readActions...),
+		handlers.WriteFeatureFlagsAction,
+		handlers.ApproveRevisionsAction,
+		handlers.ImportFeatureFlagsAction,
+		handlers.ManageContextSamplesAction,
+		handlers.ManageTestIdentitiesAction,
+		handlers.ManageUserListsAction,
+	)
+	adminActions := append(append([]string{}, collaboratorActions...),
+		handlers.ManageAPIKeysAction,
+		handlers.ReadAuditLogAction,
+		handlers.ReadUsageAction,
+		handlers.ManageContextSchemaAction,
+		handlers.ManageSettingsAction,
+		handlers.ManageFlagTemplatesAction,
+		handlers.PauseAutomationAction,
+		handlers.ManageMembersAction,
+		handlers.ManageWebhooksAction,
+	)
+
+	testCases := []struct {
+		user            *models.UserRecord
+		permissionLevel string
+		actions         []string
+	}{
+		{admin, models.Admin, adminActions},
+		{collaborator, models.Collaborator, collaboratorActions},
+		{readOnly, models.ReadOnly, readActions},
+	}
+	for _, testCase := range testCases {
+		recorder, response := whoAmI(testCase.user.ID)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, testCase.user.ID.Hex(), response.UserID)
+		assert.Equal(t, organization.ID.Hex(), response.OrganizationID)
+		assert.Equal(t, testCase.permissionLevel, response.PermissionLevel)
+		assert.Equal(t, testCase.actions, response.Actions)
+	}
+
+	recorder, _ := whoAmI(outsider.ID)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestListOrganizations() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	owned := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	joined := fixtures.CreateOrganization("the other company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.ReadOnly,
+		),
+	}, suite.db)
+	deleted := fixtures.CreateOrganization("the old company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			user,
+			models.Admin,
+		),
+	}, suite.db)
+	fixtures.CreateOrganization("someone else's company", nil, suite.db)
+
+	model := models.NewOrganizationModel(suite.db)
+	assert.NoError(t, model.UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: deleted.ID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "deleted_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+		}}},
+	))
+
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	listOrganizations := func(query string) handlers.ListOrganizationsResponse {
+		request := httptest.NewRequest(http.MethodGet, "/organizations"+query, nil)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+
+		var response handlers.ListOrganizationsResponse
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		return response
+	}
+
+	response := listOrganizations("")
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, []handlers.OrganizationMembership{
+		{ID: owned.ID.Hex(), Name: owned.Name, PermissionLevel: models.Admin},
+		{ID: joined.ID.Hex(), Name: joined.Name, PermissionLevel: models.ReadOnly},
+	}, response.Data)
+
+	response = listOrganizations("?page=2&page_size=1")
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.Equal(t, 2, response.Page)
+	assert.Equal(t, 1, response.PageSize)
+	assert.Equal(t, []handlers.OrganizationMembership{
+		{ID: joined.ID.Hex(), Name: joined.Name, PermissionLevel: models.ReadOnly},
+	}, response.Data)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestOrganizationSettings() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	readOnly := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			readOnly,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	settingsRequest := func(method string, userID primitive.ObjectID, body string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			method,
+			"/organizations/"+organization.ID.Hex()+"/settings",
+			bytes.NewBufferString(body),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := settingsRequest(http.MethodGet, readOnly.ID, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.OrganizationSettingsResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.False(t, response.RequireSeparateApprover)
+	assert.Empty(t, response.MaintenanceWindows)
+	assert.Equal(t, models.DefaultOrganizationLimits, response.Limits)
+
+	recorder = settingsRequest(http.MethodPatch, readOnly.ID, `{"require_separate_approver": true}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = settingsRequest(http.MethodPatch, admin.ID, `{"maintenance_windows": [
+		{"start": "2024-03-01T10:00:00Z", "end": "2024-03-01T09:00:00Z"}
+	]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = settingsRequest(http.MethodPatch, admin.ID, `{
+		"default_environment": "production",
+		"require_separate_approver": true,
+		"maintenance_windows": [
+			{"start": "2024-03-01T10:00:00Z", "end": "2024-03-01T11:00:00Z", "environment": "production"}
+		]
+	}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = settingsRequest(http.MethodPatch, admin.ID, `{"environment_parents": {"dev": "staging", "staging": "dev"}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var errorResponse apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errorResponse))
+	assert.Equal(t, apierrors.EnvironmentCycleError, errorResponse.Message)
+
+	recorder = settingsRequest(http.MethodPatch, admin.ID, `{"environment_parents": {"dev": "staging"}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Settings left out of a patch keep their value.
+	recorder = settingsRequest(http.MethodPatch, admin.ID, `{"default_environment": "staging"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	model := models.NewOrganizationModel(suite.db)
+	saved, err := model.FindByID(context.Background(), organization.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "staging", saved.Settings.DefaultEnvironment)
+	assert.True(t, saved.Settings.RequireSeparateApprover)
+	assert.Equal(t, map[string]string{"dev": "staging"}, saved.Settings.EnvironmentParents)
+	assert.Equal(t, []models.MaintenanceWindow{
+		{
+			Start:       primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)),
+			End:         primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)),
+			Environment: "production",
+		},
+	}, saved.Settings.MaintenanceWindows)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestPauseAutomation() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	automationRequest := func(userID primitive.ObjectID, action string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			http.MethodPost,
+			"/organizations/"+organization.ID.Hex()+"/automation/"+action,
+			nil,
+		)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	featureFlag := fixtures.CreateFeatureFlag(admin.ID, organization.ID, "checkout", 1, models.Boolean, nil, suite.db)
+	nextStepAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: featureFlag.ID}},
+		bson.D{{Key: "$set", Value: bson.M{"rollout": models.Rollout{
+			Percentage: 25,
+			Ramp: &models.RolloutRamp{
+				Step:       25,
+				Interval:   time.Hour,
+				NextStepAt: primitive.NewDateTimeFromTime(nextStepAt),
+			},
+		}}}},
+	)
+	assert.NoError(t, err)
+
+	recorder := automationRequest(collaborator.ID, "pause")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = automationRequest(admin.ID, "resume")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = automationRequest(admin.ID, "pause")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.OrganizationSettingsResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.True(t, response.AutomationPaused())
+	assert.Equal(t, admin.ID, response.AutomationPausedBy)
+
+	recorder = automationRequest(admin.ID, "pause")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Due ramps of the paused organization are left alone.
+	featureFlagModel := models.NewFeatureFlagModel(suite.db)
+	organizationModel := models.NewOrganizationModel(suite.db)
+	paused, err := organizationModel.FindAutomationPausedIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []primitive.ObjectID{organization.ID}, paused)
+	due, err := featureFlagModel.FindDueRolloutRamps(context.Background(), time.Now().UTC(), paused)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Resuming pushes ramps back by as long as the pause lasted.
+	_, err = suite.db.Collection(models.OrganizationCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: organization.ID}},
+		bson.D{{Key: "$set", Value: bson.M{
+			"settings.automation_paused_at": primitive.NewDateTimeFromTime(time.Now().UTC().Add(-time.Hour)),
+		}}},
+	)
+	assert.NoError(t, err)
+
+	recorder = automationRequest(admin.ID, "resume")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.False(t, response.AutomationPaused())
+
+	saved, err := featureFlagModel.FindByID(context.Background(), featureFlag.ID)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, nextStepAt.Add(time.Hour), saved.Rollout.Ramp.NextStepAt.Time(), time.Minute)
+
+	auditLogModel := models.NewAuditLogModel(suite.db)
+	count, err := auditLogModel.CountMany(context.Background(), organization.ID, bson.D{
+		{Key: "action", Value: bson.M{"$in": bson.A{models.PauseAutomationAction, models.ResumeAutomationAction}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestMembers() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	newcomer := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	memberRequest := func(
+		method string,
+		userID,
+		memberID primitive.ObjectID,
+		permissionLevel models.PermissionLevelEnum,
+	) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		requestBody, err := json.Marshal(handlers.PutMemberRequest{PermissionLevel: permissionLevel})
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(
+			method,
+			"/organizations/"+organization.ID.Hex()+"/members/"+memberID.Hex(),
+			bytes.NewBuffer(requestBody),
+		)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := memberRequest(http.MethodPut, collaborator.ID, newcomer.ID, models.ReadOnly)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = memberRequest(http.MethodPut, admin.ID, newcomer.ID, models.ReadOnly)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "password")
+
+	recorder = memberRequest(http.MethodPut, admin.ID, newcomer.ID, models.ReadOnly)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = memberRequest(http.MethodPut, admin.ID, newcomer.ID, models.Collaborator)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = memberRequest(http.MethodPut, admin.ID, admin.ID, models.Collaborator)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = memberRequest(http.MethodDelete, admin.ID, admin.ID, "")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = memberRequest(http.MethodDelete, admin.ID, newcomer.ID, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = memberRequest(http.MethodDelete, admin.ID, newcomer.ID, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	model := models.NewOrganizationModel(suite.db)
+	organizationRecord, err := model.FindByID(context.Background(), organization.ID)
+	assert.NoError(t, err)
+	assert.Len(t, organizationRecord.Members, 2)
+
+	// Setting the level a member already has is not an event.
+	events := make([]string, 0, len(suite.publisher.organizationPayloads))
+	for _, payload := range suite.publisher.organizationPayloads {
+		assert.Equal(t, organization.ID, payload.OrganizationID)
+		assert.Equal(t, newcomer.ID, payload.UserID)
+		assert.Equal(t, admin.ID, payload.ActorID)
+		events = append(events, payload.Event)
+	}
+	assert.Equal(t, []string{
+		webhooks.MemberAddedEvent,
+		webhooks.MemberRoleChangedEvent,
+		webhooks.MemberRemovedEvent,
+	}, events)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestGetUsage() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	collaborator := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			collaborator,
+			models.Collaborator,
+		),
+	}, suite.db)
+
+	usageRequest := func(userID primitive.ObjectID, query string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodGet, "/organizations/"+organization.ID.Hex()+"/usage?"+query, nil)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	now := models.UsageBucket(time.Now())
+	err := models.NewUsageModel(suite.db).SaveUsage(context.Background(), map[models.UsageKey]*models.UsageCounts{
+		{OrganizationID: organization.ID, Bucket: now}: {
+			Requests: 4, ClientErrors: 1, LatencyMillis: 100, MaxLatencyMillis: 60, Evaluations: 10,
+		},
+		{OrganizationID: organization.ID, Bucket: now.Add(-2 * time.Hour)}: {
+			Requests: 1, LatencyMillis: 20, MaxLatencyMillis: 20, Evaluations: 5,
+		},
+		{OrganizationID: organization.ID, Bucket: now.Add(-3 * 24 * time.Hour)}: {
+			Requests: 5, ServerErrors: 1, LatencyMillis: 30, MaxLatencyMillis: 10,
+		},
+		{OrganizationID: primitive.NewObjectID(), Bucket: now}: {Requests: 100},
+	})
+	assert.NoError(t, err)
+
+	recorder := usageRequest(collaborator.ID, "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = usageRequest(admin.ID, "window=1y")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = usageRequest(admin.ID, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.UsageResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "24h", response.Window)
+	assert.Equal(t, models.UsageCounts{
+		Requests: 5, ClientErrors: 1, LatencyMillis: 120, MaxLatencyMillis: 60, Evaluations: 15,
+	}, response.Totals.UsageCounts)
+	assert.Equal(t, 24.0, response.Totals.AverageLatencyMillis)
+	assert.Len(t, response.Buckets, 24)
+	assert.True(t, now.Equal(response.Buckets[23].Start))
+	assert.Equal(t, int64(4), response.Buckets[23].Requests)
+	assert.Equal(t, 25.0, response.Buckets[23].AverageLatencyMillis)
+	assert.Equal(t, int64(1), response.Buckets[21].Requests)
+	assert.Equal(t, int64(0), response.Buckets[22].Requests)
+
+	recorder = usageRequest(admin.ID, "window=7d")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	response = handlers.UsageResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Buckets, 7)
+	assert.Equal(t, int64(10), response.Totals.Requests)
+	assert.Equal(t, int64(1), response.Totals.ServerErrors)
+	assert.Equal(t, int64(5), response.Buckets[3].Requests)
+}
+
+func (suite *OrganizationHandlerTestSuite) TestGetDashboard() {
+	t := suite.T()
+
+	admin := fixtures.CreateUser("", "", "", "", suite.db)
+	reader := fixtures.CreateUser("", "", "", "", suite.db)
+	stranger := fixtures.CreateUser("", "", "", "", suite.db)
+	organization := fixtures.CreateOrganization("the company", []common.Tuple[*models.UserRecord, string]{
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			admin,
+			models.Admin,
+		),
+		common.NewTuple[*models.UserRecord, models.PermissionLevelEnum](
+			reader,
+			models.ReadOnly,
+		),
+	}, suite.db)
+
+	dashboardRequest := func(userID primitive.ObjectID) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateJWT(userID, time.Second*120)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodGet, "/organizations/"+organization.ID.Hex()+"/dashboard", nil)
+		request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+		recorder := httptest.NewRecorder()
+
+		suite.Server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	live := []models.Revision{*fixtures.CreateRevision(admin.ID, models.Live, primitive.NilObjectID)}
+	fixtures.CreateFeatureFlag(admin.ID, organization.ID, "checkout", 1, models.Boolean, live, suite.db)
+	fixtures.CreateFeatureFlag(admin.ID, organization.ID, "search", 1, models.String, nil, suite.db)
+	stale := fixtures.CreateFeatureFlag(admin.ID, organization.ID, "legacy", 1, models.Boolean, live, suite.db)
+	_, err := suite.db.Collection(models.FeatureFlagCollectionName).UpdateOne(
+		context.Background(),
+		bson.D{{Key: "_id", Value: stale.ID}},
+		bson.D{{Key: "$set", Value: bson.M{
+			"archived_at": primitive.NewDateTimeFromTime(time.Now().UTC()),
+			"updated_at":  primitive.NewDateTimeFromTime(time.Now().UTC().Add(-30 * 24 * time.Hour)),
+		}}},
+	)
+	assert.NoError(t, err)
+	fixtures.CreateFeatureFlag(admin.ID, primitive.NewObjectID(), "elsewhere", 1, models.Boolean, live, suite.db)
+
+	_, err = models.NewAuditLogModel(suite.db).InsertOne(context.Background(), models.NewAuditEntry(
+		organization.ID,
+		primitive.NilObjectID,
+		admin.ID,
+		models.PauseAutomationAction,
+		nil,
+	))
+	assert.NoError(t, err)
+
+	recorder := dashboardRequest(stranger.ID)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = dashboardRequest(admin.ID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response handlers.DashboardResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, &models.FeatureFlagCounts{
+		Total: 3,
+		ByStatus: map[models.FlagStatus]int64{
+			models.LiveFlag:       1,
+			models.UnreleasedFlag: 1,
+			models.ArchivedFlag:   1,
+		},
+		ByType: map[models.FlagType]int64{
+			models.Boolean: 2,
+			models.String:  1,
+		},
+	}, response.FeatureFlags)
+	assert.Equal(t, int64(2), response.ChangedFeatureFlags)
+	assert.Equal(t, 2, response.Members)
+	assert.Len(t, response.RecentAuditEntries, 1)
+
+	// Read-only members see everything but the audit log.
+	recorder = dashboardRequest(reader.ID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	response = handlers.DashboardResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, int64(3), response.FeatureFlags.Total)
+	assert.Empty(t, response.RecentAuditEntries)
 }
 
 func TestOrganizationHandlerTestSuite(t *testing.T) {
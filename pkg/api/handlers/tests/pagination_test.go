@@ -0,0 +1,27 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPaginatedResponse(t *testing.T) {
+	testCases := []struct {
+		total      int64
+		pageSize   int
+		totalPages int
+	}{
+		{0, 10, 0},
+		{1, 10, 1},
+		{10, 10, 1},
+		{11, 10, 2},
+		{5, 0, 0},
+	}
+	for _, testCase := range testCases {
+		response := handlers.NewPaginatedResponse([]string{}, 1, testCase.pageSize, testCase.total)
+		assert.Equal(t, int(testCase.total), response.Total)
+		assert.Equal(t, testCase.totalPages, response.TotalPages, "total %d, page size %d", testCase.total, testCase.pageSize)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	apiKey := models.APIKeyRecord{ID: primitive.NewObjectID(), OrganizationID: primitive.NewObjectID()}
+
+	server := echo.New()
+	rateLimit := middlewares.RateLimitMiddleware(middlewares.NewRateLimiter(2, time.Hour))
+	server.GET("/organizations/:organizationID/env", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, rateLimit)
+	// Stands in for APIKeyMiddleware ahead of the limit.
+	server.GET("/env", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(middlewares.APIKeyContextKey, apiKey)
+			return next(c)
+		}
+	}, rateLimit)
+	// Stands in for AuthMiddleware, with the caller taken from the query.
+	server.GET("/organizations/:organizationID/members", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, err := primitive.ObjectIDFromHex(c.QueryParam("user"))
+			assert.NoError(t, err)
+			c.Set("user", apiutils.ContextUser{ID: userID})
+			return next(c)
+		}
+	}, rateLimit)
+	server.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, rateLimit)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	organizationPath := "/organizations/" + primitive.NewObjectID().Hex() + "/env"
+	for _, remaining := range []string{"1", "0"} {
+		recorder := get(organizationPath)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "2", recorder.Header().Get(middlewares.RateLimitLimitHeader))
+		assert.Equal(t, remaining, recorder.Header().Get(middlewares.RateLimitRemainingHeader))
+	}
+
+	recorder := get(organizationPath)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "0", recorder.Header().Get(middlewares.RateLimitRemainingHeader))
+	assert.Equal(t, "3600", recorder.Header().Get(echo.HeaderRetryAfter))
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.RateLimitExceededError, response.Message)
+
+	// Other organizations and API keys have buckets of their own.
+	recorder = get("/organizations/" + primitive.NewObjectID().Hex() + "/env")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get(middlewares.RateLimitRemainingHeader))
+
+	recorder = get("/env")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get(middlewares.RateLimitRemainingHeader))
+
+	// Callers of an organization are counted apart, so one that isn't a
+	// member can't use up the budget of those that are.
+	membersPath := "/organizations/" + primitive.NewObjectID().Hex() + "/members?user="
+	stranger := primitive.NewObjectID().Hex()
+	for range []int{0, 1} {
+		assert.Equal(t, http.StatusOK, get(membersPath+stranger).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, get(membersPath+stranger).Code)
+
+	recorder = get(membersPath + primitive.NewObjectID().Hex())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get(middlewares.RateLimitRemainingHeader))
+
+	// Requests to no organization aren't limited.
+	recorder = get("/healthz")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(middlewares.RateLimitLimitHeader))
+}
+
+func TestRateLimiterWindows(t *testing.T) {
+	limiter := middlewares.NewRateLimiter(1, 20*time.Millisecond)
+
+	remaining, _, allowed := limiter.Allow("organization")
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+
+	_, reset, allowed := limiter.Allow("organization")
+	assert.False(t, allowed)
+
+	time.Sleep(time.Until(reset) + time.Millisecond)
+	_, _, allowed = limiter.Allow("organization")
+	assert.True(t, allowed)
+}
@@ -0,0 +1,229 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/relay"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	testutils "github.com/Roll-Play/togglelabs/pkg/utils/test_utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type RelayHandlerTestSuite struct {
+	testutils.DefaultTestSuite
+	upstream       *httptest.Server
+	client         *relay.Client
+	flags          []models.FeatureFlagRecord
+	organizationID primitive.ObjectID
+	ruleID         primitive.ObjectID
+	token          string
+}
+
+func (suite *RelayHandlerTestSuite) SetupTest() {
+	suite.organizationID = primitive.NewObjectID()
+	suite.ruleID = primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	suite.flags = []models.FeatureFlagRecord{
+		{
+			ID:        primitive.NewObjectID(),
+			Name:      "checkout",
+			Namespace: "billing",
+			Type:      models.String,
+			Revisions: []models.Revision{{
+				ID:           primitive.NewObjectID(),
+				UserID:       userID,
+				Status:       models.Live,
+				DefaultValue: "legacy",
+				Rules: []models.Rule{
+					{ID: suite.ruleID, Predicate: "country: BR", Value: "pix", Env: "prd", IsEnabled: true},
+					{Predicate: "ip: 203.0.113.7", Value: "office", Env: "prd", IsEnabled: true},
+				},
+			}},
+		},
+	}
+	suite.upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := suite.flags
+		if r.URL.Query().Get("archived") == "true" {
+			data = []models.FeatureFlagRecord{}
+		}
+		_ = json.NewEncoder(w).Encode(handlers.APIKeyFeatureFlagsResponse{
+			ListFeatureFlagResponse: handlers.NewPaginatedResponse(data, 1, relay.SyncPageSize, int64(len(data))),
+			EnvironmentParents:      map[string]string{"prd-eu": "prd"},
+		})
+	}))
+
+	store := relay.NewStore()
+	suite.client = relay.NewClient(
+		suite.upstream.Client(),
+		suite.upstream.URL,
+		"tgl_relay",
+		store,
+	)
+
+	token, err := apiutils.CreateJWT(userID, time.Second*120)
+	if err != nil {
+		panic(err)
+	}
+	suite.token = token
+
+	suite.Server = echo.New()
+	suite.Server.IPExtractor = middlewares.ClientIPExtractor(nil)
+	h := handlers.NewRelayHandler(store, suite.organizationID, zap.NewNop())
+	suite.Server.GET(
+		"/organizations/:organizationID/env",
+		middlewares.AuthMiddleware(h.GetFeatureFlagEnv),
+		middlewares.ObjectIDParamsMiddleware("organizationID"),
+	)
+}
+
+func (suite *RelayHandlerTestSuite) TearDownTest() {
+	suite.upstream.Close()
+}
+
+func (suite *RelayHandlerTestSuite) getEnv(organizationID primitive.ObjectID, query string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+organizationID.Hex()+"/env?"+query,
+		nil,
+	)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", suite.token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvBeforeSync() {
+	t := suite.T()
+
+	recorder := suite.getEnv(suite.organizationID, "")
+
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, apierrors.RelayNotSyncedError, response.Message)
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvEvaluatesLocally() {
+	t := suite.T()
+
+	_, err := suite.client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(suite.organizationID, "environment=prd&country=BR")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "BILLING_CHECKOUT=pix\n", recorder.Body.String())
+
+	recorder = suite.getEnv(suite.organizationID, "environment=prd&country=US")
+	assert.Equal(t, "BILLING_CHECKOUT=legacy\n", recorder.Body.String())
+
+	// prd-eu has no rules of its own and inherits those of prd.
+	recorder = suite.getEnv(suite.organizationID, "environment=prd-eu&country=BR")
+	assert.Equal(t, "BILLING_CHECKOUT=pix\n", recorder.Body.String())
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvReasons() {
+	t := suite.T()
+
+	_, err := suite.client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(suite.organizationID, "environment=prd&country=BR&reasons=true")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "BILLING_CHECKOUT=pix # RULE_MATCH:"+suite.ruleID.Hex()+"\n", recorder.Body.String())
+
+	recorder = suite.getEnv(suite.organizationID, "environment=prd&country=US&reasons=true")
+	assert.Equal(t, "BILLING_CHECKOUT=legacy # DEFAULT\n", recorder.Body.String())
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvTargetsClientIP() {
+	t := suite.T()
+
+	_, err := suite.client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+suite.organizationID.Hex()+"/env?environment=prd",
+		nil,
+	)
+	request.RemoteAddr = "203.0.113.7:4000"
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", suite.token))
+	recorder := httptest.NewRecorder()
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, "BILLING_CHECKOUT=office\n", recorder.Body.String())
+
+	// Neither the query nor a forwarded header can claim another IP.
+	request = httptest.NewRequest(
+		http.MethodGet,
+		"/organizations/"+suite.organizationID.Hex()+"/env?environment=prd&ip=203.0.113.7",
+		nil,
+	)
+	request.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", suite.token))
+	recorder = httptest.NewRecorder()
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, "BILLING_CHECKOUT=legacy\n", recorder.Body.String())
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvRefusesUserLists() {
+	t := suite.T()
+
+	suite.flags = append(suite.flags, models.FeatureFlagRecord{
+		ID:   primitive.NewObjectID(),
+		Name: "beta",
+		Type: models.Boolean,
+		Revisions: []models.Revision{{
+			ID:           primitive.NewObjectID(),
+			Status:       models.Live,
+			DefaultValue: "false",
+			Rules: []models.Rule{
+				{UserListID: primitive.NewObjectID(), Value: "true", Env: "prd", IsEnabled: true},
+			},
+		}},
+	})
+	_, err := suite.client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(suite.organizationID, "environment=prd")
+
+	var response apierrors.Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, apierrors.RelayUserListError, response.Message)
+
+	// Flags that don't target a list are still served.
+	recorder = suite.getEnv(suite.organizationID, "environment=prd&country=BR&flags=billing/checkout")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "BILLING_CHECKOUT=pix\n", recorder.Body.String())
+}
+
+func (suite *RelayHandlerTestSuite) TestGetFeatureFlagEnvOtherOrganization() {
+	t := suite.T()
+
+	_, err := suite.client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	recorder := suite.getEnv(primitive.NewObjectID(), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRelayHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(RelayHandlerTestSuite))
+}
@@ -0,0 +1,48 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	server := echo.New()
+	server.Use(middlewares.RequestTimeout(20 * time.Millisecond))
+
+	// Stands in for a handler whose query is cancelled by the deadline.
+	server.GET("/stalled-query", func(c echo.Context) error {
+		<-c.Request().Context().Done()
+		return apierrors.CustomError(c, http.StatusInternalServerError, apierrors.InternalServerError)
+	})
+	// Stands in for a handler stuck on something that ignores the context.
+	server.GET("/slow", func(c echo.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	server.GET("/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/stalled-query", "/slow"} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var response apierrors.Error
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, path)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, apierrors.RequestTimeoutError, response.Message, path)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
@@ -126,6 +126,26 @@ func (suite *SignInHandlerTestSuite) TestSignInHandlerUnauthorized() {
 	}, response)
 }
 
+func (suite *SignInHandlerTestSuite) TestSignInHandlerMalformedBody() {
+	t := suite.T()
+
+	requestBody := []byte(`{"email": "fizi@gmail.com",`)
+
+	request := httptest.NewRequest(http.MethodPost, "/signin", bytes.NewBuffer(requestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	var response apierrors.Error
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.Error{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: apierrors.BadRequestError,
+	}, response)
+}
+
 func TestSignInHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(SignInHandlerTestSuite))
 }
@@ -81,15 +81,19 @@ func (suite *SignUpHandlerTestSuite) TestSignUpHandlerSuccess() {
 	assert.Equal(t, ur.FirstName, response.FirstName)
 	assert.Equal(t, ur.LastName, response.LastName)
 	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(ur.Password), []byte("123123123")))
+	assert.NotContains(t, recorder.Body.String(), "password")
+	assert.NotContains(t, recorder.Body.String(), ur.Password)
 }
 
 func (suite *SignUpHandlerTestSuite) TestSignUpHandlerUnsuccessful() {
 	t := suite.T()
 
-	user := fixtures.CreateUser("fizi@gmail.com", "123123123", "", "", suite.db)
+	fixtures.CreateUser("fizi@gmail.com", "", "", "123123123", suite.db)
 
-	requestBody, err := json.Marshal(user)
-	assert.NoError(t, err)
+	requestBody := []byte(`{
+		"email": "fizi@gmail.com",
+		"password": "123123123"
+	}`)
 
 	request := httptest.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(requestBody))
 	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -106,6 +110,45 @@ func (suite *SignUpHandlerTestSuite) TestSignUpHandlerUnsuccessful() {
 	})
 }
 
+func (suite *SignUpHandlerTestSuite) TestSignUpHandlerPasswordTooShort() {
+	t := suite.T()
+
+	requestBody := []byte(`{
+		"email": "fizi@gmail.com",
+		"password": "123"
+	}`)
+
+	request := httptest.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(requestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	var response apierrors.Error
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.Error{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: "password must be at least 8 characters long",
+	}, response)
+}
+
+func (suite *SignUpHandlerTestSuite) TestUserRecordNeverSerializesPassword() {
+	t := suite.T()
+
+	ur, err := models.NewUserRecord("fizi@gmail.com", "123123123", "fizi", "valores")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ur.Password)
+
+	body, err := json.Marshal(ur)
+	assert.NoError(t, err)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &fields))
+	assert.NotContains(t, fields, "password")
+	assert.NotContains(t, string(body), ur.Password)
+}
+
 func TestSignUpHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(SignUpHandlerTestSuite))
 }
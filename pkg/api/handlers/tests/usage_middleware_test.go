@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordedRequest struct {
+	organizationID primitive.ObjectID
+	status         int
+}
+
+type recordingUsage struct {
+	requests []recordedRequest
+}
+
+func (ru *recordingUsage) RecordRequest(organizationID primitive.ObjectID, status int, _ time.Duration, _ time.Time) {
+	ru.requests = append(ru.requests, recordedRequest{organizationID: organizationID, status: status})
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	usage := new(recordingUsage)
+	apiKeyOrganizationID := primitive.NewObjectID()
+
+	server := echo.New()
+	server.Use(middlewares.UsageMiddleware(usage))
+	server.GET("/organizations/:organizationID/flags", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	server.GET("/organizations/:organizationID/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+	server.GET("/organizations/:organizationID/forbidden", func(c echo.Context) error {
+		return apierrors.CustomError(c, http.StatusForbidden, apierrors.ForbiddenError)
+	})
+	server.GET("/env", func(c echo.Context) error {
+		c.Set(middlewares.APIKeyContextKey, models.APIKeyRecord{OrganizationID: apiKeyOrganizationID})
+		return c.NoContent(http.StatusOK)
+	})
+	server.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	organizationID := primitive.NewObjectID()
+	for _, path := range []string{"/flags", "/missing", "/forbidden"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(
+			http.MethodGet,
+			"/organizations/"+organizationID.Hex()+path,
+			nil,
+		))
+	}
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/env", nil))
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, []recordedRequest{
+		{organizationID: organizationID, status: http.StatusOK},
+		{organizationID: organizationID, status: http.StatusNotFound},
+		{organizationID: apiKeyOrganizationID, status: http.StatusOK},
+	}, usage.requests)
+}
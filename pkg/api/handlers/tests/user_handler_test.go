@@ -25,6 +25,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandlerTestSuite struct {
@@ -42,7 +43,11 @@ func (suite *UserHandlerTestSuite) SetupTest() {
 	suite.Server = echo.New()
 	logger, _ := common.NewZapLogger()
 	h := handlers.NewUserHandler(suite.db, logger)
+	sessionMiddleware := middlewares.SessionMiddleware(suite.db)
 	suite.Server.PATCH("/user", middlewares.AuthMiddleware(h.PatchUser))
+	suite.Server.PATCH("/user/password", middlewares.AuthMiddleware(h.PatchPassword))
+	suite.Server.POST("/user/signout-all", middlewares.AuthMiddleware(sessionMiddleware(h.PostSignOutAll)))
+	suite.Server.GET("/users/me", middlewares.AuthMiddleware(sessionMiddleware(h.GetMe)))
 }
 
 func (suite *UserHandlerTestSuite) AfterTest(_, _ string) {
@@ -158,6 +163,220 @@ func (suite *UserHandlerTestSuite) TestUserPatchHandlerOnlyChangesAllowedFields(
 	assert.Equal(t, ur.LastName, response.LastName)
 }
 
+func (suite *UserHandlerTestSuite) TestGetMeSuccess() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("fizi@gmail.com", "fizi", "valores", "", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	var response handlers.UserResponse
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, handlers.UserResponse{
+		ID:        user.ID,
+		Email:     "fizi@gmail.com",
+		FirstName: "fizi",
+		LastName:  "valores",
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}, response)
+	assert.NotContains(t, recorder.Body.String(), "password")
+}
+
+func (suite *UserHandlerTestSuite) patchPassword(token string, body handlers.UserPasswordPatchRequest) *httptest.ResponseRecorder {
+	requestBody, err := json.Marshal(body)
+	assert.NoError(suite.T(), err)
+
+	request := httptest.NewRequest(http.MethodPatch, "/user/password", bytes.NewBuffer(requestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *UserHandlerTestSuite) TestUserPatchPasswordSuccess() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "old_password", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.patchPassword(token, handlers.UserPasswordPatchRequest{
+		CurrentPassword: "old_password",
+		NewPassword:     "new_password",
+	})
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+
+	model := models.NewUserModel(suite.db)
+	ur, err := model.FindByID(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(ur.Password), []byte("new_password")))
+}
+
+func (suite *UserHandlerTestSuite) TestUserPatchPasswordWrongCurrentPassword() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "old_password", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.patchPassword(token, handlers.UserPasswordPatchRequest{
+		CurrentPassword: "not_my_password",
+		NewPassword:     "new_password",
+	})
+
+	var response apierrors.Error
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.Error{
+		Error:   http.StatusText(http.StatusUnauthorized),
+		Message: apierrors.UnauthorizedError,
+	}, response)
+}
+
+func (suite *UserHandlerTestSuite) TestUserPatchPasswordPolicyViolation() {
+	t := suite.T()
+
+	previousPolicy := config.Password
+	config.Password = config.PasswordPolicy{MinLength: 8, RequireDigit: true}
+	defer func() { config.Password = previousPolicy }()
+
+	user := fixtures.CreateUser("", "", "", "old_password", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.patchPassword(token, handlers.UserPasswordPatchRequest{
+		CurrentPassword: "old_password",
+		NewPassword:     "no_digits_here",
+	})
+
+	var response apierrors.Error
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.Error{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: apiutils.ErrPasswordMissingDigit.Error(),
+	}, response)
+}
+
+func (suite *UserHandlerTestSuite) TestUserPatchPasswordRejectsRecentPasswords() {
+	t := suite.T()
+
+	previousPolicy := config.Password
+	config.Password.HistorySize = 2
+	defer func() { config.Password = previousPolicy }()
+
+	user := fixtures.CreateUser("", "", "", "first_password", suite.db)
+
+	changePassword := func(version int, current, next string) *httptest.ResponseRecorder {
+		token, err := apiutils.CreateVersionedJWT(user.ID, version, time.Second*120)
+		assert.NoError(t, err)
+
+		return suite.patchPassword(token, handlers.UserPasswordPatchRequest{
+			CurrentPassword: current,
+			NewPassword:     next,
+		})
+	}
+
+	recorder := changePassword(0, "first_password", "first_password")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	assert.Equal(t, http.StatusNoContent, changePassword(0, "first_password", "second_password").Code)
+	assert.Equal(t, http.StatusNoContent, changePassword(1, "second_password", "third_password").Code)
+
+	recorder = changePassword(2, "third_password", "first_password")
+
+	var response apierrors.Error
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apierrors.PasswordReusedError, response.Message)
+
+	// Only the last two are remembered, so the first one is free again
+	// after another change.
+	assert.Equal(t, http.StatusNoContent, changePassword(2, "third_password", "fourth_password").Code)
+	assert.Equal(t, http.StatusNoContent, changePassword(3, "fourth_password", "first_password").Code)
+
+	model := models.NewUserModel(suite.db)
+	ur, err := model.FindByID(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, ur.PasswordHistory, 2)
+	for _, hash := range ur.PasswordHistory {
+		assert.NotContains(t, hash, "password")
+	}
+}
+
 func TestUserHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(UserHandlerTestSuite))
 }
+
+func (suite *UserHandlerTestSuite) getMe(token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (suite *UserHandlerTestSuite) TestUserPatchPasswordRevokesTokens() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "old_password", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder := suite.patchPassword(token, handlers.UserPasswordPatchRequest{
+		CurrentPassword: "old_password",
+		NewPassword:     "new_password",
+	})
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = suite.getMe(token)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	token, err = apiutils.CreateVersionedJWT(user.ID, 1, time.Second*120)
+	assert.NoError(t, err)
+
+	recorder = suite.getMe(token)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestUserSignOutAll() {
+	t := suite.T()
+
+	user := fixtures.CreateUser("", "", "", "", suite.db)
+	token, err := apiutils.CreateJWT(user.ID, time.Second*120)
+	assert.NoError(t, err)
+	otherToken, err := apiutils.CreateJWT(user.ID, time.Second*60)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/user/signout-all", nil)
+	request.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	suite.Server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = suite.getMe(token)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = suite.getMe(otherToken)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
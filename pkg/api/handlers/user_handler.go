@@ -1,12 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"errors"
-	"log"
 	"net/http"
 
 	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/config"
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
 	"github.com/go-playground/validator/v10"
@@ -15,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
@@ -67,7 +67,6 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 
 	userID, err := apiutils.GetObjectIDFromContext(c)
 	if err != nil {
-		log.Println(apiutils.HandlerErrorLogMessage(err, c))
 		// Should never happen but better safe than sorry
 		if errors.Is(err, apiutils.ErrNotAuthenticated) {
 			uh.logger.Debug("Client error",
@@ -80,7 +79,7 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 			)
 		}
 
-		uh.logger.Debug("Server error",
+		uh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(
@@ -91,7 +90,7 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 	}
 
 	model := models.NewUserModel(uh.db)
-	ur, err := model.FindByID(context.Background(), userID)
+	ur, err := model.FindByID(c.Request().Context(), userID)
 	if err != nil {
 		uh.logger.Debug("Client error",
 			zap.String("cause", err.Error()),
@@ -103,7 +102,7 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 	}
 
 	objectID, err := model.UpdateOne(
-		context.Background(),
+		c.Request().Context(),
 		userID,
 		bson.D{
 			{Key: "first_name", Value: request.FirstName},
@@ -112,7 +111,7 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 	)
 
 	if err != nil {
-		uh.logger.Debug("Server error",
+		uh.logger.Error("Server error",
 			zap.String("cause", err.Error()),
 		)
 		return apierrors.CustomError(c,
@@ -128,3 +127,184 @@ func (uh *UserHandler) PatchUser(c echo.Context) error {
 		LastName:  request.LastName,
 	})
 }
+
+type UserResponse struct {
+	ID        primitive.ObjectID `json:"_id"`
+	Email     string             `json:"email"`
+	FirstName string             `json:"first_name,omitempty"`
+	LastName  string             `json:"last_name,omitempty"`
+	CreatedAt primitive.DateTime `json:"created_at"`
+	UpdatedAt primitive.DateTime `json:"updated_at"`
+}
+
+func (uh *UserHandler) GetMe(c echo.Context) error {
+	userID, err := apiutils.GetObjectIDFromContext(c)
+	if err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	model := models.NewUserModel(uh.db)
+	ur, err := model.FindByID(c.Request().Context(), userID)
+	if err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, UserResponse{
+		ID:        ur.ID,
+		Email:     ur.Email,
+		FirstName: ur.FirstName,
+		LastName:  ur.LastName,
+		CreatedAt: ur.CreatedAt,
+		UpdatedAt: ur.UpdatedAt,
+	})
+}
+
+// PostSignOutAll revokes every token issued to the caller, including the one
+// used for this request.
+func (uh *UserHandler) PostSignOutAll(c echo.Context) error {
+	userID, err := apiutils.GetObjectIDFromContext(c)
+	if err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	model := models.NewUserModel(uh.db)
+	if err := model.RevokeTokens(c.Request().Context(), userID); err != nil {
+		uh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	uh.logger.Info("User signed out of every session",
+		zap.String("_id", userID.Hex()),
+	)
+	return c.NoContent(http.StatusNoContent)
+}
+
+type UserPasswordPatchRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+func (uh *UserHandler) PatchPassword(c echo.Context) error {
+	request := new(UserPasswordPatchRequest)
+	if err := c.Bind(request); err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userID, err := apiutils.GetObjectIDFromContext(c)
+	if err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	model := models.NewUserModel(uh.db)
+	ur, err := model.FindByID(c.Request().Context(), userID)
+	if err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(ur.Password), []byte(request.CurrentPassword)); err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusUnauthorized,
+			apierrors.UnauthorizedError,
+		)
+	}
+
+	if err := apiutils.ValidatePassword(request.NewPassword, config.Password); err != nil {
+		uh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			err.Error(),
+		)
+	}
+
+	if ur.UsedPassword(request.NewPassword, config.Password.HistorySize) {
+		uh.logger.Debug("Client error",
+			zap.String("cause", apierrors.PasswordReusedError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.PasswordReusedError,
+		)
+	}
+
+	if err := model.UpdatePassword(
+		c.Request().Context(),
+		ur,
+		request.NewPassword,
+		config.Password.HistorySize,
+	); err != nil {
+		uh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	uh.logger.Info("User changed password",
+		zap.String("_id", userID.Hex()),
+	)
+	return c.NoContent(http.StatusNoContent)
+}
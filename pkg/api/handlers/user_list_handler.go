@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	UserListNameFormField = "name"
+	UserListFileFormField = "file"
+	// MaxUserListUploadSize is the biggest CSV file accepted, comfortably
+	// above models.MaxUserListSize ids of usual lengths.
+	MaxUserListUploadSize = 1 << 20
+)
+
+type UserListHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewUserListHandler(db *mongo.Database, logger *zap.Logger) *UserListHandler {
+	return &UserListHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type ListUserListsResponse struct {
+	Data []models.UserListRecord `json:"data"`
+}
+
+// PostUserList creates a list from a multipart upload of its name and a CSV
+// file of user ids, see models.ParseUserListCSV.
+func (ulh *UserListHandler) PostUserList(c echo.Context) error {
+	organizationID, err := ulh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	name := c.FormValue(UserListNameFormField)
+	if name == "" || len(name) > 100 {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.BadRequestError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	userIDs, err := ulh.readUserIDs(c)
+	if userIDs == nil {
+		return err
+	}
+
+	record := models.NewUserListRecord(organizationID, name, userIDs)
+	model := models.NewUserListModel(ulh.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	record.UserIDs = nil
+	return c.JSON(http.StatusCreated, record)
+}
+
+func (ulh *UserListHandler) ListUserLists(c echo.Context) error {
+	organizationID, err := ulh.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	model := models.NewUserListModel(ulh.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListUserListsResponse{Data: records})
+}
+
+// GetUserList returns the list along with every one of its user ids.
+func (ulh *UserListHandler) GetUserList(c echo.Context) error {
+	organizationID, err := ulh.authorize(c, models.ReadOnly)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	userListID := apiutils.ObjectIDParam(c, "userListID")
+
+	model := models.NewUserListModel(ulh.db)
+	record, err := model.FindByID(c.Request().Context(), organizationID, userListID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ulh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, record)
+}
+
+// PutUserList replaces every user id of the list with those of an uploaded
+// CSV file, like PostUserList. The name is only changed when one is given.
+// Rules targeting the list serve the new users from then on.
+func (ulh *UserListHandler) PutUserList(c echo.Context) error {
+	organizationID, err := ulh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	userListID := apiutils.ObjectIDParam(c, "userListID")
+
+	model := models.NewUserListModel(ulh.db)
+	record, err := model.FindByID(c.Request().Context(), organizationID, userListID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			ulh.logger.Debug("Client error",
+				zap.String("cause", err.Error()),
+			)
+			return apierrors.CustomError(c,
+				http.StatusNotFound,
+				apierrors.NotFoundError,
+			)
+		}
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	name := c.FormValue(UserListNameFormField)
+	if len(name) > 100 {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.BadRequestError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+	if name == "" {
+		name = record.Name
+	}
+
+	userIDs, err := ulh.readUserIDs(c)
+	if userIDs == nil {
+		return err
+	}
+
+	found, err := model.ReplaceUserIDs(c.Request().Context(), organizationID, userListID, name, userIDs)
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	record.Name = name
+	record.Size = len(userIDs)
+	record.UserIDs = nil
+	return c.JSON(http.StatusOK, record)
+}
+
+// DeleteUserList refuses to delete a list that rules of a live or draft
+// revision, or segment overrides, still target.
+func (ulh *UserListHandler) DeleteUserList(c echo.Context) error {
+	organizationID, err := ulh.authorize(c, models.Collaborator)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	userListID := apiutils.ObjectIDParam(c, "userListID")
+
+	featureFlagModel := models.NewFeatureFlagModel(ulh.db)
+	targeting, err := featureFlagModel.CountMany(c.Request().Context(), organizationID, bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.M{"revisions": bson.M{"$elemMatch": bson.M{
+				"status":             bson.M{"$ne": models.Archived},
+				"rules.user_list_id": userListID,
+			}}},
+			bson.M{"segment_overrides.user_list_id": userListID},
+		}},
+	})
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if targeting > 0 {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.UserListInUseError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusConflict,
+			apierrors.UserListInUseError,
+		)
+	}
+
+	model := models.NewUserListModel(ulh.db)
+	found, err := model.DeleteOne(c.Request().Context(), organizationID, userListID)
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorize returns the organization in the path when the caller has at
+// least permissionLevel in it. The id is zero, and the error response already
+// written, when they don't.
+func (ulh *UserListHandler) authorize(
+	c echo.Context,
+	permissionLevel models.PermissionLevelEnum,
+) (primitive.ObjectID, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, err
+	}
+
+	organizationModel := models.NewOrganizationModel(ulh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, permissionLevel)
+	if !permission {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return organizationID, nil
+}
+
+// readUserIDs parses the uploaded CSV file. It returns nil, with the error
+// response already written, when there is no file or it isn't a valid list.
+func (ulh *UserListHandler) readUserIDs(c echo.Context) ([]string, error) {
+	fileHeader, err := c.FormFile(UserListFileFormField)
+	if err != nil {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.InvalidUserListError,
+		)
+	}
+	if fileHeader.Size > MaxUserListUploadSize {
+		ulh.logger.Debug("Client error",
+			zap.String("cause", apierrors.UserListTooLargeError),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.UserListTooLargeError,
+		)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ulh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	defer file.Close()
+
+	userIDs, err := models.ParseUserListCSV(file)
+	if err != nil {
+		message := apierrors.InvalidUserListError
+		if errors.Is(err, models.ErrUserListTooLarge) {
+			message = apierrors.UserListTooLargeError
+		}
+		ulh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return nil, apierrors.CustomError(c,
+			http.StatusBadRequest,
+			message,
+		)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	db     *mongo.Database
+	logger *zap.Logger
+}
+
+func NewWebhookHandler(db *mongo.Database, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WebhookRequest subscribes URL to Events, or to every event when it lists
+// none. URL can't point into the service's own network, see
+// webhooks.ValidateURL.
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"max=10,dive,oneof=feature_flag.updated member.added member.removed member.role_changed"`
+}
+
+// WebhookResponse is the only time the secret payloads are signed with is
+// returned.
+type WebhookResponse struct {
+	models.WebhookRecord
+	Secret string `json:"secret"`
+}
+
+type ListWebhooksResponse struct {
+	Data []models.WebhookRecord `json:"data"`
+}
+
+func (wh *WebhookHandler) PostWebhook(c echo.Context) error {
+	organizationID, err := wh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	request := new(WebhookRequest)
+	if err := c.Bind(request); err != nil {
+		wh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(
+			c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		wh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.BadRequestError,
+		)
+	}
+
+	if err := webhooks.ValidateURL(request.URL); err != nil {
+		wh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusBadRequest,
+			apierrors.ForbiddenWebhookURLError,
+		)
+	}
+
+	record, err := models.NewWebhookRecord(organizationID, request.URL, request.Events)
+	if err != nil {
+		wh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	model := models.NewWebhookModel(wh.db)
+	if _, err := model.InsertOne(c.Request().Context(), record); err != nil {
+		wh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusCreated, WebhookResponse{
+		WebhookRecord: *record,
+		Secret:        record.Secret,
+	})
+}
+
+func (wh *WebhookHandler) ListWebhooks(c echo.Context) error {
+	organizationID, err := wh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	model := models.NewWebhookModel(wh.db)
+	records, err := model.FindAllByOrganization(c.Request().Context(), organizationID)
+	if err != nil {
+		wh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	return c.JSON(http.StatusOK, ListWebhooksResponse{Data: records})
+}
+
+func (wh *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	organizationID, err := wh.authorize(c)
+	if organizationID.IsZero() {
+		return err
+	}
+
+	webhookID := apiutils.ObjectIDParam(c, "webhookID")
+
+	model := models.NewWebhookModel(wh.db)
+	found, err := model.DeleteOne(c.Request().Context(), organizationID, webhookID)
+	if err != nil {
+		wh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+	if !found {
+		wh.logger.Debug("Client error",
+			zap.String("cause", apierrors.NotFoundError),
+		)
+		return apierrors.CustomError(c,
+			http.StatusNotFound,
+			apierrors.NotFoundError,
+		)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorize returns the organization in the path when the caller is one of
+// its admins. The id is zero, and the error response already written, when
+// they aren't.
+func (wh *WebhookHandler) authorize(c echo.Context) (primitive.ObjectID, error) {
+	userID, organizationID, err := getIDsFromContext(c)
+	if err != nil {
+		wh.logger.Debug("Client error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, err
+	}
+
+	organizationModel := models.NewOrganizationModel(wh.db)
+	organizationRecord, err := organizationModel.FindByID(c.Request().Context(), organizationID)
+	if err != nil {
+		wh.logger.Error("Server error",
+			zap.String("cause", err.Error()),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(c,
+			http.StatusInternalServerError,
+			apierrors.InternalServerError,
+		)
+	}
+
+	permission := apiutils.UserHasPermission(userID, organizationRecord, models.Admin)
+	if !permission {
+		wh.logger.Debug("Client error",
+			zap.String("cause", apierrors.ForbiddenError),
+		)
+		return primitive.NilObjectID, apierrors.CustomError(
+			c,
+			http.StatusForbidden,
+			apierrors.ForbiddenError,
+		)
+	}
+
+	return organizationID, nil
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/labstack/echo/v4"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminMiddleware guards operational endpoints with a static token. When no
+// token is configured the endpoints are disabled altogether.
+func AdminMiddleware(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token == "" {
+				return apierrors.CustomError(c, http.StatusNotFound, apierrors.NotFoundError)
+			}
+
+			provided := c.Request().Header.Get(AdminTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+			}
+
+			return next(c)
+		}
+	}
+}
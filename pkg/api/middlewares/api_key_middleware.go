@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	APIKeyHeader     = "X-API-Key"
+	APIKeyContextKey = "api_key"
+)
+
+var ErrMissingAPIKey = errors.New("missing api key header")
+
+// APIKeyMiddleware authenticates SDK requests by their API key and stores
+// the key's record in the context under APIKeyContextKey.
+func APIKeyMiddleware(db *mongo.Database) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(APIKeyHeader)
+			if key == "" {
+				log.Println(apiutils.HandlerErrorLogMessage(ErrMissingAPIKey, c))
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+			}
+
+			model := models.NewAPIKeyModel(db)
+			record, err := model.FindByKey(c.Request().Context(), key)
+			if err != nil {
+				log.Println(apiutils.HandlerErrorLogMessage(err, c))
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+				}
+				return apierrors.CustomError(c, http.StatusInternalServerError, apierrors.InternalServerError)
+			}
+
+			c.Set(APIKeyContextKey, *record)
+			return next(c)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
@@ -16,6 +17,7 @@ import (
 var ErrMissingAuthHeader = errors.New("missing authorization header")
 var ErrInvalidSignMethod = errors.New("invalid signing method")
 var ErrInvalidToken = errors.New("invalid token")
+var ErrForeignTokenScope = errors.New("token issuer or audience isn't the service's")
 
 func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -59,9 +61,51 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 				})
 			}
 
-			c.Set("user", apiutils.ContextUser{
-				ID: userID,
-			})
+			// Both claims are optional, but must be strings when set: a
+			// token for several audiences isn't scoped to any of them.
+			issuer, issuerOK := claims["iss"].(string)
+			audience, audienceOK := claims["aud"].(string)
+			if (claims["iss"] != nil && !issuerOK) || (claims["aud"] != nil && !audienceOK) {
+				log.Println(apiutils.HandlerErrorLogMessage(ErrInvalidToken, c))
+				return c.JSON(http.StatusUnauthorized, apierrors.Error{
+					Error:   "invalid token scope",
+					Message: http.StatusText(http.StatusUnauthorized),
+				})
+			}
+
+			// Routes of an organization accept the scope it requires, which
+			// TokenScopeMiddleware checks; every other route only accepts
+			// the service's own.
+			scope := apiutils.NewTokenScope(issuer, audience)
+			if c.Param("organizationID") == "" && !scope.Matches(apiutils.TokenScope{}) {
+				log.Println(apiutils.HandlerErrorLogMessage(ErrForeignTokenScope, c))
+				return c.JSON(http.StatusUnauthorized, apierrors.Error{
+					Error:   ErrForeignTokenScope.Error(),
+					Message: http.StatusText(http.StatusUnauthorized),
+				})
+			}
+
+			// Tokens signed before versions existed count as version 0
+			tokenVersion, _ := claims[apiutils.TokenVersionClaim].(float64)
+			contextUser := apiutils.ContextUser{
+				ID:           userID,
+				TokenVersion: int(tokenVersion),
+				Scope:        scope,
+			}
+			if expiresAt, ok := claims["exp"].(float64); ok {
+				contextUser.ExpiresAt = time.Unix(int64(expiresAt), 0).UTC()
+			}
+			if impersonation, ok := claims[apiutils.ImpersonationClaim].(string); ok {
+				contextUser.ImpersonationID, err = primitive.ObjectIDFromHex(impersonation)
+				if err != nil {
+					log.Println(apiutils.HandlerErrorLogMessage(err, c))
+					return c.JSON(http.StatusUnauthorized, apierrors.Error{
+						Error:   "invalid token imp",
+						Message: http.StatusText(http.StatusUnauthorized),
+					})
+				}
+			}
+			c.Set("user", contextUser)
 			return next(c)
 		}
 
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ClientIPExtractor decides what c.RealIP() returns. Without trusted proxies
+// the address of the connection is used and forwarding headers are ignored,
+// since anyone can send them. With trusted proxies X-Forwarded-For is read
+// from the right, skipping the hops added by those proxies, and the first
+// address outside of them is the client; anything further left could have
+// been made up by the client.
+func ClientIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, network := range trustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Compress gzips responses of at least minLength bytes for clients that
+// accept it, and always adds Vary: Accept-Encoding so caches keep both
+// representations apart. It's meant for routes whose responses grow with the
+// organization, such as exports and environments: tiny responses aren't
+// worth the CPU.
+//
+// The ETag of a compressed response is made weak, since its bytes aren't the
+// ones it was derived from. Conditional requests still match it, see
+// apiutils.NotModified.
+func Compress(minLength int) echo.MiddlewareFunc {
+	gzip := middleware.GzipWithConfig(middleware.GzipConfig{MinLength: minLength})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		compress := gzip(next)
+
+		return func(c echo.Context) error {
+			response := c.Response()
+			response.Writer = &weakETagWriter{ResponseWriter: response.Writer}
+
+			return compress(c)
+		}
+	}
+}
+
+// weakETagWriter sits under the gzip writer, which only writes the header
+// once it decided whether to compress.
+type weakETagWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *weakETagWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		header := w.Header()
+		etag := header.Get("ETag")
+		if header.Get(echo.HeaderContentEncoding) != "" && etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *weakETagWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *weakETagWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+)
+
+// DenyImpersonation runs after AuthMiddleware and keeps impersonation tokens
+// away from routes that change the account itself, like its password.
+func DenyImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !apiutils.GetImpersonationIDFromContext(c).IsZero() {
+			return apierrors.CustomError(c, http.StatusForbidden, apierrors.ImpersonationForbiddenError)
+		}
+
+		return next(c)
+	}
+}
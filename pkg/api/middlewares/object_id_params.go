@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ObjectIDParams are the path params holding ObjectIDs on the routes of an
+// organization. userID isn't one of them: it is a member's id on member
+// routes but any user id on override routes, so member routes declare it
+// themselves.
+var ObjectIDParams = []string{
+	"organizationID",
+	"featureFlagID",
+	"revisionID",
+	"ruleID",
+	"templateID",
+	"userListID",
+	"sampleSetID",
+	"testIdentityID",
+	"webhookID",
+	"apiKeyID",
+}
+
+// ObjectIDParamsMiddleware parses the path params in names as ObjectIDs once,
+// before the handler runs, so handlers read them with apiutils.ObjectIDParam
+// without checking them again. A malformed id is rejected with a 400. Names
+// the route doesn't have are skipped, so a group can declare every id param
+// its routes use.
+func ObjectIDParamsMiddleware(names ...string) echo.MiddlewareFunc {
+	declared := make(map[string]bool, len(names))
+	for _, name := range names {
+		declared[name] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, name := range c.ParamNames() {
+				if !declared[name] {
+					continue
+				}
+
+				id, err := primitive.ObjectIDFromHex(c.Param(name))
+				if err != nil {
+					log.Println(apiutils.HandlerErrorLogMessage(err, c))
+					return apierrors.CustomError(c, http.StatusBadRequest, apierrors.InvalidObjectIDError)
+				}
+				apiutils.SetObjectIDParam(c, name, id)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+// RateLimiter counts requests per key in fixed windows: the first request of
+// a key opens a window of its own, which allows limit requests.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	nextSweep time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow counts a request of key. It returns how many requests key has left
+// in its window, and when that window ends, along with whether the request
+// is within the limit.
+func (rl *RateLimiter) Allow(key string) (int, time.Time, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+
+	current, ok := rl.windows[key]
+	if !ok || !now.Before(current.start.Add(rl.window)) {
+		current = &rateWindow{start: now}
+		rl.windows[key] = current
+	}
+	reset := current.start.Add(rl.window)
+
+	if current.count >= rl.limit {
+		return 0, reset, false
+	}
+	current.count++
+
+	return rl.limit - current.count, reset, true
+}
+
+// sweep drops ended windows once per window, so keys that stopped calling
+// don't stay around.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Before(rl.nextSweep) {
+		return
+	}
+
+	for key, current := range rl.windows {
+		if !now.Before(current.start.Add(rl.window)) {
+			delete(rl.windows, key)
+		}
+	}
+	rl.nextSweep = now.Add(rl.window)
+}
+
+// RateLimitMiddleware limits requests per caller and organization, or per API
+// key on SDK routes. Buckets are kept per caller, as the limit runs before the
+// handler checks membership: a stranger to an organization must not be able
+// to spend its members' budget.
+//
+// Every response carries the limit and what is left of it, so clients can
+// slow down before they get a 429, which also tells them when to retry.
+// Requests to no organization aren't limited.
+func RateLimitMiddleware(limiter *RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, ok := rateLimitKey(c)
+			if !ok {
+				return next(c)
+			}
+
+			remaining, reset, allowed := limiter.Allow(key)
+			header := c.Response().Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(limiter.limit))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(remaining))
+
+			if !allowed {
+				retryAfter := int(time.Until(reset).Round(time.Second).Seconds())
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+				log.Println(apiutils.HandlerErrorLogMessage(ErrRateLimitExceeded, c))
+				return apierrors.CustomError(c, http.StatusTooManyRequests, apierrors.RateLimitExceededError)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func rateLimitKey(c echo.Context) (string, bool) {
+	if apiKey, ok := c.Get(APIKeyContextKey).(models.APIKeyRecord); ok {
+		return "api_key:" + apiKey.ID.Hex(), true
+	}
+	organizationID, err := primitive.ObjectIDFromHex(c.Param("organizationID"))
+	if err != nil {
+		return "", false
+	}
+	key := "organization:" + organizationID.Hex()
+	if contextUser, ok := c.Get("user").(apiutils.ContextUser); ok {
+		key += ":user:" + contextUser.ID.Hex()
+	}
+
+	return key, true
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/labstack/echo/v4"
+)
+
+// ReadOnlyMode is the process-wide maintenance switch. It is safe to toggle
+// while requests are in flight.
+type ReadOnlyMode struct {
+	enabled atomic.Bool
+}
+
+func NewReadOnlyMode(enabled bool) *ReadOnlyMode {
+	mode := &ReadOnlyMode{}
+	mode.enabled.Store(enabled)
+
+	return mode
+}
+
+func (m *ReadOnlyMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+func (m *ReadOnlyMode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// ReadOnlyMiddleware rejects mutating requests with 503 while the mode is
+// enabled. Routes listed in allowed (matched against the registered route
+// path) are let through regardless of method.
+func ReadOnlyMiddleware(mode *ReadOnlyMode, allowed ...string) echo.MiddlewareFunc {
+	allowedPaths := make(map[string]struct{}, len(allowed))
+	for _, path := range allowed {
+		allowedPaths[path] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !mode.Enabled() {
+				return next(c)
+			}
+
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+
+			if _, ok := allowedPaths[c.Path()]; ok {
+				return next(c)
+			}
+
+			c.Response().Header().Set(echo.HeaderRetryAfter, "60")
+			return apierrors.CustomError(c, http.StatusServiceUnavailable, apierrors.ReadOnlyModeError)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -40,6 +41,10 @@ func ZapLogger(log *zap.Logger) echo.MiddlewareFunc {
 				zap.Int64("bytes_out", response.Size),
 			}
 
+			if impersonationID := apiutils.GetImpersonationIDFromContext(c); !impersonationID.IsZero() {
+				fields = append(fields, zap.String("impersonation_id", impersonationID.Hex()))
+			}
+
 			status := response.Status
 			switch {
 			case status >= http.StatusInternalServerError:
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTimeout gives every request a deadline of timeout. Handlers pass the
+// request context to their queries, so a query still running at the deadline
+// is cancelled and the request answers 503 rather than hang on a stalled
+// database.
+func RequestTimeout(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if c.Response().Committed || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return err
+			}
+
+			return apierrors.CustomError(c, http.StatusServiceUnavailable, apierrors.RequestTimeoutError)
+		}
+	}
+}
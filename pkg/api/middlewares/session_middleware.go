@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrRevokedToken = errors.New("token was revoked")
+
+// SessionMiddleware runs after AuthMiddleware and rejects tokens whose
+// version no longer matches the user's, i.e. tokens issued before a password
+// change or a sign out of every session.
+func SessionMiddleware(db *mongo.Database) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			contextUser, ok := c.Get("user").(apiutils.ContextUser)
+			if !ok {
+				log.Println(apiutils.HandlerErrorLogMessage(apiutils.ErrContextUserTypeAssertion, c))
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+			}
+
+			model := models.NewUserModel(db)
+			user, err := model.FindByID(c.Request().Context(), contextUser.ID)
+			if err != nil {
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					log.Println(apiutils.HandlerErrorLogMessage(err, c))
+					return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+				}
+				log.Println(apiutils.HandlerErrorLogMessage(err, c))
+				return apierrors.CustomError(c, http.StatusInternalServerError, apierrors.InternalServerError)
+			}
+
+			if user.TokenVersion != contextUser.TokenVersion {
+				log.Println(apiutils.HandlerErrorLogMessage(ErrRevokedToken, c))
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+			}
+
+			return next(c)
+		}
+	}
+}
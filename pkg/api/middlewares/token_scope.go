@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	apierrors "github.com/Roll-Play/togglelabs/pkg/api/error"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrTokenScopeMismatch = errors.New("token issuer or audience doesn't match the organization's")
+
+// TokenScopeMiddleware runs after AuthMiddleware on routes of an organization
+// and rejects tokens whose issuer or audience isn't the one the organization
+// requires, see models.OrganizationSettings.TokenIssuer. Unknown
+// organizations are left for the handler to report.
+func TokenScopeMiddleware(db *mongo.Database) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			organizationID, err := primitive.ObjectIDFromHex(c.Param("organizationID"))
+			if err != nil {
+				return next(c)
+			}
+
+			contextUser, ok := c.Get("user").(apiutils.ContextUser)
+			if !ok {
+				log.Println(apiutils.HandlerErrorLogMessage(apiutils.ErrContextUserTypeAssertion, c))
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.UnauthorizedError)
+			}
+
+			model := models.NewOrganizationModel(db)
+			organization, err := model.FindByID(c.Request().Context(), organizationID)
+			if err != nil {
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					return next(c)
+				}
+				log.Println(apiutils.HandlerErrorLogMessage(err, c))
+				return apierrors.CustomError(c, http.StatusInternalServerError, apierrors.InternalServerError)
+			}
+
+			expected := apiutils.NewTokenScope(organization.Settings.TokenIssuer, organization.Settings.TokenAudience)
+			if !contextUser.Scope.Matches(expected) {
+				log.Println(apiutils.HandlerErrorLogMessage(ErrTokenScopeMismatch, c))
+				return apierrors.CustomError(c, http.StatusUnauthorized, apierrors.TokenScopeMismatchError)
+			}
+
+			return next(c)
+		}
+	}
+}
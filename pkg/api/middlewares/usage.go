@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UsageRecorder counts the calls made to an organization's API, see
+// workers.UsageWorker. RecordRequest is called while the request is being
+// served, so it must not block on I/O.
+type UsageRecorder interface {
+	RecordRequest(organizationID primitive.ObjectID, status int, latency time.Duration, at time.Time)
+}
+
+// UsageMiddleware records every request to an organization's routes with its
+// status and latency. The organization is the one in the path or, on SDK
+// routes, the one of the API key. Requests refused for lack of access aren't
+// counted, so outsiders can't add to an organization's usage.
+func UsageMiddleware(recorder UsageRecorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			organizationID, ok := usageOrganization(c)
+			if !ok {
+				return err
+			}
+
+			status := c.Response().Status
+			if !c.Response().Committed && err != nil {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			if status == http.StatusUnauthorized || status == http.StatusForbidden {
+				return err
+			}
+
+			recorder.RecordRequest(organizationID, status, time.Since(start), start)
+			return err
+		}
+	}
+}
+
+func usageOrganization(c echo.Context) (primitive.ObjectID, bool) {
+	if organizationID, err := primitive.ObjectIDFromHex(c.Param("organizationID")); err == nil {
+		return organizationID, true
+	}
+	if apiKey, ok := c.Get(APIKeyContextKey).(models.APIKeyRecord); ok {
+		return apiKey.OrganizationID, true
+	}
+
+	return primitive.NilObjectID, false
+}
@@ -1,23 +1,204 @@
 package api
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
 	"github.com/Roll-Play/togglelabs/pkg/api/handlers"
 	"github.com/Roll-Play/togglelabs/pkg/api/middlewares"
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/kafka"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/nats"
+	"github.com/Roll-Play/togglelabs/pkg/relay"
 	"github.com/Roll-Play/togglelabs/pkg/storage"
 	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/Roll-Play/togglelabs/pkg/workers"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
 type App struct {
-	port    string
-	server  *echo.Echo
-	storage *storage.MongoStorage
-	logger  *zap.Logger
+	port      string
+	server    *echo.Echo
+	storage   *storage.MongoStorage
+	logger    *zap.Logger
+	buildInfo config.BuildInfo
+	readOnly  *middlewares.ReadOnlyMode
+	workers   *workers.Registry
+	relay     *relay.Client
+	analytics *analytics.BatchSink
+	kafka     *kafka.Producer
+	nats      *nats.Client
+
+	lastEvaluated    *workers.LastEvaluatedWorker
+	evaluationCounts *workers.EvaluationCountsWorker
+	usage            *workers.UsageWorker
+	webhooks         *webhooks.Dispatcher
+}
+
+// Workers is where background workers register their heartbeat.
+func (a *App) Workers() *workers.Registry {
+	return a.workers
+}
+
+// StartWorkers runs the background workers until ctx is done.
+func (a *App) StartWorkers(ctx context.Context) {
+	if a.analytics != nil {
+		go a.analytics.Run(ctx)
+	}
+	if a.kafka != nil {
+		go a.kafka.Run(ctx)
+	}
+
+	if a.nats != nil {
+		go func() {
+			<-ctx.Done()
+			a.nats.Close()
+		}()
+	}
+
+	if a.relay != nil {
+		relaySync := workers.NewRelaySyncWorker(a.relay, a.logger, a.workers, config.Relay.SyncInterval)
+		if a.nats != nil {
+			err := a.nats.SubscribeChanges(config.Relay.OrganizationID, func(webhooks.FeatureFlagPayload) {
+				relaySync.Trigger()
+			})
+			if err != nil {
+				a.logger.Error("NATS error",
+					zap.String("cause", err.Error()),
+				)
+			}
+		}
+		go relaySync.Run(ctx)
+		return
+	}
+
+	rolloutRamp := workers.NewRolloutRampWorker(
+		a.storage.DB(),
+		a.logger,
+		a.workers,
+		workers.DefaultRolloutRampInterval,
+	)
+	go rolloutRamp.Run(ctx)
+	go a.lastEvaluated.Run(ctx)
+	go a.evaluationCounts.Run(ctx)
+	go a.usage.Run(ctx)
+	go a.webhooks.Run(ctx)
+}
+
+// newAnalyticsSink builds the sink set up in config.Analytics, or returns nil
+// when analytics are off.
+func newAnalyticsSink(logger *zap.Logger) *analytics.BatchSink {
+	if !config.Analytics.Enabled() {
+		return nil
+	}
+
+	var destination analytics.Destination
+	switch config.Analytics.Destination {
+	case config.FileAnalyticsDestination:
+		destination = analytics.NewFileDestination(config.Analytics.Path)
+	default:
+		destination = analytics.NewHTTPDestination(
+			&http.Client{Timeout: config.DBFetchTimeout * time.Second},
+			config.Analytics.URL,
+		)
+	}
+
+	return analytics.NewBatchSink(destination, logger, config.Analytics.BatchSize, config.Analytics.FlushInterval)
+}
+
+// newKafkaProducer builds the producer set up in config.Kafka, or returns nil
+// when Kafka is off.
+func newKafkaProducer(logger *zap.Logger) *kafka.Producer {
+	if !config.Kafka.Enabled() {
+		return nil
+	}
+
+	return kafka.NewProducer(
+		kafka.NewWriter(config.Kafka.Brokers, logger),
+		logger,
+		config.Kafka.ChangesTopic,
+		config.Kafka.EvaluationsTopic,
+	)
+}
+
+// newNATSClient connects to the server set up in config.NATS, or returns nil
+// when NATS is off or the connection can't be set up.
+func newNATSClient(logger *zap.Logger) *nats.Client {
+	if !config.NATS.Enabled() {
+		return nil
+	}
+
+	conn, err := nats.Connect(config.NATS.URL, logger)
+	if err != nil {
+		logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+		)
+		return nil
+	}
+
+	return nats.NewClient(conn, logger, config.NATS.SubjectPrefix).
+		WithOrganizationPrefixes(config.NATS.OrganizationPrefixes)
+}
+
+// analyticsSink is where handlers record evaluations.
+func (a *App) analyticsSink() analytics.AnalyticsSink {
+	sinks := make(analytics.MultiSink, 0, 5)
+	if a.lastEvaluated != nil {
+		sinks = append(sinks, a.lastEvaluated)
+	}
+	if a.evaluationCounts != nil {
+		sinks = append(sinks, a.evaluationCounts)
+	}
+	if a.usage != nil {
+		sinks = append(sinks, a.usage)
+	}
+	if a.analytics != nil {
+		sinks = append(sinks, a.analytics)
+	}
+	if a.kafka != nil {
+		sinks = append(sinks, a.kafka)
+	}
+
+	switch len(sinks) {
+	case 0:
+		return analytics.NopSink{}
+	case 1:
+		return sinks[0]
+	default:
+		return sinks
+	}
+}
+
+// changePublisher is where handlers publish flag and organization changes.
+func (a *App) changePublisher() webhooks.Publisher {
+	publishers := make(webhooks.MultiPublisher, 0, 3)
+	if a.webhooks != nil {
+		publishers = append(publishers, a.webhooks)
+	}
+	if a.kafka != nil {
+		publishers = append(publishers, a.kafka)
+	}
+	if a.nats != nil {
+		publishers = append(publishers, a.nats)
+	}
+
+	switch len(publishers) {
+	case 0:
+		return webhooks.NopPublisher{}
+	case 1:
+		return publishers[0]
+	default:
+		return publishers
+	}
 }
 
 func (a *App) Listen() error {
@@ -32,25 +213,158 @@ func normalizePort(port string) string {
 	return port
 }
 
-func NewApp(port string, storage *storage.MongoStorage, logger *zap.Logger) *App {
+func NewApp(
+	port string,
+	storage *storage.MongoStorage,
+	logger *zap.Logger,
+	buildInfo config.BuildInfo,
+) *App {
 	server := echo.New()
+	server.IPExtractor = middlewares.ClientIPExtractor(config.TrustedProxies)
 
 	app := &App{
-		server:  server,
-		port:    normalizePort(port),
-		storage: storage,
-		logger:  logger,
+		server:    server,
+		port:      normalizePort(port),
+		storage:   storage,
+		logger:    logger,
+		buildInfo: buildInfo,
+		readOnly:  middlewares.NewReadOnlyMode(config.ReadOnly),
+		workers:   workers.NewRegistry(),
+		analytics: newAnalyticsSink(logger),
+		kafka:     newKafkaProducer(logger),
+		nats:      newNATSClient(logger),
 	}
+	app.lastEvaluated = workers.NewLastEvaluatedWorker(
+		models.NewFeatureFlagModel(storage.DB()),
+		logger,
+		app.workers,
+		workers.DefaultLastEvaluatedInterval,
+	)
+	app.evaluationCounts = workers.NewEvaluationCountsWorker(
+		models.NewFeatureFlagModel(storage.DB()),
+		logger,
+		app.workers,
+		workers.DefaultEvaluationCountsInterval,
+	)
+	app.usage = workers.NewUsageWorker(
+		models.NewUsageModel(storage.DB()),
+		logger,
+		app.workers,
+		workers.DefaultUsageInterval,
+	)
+	app.webhooks = webhooks.NewDispatcher(models.NewWebhookModel(storage.DB()), webhooks.NewClient(), logger)
 	app.server.Use(middlewares.ZapLogger(logger))
+	app.server.Use(middlewares.RequestTimeout(config.RequestTimeout))
+	app.server.Use(middlewares.ReadOnlyMiddleware(
+		app.readOnly,
+		readOnlyAdminPath,
+		evaluateBatchPath,
+		validateFeatureFlagPath,
+		dryRunFeatureFlagPath,
+	))
 
 	registerRoutes(app)
 
 	return app
 }
 
+// NewRelayApp builds an edge relay that serves evaluations of the organization
+// set in relayConfig from memory, without a database of its own.
+func NewRelayApp(
+	port string,
+	logger *zap.Logger,
+	buildInfo config.BuildInfo,
+	relayConfig config.RelayConfig,
+) (*App, error) {
+	organizationID, err := primitive.ObjectIDFromHex(relayConfig.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	server := echo.New()
+	server.IPExtractor = middlewares.ClientIPExtractor(config.TrustedProxies)
+	store := relay.NewStore()
+
+	app := &App{
+		server:    server,
+		port:      normalizePort(port),
+		logger:    logger,
+		buildInfo: buildInfo,
+		workers:   workers.NewRegistry(),
+		relay: relay.NewClient(
+			&http.Client{Timeout: config.DBFetchTimeout * time.Second},
+			relayConfig.UpstreamURL,
+			relayConfig.APIKey,
+			store,
+		),
+		analytics: newAnalyticsSink(logger),
+		kafka:     newKafkaProducer(logger),
+		nats:      newNATSClient(logger),
+	}
+	app.server.Use(middlewares.ZapLogger(logger))
+	app.server.Use(middlewares.RequestTimeout(config.RequestTimeout))
+
+	app.server.GET("/healthz", handlers.HealthHandler)
+
+	versionHandler := handlers.NewVersionHandler(app.buildInfo)
+	app.server.GET("/version", versionHandler.GetVersion)
+
+	workersHandler := handlers.NewWorkersHandler(app.workers)
+	app.server.GET("/workers/status", workersHandler.GetStatus)
+
+	relayHandler := handlers.NewRelayHandler(store, organizationID, logger).WithAnalytics(app.analyticsSink())
+	app.server.GET(
+		"/organizations/:organizationID/env",
+		relayHandler.GetFeatureFlagEnv,
+		middlewares.AuthMiddleware,
+		middlewares.ObjectIDParamsMiddleware("organizationID"),
+		middlewares.Compress(config.CompressionMinLength),
+	)
+
+	return app, nil
+}
+
+const (
+	readOnlyAdminPath = "/admin/read-only"
+	// evaluateBatchPath is a POST that only reads, so it stays available in
+	// read-only mode.
+	evaluateBatchPath = "/organizations/:organizationID/feature-flags/:flagName/evaluate-batch"
+	// validateFeatureFlagPath is a POST that checks a flag without saving it,
+	// so it stays available too.
+	validateFeatureFlagPath = "/organizations/:organizationID/feature-flags/validate"
+	// dryRunFeatureFlagPath is a POST that evaluates a flag without recording
+	// the evaluation anywhere, so it stays available too.
+	dryRunFeatureFlagPath = "/organizations/:organizationID/feature-flags/:featureFlagID/dry-run"
+)
+
 func registerRoutes(app *App) {
 	app.server.GET("/healthz", handlers.HealthHandler)
 
+	versionHandler := handlers.NewVersionHandler(app.buildInfo)
+	app.server.GET("/version", versionHandler.GetVersion)
+
+	workersHandler := handlers.NewWorkersHandler(app.workers)
+	app.server.GET("/workers/status", workersHandler.GetStatus)
+
+	adminHandler := handlers.NewAdminHandler(app.readOnly, app.logger)
+	adminMiddleware := middlewares.AdminMiddleware(config.AdminToken)
+	// compress is for routes whose responses grow with the organization.
+	compress := middlewares.Compress(config.CompressionMinLength)
+	rateLimit := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	if config.RateLimit.Enabled() {
+		rateLimit = middlewares.RateLimitMiddleware(
+			middlewares.NewRateLimiter(config.RateLimit.Requests, config.RateLimit.Window),
+		)
+	}
+	app.server.GET(readOnlyAdminPath, adminHandler.GetReadOnly, adminMiddleware)
+	app.server.PUT(readOnlyAdminPath, adminHandler.PutReadOnly, adminMiddleware)
+
+	impersonationHandler := handlers.NewImpersonationHandler(app.storage.DB(), app.logger)
+	app.server.POST("/admin/impersonations", impersonationHandler.PostImpersonation, adminMiddleware)
+
+	consistencyHandler := handlers.NewConsistencyHandler(app.storage.DB(), app.logger)
+	app.server.POST("/admin/consistency-check", consistencyHandler.PostConsistencyCheck, adminMiddleware)
+
 	oauthConfig := &oauth2.Config{
 		RedirectURL:  os.Getenv("REDIRECT_URL"),
 		ClientID:     os.Getenv("CLIENT_ID"),
@@ -75,25 +389,281 @@ func registerRoutes(app *App) {
 	signInHandler := handlers.NewSignInHandler(app.storage.DB(), app.logger)
 	app.server.POST("/signin", signInHandler.PostSignIn)
 
+	sessionMiddleware := middlewares.SessionMiddleware(app.storage.DB())
+	// objectIDParams rejects malformed ids in the path before any handler
+	// looks them up.
+	objectIDParams := middlewares.ObjectIDParamsMiddleware(middlewares.ObjectIDParams...)
+	memberIDParam := middlewares.ObjectIDParamsMiddleware("userID")
+
 	userHandler := handlers.NewUserHandler(app.storage.DB(), app.logger)
-	userGroup := app.server.Group("/user", middlewares.AuthMiddleware)
+	userGroup := app.server.Group(
+		"/user",
+		middlewares.AuthMiddleware,
+		sessionMiddleware,
+		middlewares.DenyImpersonation,
+	)
 	userGroup.PATCH("", userHandler.PatchUser)
+	userGroup.PATCH("/password", userHandler.PatchPassword)
+	userGroup.POST("/signout-all", userHandler.PostSignOutAll)
+	app.server.GET("/users/me", userHandler.GetMe, middlewares.AuthMiddleware, sessionMiddleware)
 
-	organizationHandler := handlers.NewOrganizationHandler(app.storage.DB(), app.logger)
-	organizationGroup := app.server.Group("/organizations", middlewares.AuthMiddleware)
+	organizationHandler := handlers.NewOrganizationHandler(app.storage.DB(), app.logger).
+		WithChangePublisher(app.changePublisher())
+	app.server.PUT(
+		"/admin/organizations/:organizationID/limits",
+		organizationHandler.PutLimits,
+		adminMiddleware,
+		objectIDParams,
+	)
+	// Exchanging a session token for one of the organization's scope can't
+	// require that scope already. Impersonation sessions keep their own
+	// token.
+	app.server.POST(
+		"/organizations/:organizationID/token",
+		organizationHandler.PostOrganizationToken,
+		middlewares.AuthMiddleware,
+		sessionMiddleware,
+		middlewares.DenyImpersonation,
+		objectIDParams,
+	)
+	organizationGroup := app.server.Group(
+		"/organizations",
+		middlewares.AuthMiddleware,
+		sessionMiddleware,
+		objectIDParams,
+		middlewares.TokenScopeMiddleware(app.storage.DB()),
+		middlewares.UsageMiddleware(app.usage),
+		rateLimit,
+	)
 	organizationGroup.POST("", organizationHandler.PostOrganization)
+	organizationGroup.GET("", organizationHandler.ListOrganizations)
+	organizationGroup.PUT("/:organizationID/context-schema", organizationHandler.PutContextSchema)
+	organizationGroup.GET("/:organizationID/whoami", organizationHandler.GetWhoAmI)
+	organizationGroup.GET("/:organizationID/settings", organizationHandler.GetSettings)
+	organizationGroup.GET("/:organizationID/dashboard", organizationHandler.GetDashboard)
+	organizationGroup.GET("/:organizationID/usage", organizationHandler.GetUsage)
+	organizationGroup.PATCH("/:organizationID/settings", organizationHandler.PatchSettings)
+	organizationGroup.POST("/:organizationID/automation/pause", organizationHandler.PauseAutomation)
+	organizationGroup.POST("/:organizationID/automation/resume", organizationHandler.ResumeAutomation)
+	organizationGroup.PUT("/:organizationID/members/:userID", organizationHandler.PutMember, memberIDParam)
+	organizationGroup.DELETE("/:organizationID/members/:userID", organizationHandler.DeleteMember, memberIDParam)
 
-	featureFlagHandler := handlers.NewFeatureFlagHandler(app.storage.DB(), app.logger)
+	webhookHandler := handlers.NewWebhookHandler(app.storage.DB(), app.logger)
+	organizationGroup.POST("/:organizationID/webhooks", webhookHandler.PostWebhook)
+	organizationGroup.GET("/:organizationID/webhooks", webhookHandler.ListWebhooks)
+	organizationGroup.DELETE("/:organizationID/webhooks/:webhookID", webhookHandler.DeleteWebhook)
+
+	auditLogHandler := handlers.NewAuditLogHandler(app.storage.DB(), app.logger)
+	organizationGroup.GET("/:organizationID/audit-log", auditLogHandler.ListAuditLog)
+
+	apiKeyHandler := handlers.NewAPIKeyHandler(app.storage.DB(), app.logger)
+	// API keys outlive any session, so impersonation sessions can't mint or
+	// revoke them.
+	organizationGroup.POST("/:organizationID/api-keys", apiKeyHandler.PostAPIKey, middlewares.DenyImpersonation)
+	organizationGroup.GET("/:organizationID/api-keys", apiKeyHandler.ListAPIKeys)
+	organizationGroup.DELETE(
+		"/:organizationID/api-keys/:apiKeyID",
+		apiKeyHandler.DeleteAPIKey,
+		middlewares.DenyImpersonation,
+	)
+
+	contextSampleSetHandler := handlers.NewContextSampleSetHandler(app.storage.DB(), app.logger)
+	organizationGroup.POST("/:organizationID/context-samples", contextSampleSetHandler.PostContextSampleSet)
+	organizationGroup.GET("/:organizationID/context-samples", contextSampleSetHandler.ListContextSampleSets)
+	organizationGroup.GET("/:organizationID/context-samples/:sampleSetID", contextSampleSetHandler.GetContextSampleSet)
+	organizationGroup.PUT("/:organizationID/context-samples/:sampleSetID", contextSampleSetHandler.PutContextSampleSet)
+	organizationGroup.DELETE(
+		"/:organizationID/context-samples/:sampleSetID",
+		contextSampleSetHandler.DeleteContextSampleSet,
+	)
+
+	testIdentityHandler := handlers.NewTestIdentityHandler(app.storage.DB(), app.logger)
+	organizationGroup.POST("/:organizationID/test-identities", testIdentityHandler.PostTestIdentity)
+	organizationGroup.GET("/:organizationID/test-identities", testIdentityHandler.ListTestIdentities)
+	organizationGroup.GET("/:organizationID/test-identities/:testIdentityID", testIdentityHandler.GetTestIdentity)
+	organizationGroup.PUT("/:organizationID/test-identities/:testIdentityID", testIdentityHandler.PutTestIdentity)
+	organizationGroup.DELETE(
+		"/:organizationID/test-identities/:testIdentityID",
+		testIdentityHandler.DeleteTestIdentity,
+	)
+
+	userListHandler := handlers.NewUserListHandler(app.storage.DB(), app.logger)
+	organizationGroup.POST("/:organizationID/user-lists", userListHandler.PostUserList)
+	organizationGroup.GET("/:organizationID/user-lists", userListHandler.ListUserLists)
+	organizationGroup.GET("/:organizationID/user-lists/:userListID", userListHandler.GetUserList)
+	organizationGroup.PUT("/:organizationID/user-lists/:userListID", userListHandler.PutUserList)
+	organizationGroup.DELETE("/:organizationID/user-lists/:userListID", userListHandler.DeleteUserList)
+
+	featureFlagHandler := handlers.NewFeatureFlagHandler(app.storage.DB(), app.logger).
+		WithAnalytics(app.analyticsSink()).
+		WithChangePublisher(app.changePublisher())
 	organizationGroup.POST("/:organizationID/feature-flags", featureFlagHandler.PostFeatureFlag)
-	organizationGroup.PATCH("/:organizationID/feature-flags/featureFlagID", featureFlagHandler.PatchFeatureFlag)
-	organizationGroup.GET("/:organizationID/feature-flags", featureFlagHandler.ListFeatureFlags)
+	organizationGroup.POST("/:organizationID/feature-flags/boolean", featureFlagHandler.PostBooleanFeatureFlag)
+	organizationGroup.POST("/:organizationID/feature-flags/validate", featureFlagHandler.ValidateFeatureFlag)
+	organizationGroup.POST(
+		"/:organizationID/feature-flags/from-template/:templateID",
+		featureFlagHandler.PostFeatureFlagFromTemplate,
+	)
+	organizationGroup.POST("/:organizationID/feature-flag-templates", featureFlagHandler.PostFeatureFlagTemplate)
+	organizationGroup.GET("/:organizationID/feature-flag-templates", featureFlagHandler.ListFeatureFlagTemplates)
+	organizationGroup.GET(
+		"/:organizationID/feature-flag-templates/:templateID",
+		featureFlagHandler.GetFeatureFlagTemplate,
+	)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flag-templates/:templateID",
+		featureFlagHandler.PutFeatureFlagTemplate,
+	)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flag-templates/:templateID",
+		featureFlagHandler.DeleteFeatureFlagTemplate,
+	)
+	organizationGroup.PATCH("/:organizationID/feature-flags/:featureFlagID", featureFlagHandler.PatchFeatureFlag)
+	organizationGroup.GET("/:organizationID/feature-flags", featureFlagHandler.ListFeatureFlags, compress)
 	organizationGroup.PATCH(
-		"/:organizationID/feature-flags/featureFlagID/revisions/:revisionID",
+		"/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID",
 		featureFlagHandler.ApproveRevision,
 	)
-	organizationGroup.DELETE("/:organizationID/feature-flags/featureFlagID", featureFlagHandler.DeleteFeatureFlag)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID",
+		featureFlagHandler.DeleteRevision,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID/preview",
+		featureFlagHandler.PreviewRevision,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/revisions/compare",
+		featureFlagHandler.CompareRevisions,
+	)
+	organizationGroup.POST(
+		"/:organizationID/feature-flags/:featureFlagID/dry-run",
+		featureFlagHandler.DryRunFeatureFlag,
+	)
+	organizationGroup.POST(
+		"/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID/comments",
+		featureFlagHandler.PostRevisionComment,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/revisions/:revisionID/comments",
+		featureFlagHandler.ListRevisionComments,
+	)
+	organizationGroup.DELETE("/:organizationID/feature-flags/:featureFlagID", featureFlagHandler.DeleteFeatureFlag)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flags/:featureFlagID/rollout",
+		featureFlagHandler.PutRollout,
+	)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flags/:featureFlagID/rollout",
+		featureFlagHandler.DeleteRollout,
+	)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flags/:featureFlagID/guard",
+		featureFlagHandler.PutGuard,
+	)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flags/:featureFlagID/guard",
+		featureFlagHandler.DeleteGuard,
+	)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flags/:featureFlagID/shadow",
+		featureFlagHandler.PutShadow,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/shadow",
+		featureFlagHandler.GetShadow,
+	)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flags/:featureFlagID/shadow",
+		featureFlagHandler.DeleteShadow,
+	)
+	organizationGroup.POST(
+		"/:organizationID/feature-flags/:featureFlagID/error-signals",
+		featureFlagHandler.PostErrorSignal,
+	)
+	organizationGroup.PATCH(
+		"/:organizationID/feature-flags/:featureFlagID/archive",
+		featureFlagHandler.ArchiveFeatureFlag,
+	)
+	organizationGroup.PATCH(
+		"/:organizationID/feature-flags/:featureFlagID/unarchive",
+		featureFlagHandler.UnarchiveFeatureFlag,
+	)
 	organizationGroup.PATCH(
 		"/:organizationID/feature-flags/:featureFlagID/rollback",
 		featureFlagHandler.RollbackFeatureFlagVersion,
 	)
+	organizationGroup.GET("/:organizationID/feature-flags/export", featureFlagHandler.ExportFeatureFlags, compress)
+	organizationGroup.POST("/:organizationID/feature-flags/tags/add", featureFlagHandler.PostBulkAddTag)
+	organizationGroup.POST("/:organizationID/feature-flags/tags/remove", featureFlagHandler.PostBulkRemoveTag)
+	organizationGroup.POST("/:organizationID/feature-flags/import", featureFlagHandler.ImportFeatureFlags)
+	organizationGroup.POST("/:organizationID/apply", featureFlagHandler.ApplyFeatureFlags)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/dependencies",
+		featureFlagHandler.GetFeatureFlagDependencies,
+	)
+	organizationGroup.GET("/:organizationID/env", featureFlagHandler.GetFeatureFlagEnv, compress)
+	organizationGroup.POST(
+		"/:organizationID/feature-flags/:flagName/evaluate-batch",
+		featureFlagHandler.EvaluateBatch,
+		compress,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/evaluate-by-id",
+		featureFlagHandler.EvaluateByID,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/environments",
+		featureFlagHandler.ListFeatureFlagEnvironments,
+	)
+	organizationGroup.GET(
+		"/:organizationID/feature-flags/:featureFlagID/stats",
+		featureFlagHandler.GetFeatureFlagStats,
+	)
+	app.server.GET(
+		"/env",
+		featureFlagHandler.GetAPIKeyEnv,
+		middlewares.APIKeyMiddleware(app.storage.DB()),
+		middlewares.UsageMiddleware(app.usage),
+		rateLimit,
+		compress,
+	)
+	// What relays mirror, see relay.Client.
+	app.server.GET(
+		"/feature-flags",
+		featureFlagHandler.ListAPIKeyFeatureFlags,
+		middlewares.APIKeyMiddleware(app.storage.DB()),
+		middlewares.UsageMiddleware(app.usage),
+		rateLimit,
+		compress,
+	)
+	// Flags of every organization of the caller; it checks their access to
+	// each organization itself.
+	app.server.GET(
+		"/users/me/feature-flags",
+		featureFlagHandler.ListAccessibleFeatureFlags,
+		middlewares.AuthMiddleware,
+		sessionMiddleware,
+		compress,
+	)
+	organizationGroup.PATCH(
+		"/:organizationID/feature-flags/:featureFlagID/rules/order",
+		featureFlagHandler.ReorderRules,
+	)
+	organizationGroup.PATCH(
+		"/:organizationID/feature-flags/:featureFlagID/rules/:ruleID",
+		featureFlagHandler.ToggleRule,
+	)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flags/:featureFlagID/overrides/:userID",
+		featureFlagHandler.PutOverride,
+	)
+	organizationGroup.DELETE(
+		"/:organizationID/feature-flags/:featureFlagID/overrides/:userID",
+		featureFlagHandler.DeleteOverride,
+	)
+	organizationGroup.PUT(
+		"/:organizationID/feature-flags/:featureFlagID/segment-overrides",
+		featureFlagHandler.PutSegmentOverrides,
+	)
 }
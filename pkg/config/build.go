@@ -0,0 +1,7 @@
+package config
+
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildTime string
+}
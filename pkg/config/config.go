@@ -1,11 +1,18 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
 	DBConnectionTimeout   = 10
 	DBFetchTimeout        = 5
-	JWTExpireTime         = 60 * 60 * 1000 * 24
 	BCryptCost            = 8
 	TestDBName            = "togglelabs_test"
 	DevEnvironment        = "DEV"
@@ -14,13 +21,836 @@ const (
 
 var Environment string
 
-func StartEnvironment() {
+// LogLevel holds the raw LOG_LEVEL value (debug, info, warn, error).
+// When empty the logger falls back to the environment default.
+var LogLevel string
+
+// PasswordPolicy is enforced every time a user picks a password.
+// HistorySize is how many previous passwords, besides the current one, can't
+// be picked again; zero allows any password but the current one.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireDigit     bool
+	RequireSymbol    bool
+	RequireMixedCase bool
+	HistorySize      int
+}
+
+const (
+	DefaultPasswordMinLength   = 8
+	DefaultPasswordHistorySize = 5
+	// MaxPasswordHistorySize caps the hashes kept per user.
+	MaxPasswordHistorySize = 24
+)
+
+var Password = PasswordPolicy{
+	MinLength:   DefaultPasswordMinLength,
+	HistorySize: DefaultPasswordHistorySize,
+}
+
+// RuleLimitsConfig caps what a single revision can hold, so a huge rule set
+// can't slow down every evaluation of the flag.
+// MaxConditionDepth bounds how deeply compound conditions nest, and
+// MaxJSONValueSize, in bytes, how big a value of a json flag can be.
+type RuleLimitsConfig struct {
+	MaxRules           int
+	MaxPredicateLength int
+	MaxConditionDepth  int
+	MaxJSONValueSize   int
+}
+
+const (
+	DefaultMaxRules           = 200
+	DefaultMaxPredicateLength = 256
+	DefaultMaxConditionDepth  = 5
+	DefaultMaxJSONValueSize   = 64 * 1024
+)
+
+var RuleLimits = RuleLimitsConfig{
+	MaxRules:           DefaultMaxRules,
+	MaxPredicateLength: DefaultMaxPredicateLength,
+	MaxConditionDepth:  DefaultMaxConditionDepth,
+	MaxJSONValueSize:   DefaultMaxJSONValueSize,
+}
+
+var ErrInvalidRuleLimits = errors.New("invalid rule limits")
+
+func (rlc RuleLimitsConfig) Validate() error {
+	if rlc.MaxRules <= 0 {
+		return fmt.Errorf("%w: max rules must be positive", ErrInvalidRuleLimits)
+	}
+	if rlc.MaxPredicateLength <= 0 {
+		return fmt.Errorf("%w: max predicate length must be positive", ErrInvalidRuleLimits)
+	}
+	if rlc.MaxConditionDepth <= 0 {
+		return fmt.Errorf("%w: max condition depth must be positive", ErrInvalidRuleLimits)
+	}
+	if rlc.MaxJSONValueSize <= 0 {
+		return fmt.Errorf("%w: max json value size must be positive", ErrInvalidRuleLimits)
+	}
+
+	return nil
+}
+
+func loadRuleLimits() error {
+	if value := os.Getenv("RULES_MAX_PER_REVISION"); value != "" {
+		maxRules, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: RULES_MAX_PER_REVISION: %s", ErrInvalidRuleLimits, err)
+		}
+		RuleLimits.MaxRules = maxRules
+	}
+
+	if value := os.Getenv("RULES_MAX_PREDICATE_LENGTH"); value != "" {
+		maxLength, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: RULES_MAX_PREDICATE_LENGTH: %s", ErrInvalidRuleLimits, err)
+		}
+		RuleLimits.MaxPredicateLength = maxLength
+	}
+
+	if value := os.Getenv("RULES_MAX_CONDITION_DEPTH"); value != "" {
+		maxDepth, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: RULES_MAX_CONDITION_DEPTH: %s", ErrInvalidRuleLimits, err)
+		}
+		RuleLimits.MaxConditionDepth = maxDepth
+	}
+
+	if value := os.Getenv("RULES_MAX_JSON_VALUE_SIZE"); value != "" {
+		maxSize, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: RULES_MAX_JSON_VALUE_SIZE: %s", ErrInvalidRuleLimits, err)
+		}
+		RuleLimits.MaxJSONValueSize = maxSize
+	}
+
+	return RuleLimits.Validate()
+}
+
+// ReadOnly is the initial state of maintenance mode; it can be flipped at
+// runtime through the admin endpoint.
+var ReadOnly bool
+
+// AdminToken protects the /admin endpoints. Leaving it empty disables them.
+var AdminToken string
+
+// MongoPoolConfig tunes the connection pool of the Mongo client.
+// MaxPoolSize bounds concurrent connections per server, MinPoolSize keeps
+// warm connections around between bursts.
+type MongoPoolConfig struct {
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+}
+
+// The pool size and server selection defaults are the driver's own.
+const (
+	DefaultMongoMaxPoolSize            = 100
+	DefaultMongoMinPoolSize            = 0
+	DefaultMongoConnectTimeout         = DBConnectionTimeout * time.Second
+	DefaultMongoServerSelectionTimeout = 30 * time.Second
+)
+
+var MongoPool = MongoPoolConfig{
+	MaxPoolSize:            DefaultMongoMaxPoolSize,
+	MinPoolSize:            DefaultMongoMinPoolSize,
+	ConnectTimeout:         DefaultMongoConnectTimeout,
+	ServerSelectionTimeout: DefaultMongoServerSelectionTimeout,
+}
+
+var ErrInvalidMongoPool = errors.New("invalid mongo pool configuration")
+
+func (mpc MongoPoolConfig) Validate() error {
+	if mpc.MaxPoolSize == 0 {
+		return fmt.Errorf("%w: max pool size must be greater than zero", ErrInvalidMongoPool)
+	}
+	if mpc.MinPoolSize > mpc.MaxPoolSize {
+		return fmt.Errorf("%w: min pool size %d is above max pool size %d",
+			ErrInvalidMongoPool, mpc.MinPoolSize, mpc.MaxPoolSize)
+	}
+	if mpc.ConnectTimeout <= 0 {
+		return fmt.Errorf("%w: connect timeout must be positive", ErrInvalidMongoPool)
+	}
+	if mpc.ServerSelectionTimeout <= 0 {
+		return fmt.Errorf("%w: server selection timeout must be positive", ErrInvalidMongoPool)
+	}
+
+	return nil
+}
+
+func loadMongoPool() error {
+	if value := os.Getenv("MONGO_MAX_POOL_SIZE"); value != "" {
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: MONGO_MAX_POOL_SIZE: %s", ErrInvalidMongoPool, err)
+		}
+		MongoPool.MaxPoolSize = size
+	}
+
+	if value := os.Getenv("MONGO_MIN_POOL_SIZE"); value != "" {
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: MONGO_MIN_POOL_SIZE: %s", ErrInvalidMongoPool, err)
+		}
+		MongoPool.MinPoolSize = size
+	}
+
+	if value := os.Getenv("MONGO_CONNECT_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: MONGO_CONNECT_TIMEOUT: %s", ErrInvalidMongoPool, err)
+		}
+		MongoPool.ConnectTimeout = timeout
+	}
+
+	if value := os.Getenv("MONGO_SERVER_SELECTION_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: MONGO_SERVER_SELECTION_TIMEOUT: %s", ErrInvalidMongoPool, err)
+		}
+		MongoPool.ServerSelectionTimeout = timeout
+	}
+
+	return MongoPool.Validate()
+}
+
+// MongoReadPreferenceConfig picks which members of the replica set reads go
+// to, for the whole client or per collection in Collections, e.g. to serve
+// evaluations from a nearby secondary. Writes always go to the primary.
+// TagSets and MaxStaleness narrow down every mode but primary.
+//
+// Secondaries lag behind the primary, so reads off them can miss the latest
+// writes: a flag just changed may still be served with its previous value
+// for as long as replication takes. MaxStaleness, at least 90s when set,
+// keeps secondaries lagging further behind out. Collections read right after
+// they're written, like user sessions, should stay on the primary.
+type MongoReadPreferenceConfig struct {
+	Mode         string
+	TagSets      []map[string]string
+	MaxStaleness time.Duration
+	Collections  map[string]string
+}
+
+const (
+	PrimaryReadPreference            = "primary"
+	PrimaryPreferredReadPreference   = "primaryPreferred"
+	SecondaryReadPreference          = "secondary"
+	SecondaryPreferredReadPreference = "secondaryPreferred"
+	NearestReadPreference            = "nearest"
+)
+
+// MinMongoMaxStaleness is the lowest max staleness Mongo accepts.
+const MinMongoMaxStaleness = 90 * time.Second
+
+var MongoReadPreference = MongoReadPreferenceConfig{
+	Mode: PrimaryReadPreference,
+}
+
+var ErrInvalidMongoReadPreference = errors.New("invalid mongo read preference")
+
+func validReadPreferenceMode(mode string) bool {
+	switch mode {
+	case PrimaryReadPreference,
+		PrimaryPreferredReadPreference,
+		SecondaryReadPreference,
+		SecondaryPreferredReadPreference,
+		NearestReadPreference:
+		return true
+	}
+
+	return false
+}
+
+func (mrpc MongoReadPreferenceConfig) Validate() error {
+	modes := []string{mrpc.Mode}
+	for collection, mode := range mrpc.Collections {
+		if collection == "" {
+			return fmt.Errorf("%w: collection name is required", ErrInvalidMongoReadPreference)
+		}
+		modes = append(modes, mode)
+	}
+
+	onlyPrimary := true
+	for _, mode := range modes {
+		if !validReadPreferenceMode(mode) {
+			return fmt.Errorf("%w: unknown mode %q", ErrInvalidMongoReadPreference, mode)
+		}
+		onlyPrimary = onlyPrimary && mode == PrimaryReadPreference
+	}
+	// The primary is a single member, so tags and staleness can't pick among
+	// members.
+	if onlyPrimary && (len(mrpc.TagSets) > 0 || mrpc.MaxStaleness != 0) {
+		return fmt.Errorf("%w: tag sets and max staleness need a mode other than primary", ErrInvalidMongoReadPreference)
+	}
+
+	if mrpc.MaxStaleness != 0 && mrpc.MaxStaleness < MinMongoMaxStaleness {
+		return fmt.Errorf("%w: max staleness must be at least %s", ErrInvalidMongoReadPreference, MinMongoMaxStaleness)
+	}
+
+	return nil
+}
+
+// loadMongoReadPreference reads MONGO_READ_PREFERENCE_TAGS as tag sets
+// separated by ";", each a list of "name:value" pairs separated by ",", e.g.
+// "region:eu,zone:a;region:eu", and MONGO_COLLECTION_READ_PREFERENCES as
+// "collection=mode" pairs separated by ",".
+func loadMongoReadPreference() error {
+	if value := os.Getenv("MONGO_READ_PREFERENCE"); value != "" {
+		MongoReadPreference.Mode = value
+	}
+
+	MongoReadPreference.TagSets = nil
+	for _, tagSet := range strings.Split(os.Getenv("MONGO_READ_PREFERENCE_TAGS"), ";") {
+		if tagSet = strings.TrimSpace(tagSet); tagSet == "" {
+			continue
+		}
+
+		tags := make(map[string]string)
+		for _, tag := range strings.Split(tagSet, ",") {
+			name, value, found := strings.Cut(tag, ":")
+			name = strings.TrimSpace(name)
+			if !found || name == "" {
+				return fmt.Errorf("%w: MONGO_READ_PREFERENCE_TAGS: invalid tag %q", ErrInvalidMongoReadPreference, tag)
+			}
+			tags[name] = strings.TrimSpace(value)
+		}
+		MongoReadPreference.TagSets = append(MongoReadPreference.TagSets, tags)
+	}
+
+	if value := os.Getenv("MONGO_READ_PREFERENCE_MAX_STALENESS"); value != "" {
+		staleness, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: MONGO_READ_PREFERENCE_MAX_STALENESS: %s", ErrInvalidMongoReadPreference, err)
+		}
+		MongoReadPreference.MaxStaleness = staleness
+	}
+
+	MongoReadPreference.Collections = nil
+	for _, pair := range strings.Split(os.Getenv("MONGO_COLLECTION_READ_PREFERENCES"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		collection, mode, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("%w: MONGO_COLLECTION_READ_PREFERENCES: invalid pair %q", ErrInvalidMongoReadPreference, pair)
+		}
+		if MongoReadPreference.Collections == nil {
+			MongoReadPreference.Collections = make(map[string]string)
+		}
+		MongoReadPreference.Collections[strings.TrimSpace(collection)] = strings.TrimSpace(mode)
+	}
+
+	return MongoReadPreference.Validate()
+}
+
+// JWTConfig holds token lifetimes. RefreshTokenTTL must outlive the access
+// token it renews.
+type JWTConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+const (
+	DefaultAccessTokenTTL  = 24 * time.Hour
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var JWT = JWTConfig{
+	AccessTokenTTL:  DefaultAccessTokenTTL,
+	RefreshTokenTTL: DefaultRefreshTokenTTL,
+}
+
+var ErrInvalidJWTConfig = errors.New("invalid jwt configuration")
+
+func (jc JWTConfig) Validate() error {
+	if jc.AccessTokenTTL <= 0 {
+		return fmt.Errorf("%w: access token ttl must be positive", ErrInvalidJWTConfig)
+	}
+	if jc.RefreshTokenTTL <= jc.AccessTokenTTL {
+		return fmt.Errorf("%w: refresh token ttl %s must be longer than access token ttl %s",
+			ErrInvalidJWTConfig, jc.RefreshTokenTTL, jc.AccessTokenTTL)
+	}
+
+	return nil
+}
+
+func loadJWT() error {
+	if value := os.Getenv("JWT_ACCESS_TOKEN_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: JWT_ACCESS_TOKEN_TTL: %s", ErrInvalidJWTConfig, err)
+		}
+		JWT.AccessTokenTTL = ttl
+	}
+
+	if value := os.Getenv("JWT_REFRESH_TOKEN_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: JWT_REFRESH_TOKEN_TTL: %s", ErrInvalidJWTConfig, err)
+		}
+		JWT.RefreshTokenTTL = ttl
+	}
+
+	return JWT.Validate()
+}
+
+// RelayConfig turns the instance into an edge relay: instead of talking to
+// Mongo it mirrors the flags of one organization from the central server at
+// UpstreamURL and evaluates them locally. APIKey, one of the organization's
+// API keys, authenticates the relay against the central server; unlike a
+// user's token it doesn't expire.
+type RelayConfig struct {
+	UpstreamURL    string
+	APIKey         string
+	OrganizationID string
+	SyncInterval   time.Duration
+}
+
+const DefaultRelaySyncInterval = 10 * time.Second
+
+var Relay = RelayConfig{
+	SyncInterval: DefaultRelaySyncInterval,
+}
+
+var ErrInvalidRelayConfig = errors.New("invalid relay configuration")
+
+// Enabled reports whether the instance runs as a relay.
+func (rc RelayConfig) Enabled() bool {
+	return rc.UpstreamURL != ""
+}
+
+func (rc RelayConfig) Validate() error {
+	if !rc.Enabled() {
+		return nil
+	}
+	if rc.APIKey == "" {
+		return fmt.Errorf("%w: api key is required", ErrInvalidRelayConfig)
+	}
+	if rc.OrganizationID == "" {
+		return fmt.Errorf("%w: organization id is required", ErrInvalidRelayConfig)
+	}
+	if rc.SyncInterval <= 0 {
+		return fmt.Errorf("%w: sync interval must be positive", ErrInvalidRelayConfig)
+	}
+
+	return nil
+}
+
+func loadRelay() error {
+	Relay.UpstreamURL = strings.TrimSuffix(os.Getenv("RELAY_UPSTREAM_URL"), "/")
+	Relay.APIKey = os.Getenv("RELAY_API_KEY")
+	Relay.OrganizationID = os.Getenv("RELAY_ORGANIZATION_ID")
+
+	if value := os.Getenv("RELAY_SYNC_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: RELAY_SYNC_INTERVAL: %s", ErrInvalidRelayConfig, err)
+		}
+		Relay.SyncInterval = interval
+	}
+
+	return Relay.Validate()
+}
+
+// AnalyticsConfig sends every evaluation to a data warehouse. Destination
+// picks where batches go: "file" writes them to the directory at Path and
+// "http" posts them to URL. No destination turns analytics off.
+type AnalyticsConfig struct {
+	Destination   string
+	Path          string
+	URL           string
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+const (
+	FileAnalyticsDestination = "file"
+	HTTPAnalyticsDestination = "http"
+)
+
+const (
+	DefaultAnalyticsBatchSize     = 500
+	DefaultAnalyticsFlushInterval = 30 * time.Second
+)
+
+var Analytics = AnalyticsConfig{
+	BatchSize:     DefaultAnalyticsBatchSize,
+	FlushInterval: DefaultAnalyticsFlushInterval,
+}
+
+var ErrInvalidAnalyticsConfig = errors.New("invalid analytics configuration")
+
+func (ac AnalyticsConfig) Enabled() bool {
+	return ac.Destination != ""
+}
+
+func (ac AnalyticsConfig) Validate() error {
+	switch ac.Destination {
+	case "":
+		return nil
+	case FileAnalyticsDestination:
+		if ac.Path == "" {
+			return fmt.Errorf("%w: path is required", ErrInvalidAnalyticsConfig)
+		}
+	case HTTPAnalyticsDestination:
+		if ac.URL == "" {
+			return fmt.Errorf("%w: url is required", ErrInvalidAnalyticsConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown destination %q", ErrInvalidAnalyticsConfig, ac.Destination)
+	}
+	if ac.BatchSize <= 0 {
+		return fmt.Errorf("%w: batch size must be positive", ErrInvalidAnalyticsConfig)
+	}
+	if ac.FlushInterval <= 0 {
+		return fmt.Errorf("%w: flush interval must be positive", ErrInvalidAnalyticsConfig)
+	}
+
+	return nil
+}
+
+func loadAnalytics() error {
+	Analytics.Destination = os.Getenv("ANALYTICS_DESTINATION")
+	Analytics.Path = os.Getenv("ANALYTICS_PATH")
+	Analytics.URL = os.Getenv("ANALYTICS_URL")
+
+	if value := os.Getenv("ANALYTICS_BATCH_SIZE"); value != "" {
+		batchSize, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: ANALYTICS_BATCH_SIZE: %s", ErrInvalidAnalyticsConfig, err)
+		}
+		Analytics.BatchSize = batchSize
+	}
+
+	if value := os.Getenv("ANALYTICS_FLUSH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: ANALYTICS_FLUSH_INTERVAL: %s", ErrInvalidAnalyticsConfig, err)
+		}
+		Analytics.FlushInterval = interval
+	}
+
+	return Analytics.Validate()
+}
+
+// KafkaConfig publishes flag changes to ChangesTopic and evaluations to
+// EvaluationsTopic on the cluster at Brokers. Either topic can be left empty
+// to skip those events; no brokers turns Kafka off.
+type KafkaConfig struct {
+	Brokers          []string
+	ChangesTopic     string
+	EvaluationsTopic string
+}
+
+var Kafka KafkaConfig
+
+var ErrInvalidKafkaConfig = errors.New("invalid kafka configuration")
+
+func (kc KafkaConfig) Enabled() bool {
+	return len(kc.Brokers) > 0
+}
+
+func (kc KafkaConfig) Validate() error {
+	if !kc.Enabled() {
+		return nil
+	}
+	if kc.ChangesTopic == "" && kc.EvaluationsTopic == "" {
+		return fmt.Errorf("%w: at least one topic is required", ErrInvalidKafkaConfig)
+	}
+
+	return nil
+}
+
+func loadKafka() error {
+	Kafka.Brokers = nil
+	for _, value := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			Kafka.Brokers = append(Kafka.Brokers, value)
+		}
+	}
+	Kafka.ChangesTopic = os.Getenv("KAFKA_CHANGES_TOPIC")
+	Kafka.EvaluationsTopic = os.Getenv("KAFKA_EVALUATIONS_TOPIC")
+
+	return Kafka.Validate()
+}
+
+// NATSConfig publishes flag changes to the NATS server at URL. Every
+// organization gets its own subjects under SubjectPrefix, so a relay can
+// subscribe to the changes of its organization alone and sync right away.
+// OrganizationPrefixes replaces SubjectPrefix for the organizations it holds,
+// by id, e.g. to route their changes to a NATS account of their own.
+type NATSConfig struct {
+	URL                  string
+	SubjectPrefix        string
+	OrganizationPrefixes map[string]string
+}
+
+const DefaultNATSSubjectPrefix = "togglelabs"
+
+var NATS = NATSConfig{
+	SubjectPrefix: DefaultNATSSubjectPrefix,
+}
+
+var ErrInvalidNATSConfig = errors.New("invalid nats configuration")
+
+func (nc NATSConfig) Enabled() bool {
+	return nc.URL != ""
+}
+
+func (nc NATSConfig) Validate() error {
+	if !nc.Enabled() {
+		return nil
+	}
+	if nc.SubjectPrefix == "" {
+		return fmt.Errorf("%w: subject prefix is required", ErrInvalidNATSConfig)
+	}
+	if !validSubjectPrefix(nc.SubjectPrefix) {
+		return fmt.Errorf("%w: invalid subject prefix %q", ErrInvalidNATSConfig, nc.SubjectPrefix)
+	}
+	for organizationID, prefix := range nc.OrganizationPrefixes {
+		if organizationID == "" {
+			return fmt.Errorf("%w: organization id is required", ErrInvalidNATSConfig)
+		}
+		if prefix == "" || !validSubjectPrefix(prefix) {
+			return fmt.Errorf("%w: invalid subject prefix %q for organization %s", ErrInvalidNATSConfig, prefix, organizationID)
+		}
+	}
+
+	return nil
+}
+
+func validSubjectPrefix(prefix string) bool {
+	return !strings.ContainsAny(prefix, "*> \t") &&
+		!strings.HasPrefix(prefix, ".") &&
+		!strings.HasSuffix(prefix, ".")
+}
+
+// loadNATS reads NATS_ORGANIZATION_SUBJECT_PREFIXES as "organization
+// id=prefix" pairs separated by ",".
+func loadNATS() error {
+	NATS.URL = os.Getenv("NATS_URL")
+	if value := os.Getenv("NATS_SUBJECT_PREFIX"); value != "" {
+		NATS.SubjectPrefix = value
+	}
+
+	NATS.OrganizationPrefixes = nil
+	for _, pair := range strings.Split(os.Getenv("NATS_ORGANIZATION_SUBJECT_PREFIXES"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		organizationID, prefix, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("%w: NATS_ORGANIZATION_SUBJECT_PREFIXES: invalid pair %q", ErrInvalidNATSConfig, pair)
+		}
+		if NATS.OrganizationPrefixes == nil {
+			NATS.OrganizationPrefixes = make(map[string]string)
+		}
+		NATS.OrganizationPrefixes[strings.TrimSpace(organizationID)] = strings.TrimSpace(prefix)
+	}
+
+	return NATS.Validate()
+}
+
+// RequestTimeout bounds how long the service works on a single request
+// before cancelling its queries and answering 503.
+var RequestTimeout = DefaultRequestTimeout
+
+const DefaultRequestTimeout = 30 * time.Second
+
+var ErrInvalidRequestTimeout = errors.New("invalid request timeout")
+
+func loadRequestTimeout() error {
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: REQUEST_TIMEOUT: %s", ErrInvalidRequestTimeout, err)
+		}
+		RequestTimeout = timeout
+	}
+
+	if RequestTimeout <= 0 {
+		return fmt.Errorf("%w: must be positive", ErrInvalidRequestTimeout)
+	}
+
+	return nil
+}
+
+// RateLimitConfig caps each caller of an organization, or API key on SDK
+// routes, at Requests per Window. Zero requests turns limiting off.
+type RateLimitConfig struct {
+	Requests int
+	Window   time.Duration
+}
+
+const DefaultRateLimitWindow = time.Minute
+
+var RateLimit = RateLimitConfig{
+	Window: DefaultRateLimitWindow,
+}
+
+var ErrInvalidRateLimitConfig = errors.New("invalid rate limit configuration")
+
+// Enabled reports whether requests are rate limited.
+func (rlc RateLimitConfig) Enabled() bool {
+	return rlc.Requests > 0
+}
+
+func (rlc RateLimitConfig) Validate() error {
+	if rlc.Requests < 0 {
+		return fmt.Errorf("%w: requests must not be negative", ErrInvalidRateLimitConfig)
+	}
+	if rlc.Window <= 0 {
+		return fmt.Errorf("%w: window must be positive", ErrInvalidRateLimitConfig)
+	}
+
+	return nil
+}
+
+func loadRateLimit() error {
+	if value := os.Getenv("RATE_LIMIT_REQUESTS"); value != "" {
+		requests, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: RATE_LIMIT_REQUESTS: %s", ErrInvalidRateLimitConfig, err)
+		}
+		RateLimit.Requests = requests
+	}
+
+	if value := os.Getenv("RATE_LIMIT_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: RATE_LIMIT_WINDOW: %s", ErrInvalidRateLimitConfig, err)
+		}
+		RateLimit.Window = window
+	}
+
+	return RateLimit.Validate()
+}
+
+// CompressionMinLength is the size in bytes from which responses of bulk
+// routes are gzipped, see middlewares.Compress.
+var CompressionMinLength = DefaultCompressionMinLength
+
+const DefaultCompressionMinLength = 1024
+
+var ErrInvalidCompressionMinLength = errors.New("invalid compression min length")
+
+func loadCompressionMinLength() error {
+	if value := os.Getenv("COMPRESSION_MIN_LENGTH"); value != "" {
+		minLength, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: COMPRESSION_MIN_LENGTH: %s", ErrInvalidCompressionMinLength, err)
+		}
+		CompressionMinLength = minLength
+	}
+
+	if CompressionMinLength < 0 {
+		return fmt.Errorf("%w: must not be negative", ErrInvalidCompressionMinLength)
+	}
+
+	return nil
+}
+
+// TrustedProxies lists the networks of the load balancers in front of the
+// service. X-Forwarded-For is only believed for the hops they added; without
+// any, the client IP is always the address of the connection.
+var TrustedProxies []*net.IPNet
+
+var ErrInvalidTrustedProxies = errors.New("invalid trusted proxies")
+
+func loadTrustedProxies() error {
+	TrustedProxies = nil
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return fmt.Errorf("%w: TRUSTED_PROXIES: %s", ErrInvalidTrustedProxies, err)
+		}
+		TrustedProxies = append(TrustedProxies, network)
+	}
+
+	return nil
+}
+
+func StartEnvironment() error {
 	env := os.Getenv("ENV")
+	LogLevel = os.Getenv("LOG_LEVEL")
+
+	if minLength, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && minLength > 0 {
+		Password.MinLength = minLength
+	}
+	Password.RequireDigit = os.Getenv("PASSWORD_REQUIRE_DIGIT") == "true"
+	Password.RequireSymbol = os.Getenv("PASSWORD_REQUIRE_SYMBOL") == "true"
+	Password.RequireMixedCase = os.Getenv("PASSWORD_REQUIRE_MIXED_CASE") == "true"
+	if historySize, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORY_SIZE")); err == nil && historySize >= 0 {
+		Password.HistorySize = historySize
+		if historySize > MaxPasswordHistorySize {
+			Password.HistorySize = MaxPasswordHistorySize
+		}
+	}
+
+	ReadOnly = os.Getenv("READ_ONLY") == "true"
+	AdminToken = os.Getenv("ADMIN_TOKEN")
+
+	if err := loadMongoPool(); err != nil {
+		return err
+	}
+
+	if err := loadMongoReadPreference(); err != nil {
+		return err
+	}
+
+	if err := loadRuleLimits(); err != nil {
+		return err
+	}
+
+	if err := loadRequestTimeout(); err != nil {
+		return err
+	}
+
+	if err := loadCompressionMinLength(); err != nil {
+		return err
+	}
+
+	if err := loadRateLimit(); err != nil {
+		return err
+	}
+
+	if err := loadJWT(); err != nil {
+		return err
+	}
+
+	if err := loadRelay(); err != nil {
+		return err
+	}
+
+	if err := loadTrustedProxies(); err != nil {
+		return err
+	}
+
+	if err := loadAnalytics(); err != nil {
+		return err
+	}
+
+	if err := loadKafka(); err != nil {
+		return err
+	}
+
+	if err := loadNATS(); err != nil {
+		return err
+	}
 
 	if env == ProductionEnvironment {
 		Environment = ProductionEnvironment
-		return
+		return nil
 	}
 
 	Environment = DevEnvironment
+	return nil
 }
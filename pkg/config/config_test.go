@@ -0,0 +1,503 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetMongoPool(t *testing.T) {
+	previous := MongoPool
+	t.Cleanup(func() {
+		MongoPool = previous
+	})
+}
+
+func resetJWT(t *testing.T) {
+	previous := JWT
+	t.Cleanup(func() {
+		JWT = previous
+	})
+}
+
+func resetRelay(t *testing.T) {
+	previous := Relay
+	t.Cleanup(func() {
+		Relay = previous
+	})
+}
+
+func resetAnalytics(t *testing.T) {
+	previous := Analytics
+	t.Cleanup(func() {
+		Analytics = previous
+	})
+}
+
+func resetKafka(t *testing.T) {
+	previous := Kafka
+	t.Cleanup(func() {
+		Kafka = previous
+	})
+}
+
+func resetNATS(t *testing.T) {
+	previous := NATS
+	t.Cleanup(func() {
+		NATS = previous
+	})
+}
+
+func resetRequestTimeout(t *testing.T) {
+	previous := RequestTimeout
+	t.Cleanup(func() {
+		RequestTimeout = previous
+	})
+}
+
+func resetMongoReadPreference(t *testing.T) {
+	previous := MongoReadPreference
+	t.Cleanup(func() {
+		MongoReadPreference = previous
+	})
+}
+
+func resetRateLimit(t *testing.T) {
+	previous := RateLimit
+	t.Cleanup(func() {
+		RateLimit = previous
+	})
+}
+
+func resetCompressionMinLength(t *testing.T) {
+	previous := CompressionMinLength
+	t.Cleanup(func() {
+		CompressionMinLength = previous
+	})
+}
+
+func resetRuleLimits(t *testing.T) {
+	previous := RuleLimits
+	t.Cleanup(func() {
+		RuleLimits = previous
+	})
+}
+
+func TestMongoPoolDefaultsAreValid(t *testing.T) {
+	resetMongoPool(t)
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, MongoPoolConfig{
+		MaxPoolSize:            DefaultMongoMaxPoolSize,
+		MinPoolSize:            DefaultMongoMinPoolSize,
+		ConnectTimeout:         DefaultMongoConnectTimeout,
+		ServerSelectionTimeout: DefaultMongoServerSelectionTimeout,
+	}, MongoPool)
+}
+
+func TestMongoPoolFromEnvironment(t *testing.T) {
+	resetMongoPool(t)
+	t.Setenv("MONGO_MAX_POOL_SIZE", "50")
+	t.Setenv("MONGO_MIN_POOL_SIZE", "5")
+	t.Setenv("MONGO_CONNECT_TIMEOUT", "3s")
+	t.Setenv("MONGO_SERVER_SELECTION_TIMEOUT", "1500ms")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, MongoPoolConfig{
+		MaxPoolSize:            50,
+		MinPoolSize:            5,
+		ConnectTimeout:         3 * time.Second,
+		ServerSelectionTimeout: 1500 * time.Millisecond,
+	}, MongoPool)
+}
+
+func TestMongoPoolRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unparsable size":    {"MONGO_MAX_POOL_SIZE": "lots"},
+		"negative size":      {"MONGO_MIN_POOL_SIZE": "-1"},
+		"zero max size":      {"MONGO_MAX_POOL_SIZE": "0"},
+		"min above max":      {"MONGO_MAX_POOL_SIZE": "10", "MONGO_MIN_POOL_SIZE": "20"},
+		"unparsable timeout": {"MONGO_CONNECT_TIMEOUT": "10"},
+		"zero timeout":       {"MONGO_SERVER_SELECTION_TIMEOUT": "0s"},
+		"negative timeout":   {"MONGO_CONNECT_TIMEOUT": "-1s"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetMongoPool(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidMongoPool)
+		})
+	}
+}
+
+func TestJWTFromEnvironment(t *testing.T) {
+	resetJWT(t)
+	t.Setenv("JWT_ACCESS_TOKEN_TTL", "15m")
+	t.Setenv("JWT_REFRESH_TOKEN_TTL", "12h")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, JWTConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 12 * time.Hour,
+	}, JWT)
+}
+
+func TestJWTRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unparsable ttl":          {"JWT_ACCESS_TOKEN_TTL": "1 day"},
+		"zero ttl":                {"JWT_ACCESS_TOKEN_TTL": "0s"},
+		"negative ttl":            {"JWT_REFRESH_TOKEN_TTL": "-1h"},
+		"refresh shorter":         {"JWT_ACCESS_TOKEN_TTL": "2h", "JWT_REFRESH_TOKEN_TTL": "1h"},
+		"access above default rt": {"JWT_ACCESS_TOKEN_TTL": "1000h"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetJWT(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidJWTConfig)
+		})
+	}
+}
+
+func TestRelayFromEnvironment(t *testing.T) {
+	resetRelay(t)
+	t.Setenv("RELAY_UPSTREAM_URL", "https://flags.example.com/")
+	t.Setenv("RELAY_API_KEY", "tgl_key")
+	t.Setenv("RELAY_ORGANIZATION_ID", "65f1c0ffee00000000000001")
+	t.Setenv("RELAY_SYNC_INTERVAL", "5s")
+
+	assert.NoError(t, StartEnvironment())
+	assert.True(t, Relay.Enabled())
+	assert.Equal(t, RelayConfig{
+		UpstreamURL:    "https://flags.example.com",
+		APIKey:         "tgl_key",
+		OrganizationID: "65f1c0ffee00000000000001",
+		SyncInterval:   5 * time.Second,
+	}, Relay)
+}
+
+func TestRelayRejectsInvalidValues(t *testing.T) {
+	upstream := map[string]string{
+		"RELAY_UPSTREAM_URL":    "https://flags.example.com",
+		"RELAY_API_KEY":         "tgl_key",
+		"RELAY_ORGANIZATION_ID": "65f1c0ffee00000000000001",
+	}
+	testCases := map[string]map[string]string{
+		"missing api key":      {"RELAY_API_KEY": ""},
+		"missing organization": {"RELAY_ORGANIZATION_ID": ""},
+		"unparsable interval":  {"RELAY_SYNC_INTERVAL": "often"},
+		"zero interval":        {"RELAY_SYNC_INTERVAL": "0s"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetRelay(t)
+			for key, value := range upstream {
+				t.Setenv(key, value)
+			}
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidRelayConfig)
+		})
+	}
+}
+
+func TestTrustedProxiesFromEnvironment(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 2001:db8::/32")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Len(t, TrustedProxies, 2)
+	assert.Equal(t, "10.0.0.0/8", TrustedProxies[0].String())
+	assert.Equal(t, "2001:db8::/32", TrustedProxies[1].String())
+}
+
+func TestTrustedProxiesRejectsInvalidValues(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1")
+
+	assert.ErrorIs(t, StartEnvironment(), ErrInvalidTrustedProxies)
+}
+
+func TestAnalyticsFromEnvironment(t *testing.T) {
+	resetAnalytics(t)
+	t.Setenv("ANALYTICS_DESTINATION", "http")
+	t.Setenv("ANALYTICS_URL", "https://ingest.example.com/evaluations")
+	t.Setenv("ANALYTICS_BATCH_SIZE", "100")
+	t.Setenv("ANALYTICS_FLUSH_INTERVAL", "5s")
+
+	assert.NoError(t, StartEnvironment())
+	assert.True(t, Analytics.Enabled())
+	assert.Equal(t, AnalyticsConfig{
+		Destination:   HTTPAnalyticsDestination,
+		URL:           "https://ingest.example.com/evaluations",
+		BatchSize:     100,
+		FlushInterval: 5 * time.Second,
+	}, Analytics)
+}
+
+func TestAnalyticsRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unknown destination": {"ANALYTICS_DESTINATION": "kafka"},
+		"missing path":        {"ANALYTICS_DESTINATION": "file"},
+		"missing url":         {"ANALYTICS_DESTINATION": "http"},
+		"zero batch size":     {"ANALYTICS_DESTINATION": "file", "ANALYTICS_PATH": "/tmp", "ANALYTICS_BATCH_SIZE": "0"},
+		"unparsable interval": {"ANALYTICS_DESTINATION": "file", "ANALYTICS_PATH": "/tmp", "ANALYTICS_FLUSH_INTERVAL": "often"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetAnalytics(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidAnalyticsConfig)
+		})
+	}
+}
+
+func TestKafkaFromEnvironment(t *testing.T) {
+	resetKafka(t)
+	t.Setenv("KAFKA_BROKERS", "kafka-1:9092, kafka-2:9092,")
+	t.Setenv("KAFKA_CHANGES_TOPIC", "togglelabs.changes")
+
+	assert.NoError(t, StartEnvironment())
+	assert.True(t, Kafka.Enabled())
+	assert.Equal(t, KafkaConfig{
+		Brokers:      []string{"kafka-1:9092", "kafka-2:9092"},
+		ChangesTopic: "togglelabs.changes",
+	}, Kafka)
+}
+
+func TestKafkaRequiresATopic(t *testing.T) {
+	resetKafka(t)
+	t.Setenv("KAFKA_BROKERS", "kafka-1:9092")
+
+	assert.ErrorIs(t, StartEnvironment(), ErrInvalidKafkaConfig)
+}
+
+func TestNATSFromEnvironment(t *testing.T) {
+	resetNATS(t)
+	t.Setenv("NATS_URL", "nats://nats:4222")
+
+	assert.NoError(t, StartEnvironment())
+	assert.True(t, NATS.Enabled())
+	assert.Equal(t, NATSConfig{
+		URL:           "nats://nats:4222",
+		SubjectPrefix: DefaultNATSSubjectPrefix,
+	}, NATS)
+}
+
+func TestNATSRejectsInvalidSubjectPrefixes(t *testing.T) {
+	for _, prefix := range []string{"toggle.*", "toggle.>", "toggle labs", "togglelabs.", ".togglelabs"} {
+		t.Run(prefix, func(t *testing.T) {
+			resetNATS(t)
+			t.Setenv("NATS_URL", "nats://nats:4222")
+			t.Setenv("NATS_SUBJECT_PREFIX", prefix)
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidNATSConfig)
+		})
+	}
+}
+
+func TestNATSOrganizationPrefixesFromEnvironment(t *testing.T) {
+	resetNATS(t)
+	t.Setenv("NATS_URL", "nats://nats:4222")
+	t.Setenv("NATS_ORGANIZATION_SUBJECT_PREFIXES", "65f1c0ffee = acme.flags, 65f1decade=globex")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, map[string]string{
+		"65f1c0ffee": "acme.flags",
+		"65f1decade": "globex",
+	}, NATS.OrganizationPrefixes)
+}
+
+func TestNATSRejectsInvalidOrganizationPrefixes(t *testing.T) {
+	for _, value := range []string{"65f1c0ffee", "65f1c0ffee=", "=acme", "65f1c0ffee=acme.*"} {
+		t.Run(value, func(t *testing.T) {
+			resetNATS(t)
+			t.Setenv("NATS_URL", "nats://nats:4222")
+			t.Setenv("NATS_ORGANIZATION_SUBJECT_PREFIXES", value)
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidNATSConfig)
+		})
+	}
+}
+
+func TestRuleLimitsFromEnvironment(t *testing.T) {
+	resetRuleLimits(t)
+	t.Setenv("RULES_MAX_PER_REVISION", "50")
+	t.Setenv("RULES_MAX_PREDICATE_LENGTH", "64")
+	t.Setenv("RULES_MAX_CONDITION_DEPTH", "3")
+	t.Setenv("RULES_MAX_JSON_VALUE_SIZE", "1024")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, RuleLimitsConfig{
+		MaxRules:           50,
+		MaxPredicateLength: 64,
+		MaxConditionDepth:  3,
+		MaxJSONValueSize:   1024,
+	}, RuleLimits)
+}
+
+func TestRuleLimitsRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"zero rules":        {"RULES_MAX_PER_REVISION": "0"},
+		"unparsable rules":  {"RULES_MAX_PER_REVISION": "many"},
+		"negative length":   {"RULES_MAX_PREDICATE_LENGTH": "-1"},
+		"unparsable length": {"RULES_MAX_PREDICATE_LENGTH": "long"},
+		"zero depth":        {"RULES_MAX_CONDITION_DEPTH": "0"},
+		"negative size":     {"RULES_MAX_JSON_VALUE_SIZE": "-1"},
+		"unparsable size":   {"RULES_MAX_JSON_VALUE_SIZE": "1mb"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetRuleLimits(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidRuleLimits)
+		})
+	}
+}
+
+func TestRequestTimeoutFromEnvironment(t *testing.T) {
+	resetRequestTimeout(t)
+	t.Setenv("REQUEST_TIMEOUT", "5s")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, 5*time.Second, RequestTimeout)
+}
+
+func TestMongoReadPreferenceDefaultsToPrimary(t *testing.T) {
+	resetMongoReadPreference(t)
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, MongoReadPreferenceConfig{Mode: PrimaryReadPreference}, MongoReadPreference)
+}
+
+func TestMongoReadPreferenceFromEnvironment(t *testing.T) {
+	resetMongoReadPreference(t)
+	t.Setenv("MONGO_READ_PREFERENCE", "secondaryPreferred")
+	t.Setenv("MONGO_READ_PREFERENCE_TAGS", "region: eu, zone:a;region:eu")
+	t.Setenv("MONGO_READ_PREFERENCE_MAX_STALENESS", "2m")
+	t.Setenv("MONGO_COLLECTION_READ_PREFERENCES", "feature_flag=nearest, user=primaryPreferred")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, MongoReadPreferenceConfig{
+		Mode:         SecondaryPreferredReadPreference,
+		TagSets:      []map[string]string{{"region": "eu", "zone": "a"}, {"region": "eu"}},
+		MaxStaleness: 2 * time.Minute,
+		Collections:  map[string]string{"feature_flag": NearestReadPreference, "user": PrimaryPreferredReadPreference},
+	}, MongoReadPreference)
+}
+
+func TestMongoReadPreferenceOfCollections(t *testing.T) {
+	resetMongoReadPreference(t)
+	t.Setenv("MONGO_READ_PREFERENCE_TAGS", "region:eu")
+	t.Setenv("MONGO_COLLECTION_READ_PREFERENCES", "feature_flag=nearest")
+
+	// Tags are for the collections read off secondaries.
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, PrimaryReadPreference, MongoReadPreference.Mode)
+	assert.Equal(t, map[string]string{"feature_flag": NearestReadPreference}, MongoReadPreference.Collections)
+}
+
+func TestMongoReadPreferenceRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unknown mode":            {"MONGO_READ_PREFERENCE": "closest"},
+		"unknown collection mode": {"MONGO_COLLECTION_READ_PREFERENCES": "feature_flag=closest"},
+		"collection without mode": {"MONGO_COLLECTION_READ_PREFERENCES": "feature_flag"},
+		"tag without value":       {"MONGO_READ_PREFERENCE": "nearest", "MONGO_READ_PREFERENCE_TAGS": "region"},
+		"tags on primary":         {"MONGO_READ_PREFERENCE_TAGS": "region:eu"},
+		"staleness on primary":    {"MONGO_READ_PREFERENCE_MAX_STALENESS": "2m"},
+		"unparsable staleness":    {"MONGO_READ_PREFERENCE": "nearest", "MONGO_READ_PREFERENCE_MAX_STALENESS": "soon"},
+		"short staleness":         {"MONGO_READ_PREFERENCE": "nearest", "MONGO_READ_PREFERENCE_MAX_STALENESS": "10s"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetMongoReadPreference(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidMongoReadPreference)
+		})
+	}
+}
+
+func TestRateLimitFromEnvironment(t *testing.T) {
+	resetRateLimit(t)
+	assert.NoError(t, StartEnvironment())
+	assert.False(t, RateLimit.Enabled())
+
+	t.Setenv("RATE_LIMIT_REQUESTS", "600")
+	t.Setenv("RATE_LIMIT_WINDOW", "30s")
+
+	assert.NoError(t, StartEnvironment())
+	assert.True(t, RateLimit.Enabled())
+	assert.Equal(t, RateLimitConfig{Requests: 600, Window: 30 * time.Second}, RateLimit)
+}
+
+func TestRateLimitRejectsInvalidValues(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unparsable requests": {"RATE_LIMIT_REQUESTS": "many"},
+		"negative requests":   {"RATE_LIMIT_REQUESTS": "-1"},
+		"unparsable window":   {"RATE_LIMIT_WINDOW": "often"},
+		"zero window":         {"RATE_LIMIT_WINDOW": "0s"},
+	}
+
+	for name, env := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resetRateLimit(t)
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidRateLimitConfig)
+		})
+	}
+}
+
+func TestCompressionMinLengthFromEnvironment(t *testing.T) {
+	resetCompressionMinLength(t)
+	t.Setenv("COMPRESSION_MIN_LENGTH", "0")
+
+	assert.NoError(t, StartEnvironment())
+	assert.Equal(t, 0, CompressionMinLength)
+}
+
+func TestCompressionMinLengthRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"big", "-1"} {
+		t.Run(value, func(t *testing.T) {
+			resetCompressionMinLength(t)
+			t.Setenv("COMPRESSION_MIN_LENGTH", value)
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidCompressionMinLength)
+		})
+	}
+}
+
+func TestRequestTimeoutRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"soon", "0s", "-1s"} {
+		t.Run(value, func(t *testing.T) {
+			resetRequestTimeout(t)
+			t.Setenv("REQUEST_TIMEOUT", value)
+
+			assert.ErrorIs(t, StartEnvironment(), ErrInvalidRequestTimeout)
+		})
+	}
+}
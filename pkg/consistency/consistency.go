@@ -0,0 +1,173 @@
+// Package consistency finds feature flags whose documents drifted from what
+// the rest of the code assumes, e.g. legacy documents written before a
+// feature existed, and repairs the problems that have an unambiguous fix. It
+// does no I/O: callers load the flags and save the repaired ones.
+package consistency
+
+import (
+	"fmt"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kind is what is wrong with a flag.
+type Kind = string
+
+const (
+	// MultipleLiveRevisions flags serve whichever live revision comes first.
+	MultipleLiveRevisions Kind = "multiple_live_revisions"
+	// LostLiveRevision flags had a revision approved once but have none live
+	// anymore, so they serve their base default value again.
+	LostLiveRevision Kind = "lost_live_revision"
+	// OrphanedPrerequisite flags depend on a flag that doesn't exist in their
+	// organization, so their prerequisites never pass.
+	OrphanedPrerequisite Kind = "orphaned_prerequisite"
+	// InvalidType flags have a type other than the known ones.
+	InvalidType Kind = "invalid_type"
+	// InvalidValue flags can serve a value that isn't one of their type.
+	InvalidValue Kind = "invalid_value"
+)
+
+// Problem is one inconsistency of one flag. Repairable problems are fixed by
+// Repair; the others need someone to decide what the flag should serve.
+type Problem struct {
+	OrganizationID primitive.ObjectID `json:"organization_id"`
+	FeatureFlagID  primitive.ObjectID `json:"feature_flag_id"`
+	FeatureFlag    string             `json:"feature_flag"`
+	Kind           Kind               `json:"kind"`
+	Detail         string             `json:"detail"`
+	Repairable     bool               `json:"repairable"`
+	Repaired       bool               `json:"repaired"`
+}
+
+// Check lists the problems of featureFlag. organizations maps the id of
+// every flag that isn't deleted to its organization, to find orphaned
+// prerequisites.
+func Check(
+	featureFlag *models.FeatureFlagRecord,
+	organizations map[primitive.ObjectID]primitive.ObjectID,
+) []Problem {
+	problems := make([]Problem, 0)
+	problem := func(kind Kind, repairable bool, format string, args ...any) {
+		problems = append(problems, Problem{
+			OrganizationID: featureFlag.OrganizationID,
+			FeatureFlagID:  featureFlag.ID,
+			FeatureFlag:    featureFlag.QualifiedName(),
+			Kind:           kind,
+			Detail:         fmt.Sprintf(format, args...),
+			Repairable:     repairable,
+		})
+	}
+
+	live := 0
+	approved := false
+	for index := range featureFlag.Revisions {
+		switch featureFlag.Revisions[index].Status {
+		case models.Live:
+			live++
+		case models.Archived:
+			approved = true
+		}
+	}
+	if live > 1 {
+		problem(MultipleLiveRevisions, true, "%d revisions are live", live)
+	}
+	if live == 0 && approved {
+		problem(LostLiveRevision, false, "no revision is live but some were approved before")
+	}
+
+	for _, prerequisite := range featureFlag.Prerequisites {
+		if organizations[prerequisite.FeatureFlagID] != featureFlag.OrganizationID {
+			problem(OrphanedPrerequisite, true,
+				"prerequisite %s doesn't exist", prerequisite.FeatureFlagID.Hex())
+		}
+	}
+
+	switch featureFlag.Type {
+	case models.Boolean, models.JSON, models.String, models.Number:
+	default:
+		problem(InvalidType, false, "type %q is unknown", featureFlag.Type)
+		return problems
+	}
+
+	invalid := func(value, where string) {
+		if !flagvalue.Valid(featureFlag.Type, value) {
+			problem(InvalidValue, false, "%s %q isn't a %s value", where, value, featureFlag.Type)
+		}
+	}
+	for index := range featureFlag.Revisions {
+		revision := &featureFlag.Revisions[index]
+		if revision.Status == models.Archived {
+			continue
+		}
+
+		invalid(revision.DefaultValue, "default value of revision "+revision.ID.Hex())
+		for _, rule := range revision.Rules {
+			invalid(rule.Value, "value of rule "+rule.ID.Hex())
+		}
+	}
+	for _, override := range featureFlag.Overrides {
+		invalid(override.Value, "override of user "+override.UserID)
+	}
+	for _, override := range featureFlag.SegmentOverrides {
+		invalid(override.Value, "override of segment "+override.UserListID.Hex())
+	}
+	if featureFlag.Rollout != nil {
+		invalid(featureFlag.Rollout.Value, "rollout value")
+	}
+
+	return problems
+}
+
+// Repair fixes the repairable problems Check found in featureFlag in place,
+// and marks them repaired. Of several live revisions, the one served stays
+// live and the others are archived, so repairs don't change what the flag
+// serves; orphaned prerequisites are dropped, since they already fail. It
+// reports whether featureFlag changed.
+func Repair(
+	featureFlag *models.FeatureFlagRecord,
+	organizations map[primitive.ObjectID]primitive.ObjectID,
+	problems []Problem,
+) bool {
+	changed := false
+	for index := range problems {
+		switch problems[index].Kind {
+		case MultipleLiveRevisions:
+			keepServedLiveRevision(featureFlag)
+		case OrphanedPrerequisite:
+			dropOrphanedPrerequisites(featureFlag, organizations)
+		default:
+			continue
+		}
+
+		problems[index].Repaired = true
+		changed = true
+	}
+
+	return changed
+}
+
+func keepServedLiveRevision(featureFlag *models.FeatureFlagRecord) {
+	served := featureFlag.LiveRevision()
+	for index := range featureFlag.Revisions {
+		revision := &featureFlag.Revisions[index]
+		if revision != served && revision.Status == models.Live {
+			revision.Status = models.Archived
+		}
+	}
+}
+
+func dropOrphanedPrerequisites(
+	featureFlag *models.FeatureFlagRecord,
+	organizations map[primitive.ObjectID]primitive.ObjectID,
+) {
+	prerequisites := make([]models.Prerequisite, 0, len(featureFlag.Prerequisites))
+	for _, prerequisite := range featureFlag.Prerequisites {
+		if organizations[prerequisite.FeatureFlagID] == featureFlag.OrganizationID {
+			prerequisites = append(prerequisites, prerequisite)
+		}
+	}
+	featureFlag.Prerequisites = prerequisites
+}
@@ -0,0 +1,133 @@
+package consistency_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/consistency"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newFlag(flagType models.FlagType, revisions ...models.Revision) *models.FeatureFlagRecord {
+	return &models.FeatureFlagRecord{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: primitive.NewObjectID(),
+		Name:           "checkout",
+		Type:           flagType,
+		Revisions:      revisions,
+	}
+}
+
+func revision(status models.RevisionStatus, defaultValue string) models.Revision {
+	return models.Revision{ID: primitive.NewObjectID(), Status: status, DefaultValue: defaultValue}
+}
+
+func kinds(problems []consistency.Problem) []consistency.Kind {
+	found := make([]consistency.Kind, 0, len(problems))
+	for _, problem := range problems {
+		found = append(found, problem.Kind)
+	}
+
+	return found
+}
+
+func TestCheckConsistentFlag(t *testing.T) {
+	featureFlag := newFlag(models.Boolean, revision(models.Archived, "false"), revision(models.Live, "true"))
+	assert.Empty(t, consistency.Check(featureFlag, nil))
+
+	// Flags never released are fine.
+	featureFlag = newFlag(models.Boolean, revision(models.Draft, "false"))
+	assert.Empty(t, consistency.Check(featureFlag, nil))
+}
+
+func TestCheckLiveRevisions(t *testing.T) {
+	featureFlag := newFlag(models.Boolean,
+		revision(models.Live, "false"),
+		revision(models.Live, "true"),
+		revision(models.Draft, "true"),
+	)
+	problems := consistency.Check(featureFlag, nil)
+	assert.Equal(t, []consistency.Kind{consistency.MultipleLiveRevisions}, kinds(problems))
+	assert.True(t, problems[0].Repairable)
+
+	featureFlag = newFlag(models.Boolean, revision(models.Archived, "false"), revision(models.Draft, "true"))
+	problems = consistency.Check(featureFlag, nil)
+	assert.Equal(t, []consistency.Kind{consistency.LostLiveRevision}, kinds(problems))
+	assert.False(t, problems[0].Repairable)
+}
+
+func TestCheckOrphanedPrerequisites(t *testing.T) {
+	featureFlag := newFlag(models.Boolean, revision(models.Live, "true"))
+	billing := primitive.NewObjectID()
+	otherOrganizations := primitive.NewObjectID()
+	featureFlag.Prerequisites = []models.Prerequisite{
+		{FeatureFlagID: billing, Value: "true"},
+		{FeatureFlagID: otherOrganizations, Value: "true"},
+		{FeatureFlagID: primitive.NewObjectID(), Value: "true"},
+	}
+	organizations := map[primitive.ObjectID]primitive.ObjectID{
+		featureFlag.ID:     featureFlag.OrganizationID,
+		billing:            featureFlag.OrganizationID,
+		otherOrganizations: primitive.NewObjectID(),
+	}
+
+	problems := consistency.Check(featureFlag, organizations)
+	assert.Equal(t, []consistency.Kind{
+		consistency.OrphanedPrerequisite,
+		consistency.OrphanedPrerequisite,
+	}, kinds(problems))
+}
+
+func TestCheckValues(t *testing.T) {
+	featureFlag := newFlag(models.Number, revision(models.Archived, "legacy"), revision(models.Live, "ten"))
+	featureFlag.Revisions[1].Rules = []models.Rule{{ID: primitive.NewObjectID(), Value: "10"}}
+	featureFlag.Overrides = []models.Override{{UserID: "user-1", Value: "{}"}}
+	featureFlag.Rollout = &models.Rollout{Percentage: 10, Value: "2.5"}
+
+	// Archived revisions aren't served anymore, so they are left alone.
+	problems := consistency.Check(featureFlag, nil)
+	assert.Equal(t, []consistency.Kind{consistency.InvalidValue, consistency.InvalidValue}, kinds(problems))
+	assert.Contains(t, problems[0].Detail, `"ten"`)
+	assert.Contains(t, problems[1].Detail, "user-1")
+
+	featureFlag.Type = "date"
+	assert.Equal(t, []consistency.Kind{consistency.InvalidType}, kinds(consistency.Check(featureFlag, nil)))
+}
+
+func TestRepair(t *testing.T) {
+	featureFlag := newFlag(models.Boolean,
+		revision(models.Live, "false"),
+		revision(models.Live, "true"),
+		revision(models.Draft, "bad"),
+	)
+	billing := primitive.NewObjectID()
+	featureFlag.Prerequisites = []models.Prerequisite{
+		{FeatureFlagID: primitive.NewObjectID(), Value: "true"},
+		{FeatureFlagID: billing, Value: "true"},
+	}
+	organizations := map[primitive.ObjectID]primitive.ObjectID{billing: featureFlag.OrganizationID}
+	served := featureFlag.LiveRevision().ID
+
+	problems := consistency.Check(featureFlag, organizations)
+	assert.True(t, consistency.Repair(featureFlag, organizations, problems))
+
+	// The revision served stays live.
+	assert.Equal(t, served, featureFlag.LiveRevision().ID)
+	assert.Equal(t, models.Archived, featureFlag.Revisions[1].Status)
+	assert.Equal(t, []models.Prerequisite{{FeatureFlagID: billing, Value: "true"}}, featureFlag.Prerequisites)
+
+	repaired := make(map[consistency.Kind]bool)
+	for _, problem := range problems {
+		repaired[problem.Kind] = problem.Repaired
+	}
+	assert.Equal(t, map[consistency.Kind]bool{
+		consistency.MultipleLiveRevisions: true,
+		consistency.OrphanedPrerequisite:  true,
+		consistency.InvalidValue:          false,
+	}, repaired)
+
+	remaining := consistency.Check(featureFlag, organizations)
+	assert.Equal(t, []consistency.Kind{consistency.InvalidValue}, kinds(remaining))
+	assert.False(t, consistency.Repair(featureFlag, organizations, remaining))
+}
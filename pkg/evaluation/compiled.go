@@ -0,0 +1,216 @@
+package evaluation
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCompiledRevisions bounds the cache of compiled revisions, one per flag.
+// Once full, the flag evaluated least recently is evicted.
+const maxCompiledRevisions = 10000
+
+// compiledRevision is the rules of a revision with their predicates already
+// split into attribute and value, so evaluating them doesn't parse anything.
+// It copies what it needs out of the revision: the flag it was compiled from
+// is only valid for the request that loaded it.
+type compiledRevision struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	id         primitive.ObjectID
+	value      string
+	env        string
+	isEnabled  bool
+	userListID primitive.ObjectID
+	condition  compiledCondition
+}
+
+// compiledCondition is a models.Condition, or the predicate of a rule without
+// one, with every leaf parsed. Leaves whose predicate has no separator never
+// match.
+type compiledCondition struct {
+	operator   models.ConditionOperator
+	conditions []compiledCondition
+	attribute  string
+	expected   string
+	valid      bool
+}
+
+// compiledEntry is the revision of a flag compiled last, along with the
+// write of the flag it was compiled at.
+type compiledEntry struct {
+	featureFlagID primitive.ObjectID
+	revisionID    primitive.ObjectID
+	updatedAt     primitive.DateTime
+	writes        int64
+	compiled      *compiledRevision
+}
+
+// compiledCache keeps the compiled revision of each flag evaluated lately,
+// most recently used first in order. A flag only has one: compiling another
+// revision of it, or the same one after a write, replaces its entry.
+type compiledCache struct {
+	mu      sync.Mutex
+	entries map[primitive.ObjectID]*list.Element
+	order   *list.List
+}
+
+var compiledRevisions = &compiledCache{
+	entries: make(map[primitive.ObjectID]*list.Element),
+	order:   list.New(),
+}
+
+// compile returns the compiled rules of revision, from the cache when they
+// were compiled at featureFlag's current write, as its writes counter and
+// updated_at tell. Flags that were never saved, e.g. built in memory, can't
+// be told apart from their edited copies and are compiled every time.
+func compile(featureFlag *models.FeatureFlagRecord, revision *models.Revision) *compiledRevision {
+	if featureFlag.UpdatedAt == 0 {
+		return compileRevision(revision)
+	}
+
+	if compiled := compiledRevisions.get(featureFlag, revision); compiled != nil {
+		return compiled
+	}
+
+	compiled := compileRevision(revision)
+	compiledRevisions.put(&compiledEntry{
+		featureFlagID: featureFlag.ID,
+		revisionID:    revision.ID,
+		updatedAt:     featureFlag.UpdatedAt,
+		writes:        featureFlag.Writes,
+		compiled:      compiled,
+	})
+
+	return compiled
+}
+
+func (cc *compiledCache) get(featureFlag *models.FeatureFlagRecord, revision *models.Revision) *compiledRevision {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	element, ok := cc.entries[featureFlag.ID]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*compiledEntry)
+	if entry.revisionID != revision.ID ||
+		entry.updatedAt != featureFlag.UpdatedAt ||
+		entry.writes != featureFlag.Writes {
+		return nil
+	}
+	cc.order.MoveToFront(element)
+
+	return entry.compiled
+}
+
+// put caches entry in place of whatever its flag had, evicting the least
+// recently used flag when the cache is full.
+func (cc *compiledCache) put(entry *compiledEntry) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if element, ok := cc.entries[entry.featureFlagID]; ok {
+		element.Value = entry
+		cc.order.MoveToFront(element)
+		return
+	}
+
+	if cc.order.Len() >= maxCompiledRevisions {
+		oldest := cc.order.Back()
+		cc.order.Remove(oldest)
+		delete(cc.entries, oldest.Value.(*compiledEntry).featureFlagID)
+	}
+	cc.entries[entry.featureFlagID] = cc.order.PushFront(entry)
+}
+
+func compileRevision(revision *models.Revision) *compiledRevision {
+	compiled := &compiledRevision{
+		rules: make([]compiledRule, len(revision.Rules)),
+	}
+	for index := range revision.Rules {
+		rule := &revision.Rules[index]
+		compiled.rules[index] = compiledRule{
+			id:         rule.ID,
+			value:      rule.Value,
+			env:        rule.Env,
+			isEnabled:  rule.IsEnabled,
+			userListID: rule.UserListID,
+			condition:  compileRule(rule),
+		}
+	}
+
+	return compiled
+}
+
+func compileRule(rule *models.Rule) compiledCondition {
+	if rule.Condition != nil {
+		return compileCondition(rule.Condition)
+	}
+
+	return compilePredicate(rule.Predicate)
+}
+
+func compileCondition(condition *models.Condition) compiledCondition {
+	switch condition.Operator {
+	case models.AndOperator, models.OrOperator:
+	default:
+		return compilePredicate(condition.Predicate)
+	}
+
+	compiled := compiledCondition{
+		operator:   condition.Operator,
+		conditions: make([]compiledCondition, len(condition.Conditions)),
+	}
+	for index := range condition.Conditions {
+		compiled.conditions[index] = compileCondition(&condition.Conditions[index])
+	}
+
+	return compiled
+}
+
+// compilePredicate parses an "attribute: value" predicate, ignoring the
+// spaces around either side.
+func compilePredicate(predicate string) compiledCondition {
+	attribute, expected, found := strings.Cut(predicate, models.PredicateSeparator)
+
+	return compiledCondition{
+		attribute: strings.TrimSpace(attribute),
+		expected:  strings.TrimSpace(expected),
+		valid:     found,
+	}
+}
+
+// match evaluates the tree depth first and stops at the first child deciding
+// a group: a failed one for "and", a matching one for "or". An empty "and"
+// group matches and an empty "or" group doesn't, though neither can be saved.
+func (c *compiledCondition) match(attributes map[string]string) bool {
+	switch c.operator {
+	case models.AndOperator:
+		for index := range c.conditions {
+			if !c.conditions[index].match(attributes) {
+				return false
+			}
+		}
+		return true
+	case models.OrOperator:
+		for index := range c.conditions {
+			if c.conditions[index].match(attributes) {
+				return true
+			}
+		}
+		return false
+	default:
+		if !c.valid {
+			return false
+		}
+		value, ok := attributes[c.attribute]
+
+		return ok && value == c.expected
+	}
+}
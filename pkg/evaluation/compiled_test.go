@@ -0,0 +1,78 @@
+package evaluation
+
+import (
+	"container/list"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newCompiledCache() *compiledCache {
+	return &compiledCache{
+		entries: make(map[primitive.ObjectID]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func cachedFlag(revisions ...models.Revision) *models.FeatureFlagRecord {
+	return &models.FeatureFlagRecord{
+		ID:         primitive.NewObjectID(),
+		Revisions:  revisions,
+		Timestamps: storage.Timestamps{UpdatedAt: primitive.NewDateTimeFromTime(time.Now())},
+	}
+}
+
+func TestCompiledCacheKeepsOneRevisionPerFlag(t *testing.T) {
+	cache := newCompiledCache()
+	live := models.Revision{ID: primitive.NewObjectID()}
+	draft := models.Revision{ID: primitive.NewObjectID()}
+	featureFlag := cachedFlag(live, draft)
+
+	for _, revision := range []*models.Revision{&live, &draft} {
+		cache.put(&compiledEntry{
+			featureFlagID: featureFlag.ID,
+			revisionID:    revision.ID,
+			updatedAt:     featureFlag.UpdatedAt,
+			compiled:      compileRevision(revision),
+		})
+	}
+
+	assert.Equal(t, 1, cache.order.Len())
+	assert.Nil(t, cache.get(featureFlag, &live))
+	assert.NotNil(t, cache.get(featureFlag, &draft))
+
+	featureFlag.Writes++
+	assert.Nil(t, cache.get(featureFlag, &draft), "written since it was compiled")
+}
+
+func TestCompiledCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newCompiledCache()
+	revision := models.Revision{ID: primitive.NewObjectID()}
+	put := func(featureFlag *models.FeatureFlagRecord) {
+		cache.put(&compiledEntry{
+			featureFlagID: featureFlag.ID,
+			revisionID:    revision.ID,
+			updatedAt:     featureFlag.UpdatedAt,
+			compiled:      compileRevision(&revision),
+		})
+	}
+
+	first := cachedFlag(revision)
+	second := cachedFlag(revision)
+	put(first)
+	put(second)
+	for index := 2; index < maxCompiledRevisions; index++ {
+		put(cachedFlag(revision))
+	}
+	// Using the first flag again leaves the second the least recently used.
+	assert.NotNil(t, cache.get(first, &revision))
+
+	put(cachedFlag(revision))
+	assert.Equal(t, maxCompiledRevisions, cache.order.Len())
+	assert.NotNil(t, cache.get(first, &revision))
+	assert.Nil(t, cache.get(second, &revision))
+}
@@ -0,0 +1,278 @@
+// Package evaluation decides which value a feature flag serves to a context.
+// It does no I/O: callers load the flags and hand them over, so every place
+// serving flags resolves them the same way.
+package evaluation
+
+import (
+	"errors"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserIDAttribute is the context attribute per-user overrides are matched
+// against. It is the key of the user kind.
+const UserIDAttribute = "user_id"
+
+// Reason is a compact code for why a flag served its value.
+type Reason = string
+
+const (
+	DefaultReason            Reason = "DEFAULT"
+	ArchivedReason           Reason = "ARCHIVED"
+	OverrideReason           Reason = "OVERRIDE"
+	SegmentOverrideReason    Reason = "SEGMENT_OVERRIDE"
+	PrerequisiteFailedReason Reason = "PREREQUISITE_FAILED"
+	RuleMatchReason          Reason = "RULE_MATCH"
+	PercentageReason         Reason = "PERCENTAGE"
+	NoLiveRevisionReason     Reason = "NO_LIVE_REVISION"
+)
+
+// ErrNotServed is returned for flags without any revision, which have no
+// value to serve at all.
+var ErrNotServed = errors.New("feature flag has no revision")
+
+// Context is who a flag is evaluated for: the environment whose rules apply
+// and the attributes rules are matched against, of one or more kinds.
+// EnvironmentParents is the organization's environment inheritance, child to
+// parent, and UserLists the user lists rules target, by id; either may be nil.
+// FallbackEnvironments are tried in order for flags that have no rules in
+// Environment, see RulesEnvironment.
+type Context struct {
+	Environment          string
+	Attributes           map[string]string
+	EnvironmentParents   map[string]string
+	UserLists            map[primitive.ObjectID]models.UserSet
+	FallbackEnvironments []string
+}
+
+// InUserList reports whether the context's user is on the list with id. A
+// list missing from UserLists has nobody on it.
+func (c Context) InUserList(id primitive.ObjectID) bool {
+	userID, ok := c.Attributes[UserIDAttribute]
+	if !ok {
+		return false
+	}
+
+	return c.UserLists[id].Contains(userID)
+}
+
+// RulesEnvironment is the environment whose rules of revision apply in the
+// context's environment, which inherits them when it has none of its own.
+// When neither it nor its ancestors have rules, the fallback environments
+// are tried in order, each along with its own ancestors, and the first with
+// rules wins. With none, it is the context's environment, whose lack of
+// rules serves the default value.
+func (c Context) RulesEnvironment(revision *models.Revision) string {
+	environment := revision.InheritedEnvironment(c.Environment, c.EnvironmentParents)
+	if revision.HasRulesIn(environment) {
+		return environment
+	}
+
+	for _, fallback := range c.FallbackEnvironments {
+		fallbackEnvironment := revision.InheritedEnvironment(fallback, c.EnvironmentParents)
+		if revision.HasRulesIn(fallbackEnvironment) {
+			return fallbackEnvironment
+		}
+	}
+
+	return environment
+}
+
+// Result is the value a flag served and why.
+type Result struct {
+	Value  string
+	Reason Reason
+	// RuleID is the rule that matched when Reason is RuleMatchReason.
+	RuleID primitive.ObjectID
+}
+
+// Code is the reason, followed by the id of the matching rule for rule
+// matches, e.g. "RULE_MATCH:65f1c0ffee...".
+func (r Result) Code() string {
+	if r.Reason == RuleMatchReason {
+		return r.Reason + models.PredicateSeparator + r.RuleID.Hex()
+	}
+
+	return r.Reason
+}
+
+// MatchedRule is the hex id of the matching rule, or empty when no rule
+// matched.
+func (r Result) MatchedRule() string {
+	if r.Reason != RuleMatchReason {
+		return ""
+	}
+
+	return r.RuleID.Hex()
+}
+
+// Evaluate resolves featureFlag on its own. Its prerequisites can't be looked
+// up, so a flag that has any serves its default value; an Evaluator resolves
+// flags along with their prerequisites.
+func Evaluate(featureFlag *models.FeatureFlagRecord, context Context) (Result, error) {
+	return NewEvaluator(nil, context).Evaluate(featureFlag)
+}
+
+// Evaluator resolves the flags of one organization for a single context. It
+// isn't safe for concurrent use.
+// Each prerequisite is evaluated once per Evaluator and its result reused by
+// every flag depending on it, so flags sharing a prerequisite see the same
+// result. Within a prerequisite cycle that result is the one of the first
+// flag of the cycle reached, which fails its prerequisites either way.
+type Evaluator struct {
+	context       Context
+	flags         map[primitive.ObjectID]*models.FeatureFlagRecord
+	visiting      map[primitive.ObjectID]bool
+	prerequisites map[primitive.ObjectID]prerequisiteResult
+}
+
+type prerequisiteResult struct {
+	result Result
+	err    error
+}
+
+// NewEvaluator evaluates against featureFlags, which prerequisites are looked
+// up in.
+func NewEvaluator(featureFlags []models.FeatureFlagRecord, context Context) *Evaluator {
+	flags := make(map[primitive.ObjectID]*models.FeatureFlagRecord, len(featureFlags))
+	for index := range featureFlags {
+		flags[featureFlags[index].ID] = &featureFlags[index]
+	}
+
+	return &Evaluator{
+		context:       context,
+		flags:         flags,
+		visiting:      make(map[primitive.ObjectID]bool),
+		prerequisites: make(map[primitive.ObjectID]prerequisiteResult),
+	}
+}
+
+// Evaluate returns the value served by the flag along with the reason it was
+// chosen. Flags whose revisions were never approved serve their base default
+// value, see FeatureFlagRecord.BaseDefaultValue, and archived flags always
+// serve their default value. Otherwise a per-user override wins over
+// everything else, then the segment override of highest priority among the
+// user's segments. When a prerequisite isn't met, is missing or depends
+// back on the flag, the live revision's default value is served; otherwise
+// the enabled rules of the environment, or the ones it inherits or falls
+// back to, are tried in order, user lists included, then the percentage
+// rollout, and the default value is the fallback.
+// Rules targeting a kind the context lacks never match, and neither does a
+// rollout by it.
+func (e *Evaluator) Evaluate(featureFlag *models.FeatureFlagRecord) (Result, error) {
+	revision := featureFlag.LiveRevision()
+	if revision == nil {
+		defaultValue, ok := featureFlag.BaseDefaultValue()
+		if !ok {
+			return Result{}, ErrNotServed
+		}
+
+		return Result{Value: defaultValue, Reason: NoLiveRevisionReason}, nil
+	}
+
+	if featureFlag.IsArchived() {
+		return Result{Value: revision.DefaultValue, Reason: ArchivedReason}, nil
+	}
+
+	return e.evaluateRevision(featureFlag, revision), nil
+}
+
+// EvaluateShadow evaluates the flag's shadow revision as if it were live, see
+// FeatureFlagRecord.ShadowRevision, to be compared with what Evaluate served.
+// It reports false when the flag has no shadow revision, or is archived and
+// so serves its default value whatever the revision.
+func (e *Evaluator) EvaluateShadow(featureFlag *models.FeatureFlagRecord) (Result, bool) {
+	revision := featureFlag.ShadowRevision()
+	if revision == nil || featureFlag.IsArchived() {
+		return Result{}, false
+	}
+
+	return e.evaluateRevision(featureFlag, revision), true
+}
+
+// evaluateRevision evaluates featureFlag serving revision, from its overrides
+// down to the default value of revision.
+func (e *Evaluator) evaluateRevision(featureFlag *models.FeatureFlagRecord, revision *models.Revision) Result {
+	if userID, ok := e.context.Attributes[UserIDAttribute]; ok {
+		for _, override := range featureFlag.Overrides {
+			if override.UserID == userID {
+				return Result{Value: override.Value, Reason: OverrideReason}
+			}
+		}
+	}
+
+	for _, override := range featureFlag.SegmentOverrides {
+		if e.context.InUserList(override.UserListID) {
+			return Result{Value: override.Value, Reason: SegmentOverrideReason}
+		}
+	}
+
+	e.visiting[featureFlag.ID] = true
+	defer delete(e.visiting, featureFlag.ID)
+
+	for _, prerequisite := range featureFlag.Prerequisites {
+		prerequisiteFlag, ok := e.flags[prerequisite.FeatureFlagID]
+		if !ok || e.visiting[prerequisite.FeatureFlagID] {
+			return Result{Value: revision.DefaultValue, Reason: PrerequisiteFailedReason}
+		}
+
+		// A prerequisite nobody released isn't met, whatever its base default.
+		result, err := e.evaluatePrerequisite(prerequisiteFlag)
+		if err != nil || result.Reason == NoLiveRevisionReason || result.Value != prerequisite.Value {
+			return Result{Value: revision.DefaultValue, Reason: PrerequisiteFailedReason}
+		}
+	}
+
+	environment := e.context.RulesEnvironment(revision)
+	compiled := compile(featureFlag, revision)
+	for index := range compiled.rules {
+		rule := &compiled.rules[index]
+		if !rule.isEnabled || rule.env != environment {
+			continue
+		}
+
+		if !rule.userListID.IsZero() {
+			if e.context.InUserList(rule.userListID) {
+				return Result{Value: rule.value, Reason: RuleMatchReason, RuleID: rule.id}
+			}
+			continue
+		}
+
+		if rule.condition.match(e.context.Attributes) {
+			return Result{Value: rule.value, Reason: RuleMatchReason, RuleID: rule.id}
+		}
+	}
+
+	if featureFlag.Rollout != nil {
+		key, ok := e.context.Key(featureFlag.Rollout.Kind)
+		if ok && featureFlag.Rollout.Includes(featureFlag.ID, key) {
+			return Result{Value: featureFlag.Rollout.Value, Reason: PercentageReason}
+		}
+	}
+
+	return Result{Value: revision.DefaultValue, Reason: DefaultReason}
+}
+
+// evaluatePrerequisite evaluates prerequisiteFlag the first time a flag
+// depends on it and returns that result afterwards.
+func (e *Evaluator) evaluatePrerequisite(prerequisiteFlag *models.FeatureFlagRecord) (Result, error) {
+	if evaluated, ok := e.prerequisites[prerequisiteFlag.ID]; ok {
+		return evaluated.result, evaluated.err
+	}
+
+	result, err := e.Evaluate(prerequisiteFlag)
+	e.prerequisites[prerequisiteFlag.ID] = prerequisiteResult{result: result, err: err}
+
+	return result, err
+}
+
+// MatchRule reports whether attributes satisfy the rule's predicate or
+// condition tree. Whether the rule is enabled or for the right environment
+// isn't checked, and rules targeting a user list never match: see
+// Context.InUserList.
+func MatchRule(rule *models.Rule, attributes map[string]string) bool {
+	condition := compileRule(rule)
+
+	return condition.match(attributes)
+}
@@ -0,0 +1,658 @@
+package evaluation_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newFlag(defaultValue string, rules ...models.Rule) *models.FeatureFlagRecord {
+	for index := range rules {
+		rules[index].ID = primitive.NewObjectID()
+	}
+
+	return &models.FeatureFlagRecord{
+		ID:   primitive.NewObjectID(),
+		Name: "checkout",
+		Type: models.String,
+		Revisions: []models.Revision{
+			{ID: primitive.NewObjectID(), Status: models.Draft, DefaultValue: "draft"},
+			{ID: primitive.NewObjectID(), Status: models.Live, DefaultValue: defaultValue, Rules: rules},
+		},
+	}
+}
+
+func rule(predicate, value string) models.Rule {
+	return models.Rule{Predicate: predicate, Value: value, Env: "prd", IsEnabled: true}
+}
+
+func prd(attributes map[string]string) evaluation.Context {
+	return evaluation.Context{Environment: "prd", Attributes: attributes}
+}
+
+func TestEvaluateWithoutLiveRevision(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	featureFlag.Revisions = append(featureFlag.Revisions[:1], models.Revision{
+		ID:           primitive.NewObjectID(),
+		Status:       models.Draft,
+		DefaultValue: "later",
+		Rules:        []models.Rule{rule("country: BR", "pix")},
+	})
+	featureFlag.Overrides = []models.Override{{UserID: "user-1", Value: "override"}}
+
+	// Neither rules nor overrides apply before a revision goes live.
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": "user-1", "country": "BR"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "draft", Reason: evaluation.NoLiveRevisionReason}, result)
+
+	featureFlag.Revisions = nil
+	_, err = evaluation.Evaluate(featureFlag, prd(nil))
+
+	assert.ErrorIs(t, err, evaluation.ErrNotServed)
+}
+
+func TestUnreleasedPrerequisiteIsNotMet(t *testing.T) {
+	prerequisite := newFlag("true")
+	prerequisite.Revisions = prerequisite.Revisions[:1]
+	prerequisite.Revisions[0].DefaultValue = "true"
+	checkout := newFlag("legacy")
+	checkout.Prerequisites = []models.Prerequisite{{FeatureFlagID: prerequisite.ID, Value: "true"}}
+	featureFlags := []models.FeatureFlagRecord{*prerequisite, *checkout}
+
+	result, err := evaluation.NewEvaluator(featureFlags, prd(nil)).Evaluate(&featureFlags[1])
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.PrerequisiteFailedReason, result.Reason)
+}
+
+func TestEvaluateRules(t *testing.T) {
+	featureFlag := newFlag("legacy",
+		rule("country: BR", "pix"),
+		models.Rule{Predicate: "country: BR", Value: "disabled", Env: "prd", IsEnabled: false},
+		models.Rule{Predicate: "country: US", Value: "staging", Env: "stg", IsEnabled: true},
+		rule("  country  :  US  ", "card"),
+		rule("plan: pro", "premium"),
+		rule("malformed", "never"),
+	)
+	rules := featureFlag.LiveRevision().Rules
+
+	testCases := map[string]struct {
+		context  evaluation.Context
+		expected evaluation.Result
+	}{
+		"first matching rule wins": {
+			prd(map[string]string{"country": "BR", "plan": "pro"}),
+			evaluation.Result{Value: "pix", Reason: evaluation.RuleMatchReason, RuleID: rules[0].ID},
+		},
+		"spaces around predicates are ignored": {
+			prd(map[string]string{"country": "US"}),
+			evaluation.Result{Value: "card", Reason: evaluation.RuleMatchReason, RuleID: rules[3].ID},
+		},
+		"rules of other environments are skipped": {
+			evaluation.Context{Environment: "stg", Attributes: map[string]string{"country": "US"}},
+			evaluation.Result{Value: "staging", Reason: evaluation.RuleMatchReason, RuleID: rules[2].ID},
+		},
+		"values are compared exactly": {
+			prd(map[string]string{"country": "br"}),
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"missing attributes don't match": {
+			prd(map[string]string{}),
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"unknown environment serves the default": {
+			evaluation.Context{Environment: "dev", Attributes: map[string]string{"country": "BR"}},
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := evaluation.Evaluate(featureFlag, testCase.context)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func TestEvaluateInheritedRules(t *testing.T) {
+	featureFlag := newFlag("legacy",
+		rule("country: BR", "pix"),
+		models.Rule{Predicate: "country: BR", Value: "disabled", Env: "stg", IsEnabled: false},
+	)
+	rules := featureFlag.LiveRevision().Rules
+	parents := map[string]string{"stg": "prd", "dev": "stg", "qa": "prd"}
+	attributes := map[string]string{"country": "BR"}
+
+	testCases := map[string]struct {
+		environment string
+		expected    evaluation.Result
+	}{
+		"environment without rules inherits its parent's": {
+			"qa",
+			evaluation.Result{Value: "pix", Reason: evaluation.RuleMatchReason, RuleID: rules[0].ID},
+		},
+		"disabled rules still override the parent's": {
+			"stg",
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"closest ancestor with rules wins": {
+			"dev",
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"environment outside the inheritance has its own rules": {
+			"sandbox",
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := evaluation.Evaluate(featureFlag, evaluation.Context{
+				Environment:        testCase.environment,
+				Attributes:         attributes,
+				EnvironmentParents: parents,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func TestEvaluateFallbackEnvironments(t *testing.T) {
+	featureFlag := newFlag("legacy",
+		rule("country: BR", "pix"),
+		models.Rule{Predicate: "country: BR", Value: "boleto", Env: "beta", IsEnabled: true},
+		models.Rule{Predicate: "country: BR", Value: "disabled", Env: "stg", IsEnabled: false},
+	)
+	rules := featureFlag.LiveRevision().Rules
+	parents := map[string]string{"dev": "prd", "beta-eu": "beta"}
+	attributes := map[string]string{"country": "BR"}
+
+	testCases := map[string]struct {
+		environment string
+		fallbacks   []string
+		expected    evaluation.Result
+	}{
+		"environment with rules ignores its fallbacks": {
+			"stg",
+			[]string{"prd"},
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"inherited rules come before fallbacks": {
+			"dev",
+			[]string{"beta"},
+			evaluation.Result{Value: "pix", Reason: evaluation.RuleMatchReason, RuleID: rules[0].ID},
+		},
+		"environment without rules falls back": {
+			"canary",
+			[]string{"prd"},
+			evaluation.Result{Value: "pix", Reason: evaluation.RuleMatchReason, RuleID: rules[0].ID},
+		},
+		"first fallback with rules wins": {
+			"canary",
+			[]string{"qa", "beta", "prd"},
+			evaluation.Result{Value: "boleto", Reason: evaluation.RuleMatchReason, RuleID: rules[1].ID},
+		},
+		"fallback inherits its parent's rules": {
+			"canary",
+			[]string{"beta-eu", "prd"},
+			evaluation.Result{Value: "boleto", Reason: evaluation.RuleMatchReason, RuleID: rules[1].ID},
+		},
+		"fallback with disabled rules serves the default value": {
+			"canary",
+			[]string{"stg", "prd"},
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+		"chain without rules serves the default value": {
+			"canary",
+			[]string{"qa", "sandbox"},
+			evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := evaluation.Evaluate(featureFlag, evaluation.Context{
+				Environment:          testCase.environment,
+				Attributes:           attributes,
+				EnvironmentParents:   parents,
+				FallbackEnvironments: testCase.fallbacks,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func TestRulesEnvironmentFallsBackInOrder(t *testing.T) {
+	revision := newFlag("legacy",
+		rule("country: BR", "pix"),
+		models.Rule{Predicate: "country: BR", Value: "boleto", Env: "beta", IsEnabled: true},
+	).LiveRevision()
+	flagContext := evaluation.Context{Environment: "canary", FallbackEnvironments: []string{"beta", "prd"}}
+
+	assert.Equal(t, "beta", flagContext.RulesEnvironment(revision))
+
+	flagContext.FallbackEnvironments = []string{"qa"}
+	assert.Equal(t, "canary", flagContext.RulesEnvironment(revision))
+}
+
+func TestEvaluateSkipsDisabledRules(t *testing.T) {
+	featureFlag := newFlag("legacy",
+		models.Rule{Predicate: "country: BR", Value: "disabled", Env: "prd", IsEnabled: false},
+	)
+
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"country": "BR"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason}, result)
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	// (plan: pro OR plan: enterprise) AND region: us
+	featureFlag := newFlag("false", models.Rule{
+		Condition: &models.Condition{
+			Operator: models.AndOperator,
+			Conditions: []models.Condition{
+				{
+					Operator: models.OrOperator,
+					Conditions: []models.Condition{
+						{Predicate: "plan: pro"},
+						{Predicate: "plan: enterprise"},
+					},
+				},
+				{Predicate: "region: us"},
+			},
+		},
+		Value:     "true",
+		Env:       "prd",
+		IsEnabled: true,
+	})
+
+	testCases := map[string]struct {
+		attributes map[string]string
+		expected   string
+	}{
+		"first of or":    {map[string]string{"plan": "pro", "region": "us"}, "true"},
+		"second of or":   {map[string]string{"plan": "enterprise", "region": "us"}, "true"},
+		"failed and":     {map[string]string{"plan": "pro", "region": "eu"}, "false"},
+		"failed or":      {map[string]string{"plan": "free", "region": "us"}, "false"},
+		"missing or":     {map[string]string{"region": "us"}, "false"},
+		"empty attrs":    {map[string]string{}, "false"},
+		"extra attrs ok": {map[string]string{"plan": "pro", "region": "us", "country": "BR"}, "true"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := evaluation.Evaluate(featureFlag, prd(testCase.attributes))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result.Value)
+		})
+	}
+}
+
+func TestMatchRuleWithEmptyGroups(t *testing.T) {
+	and := models.Rule{Condition: &models.Condition{Operator: models.AndOperator}}
+	or := models.Rule{Condition: &models.Condition{Operator: models.OrOperator}}
+
+	assert.True(t, evaluation.MatchRule(&and, map[string]string{}))
+	assert.False(t, evaluation.MatchRule(&or, map[string]string{}))
+}
+
+func TestEvaluateOverrides(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	featureFlag.Overrides = []models.Override{{UserID: "user-1", Value: "beta"}}
+	featureFlag.Rollout = &models.Rollout{Percentage: 100, Value: "rolled-out"}
+
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": "user-1", "country": "BR"}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "beta", Reason: evaluation.OverrideReason}, result)
+
+	result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": "user-2", "country": "BR"}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.RuleMatchReason, result.Reason)
+}
+
+func TestEvaluateArchivedFlagsServeTheirDefault(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	featureFlag.Overrides = []models.Override{{UserID: "user-1", Value: "beta"}}
+	featureFlag.ArchivedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": "user-1", "country": "BR"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "legacy", Reason: evaluation.ArchivedReason}, result)
+}
+
+func TestEvaluatePercentageRollout(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	featureFlag.Rollout = &models.Rollout{Percentage: 50, Value: "new"}
+
+	var included, excluded string
+	for _, userID := range []string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-6", "user-7", "user-8"} {
+		if featureFlag.Rollout.Includes(featureFlag.ID, userID) {
+			included = userID
+		} else {
+			excluded = userID
+		}
+	}
+	assert.NotEmpty(t, included)
+	assert.NotEmpty(t, excluded)
+
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": included}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "new", Reason: evaluation.PercentageReason}, result)
+
+	result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": excluded}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "legacy", Reason: evaluation.DefaultReason}, result)
+
+	// Rules come before the rollout, and contexts without a user are never in it.
+	result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": included, "country": "BR"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "pix", result.Value)
+
+	featureFlag.Rollout.Percentage = 100
+	result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.DefaultReason, result.Reason)
+
+	featureFlag.Rollout.Percentage = 0
+	result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": included}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.DefaultReason, result.Reason)
+}
+
+func TestEvaluateSegmentOverrides(t *testing.T) {
+	enterprise := primitive.NewObjectID()
+	beta := primitive.NewObjectID()
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	featureFlag.Overrides = []models.Override{{UserID: "user-4", Value: "override"}}
+	featureFlag.SegmentOverrides = []models.SegmentOverride{
+		{UserListID: beta, Priority: 2, Value: "beta"},
+		{UserListID: enterprise, Priority: 1, Value: "enterprise"},
+	}
+	assert.True(t, models.SortSegmentOverrides(featureFlag.SegmentOverrides))
+
+	context := prd(map[string]string{"country": "BR"})
+	context.UserLists = map[primitive.ObjectID]models.UserSet{
+		enterprise: models.NewUserSet([]string{"user-1", "user-2", "user-4"}),
+		beta:       models.NewUserSet([]string{"user-2", "user-3"}),
+	}
+
+	// Users in several segments get the value of the first priority, and
+	// per-user overrides still come first.
+	for userID, expected := range map[string]evaluation.Result{
+		"user-1": {Value: "enterprise", Reason: evaluation.SegmentOverrideReason},
+		"user-2": {Value: "enterprise", Reason: evaluation.SegmentOverrideReason},
+		"user-3": {Value: "beta", Reason: evaluation.SegmentOverrideReason},
+		"user-4": {Value: "override", Reason: evaluation.OverrideReason},
+	} {
+		context.Attributes["user_id"] = userID
+		result, err := evaluation.Evaluate(featureFlag, context)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result, userID)
+	}
+
+	// Users outside every segment fall through to rules.
+	context.Attributes["user_id"] = "user-5"
+	result, err := evaluation.Evaluate(featureFlag, context)
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.RuleMatchReason, result.Reason)
+}
+
+func TestEvaluateUserListRules(t *testing.T) {
+	beta := primitive.NewObjectID()
+	featureFlag := newFlag("legacy",
+		models.Rule{UserListID: beta, Value: "beta", Env: "prd", IsEnabled: true},
+		models.Rule{UserListID: primitive.NewObjectID(), Value: "deleted", Env: "prd", IsEnabled: true},
+	)
+	featureFlag.Rollout = &models.Rollout{Percentage: 100, Value: "new"}
+	context := prd(map[string]string{"user_id": "user-1"})
+	context.UserLists = map[primitive.ObjectID]models.UserSet{beta: models.NewUserSet([]string{"user-1"})}
+
+	// Lists are checked before the rollout.
+	result, err := evaluation.Evaluate(featureFlag, context)
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{
+		Value:  "beta",
+		Reason: evaluation.RuleMatchReason,
+		RuleID: featureFlag.Revisions[1].Rules[0].ID,
+	}, result)
+
+	// Users off the list, and lists that are gone, fall through.
+	context.Attributes["user_id"] = "user-2"
+	result, err = evaluation.Evaluate(featureFlag, context)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", result.Value)
+
+	delete(context.Attributes, "user_id")
+	context.Attributes["country"] = "BR"
+	result, err = evaluation.Evaluate(featureFlag, context)
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.DefaultReason, result.Reason)
+}
+
+func TestEvaluateShadow(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	evaluator := evaluation.NewEvaluator(nil, prd(map[string]string{"country": "BR", "plan": "pro"}))
+
+	_, ok := evaluator.EvaluateShadow(featureFlag)
+	assert.False(t, ok)
+
+	draft := &featureFlag.Revisions[0]
+	draft.Rules = []models.Rule{rule("plan: pro", "card")}
+	draft.Rules[0].ID = primitive.NewObjectID()
+	featureFlag.Shadow = &models.Shadow{RevisionID: draft.ID}
+
+	// The live revision is still the one served.
+	result, err := evaluator.Evaluate(featureFlag)
+	assert.NoError(t, err)
+	assert.Equal(t, "pix", result.Value)
+
+	shadow, ok := evaluator.EvaluateShadow(featureFlag)
+	assert.True(t, ok)
+	assert.Equal(t, evaluation.Result{
+		Value:  "card",
+		Reason: evaluation.RuleMatchReason,
+		RuleID: draft.Rules[0].ID,
+	}, shadow)
+
+	// Approved revisions aren't shadows anymore.
+	draft.Status = models.Live
+	featureFlag.Revisions[1].Status = models.Archived
+	_, ok = evaluator.EvaluateShadow(featureFlag)
+	assert.False(t, ok)
+}
+
+func TestEvaluatorChecksPrerequisites(t *testing.T) {
+	billing := newFlag("false", rule("plan: pro", "true"))
+	checkout := newFlag("legacy", rule("country: BR", "pix"))
+	checkout.Prerequisites = []models.Prerequisite{{FeatureFlagID: billing.ID, Value: "true"}}
+	featureFlags := []models.FeatureFlagRecord{*billing, *checkout}
+
+	evaluate := func(attributes map[string]string) evaluation.Result {
+		result, err := evaluation.NewEvaluator(featureFlags, prd(attributes)).Evaluate(&featureFlags[1])
+		assert.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, "pix", evaluate(map[string]string{"plan": "pro", "country": "BR"}).Value)
+	assert.Equal(t,
+		evaluation.Result{Value: "legacy", Reason: evaluation.PrerequisiteFailedReason},
+		evaluate(map[string]string{"plan": "free", "country": "BR"}),
+	)
+
+	// Evaluated on its own, the prerequisite can't be found.
+	result, err := evaluation.Evaluate(checkout, prd(map[string]string{"plan": "pro", "country": "BR"}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.PrerequisiteFailedReason, result.Reason)
+}
+
+func TestEvaluatorFailsPrerequisiteCycles(t *testing.T) {
+	first := newFlag("first-default", rule("plan: pro", "first"))
+	second := newFlag("second-default", rule("plan: pro", "second"))
+	first.Prerequisites = []models.Prerequisite{{FeatureFlagID: second.ID, Value: "second"}}
+	second.Prerequisites = []models.Prerequisite{{FeatureFlagID: first.ID, Value: "first"}}
+	featureFlags := []models.FeatureFlagRecord{*first, *second}
+
+	result, err := evaluation.NewEvaluator(featureFlags, prd(map[string]string{"plan": "pro"})).
+		Evaluate(&featureFlags[0])
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "first-default", Reason: evaluation.PrerequisiteFailedReason}, result)
+}
+
+func TestEvaluatorEvaluatesSharedPrerequisitesOnce(t *testing.T) {
+	billing := newFlag("false", rule("plan: pro", "true"))
+	checkout := newFlag("legacy", rule("country: BR", "pix"))
+	checkout.Prerequisites = []models.Prerequisite{{FeatureFlagID: billing.ID, Value: "true"}}
+	invoices := newFlag("paper", rule("country: BR", "email"))
+	invoices.Prerequisites = []models.Prerequisite{{FeatureFlagID: billing.ID, Value: "true"}}
+	featureFlags := []models.FeatureFlagRecord{*billing, *checkout, *invoices}
+	evaluator := evaluation.NewEvaluator(featureFlags, prd(map[string]string{"plan": "pro", "country": "BR"}))
+
+	result, err := evaluator.Evaluate(&featureFlags[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "pix", result.Value)
+
+	// Had billing been evaluated again, its new rule would fail invoices.
+	featureFlags[0].Revisions[1].Rules = []models.Rule{rule("plan: pro", "false")}
+
+	result, err = evaluator.Evaluate(&featureFlags[2])
+	assert.NoError(t, err)
+	assert.Equal(t, "email", result.Value)
+
+	// A new evaluator, as for the next request, evaluates it again.
+	result, err = evaluation.NewEvaluator(featureFlags, prd(map[string]string{"plan": "pro", "country": "BR"})).
+		Evaluate(&featureFlags[2])
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.Result{Value: "paper", Reason: evaluation.PrerequisiteFailedReason}, result)
+}
+
+func TestEvaluatorPrerequisitesWithoutLiveRevision(t *testing.T) {
+	billing := newFlag("true")
+	billing.Revisions = billing.Revisions[:1]
+	checkout := newFlag("legacy", rule("country: BR", "pix"))
+	checkout.Prerequisites = []models.Prerequisite{{FeatureFlagID: billing.ID, Value: "true"}}
+	featureFlags := []models.FeatureFlagRecord{*billing, *checkout}
+
+	result, err := evaluation.NewEvaluator(featureFlags, prd(map[string]string{"country": "BR"})).
+		Evaluate(&featureFlags[1])
+
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.PrerequisiteFailedReason, result.Reason)
+}
+
+func TestResultCode(t *testing.T) {
+	ruleID := primitive.NewObjectID()
+
+	assert.Equal(t, "DEFAULT", evaluation.Result{Reason: evaluation.DefaultReason}.Code())
+	assert.Equal(t,
+		"RULE_MATCH:"+ruleID.Hex(),
+		evaluation.Result{Reason: evaluation.RuleMatchReason, RuleID: ruleID}.Code(),
+	)
+}
+
+func TestResultMatchedRule(t *testing.T) {
+	ruleID := primitive.NewObjectID()
+
+	assert.Empty(t, evaluation.Result{Reason: evaluation.DefaultReason}.MatchedRule())
+	assert.Equal(t,
+		ruleID.Hex(),
+		evaluation.Result{Reason: evaluation.RuleMatchReason, RuleID: ruleID}.MatchedRule(),
+	)
+}
+
+// manyRules is a flag with count rules split between prd and stg, none of
+// which match the context of BenchmarkEvaluateManyRules, so every rule of prd
+// is tried before the default value is served.
+func manyRules(count int) *models.FeatureFlagRecord {
+	rules := make([]models.Rule, 0, count)
+	for index := 0; index < count; index++ {
+		environment := "prd"
+		if index%2 == 0 {
+			environment = "stg"
+		}
+		rules = append(rules, models.Rule{
+			Predicate: fmt.Sprintf("country: C%d", index),
+			Value:     "pix",
+			Env:       environment,
+			IsEnabled: true,
+		})
+	}
+
+	return newFlag("legacy", rules...)
+}
+
+// BenchmarkEvaluateManyRules compares evaluating a flag whose rules are
+// compiled on every evaluation, as after each write to it, with one whose
+// compiled rules are cached.
+func BenchmarkEvaluateManyRules(b *testing.B) {
+	flagContext := prd(map[string]string{"country": "BR", "user_id": "ana"})
+
+	b.Run("cold", func(b *testing.B) {
+		featureFlag := manyRules(5000)
+		featureFlag.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for index := 0; index < b.N; index++ {
+			featureFlag.Writes = int64(index)
+			if _, err := evaluation.Evaluate(featureFlag, flagContext); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		featureFlag := manyRules(5000)
+		featureFlag.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for index := 0; index < b.N; index++ {
+			if _, err := evaluation.Evaluate(featureFlag, flagContext); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestEvaluateCompilesRulesAgainAfterWrites(t *testing.T) {
+	featureFlag := newFlag("legacy", rule("country: BR", "pix"))
+	updatedAt := time.Now()
+	featureFlag.UpdatedAt = primitive.NewDateTimeFromTime(updatedAt)
+	brazil := prd(map[string]string{"country": "BR"})
+
+	result, err := evaluation.Evaluate(featureFlag, brazil)
+	assert.NoError(t, err)
+	assert.Equal(t, "pix", result.Value)
+
+	// The same flag loaded again after its rule was edited within the same
+	// millisecond, so only its writes counter moved.
+	edited := *featureFlag
+	edited.Revisions = []models.Revision{featureFlag.Revisions[0], featureFlag.Revisions[1]}
+	edited.Revisions[1].Rules = []models.Rule{featureFlag.Revisions[1].Rules[0]}
+	edited.Revisions[1].Rules[0].Predicate = "country: AR"
+	edited.Writes++
+
+	result, err = evaluation.Evaluate(&edited, brazil)
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", result.Value)
+
+	result, err = evaluation.Evaluate(&edited, prd(map[string]string{"country": "AR"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "pix", result.Value)
+}
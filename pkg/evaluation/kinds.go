@@ -0,0 +1,107 @@
+package evaluation
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+)
+
+// A context can describe several kinds of entity at once, e.g. the user, the
+// device they're on and the organization they belong to. Attributes of any
+// kind but the user are prefixed with the kind's name, as in "device.os" or
+// "org.plan", and rules target a kind by naming its attributes. Every kind is
+// identified by its "key" attribute, e.g. "device.key", except users, who
+// keep user_id.
+const (
+	UserKind      = "user"
+	KindSeparator = "."
+	KeyAttribute  = "key"
+)
+
+var kindPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// ValidKind reports whether kind can name a context kind.
+func ValidKind(kind string) bool {
+	return kindPattern.MatchString(kind)
+}
+
+// AttributeKind returns the kind attribute belongs to.
+func AttributeKind(attribute string) string {
+	kind, _, found := strings.Cut(attribute, KindSeparator)
+	if !found {
+		return UserKind
+	}
+
+	return kind
+}
+
+// KeyAttributeOf is the attribute holding the key of kind.
+func KeyAttributeOf(kind string) string {
+	if kind == "" || kind == UserKind {
+		return UserIDAttribute
+	}
+
+	return kind + KindSeparator + KeyAttribute
+}
+
+// Key returns the key of kind in the context, which percentage rollouts
+// bucket by. An empty kind is the user.
+func (c Context) Key(kind string) (string, bool) {
+	key, ok := c.Attributes[KeyAttributeOf(kind)]
+	return key, ok
+}
+
+// HasKind reports whether the context has any attribute of kind.
+func (c Context) HasKind(kind string) bool {
+	for attribute := range c.Attributes {
+		if AttributeKind(attribute) == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReferencedKinds lists, sorted, the kinds the flag's live rules for
+// environment, its segment overrides and its rollout target.
+func ReferencedKinds(featureFlag *models.FeatureFlagRecord, environment string) []string {
+	referenced := make(map[string]bool)
+	if revision := featureFlag.LiveRevision(); revision != nil {
+		for index := range revision.Rules {
+			if revision.Rules[index].Env != environment || !revision.Rules[index].IsEnabled {
+				continue
+			}
+			if !revision.Rules[index].UserListID.IsZero() {
+				referenced[UserKind] = true
+			}
+			for _, predicate := range revision.Rules[index].Predicates() {
+				attribute, _, _ := strings.Cut(predicate, models.PredicateSeparator)
+				referenced[AttributeKind(strings.TrimSpace(attribute))] = true
+			}
+		}
+	}
+	if len(featureFlag.SegmentOverrides) > 0 {
+		referenced[UserKind] = true
+	}
+	if featureFlag.Rollout != nil {
+		referenced[rolloutKind(featureFlag.Rollout)] = true
+	}
+
+	kinds := make([]string, 0, len(referenced))
+	for kind := range referenced {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+func rolloutKind(rollout *models.Rollout) string {
+	if rollout.Kind == "" {
+		return UserKind
+	}
+
+	return rollout.Kind
+}
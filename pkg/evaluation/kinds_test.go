@@ -0,0 +1,162 @@
+package evaluation_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeKind(t *testing.T) {
+	assert.Equal(t, evaluation.UserKind, evaluation.AttributeKind("plan"))
+	assert.Equal(t, evaluation.UserKind, evaluation.AttributeKind("user_id"))
+	assert.Equal(t, "device", evaluation.AttributeKind("device.os"))
+	assert.Equal(t, "org", evaluation.AttributeKind("org.plan.tier"))
+}
+
+func TestValidKind(t *testing.T) {
+	for _, kind := range []string{"device", "org", "mobile-app", "tenant_2"} {
+		assert.True(t, evaluation.ValidKind(kind), kind)
+	}
+	for _, kind := range []string{"", "Device", "2fa", "device.os", "my kind"} {
+		assert.False(t, evaluation.ValidKind(kind), kind)
+	}
+}
+
+func TestContextKeysAndKinds(t *testing.T) {
+	context := prd(map[string]string{
+		"user_id":    "user-1",
+		"device.key": "device-1",
+		"org.plan":   "pro",
+	})
+
+	key, ok := context.Key("")
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", key)
+
+	key, ok = context.Key("device")
+	assert.True(t, ok)
+	assert.Equal(t, "device-1", key)
+
+	_, ok = context.Key("org")
+	assert.False(t, ok)
+
+	assert.True(t, context.HasKind(evaluation.UserKind))
+	assert.True(t, context.HasKind("device"))
+	assert.True(t, context.HasKind("org"))
+	assert.False(t, context.HasKind("request"))
+}
+
+func TestReferencedKinds(t *testing.T) {
+	featureFlag := newFlag("false",
+		rule("device.os: ios", "true"),
+		models.Rule{
+			Condition: &models.Condition{
+				Operator: models.AndOperator,
+				Conditions: []models.Condition{
+					{Predicate: "plan: pro"},
+					{Predicate: "org.region: us"},
+				},
+			},
+			Value:     "true",
+			Env:       "prd",
+			IsEnabled: true,
+		},
+		models.Rule{Predicate: "request.path: /beta", Value: "true", Env: "stg", IsEnabled: true},
+		models.Rule{Predicate: "account.tier: gold", Value: "true", Env: "prd", IsEnabled: false},
+	)
+	featureFlag.Rollout = &models.Rollout{Percentage: 10, Value: "true", Kind: "tenant"}
+
+	assert.Equal(t,
+		[]string{"device", "org", "tenant", "user"},
+		evaluation.ReferencedKinds(featureFlag, "prd"),
+	)
+}
+
+func TestEvaluateCrossKindRules(t *testing.T) {
+	// A pro user on an iOS device of an organization in the us
+	featureFlag := newFlag("false", models.Rule{
+		Condition: &models.Condition{
+			Operator: models.AndOperator,
+			Conditions: []models.Condition{
+				{Predicate: "plan: pro"},
+				{Predicate: "device.os: ios"},
+				{Predicate: "org.region: us"},
+			},
+		},
+		Value:     "true",
+		Env:       "prd",
+		IsEnabled: true,
+	})
+
+	testCases := map[string]struct {
+		attributes map[string]string
+		expected   string
+	}{
+		"every kind matches": {
+			map[string]string{"plan": "pro", "device.os": "ios", "org.region": "us"},
+			"true",
+		},
+		"device doesn't match": {
+			map[string]string{"plan": "pro", "device.os": "android", "org.region": "us"},
+			"false",
+		},
+		"org kind is missing": {
+			map[string]string{"plan": "pro", "device.os": "ios"},
+			"false",
+		},
+		"attribute of another kind with the same name": {
+			map[string]string{"plan": "pro", "device.os": "ios", "region": "us"},
+			"false",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := evaluation.Evaluate(featureFlag, prd(testCase.attributes))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result.Value)
+		})
+	}
+}
+
+func TestEvaluateRolloutByKind(t *testing.T) {
+	featureFlag := newFlag("legacy")
+	featureFlag.Rollout = &models.Rollout{Percentage: 50, Value: "new", Kind: "device"}
+
+	var included, excluded string
+	for _, key := range []string{"device-1", "device-2", "device-3", "device-4", "device-5", "device-6"} {
+		if featureFlag.Rollout.Includes(featureFlag.ID, key) {
+			included = key
+		} else {
+			excluded = key
+		}
+	}
+	assert.NotEmpty(t, included)
+	assert.NotEmpty(t, excluded)
+
+	// Every user on the device gets the same answer
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{
+			"user_id":    userID,
+			"device.key": included,
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, evaluation.Result{Value: "new", Reason: evaluation.PercentageReason}, result)
+
+		result, err = evaluation.Evaluate(featureFlag, prd(map[string]string{
+			"user_id":    userID,
+			"device.key": excluded,
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, evaluation.DefaultReason, result.Reason)
+	}
+
+	// Without a device, the user's id isn't used instead
+	featureFlag.Rollout.Percentage = 100
+	result, err := evaluation.Evaluate(featureFlag, prd(map[string]string{"user_id": "user-1"}))
+	assert.NoError(t, err)
+	assert.Equal(t, evaluation.DefaultReason, result.Reason)
+}
@@ -0,0 +1,91 @@
+package evaluation_test
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/evaluation"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Results that change on purpose are accepted by rewriting the golden files
+// with `go test ./pkg/evaluation -run TestSnapshots -update` and committing
+// the diff along with the change that caused it.
+var update = flag.Bool("update", false, "rewrite the golden files of the snapshot tests")
+
+// snapshotInput is a testdata/snapshots/*.json file: every flag is evaluated
+// for every context, the way the env endpoints do.
+type snapshotInput struct {
+	Flags    []models.FeatureFlagRecord `json:"flags"`
+	Contexts []struct {
+		Environment string            `json:"environment"`
+		Attributes  map[string]string `json:"attributes"`
+	} `json:"contexts"`
+}
+
+// snapshot renders one block per context, listing what each flag served and
+// why, or that it wasn't served at all.
+func snapshot(t *testing.T, input snapshotInput) string {
+	var out strings.Builder
+	for _, context := range input.Contexts {
+		attributes, err := json.Marshal(context.Attributes)
+		assert.NoError(t, err)
+
+		out.WriteString("# " + context.Environment + " " + string(attributes) + "\n")
+
+		evaluator := evaluation.NewEvaluator(input.Flags, evaluation.Context{
+			Environment: context.Environment,
+			Attributes:  context.Attributes,
+		})
+		lines := make([]string, 0, len(input.Flags))
+		for index := range input.Flags {
+			name := input.Flags[index].QualifiedName()
+			result, err := evaluator.Evaluate(&input.Flags[index])
+			if err != nil {
+				lines = append(lines, name+" not served: "+err.Error())
+				continue
+			}
+			lines = append(lines, name+"="+result.Value+" "+result.Code())
+		}
+		sort.Strings(lines)
+
+		out.WriteString(strings.Join(lines, "\n") + "\n\n")
+	}
+
+	return out.String()
+}
+
+func TestSnapshots(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "snapshots", "*.json"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, inputs)
+
+	for _, inputPath := range inputs {
+		name := strings.TrimSuffix(filepath.Base(inputPath), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(inputPath)
+			assert.NoError(t, err)
+
+			var input snapshotInput
+			assert.NoError(t, json.Unmarshal(data, &input))
+
+			actual := snapshot(t, input)
+			goldenPath := strings.TrimSuffix(inputPath, ".json") + ".golden"
+			if *update {
+				assert.NoError(t, os.WriteFile(goldenPath, []byte(actual), 0o644))
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			assert.NoError(t, err, "run with -update to create the golden file")
+			assert.Equal(t, string(expected), actual,
+				"evaluation results changed; if that's intended, run with -update and commit the golden file")
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	kafkago "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// QueuedMessages is how many events can wait for the brokers. While they
+// are unreachable, new events are dropped instead of piling up in memory.
+const QueuedMessages = 10000
+
+// WriteTimeout bounds each handoff to the writer, which has to look up the
+// topic's partitions before it can queue a message.
+const WriteTimeout = 5 * time.Second
+
+// Writer is the part of kafka-go's Writer the producer uses.
+type Writer interface {
+	WriteMessages(ctx context.Context, messages ...kafkago.Message) error
+	Close() error
+}
+
+// NewWriter builds an asynchronous writer for the cluster at brokers. Failed
+// deliveries are logged and otherwise ignored.
+func NewWriter(brokers []string, logger *zap.Logger) *kafkago.Writer {
+	return &kafkago.Writer{
+		Addr:     kafkago.TCP(brokers...),
+		Balancer: &kafkago.Hash{},
+		Async:    true,
+		Completion: func(messages []kafkago.Message, err error) {
+			if err != nil {
+				logger.Error("Kafka error",
+					zap.String("cause", err.Error()),
+					zap.Int("messages", len(messages)),
+				)
+			}
+		},
+	}
+}
+
+// Producer publishes flag changes and evaluations as JSON messages. Events
+// are only handed to the writer while Run is running; until then, and while
+// the brokers are unreachable, they queue up to QueuedMessages.
+type Producer struct {
+	writer           Writer
+	logger           *zap.Logger
+	changesTopic     string
+	evaluationsTopic string
+	messages         chan kafkago.Message
+}
+
+// NewProducer publishes to changesTopic and evaluationsTopic. An empty topic
+// skips its events.
+func NewProducer(writer Writer, logger *zap.Logger, changesTopic, evaluationsTopic string) *Producer {
+	return &Producer{
+		writer:           writer,
+		logger:           logger,
+		changesTopic:     changesTopic,
+		evaluationsTopic: evaluationsTopic,
+		messages:         make(chan kafkago.Message, QueuedMessages),
+	}
+}
+
+// Publish sends a flag change keyed by the flag, so consumers see the
+// changes of one flag in order.
+func (p *Producer) Publish(payload webhooks.FeatureFlagPayload) {
+	p.enqueue(p.changesTopic, payload.FeatureFlagID.Hex(), payload)
+}
+
+// PublishOrganizationEvent sends an organization or member change to the
+// changes topic keyed by the organization. Consumers tell it from flag
+// changes by its event.
+func (p *Producer) PublishOrganizationEvent(payload webhooks.OrganizationPayload) {
+	p.enqueue(p.changesTopic, payload.OrganizationID.Hex(), payload)
+}
+
+// Record sends an evaluation keyed by the flag.
+func (p *Producer) Record(evaluation analytics.Evaluation) {
+	p.enqueue(p.evaluationsTopic, evaluation.FeatureFlagID, evaluation)
+}
+
+func (p *Producer) enqueue(topic, key string, event any) {
+	if topic == "" {
+		return
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		p.logger.Error("Kafka error",
+			zap.String("cause", err.Error()),
+		)
+		return
+	}
+
+	select {
+	case p.messages <- kafkago.Message{Topic: topic, Key: []byte(key), Value: value}:
+	default:
+		p.logger.Warn("Kafka event dropped",
+			zap.String("topic", topic),
+		)
+	}
+}
+
+// Run hands queued events to the writer until ctx is done, then closes it,
+// which flushes what the writer still holds.
+func (p *Producer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if err := p.writer.Close(); err != nil {
+				p.logger.Error("Kafka error",
+					zap.String("cause", err.Error()),
+				)
+			}
+			return
+		case message := <-p.messages:
+			p.write(ctx, message)
+		}
+	}
+}
+
+func (p *Producer) write(ctx context.Context, message kafkago.Message) {
+	writeCtx, cancel := context.WithTimeout(ctx, WriteTimeout)
+	defer cancel()
+
+	if err := p.writer.WriteMessages(writeCtx, message); err != nil {
+		p.logger.Error("Kafka error",
+			zap.String("cause", err.Error()),
+			zap.String("topic", message.Topic),
+		)
+	}
+}
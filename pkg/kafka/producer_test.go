@@ -0,0 +1,149 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/kafka"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// memoryWriter keeps every message written to it, or fails with err.
+type memoryWriter struct {
+	mu       sync.Mutex
+	err      error
+	messages []kafkago.Message
+	closed   bool
+}
+
+func (mw *memoryWriter) WriteMessages(_ context.Context, messages ...kafkago.Message) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	if mw.err != nil {
+		return mw.err
+	}
+	mw.messages = append(mw.messages, messages...)
+	return nil
+}
+
+func (mw *memoryWriter) Close() error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.closed = true
+	return nil
+}
+
+func (mw *memoryWriter) written() []kafkago.Message {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	return append([]kafkago.Message(nil), mw.messages...)
+}
+
+func run(producer *kafka.Producer) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		producer.Run(ctx)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestProducerPublishesToConfiguredTopics(t *testing.T) {
+	writer := &memoryWriter{}
+	producer := kafka.NewProducer(writer, zap.NewNop(), "flag-changes", "evaluations")
+	stop := run(producer)
+
+	featureFlagID := primitive.NewObjectID()
+	producer.Publish(webhooks.FeatureFlagPayload{
+		Event:         webhooks.FeatureFlagUpdatedEvent,
+		FeatureFlagID: featureFlagID,
+		Name:          "checkout",
+		Version:       2,
+	})
+	producer.Record(analytics.Evaluation{
+		FeatureFlagID: featureFlagID.Hex(),
+		FeatureFlag:   "checkout",
+		Value:         "true",
+	})
+
+	assert.Eventually(t, func() bool {
+		return len(writer.written()) == 2
+	}, time.Second, 10*time.Millisecond)
+	stop()
+
+	messages := writer.written()
+	assert.Equal(t, "flag-changes", messages[0].Topic)
+	assert.Equal(t, featureFlagID.Hex(), string(messages[0].Key))
+	var payload webhooks.FeatureFlagPayload
+	assert.NoError(t, json.Unmarshal(messages[0].Value, &payload))
+	assert.Equal(t, "checkout", payload.Name)
+	assert.Equal(t, 2, payload.Version)
+
+	assert.Equal(t, "evaluations", messages[1].Topic)
+	var evaluation analytics.Evaluation
+	assert.NoError(t, json.Unmarshal(messages[1].Value, &evaluation))
+	assert.Equal(t, "true", evaluation.Value)
+
+	assert.True(t, writer.closed)
+}
+
+func TestProducerSkipsEventsWithoutTopic(t *testing.T) {
+	writer := &memoryWriter{}
+	producer := kafka.NewProducer(writer, zap.NewNop(), "flag-changes", "")
+	stop := run(producer)
+
+	producer.Record(analytics.Evaluation{FeatureFlag: "checkout"})
+	producer.Publish(webhooks.FeatureFlagPayload{Name: "checkout"})
+
+	assert.Eventually(t, func() bool {
+		return len(writer.written()) == 1
+	}, time.Second, 10*time.Millisecond)
+	stop()
+
+	assert.Equal(t, "flag-changes", writer.written()[0].Topic)
+}
+
+func TestProducerLogsAndContinuesWhenBrokersAreUnavailable(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	writer := &memoryWriter{err: errors.New("dial tcp: connection refused")}
+	producer := kafka.NewProducer(writer, zap.New(core), "flag-changes", "")
+	stop := run(producer)
+
+	producer.Publish(webhooks.FeatureFlagPayload{Name: "checkout"})
+	producer.Publish(webhooks.FeatureFlagPayload{Name: "search"})
+
+	assert.Eventually(t, func() bool {
+		return logs.FilterMessage("Kafka error").Len() == 2
+	}, time.Second, 10*time.Millisecond)
+	stop()
+}
+
+func TestProducerDropsEventsWhenQueueIsFull(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	producer := kafka.NewProducer(&memoryWriter{}, zap.New(core), "flag-changes", "")
+
+	for index := 0; index <= kafka.QueuedMessages; index++ {
+		producer.Publish(webhooks.FeatureFlagPayload{Name: "checkout"})
+	}
+
+	assert.Equal(t, 1, logs.FilterMessage("Kafka event dropped").Len())
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const APIKeyCollectionName = "api_key"
+
+// APIKeyPrefix starts every key so leaked keys are easy to spot and scan for.
+const APIKeyPrefix = "tl_"
+
+type APIKeyModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewAPIKeyModel(db *mongo.Database) *APIKeyModel {
+	return &APIKeyModel{
+		db:         db,
+		collection: storage.Collection(db, APIKeyCollectionName),
+	}
+}
+
+// APIKeyRecord lets a server-side SDK evaluate the flags of an organization.
+// Only a hash of the key is stored. DefaultContext holds attributes every
+// evaluation made with the key starts from.
+type APIKeyRecord struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	KeyHash        string             `json:"-" bson:"key_hash"`
+	DefaultContext map[string]string  `json:"default_context,omitempty" bson:"default_context,omitempty"`
+	storage.Timestamps
+}
+
+// NewAPIKeyRecord generates a new key and returns it along with the record.
+// The key can't be recovered from the record, so it must be handed to the
+// caller right away.
+func NewAPIKeyRecord(
+	organizationID primitive.ObjectID,
+	name string,
+	defaultContext map[string]string,
+) (*APIKeyRecord, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(secret)
+
+	return &APIKeyRecord{
+		OrganizationID: organizationID,
+		Name:           name,
+		KeyHash:        HashAPIKey(key),
+		DefaultContext: defaultContext,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}, key, nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (akm *APIKeyModel) InsertOne(ctx context.Context, record *APIKeyRecord) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := akm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+func (akm *APIKeyModel) FindByKey(ctx context.Context, key string) (*APIKeyRecord, error) {
+	record := new(APIKeyRecord)
+	if err := akm.collection.FindOne(ctx, bson.D{{Key: "key_hash", Value: HashAPIKey(key)}}).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (akm *APIKeyModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]APIKeyRecord, error) {
+	records := make([]APIKeyRecord, 0)
+	cursor, err := akm.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]APIKeyRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// DeleteOne reports false when organizationID has no API key id.
+func (akm *APIKeyModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := akm.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const AuditLogCollectionName = "audit_log"
+
+type AuditLogModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewAuditLogModel(db *mongo.Database) *AuditLogModel {
+	return &AuditLogModel{
+		db:         db,
+		collection: storage.Collection(db, AuditLogCollectionName),
+	}
+}
+
+type AuditAction = string
+
+const (
+	PatchAction            AuditAction = "patch"
+	ApproveAction          AuditAction = "approve"
+	RollbackAction         AuditAction = "rollback"
+	DeleteAction           AuditAction = "delete"
+	DeleteRevisionAction   AuditAction = "delete_revision"
+	AutoRollbackAction     AuditAction = "auto_rollback"
+	RolloutRampStepAction  AuditAction = "rollout_ramp_step"
+	ImpersonateAction      AuditAction = "impersonate"
+	PauseAutomationAction  AuditAction = "pause_automation"
+	ResumeAutomationAction AuditAction = "resume_automation"
+	RepairAction           AuditAction = "repair"
+	CreateAPIKeyAction     AuditAction = "create_api_key"
+	DeleteAPIKeyAction     AuditAction = "delete_api_key"
+)
+
+// AuditEntry records a change to an organization. UserID is empty for
+// changes the system made on its own, like ramp steps and auto-rollbacks.
+// FeatureFlagID is empty for changes to the organization itself.
+// ImpersonationID is set for changes a platform admin made acting as UserID.
+type AuditEntry struct {
+	ID              primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID  primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	FeatureFlagID   primitive.ObjectID `json:"feature_flag_id,omitempty" bson:"feature_flag_id,omitempty"`
+	UserID          primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Action          AuditAction        `json:"action" bson:"action"`
+	Details         map[string]any     `json:"details,omitempty" bson:"details,omitempty"`
+	ImpersonationID primitive.ObjectID `json:"impersonation_id,omitempty" bson:"impersonation_id,omitempty"`
+	CreatedAt       primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+func NewAuditEntry(
+	organizationID,
+	featureFlagID,
+	userID primitive.ObjectID,
+	action AuditAction,
+	details map[string]any,
+) *AuditEntry {
+	return &AuditEntry{
+		OrganizationID: organizationID,
+		FeatureFlagID:  featureFlagID,
+		UserID:         userID,
+		Action:         action,
+		Details:        details,
+		CreatedAt:      primitive.NewDateTimeFromTime(time.Now().UTC()),
+	}
+}
+
+func (alm *AuditLogModel) InsertOne(ctx context.Context, record *AuditEntry) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := alm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+func organizationEntriesFilter(organizationID primitive.ObjectID, filter bson.D) bson.D {
+	query := bson.D{{Key: "organization_id", Value: organizationID}}
+
+	return append(query, filter...)
+}
+
+// CountMany counts the organization's entries matching filter.
+func (alm *AuditLogModel) CountMany(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	filter bson.D,
+) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = alm.collection.CountDocuments(ctx, organizationEntriesFilter(organizationID, filter))
+		return err
+	})
+
+	return count, err
+}
+
+// FindMany returns a page of the organization's entries matching filter, newest first.
+func (alm *AuditLogModel) FindMany(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	filter bson.D,
+	page,
+	limit int,
+) ([]AuditEntry, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	records := make([]AuditEntry, 0)
+	cursor, err := alm.collection.Find(ctx, organizationEntriesFilter(organizationID, filter), findOptions)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]AuditEntry, 0), err
+	}
+
+	return records, nil
+}
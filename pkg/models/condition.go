@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ConditionOperator = string
+
+const (
+	AndOperator ConditionOperator = "and"
+	OrOperator  ConditionOperator = "or"
+)
+
+// PredicateSeparator splits a predicate into the context attribute it
+// targets and the value that attribute must hold, e.g. "country: BR".
+const PredicateSeparator = ":"
+
+var ErrInvalidCondition = errors.New("invalid rule condition")
+
+// Condition is a boolean tree of predicates. A leaf holds a Predicate; a
+// group combines its Conditions with Operator, e.g.
+// (plan: pro OR plan: enterprise) AND region: us is an "and" group holding
+// an "or" group and a leaf.
+type Condition struct {
+	Operator   ConditionOperator `json:"operator,omitempty" bson:"operator,omitempty" yaml:"operator,omitempty"`
+	Conditions []Condition       `json:"conditions,omitempty" bson:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Predicate  string            `json:"predicate,omitempty" bson:"predicate,omitempty" yaml:"predicate,omitempty"`
+}
+
+// IsGroup reports whether the condition combines other conditions rather
+// than holding a predicate.
+func (c *Condition) IsGroup() bool {
+	return c.Operator != ""
+}
+
+// Equal reports whether c and other are the same tree. Either may be nil.
+func (c *Condition) Equal(other *Condition) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	if c.Operator != other.Operator || c.Predicate != other.Predicate ||
+		len(c.Conditions) != len(other.Conditions) {
+		return false
+	}
+	for index := range c.Conditions {
+		if !c.Conditions[index].Equal(&other.Conditions[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Depth is 1 for a leaf and one more than the deepest child for a group.
+func (c *Condition) Depth() int {
+	depth := 0
+	for index := range c.Conditions {
+		if childDepth := c.Conditions[index].Depth(); childDepth > depth {
+			depth = childDepth
+		}
+	}
+
+	return depth + 1
+}
+
+// Predicates lists the predicates of every leaf of the tree.
+func (c *Condition) Predicates() []string {
+	if !c.IsGroup() {
+		return []string{c.Predicate}
+	}
+
+	predicates := make([]string, 0, len(c.Conditions))
+	for index := range c.Conditions {
+		predicates = append(predicates, c.Conditions[index].Predicates()...)
+	}
+
+	return predicates
+}
+
+// Validate checks the shape of the tree: groups have a known operator and at
+// least one child and no predicate, leaves have a well-formed predicate and
+// no children.
+func (c *Condition) Validate() error {
+	if !c.IsGroup() {
+		if len(c.Conditions) > 0 {
+			return fmt.Errorf("%w: conditions without an operator", ErrInvalidCondition)
+		}
+		if !strings.Contains(c.Predicate, PredicateSeparator) {
+			return fmt.Errorf("%w: predicate %q isn't \"attribute: value\"", ErrInvalidCondition, c.Predicate)
+		}
+
+		return nil
+	}
+
+	if c.Operator != AndOperator && c.Operator != OrOperator {
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidCondition, c.Operator)
+	}
+	if c.Predicate != "" {
+		return fmt.Errorf("%w: a group can't have a predicate", ErrInvalidCondition)
+	}
+	if len(c.Conditions) == 0 {
+		return fmt.Errorf("%w: empty %s group", ErrInvalidCondition, c.Operator)
+	}
+
+	for index := range c.Conditions {
+		if err := c.Conditions[index].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
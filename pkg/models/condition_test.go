@@ -0,0 +1,123 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func leaf(predicate string) models.Condition {
+	return models.Condition{Predicate: predicate}
+}
+
+func TestConditionValidate(t *testing.T) {
+	testCases := map[string]struct {
+		condition models.Condition
+		valid     bool
+	}{
+		"leaf": {
+			condition: leaf("plan: pro"),
+			valid:     true,
+		},
+		"nested groups": {
+			condition: models.Condition{
+				Operator: models.AndOperator,
+				Conditions: []models.Condition{
+					{
+						Operator:   models.OrOperator,
+						Conditions: []models.Condition{leaf("plan: pro"), leaf("plan: enterprise")},
+					},
+					leaf("region: us"),
+				},
+			},
+			valid: true,
+		},
+		"leaf without separator": {
+			condition: leaf("plan"),
+		},
+		"unknown operator": {
+			condition: models.Condition{
+				Operator:   "xor",
+				Conditions: []models.Condition{leaf("plan: pro")},
+			},
+		},
+		"empty group": {
+			condition: models.Condition{Operator: models.OrOperator},
+		},
+		"empty nested group": {
+			condition: models.Condition{
+				Operator: models.AndOperator,
+				Conditions: []models.Condition{
+					leaf("region: us"),
+					{Operator: models.OrOperator, Conditions: []models.Condition{}},
+				},
+			},
+		},
+		"group with predicate": {
+			condition: models.Condition{
+				Operator:   models.AndOperator,
+				Predicate:  "plan: pro",
+				Conditions: []models.Condition{leaf("region: us")},
+			},
+		},
+		"children without operator": {
+			condition: models.Condition{
+				Conditions: []models.Condition{leaf("region: us")},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := testCase.condition.Validate()
+			if testCase.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, models.ErrInvalidCondition)
+			}
+		})
+	}
+}
+
+func TestConditionDepthAndPredicates(t *testing.T) {
+	condition := models.Condition{
+		Operator: models.AndOperator,
+		Conditions: []models.Condition{
+			{
+				Operator:   models.OrOperator,
+				Conditions: []models.Condition{leaf("plan: pro"), leaf("plan: enterprise")},
+			},
+			leaf("region: us"),
+		},
+	}
+
+	assert.Equal(t, 1, (&models.Condition{Predicate: "plan: pro"}).Depth())
+	assert.Equal(t, 3, condition.Depth())
+	assert.Equal(t, []string{"plan: pro", "plan: enterprise", "region: us"}, condition.Predicates())
+}
+
+func TestConditionEqual(t *testing.T) {
+	tree := func(predicate string) *models.Condition {
+		return &models.Condition{
+			Operator:   models.AndOperator,
+			Conditions: []models.Condition{leaf("plan: pro"), leaf(predicate)},
+		}
+	}
+
+	assert.True(t, tree("country: BR").Equal(tree("country: BR")))
+	assert.False(t, tree("country: BR").Equal(tree("country: US")))
+	assert.False(t, tree("country: BR").Equal(&models.Condition{Operator: models.AndOperator}))
+	assert.False(t, tree("country: BR").Equal(nil))
+	assert.True(t, (*models.Condition)(nil).Equal(nil))
+}
+
+func TestRuleCantHaveBothPredicateAndCondition(t *testing.T) {
+	condition := leaf("plan: pro")
+	rule := models.Rule{Predicate: "region: us", Condition: &condition}
+
+	assert.ErrorIs(t, rule.Validate(), models.ErrInvalidCondition)
+
+	rule.Predicate = ""
+	assert.NoError(t, rule.Validate())
+}
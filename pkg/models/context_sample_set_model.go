@@ -0,0 +1,159 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ContextSampleSetCollectionName = "context_sample_set"
+
+type ContextSampleSetModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewContextSampleSetModel(db *mongo.Database) *ContextSampleSetModel {
+	return &ContextSampleSetModel{
+		db:         db,
+		collection: storage.Collection(db, ContextSampleSetCollectionName),
+	}
+}
+
+// ContextSampleSetRecord is a named set of evaluation contexts representative
+// of an organization's traffic, used to preview revisions before approving them.
+type ContextSampleSetRecord struct {
+	ID             primitive.ObjectID  `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID  `json:"organization_id" bson:"organization_id"`
+	Name           string              `json:"name" bson:"name"`
+	Contexts       []map[string]string `json:"contexts" bson:"contexts"`
+	storage.Timestamps
+}
+
+func NewContextSampleSetRecord(
+	organizationID primitive.ObjectID,
+	name string,
+	contexts []map[string]string,
+) *ContextSampleSetRecord {
+	return &ContextSampleSetRecord{
+		OrganizationID: organizationID,
+		Name:           name,
+		Contexts:       contexts,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}
+}
+
+func (cssm *ContextSampleSetModel) InsertOne(
+	ctx context.Context,
+	record *ContextSampleSetRecord,
+) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := cssm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+// FindByID only finds sample sets of organizationID.
+func (cssm *ContextSampleSetModel) FindByID(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (*ContextSampleSetRecord, error) {
+	record := new(ContextSampleSetRecord)
+	if err := cssm.collection.FindOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	}).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (cssm *ContextSampleSetModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]ContextSampleSetRecord, error) {
+	records := make([]ContextSampleSetRecord, 0)
+	cursor, err := cssm.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]ContextSampleSetRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// ReplaceContexts overwrites the name and contexts of the sample set. It
+// reports false when organizationID has no sample set id.
+func (cssm *ContextSampleSetModel) ReplaceContexts(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+	name string,
+	contexts []map[string]string,
+) (bool, error) {
+	var result *mongo.UpdateResult
+	err := storage.Retry(ctx, func() error {
+		var err error
+		result, err = cssm.collection.UpdateOne(ctx,
+			bson.D{
+				{Key: "_id", Value: id},
+				{Key: "organization_id", Value: organizationID},
+			},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "name", Value: name},
+				{Key: "contexts", Value: contexts},
+				{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+			}}},
+		)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// DeleteOne reports false when organizationID has no sample set id.
+func (cssm *ContextSampleSetModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := cssm.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
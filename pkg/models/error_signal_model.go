@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const ErrorSignalCollectionName = "error_signal"
+
+type ErrorSignalModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewErrorSignalModel(db *mongo.Database) *ErrorSignalModel {
+	return &ErrorSignalModel{
+		db:         db,
+		collection: storage.Collection(db, ErrorSignalCollectionName),
+	}
+}
+
+// ErrorSignal is a batch of request outcomes a client observed while the
+// revision RevisionID was live.
+type ErrorSignal struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	FeatureFlagID  primitive.ObjectID `json:"feature_flag_id" bson:"feature_flag_id"`
+	RevisionID     primitive.ObjectID `json:"revision_id" bson:"revision_id"`
+	Requests       int                `json:"requests" bson:"requests"`
+	Errors         int                `json:"errors" bson:"errors"`
+	CreatedAt      primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+// RollbackGuard rolls a flag back to its previous revision when the error
+// rate reported for the live revision over Window goes above MaxErrorRate.
+// Nothing happens until at least MinRequests were reported in the window.
+type RollbackGuard struct {
+	MaxErrorRate float64       `json:"max_error_rate" bson:"max_error_rate"`
+	Window       time.Duration `json:"window" bson:"window"`
+	MinRequests  int           `json:"min_requests" bson:"min_requests"`
+}
+
+// Tripped reports whether requests and errors observed over the window cross the guard.
+func (rg *RollbackGuard) Tripped(requests, errors int) bool {
+	if requests == 0 || requests < rg.MinRequests {
+		return false
+	}
+
+	return float64(errors)/float64(requests) > rg.MaxErrorRate
+}
+
+func (esm *ErrorSignalModel) InsertOne(ctx context.Context, record *ErrorSignal) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := esm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+// SumSince totals the requests and errors reported for revisionID since since.
+func (esm *ErrorSignalModel) SumSince(
+	ctx context.Context,
+	revisionID primitive.ObjectID,
+	since time.Time,
+) (int, int, error) {
+	cursor, err := esm.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "revision_id", Value: revisionID},
+			{Key: "created_at", Value: bson.M{"$gte": primitive.NewDateTimeFromTime(since)}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "requests", Value: bson.M{"$sum": "$requests"}},
+			{Key: "errors", Value: bson.M{"$sum": "$errors"}},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Requests int `bson:"requests"`
+		Errors   int `bson:"errors"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, 0, err
+	}
+	if len(totals) == 0 {
+		return 0, 0, nil
+	}
+
+	return totals[0].Requests, totals[0].Errors, nil
+}
@@ -0,0 +1,18 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackGuardTripped(t *testing.T) {
+	guard := models.RollbackGuard{MaxErrorRate: 0.05, Window: time.Minute, MinRequests: 100}
+
+	assert.False(t, guard.Tripped(0, 0))
+	assert.False(t, guard.Tripped(50, 50), "below the minimum number of requests")
+	assert.False(t, guard.Tripped(100, 5), "exactly at the threshold")
+	assert.True(t, guard.Tripped(100, 6))
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FlagStatus tells whether a flag is served: archived flags are retired,
+// live ones have an approved revision and unreleased ones don't yet.
+type FlagStatus = string
+
+const (
+	LiveFlag       FlagStatus = "live"
+	UnreleasedFlag FlagStatus = "unreleased"
+	ArchivedFlag   FlagStatus = "archived"
+)
+
+// FeatureFlagCounts counts an organization's flags, both by status and by
+// type.
+type FeatureFlagCounts struct {
+	Total    int64                `json:"total"`
+	ByStatus map[FlagStatus]int64 `json:"by_status"`
+	ByType   map[FlagType]int64   `json:"by_type"`
+}
+
+// CountByStatusAndType counts the organization's flags that aren't deleted.
+func (ffm *FeatureFlagModel) CountByStatusAndType(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) (*FeatureFlagCounts, error) {
+	status := bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$gt": bson.A{"$archived_at", nil}}, "then": ArchivedFlag},
+			bson.M{
+				"case": bson.M{"$in": bson.A{Live, bson.M{"$ifNull": bson.A{"$revisions.status", bson.A{}}}}},
+				"then": LiveFlag,
+			},
+		},
+		"default": UnreleasedFlag,
+	}}
+
+	var groups []struct {
+		ID struct {
+			Status FlagStatus `bson:"status"`
+			Type   FlagType   `bson:"type"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	err := storage.Retry(ctx, func() error {
+		cursor, err := ffm.collection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: organizationFlagsFilter(organizationID, nil)}},
+			{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: bson.M{"status": status, "type": "$type"}},
+				{Key: "count", Value: bson.M{"$sum": 1}},
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &groups)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &FeatureFlagCounts{
+		ByStatus: map[FlagStatus]int64{LiveFlag: 0, UnreleasedFlag: 0, ArchivedFlag: 0},
+		ByType:   make(map[FlagType]int64),
+	}
+	for _, group := range groups {
+		counts.Total += group.Count
+		counts.ByStatus[group.ID.Status] += group.Count
+		counts.ByType[group.ID.Type] += group.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,153 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type DependencyEdge struct {
+	FeatureFlagID  primitive.ObjectID `json:"feature_flag_id"`
+	PrerequisiteID primitive.ObjectID `json:"prerequisite_id"`
+}
+
+// DependencyGraph indexes the prerequisite relations between the flags of an organization.
+type DependencyGraph struct {
+	flags         map[primitive.ObjectID]*FeatureFlagRecord
+	prerequisites map[primitive.ObjectID][]primitive.ObjectID
+	dependents    map[primitive.ObjectID][]primitive.ObjectID
+}
+
+func NewDependencyGraph(records []FeatureFlagRecord) *DependencyGraph {
+	graph := &DependencyGraph{
+		flags:         make(map[primitive.ObjectID]*FeatureFlagRecord, len(records)),
+		prerequisites: make(map[primitive.ObjectID][]primitive.ObjectID),
+		dependents:    make(map[primitive.ObjectID][]primitive.ObjectID),
+	}
+
+	for index := range records {
+		record := &records[index]
+		graph.flags[record.ID] = record
+		for _, prerequisite := range record.Prerequisites {
+			graph.prerequisites[record.ID] = append(graph.prerequisites[record.ID], prerequisite.FeatureFlagID)
+			graph.dependents[prerequisite.FeatureFlagID] = append(graph.dependents[prerequisite.FeatureFlagID], record.ID)
+		}
+	}
+
+	return graph
+}
+
+// Flag returns the record indexed under id, or nil when the graph
+// only knows the id because another flag references it.
+func (dg *DependencyGraph) Flag(id primitive.ObjectID) *FeatureFlagRecord {
+	return dg.flags[id]
+}
+
+// Dependents returns the flags that directly list id as a prerequisite.
+func (dg *DependencyGraph) Dependents(id primitive.ObjectID) []primitive.ObjectID {
+	return dg.dependents[id]
+}
+
+// Upstream returns every flag id reaches by following prerequisites, in breadth-first order.
+func (dg *DependencyGraph) Upstream(id primitive.ObjectID) []primitive.ObjectID {
+	return walk(id, dg.prerequisites)
+}
+
+// Downstream returns every flag that directly or transitively depends on id, in breadth-first order.
+func (dg *DependencyGraph) Downstream(id primitive.ObjectID) []primitive.ObjectID {
+	return walk(id, dg.dependents)
+}
+
+// Edges returns the prerequisite edges whose both ends are in ids.
+func (dg *DependencyGraph) Edges(ids []primitive.ObjectID) []DependencyEdge {
+	included := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		included[id] = true
+	}
+
+	edges := make([]DependencyEdge, 0)
+	processed := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if processed[id] {
+			continue
+		}
+		processed[id] = true
+
+		for _, prerequisiteID := range dg.prerequisites[id] {
+			if included[prerequisiteID] {
+				edges = append(edges, DependencyEdge{
+					FeatureFlagID:  id,
+					PrerequisiteID: prerequisiteID,
+				})
+			}
+		}
+	}
+
+	return edges
+}
+
+// Cycles returns the prerequisite cycles reachable from any of ids. Each cycle
+// is reported once, as the path of flag ids that leads back to its first element.
+func (dg *DependencyGraph) Cycles(ids []primitive.ObjectID) [][]primitive.ObjectID {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	cycles := make([][]primitive.ObjectID, 0)
+	state := make(map[primitive.ObjectID]int)
+	stack := make([]primitive.ObjectID, 0)
+
+	var visit func(id primitive.ObjectID)
+	visit = func(id primitive.ObjectID) {
+		state[id] = visiting
+		stack = append(stack, id)
+
+		for _, prerequisiteID := range dg.prerequisites[id] {
+			switch state[prerequisiteID] {
+			case unvisited:
+				visit(prerequisiteID)
+			case visiting:
+				for index := len(stack) - 1; index >= 0; index-- {
+					if stack[index] == prerequisiteID {
+						cycle := make([]primitive.ObjectID, len(stack)-index)
+						copy(cycle, stack[index:])
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	return cycles
+}
+
+func walk(start primitive.ObjectID, adjacency map[primitive.ObjectID][]primitive.ObjectID) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{start: true}
+	queue := []primitive.ObjectID{start}
+	result := make([]primitive.ObjectID, 0)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range adjacency[current] {
+			if seen[next] {
+				continue
+			}
+
+			seen[next] = true
+			result = append(result, next)
+			queue = append(queue, next)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveOverride sets the override of override.UserID on flag id, in place of
+// the one the user had, and returns the flag's overrides once saved. Only the
+// user's own entry is written, so concurrent saves for other users aren't
+// lost. It returns mongo.ErrNoDocuments when the flag doesn't exist.
+func (ffm *FeatureFlagModel) SaveOverride(
+	ctx context.Context,
+	id primitive.ObjectID,
+	override Override,
+) ([]Override, error) {
+	overrides, err := ffm.replaceOverride(ctx, id, override)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return overrides, err
+	}
+
+	overrides, err = ffm.updateOverrides(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+			{Key: "overrides.user_id", Value: bson.M{"$ne": override.UserID}},
+		},
+		bson.D{{Key: "$push", Value: bson.M{"overrides": override}}},
+	)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return overrides, err
+	}
+
+	// A concurrent save for the same user added its override in between.
+	return ffm.replaceOverride(ctx, id, override)
+}
+
+// DeleteOverride removes the override of userID from flag id. It reports
+// false when the user had none.
+func (ffm *FeatureFlagModel) DeleteOverride(ctx context.Context, id primitive.ObjectID, userID string) (bool, error) {
+	result, err := ffm.collection.UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+			{Key: "overrides.user_id", Value: userID},
+		},
+		touch(bson.D{{Key: "$pull", Value: bson.M{"overrides": bson.M{"user_id": userID}}}}),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+func (ffm *FeatureFlagModel) replaceOverride(
+	ctx context.Context,
+	id primitive.ObjectID,
+	override Override,
+) ([]Override, error) {
+	return ffm.updateOverrides(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "deleted_at", Value: bson.M{"$exists": false}},
+			{Key: "overrides.user_id", Value: override.UserID},
+		},
+		bson.D{{Key: "$set", Value: bson.M{"overrides.$.value": override.Value}}},
+	)
+}
+
+func (ffm *FeatureFlagModel) updateOverrides(ctx context.Context, filter, update bson.D) ([]Override, error) {
+	record := new(FeatureFlagRecord)
+	err := ffm.collection.FindOneAndUpdate(ctx, filter, touch(update), options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"overrides": 1}),
+	).Decode(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Overrides, nil
+}
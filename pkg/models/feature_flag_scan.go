@@ -0,0 +1,93 @@
+package models
+
+import (
+	"context"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindFlagOrganizations maps the id of every flag that isn't deleted, of any
+// organization, to its organization.
+func (ffm *FeatureFlagModel) FindFlagOrganizations(
+	ctx context.Context,
+) (map[primitive.ObjectID]primitive.ObjectID, error) {
+	var records []struct {
+		ID             primitive.ObjectID `bson:"_id"`
+		OrganizationID primitive.ObjectID `bson:"organization_id"`
+	}
+	err := storage.Retry(ctx, func() error {
+		cursor, err := ffm.collection.Find(
+			ctx,
+			bson.D{{Key: "deleted_at", Value: bson.M{"$exists": false}}},
+			options.Find().SetProjection(bson.D{{Key: "organization_id", Value: 1}}),
+		)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := make(map[primitive.ObjectID]primitive.ObjectID, len(records))
+	for _, record := range records {
+		organizations[record.ID] = record.OrganizationID
+	}
+
+	return organizations, nil
+}
+
+// ForEach calls fn with every flag that isn't deleted, of any organization,
+// one at a time so the whole collection never sits in memory. It stops at
+// the first error fn returns.
+func (ffm *FeatureFlagModel) ForEach(ctx context.Context, fn func(*FeatureFlagRecord) error) error {
+	cursor, err := ffm.collection.Find(
+		ctx,
+		bson.D{{Key: "deleted_at", Value: bson.M{"$exists": false}}},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		record := new(FeatureFlagRecord)
+		if err := cursor.Decode(record); err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// SaveRepair saves the revisions and prerequisites of a repaired flag. It
+// reports false, without saving anything, when the flag changed since it was
+// read.
+func (ffm *FeatureFlagModel) SaveRepair(ctx context.Context, record *FeatureFlagRecord) (bool, error) {
+	result, err := ffm.collection.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: record.ID},
+			{Key: "updated_at", Value: record.UpdatedAt},
+		},
+		touch(bson.D{{Key: "$set", Value: bson.D{
+			{Key: "revisions", Value: record.Revisions},
+			{Key: "prerequisites", Value: record.Prerequisites},
+		}}}),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxTags is how many tags a flag can have.
+const MaxTags = 20
+
+const MaxTagLength = 50
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]*$`)
+
+// NormalizeTag trims and lowercases tag, so "Team:Growth " and "team:growth"
+// are the same tag. Tags start with a letter or digit, followed by letters,
+// digits, _, ., : or -.
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if len(normalized) > MaxTagLength || !tagPattern.MatchString(normalized) {
+		return "", ErrInvalidTag
+	}
+
+	return normalized, nil
+}
+
+// NormalizeTags normalizes every tag of tags and returns them sorted and
+// without duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// HasTag reports whether the flag is tagged with tag, which must be normalized.
+func (ffr *FeatureFlagRecord) HasTag(tag string) bool {
+	for _, flagTag := range ffr.Tags {
+		if flagTag == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddTag tags every flag of ids with tag, except those that already have
+// MaxTags tags, and returns how many it changed.
+func (ffm *FeatureFlagModel) AddTag(
+	ctx context.Context,
+	ids []primitive.ObjectID,
+	tag string,
+	userID primitive.ObjectID,
+) (int64, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.M{"$in": ids}},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		{Key: "tags", Value: bson.M{"$ne": tag}},
+		{Key: "tags." + strconv.Itoa(MaxTags-1), Value: bson.M{"$exists": false}},
+	}
+	update := touch(bson.D{
+		{Key: "$push", Value: bson.M{"tags": bson.M{"$each": bson.A{tag}, "$sort": 1}}},
+		{Key: "$set", Value: bson.M{"updated_by": userID}},
+	})
+
+	return ffm.updateTags(ctx, filter, update)
+}
+
+// RemoveTag removes tag from every flag of ids and returns how many it
+// changed.
+func (ffm *FeatureFlagModel) RemoveTag(
+	ctx context.Context,
+	ids []primitive.ObjectID,
+	tag string,
+	userID primitive.ObjectID,
+) (int64, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.M{"$in": ids}},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+		{Key: "tags", Value: tag},
+	}
+	update := touch(bson.D{
+		{Key: "$pull", Value: bson.M{"tags": tag}},
+		{Key: "$set", Value: bson.M{"updated_by": userID}},
+	})
+
+	return ffm.updateTags(ctx, filter, update)
+}
+
+func (ffm *FeatureFlagModel) updateTags(ctx context.Context, filter, update bson.D) (int64, error) {
+	result, err := ffm.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
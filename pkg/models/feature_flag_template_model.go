@@ -0,0 +1,168 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const FeatureFlagTemplateCollectionName = "feature_flag_template"
+
+type FeatureFlagTemplateModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewFeatureFlagTemplateModel(db *mongo.Database) *FeatureFlagTemplateModel {
+	return &FeatureFlagTemplateModel{
+		db:         db,
+		collection: storage.Collection(db, FeatureFlagTemplateCollectionName),
+	}
+}
+
+// FeatureFlagTemplateRecord pre-fills the flags an organization creates from
+// it. NamePattern, when set, is a regular expression the whole name of those
+// flags has to match.
+type FeatureFlagTemplateRecord struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	NamePattern    string             `json:"name_pattern,omitempty" bson:"name_pattern,omitempty"`
+	Namespace      string             `json:"namespace,omitempty" bson:"namespace,omitempty"`
+	Description    string             `json:"description,omitempty" bson:"description,omitempty"`
+	Owner          string             `json:"owner,omitempty" bson:"owner,omitempty"`
+	Type           FlagType           `json:"type" bson:"type"`
+	DefaultValue   string             `json:"default_value" bson:"default_value"`
+	Rules          []Rule             `json:"rules" bson:"rules"`
+	Constraints    *ValueConstraints  `json:"constraints,omitempty" bson:"constraints,omitempty"`
+	storage.Timestamps
+}
+
+// NameMatcher compiles the template's name pattern so it matches whole names
+// only. It returns nil when the template has no pattern.
+func (fftr *FeatureFlagTemplateRecord) NameMatcher() (*regexp.Regexp, error) {
+	if fftr.NamePattern == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile("^(?:" + fftr.NamePattern + ")$")
+}
+
+func (fftm *FeatureFlagTemplateModel) InsertOne(
+	ctx context.Context,
+	record *FeatureFlagTemplateRecord,
+) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := fftm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+// FindByID only finds templates of organizationID.
+func (fftm *FeatureFlagTemplateModel) FindByID(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (*FeatureFlagTemplateRecord, error) {
+	record := new(FeatureFlagTemplateRecord)
+	if err := fftm.collection.FindOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	}).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (fftm *FeatureFlagTemplateModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]FeatureFlagTemplateRecord, error) {
+	records := make([]FeatureFlagTemplateRecord, 0)
+	cursor, err := fftm.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]FeatureFlagTemplateRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// ReplaceOne overwrites everything but the ids and creation time of the
+// template with record. It reports false when organizationID has no
+// template id.
+func (fftm *FeatureFlagTemplateModel) ReplaceOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+	record *FeatureFlagTemplateRecord,
+) (bool, error) {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	}
+
+	var result *mongo.UpdateResult
+	err := storage.Retry(ctx, func() error {
+		var err error
+		result, err = fftm.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "name", Value: record.Name},
+			{Key: "name_pattern", Value: record.NamePattern},
+			{Key: "namespace", Value: record.Namespace},
+			{Key: "description", Value: record.Description},
+			{Key: "owner", Value: record.Owner},
+			{Key: "type", Value: record.Type},
+			{Key: "default_value", Value: record.DefaultValue},
+			{Key: "rules", Value: record.Rules},
+			{Key: "constraints", Value: record.Constraints},
+			{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+		}}})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// DeleteOne reports false when organizationID has no template id.
+func (fftm *FeatureFlagTemplateModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := fftm.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
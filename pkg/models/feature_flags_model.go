@@ -2,7 +2,11 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Roll-Play/togglelabs/pkg/storage"
@@ -22,7 +26,7 @@ type FeatureFlagModel struct {
 func NewFeatureFlagModel(db *mongo.Database) *FeatureFlagModel {
 	return &FeatureFlagModel{
 		db:         db,
-		collection: db.Collection(FeatureFlagCollectionName),
+		collection: storage.Collection(db, FeatureFlagCollectionName),
 	}
 }
 
@@ -34,11 +38,82 @@ const (
 	Archived RevisionStatus = "archived"
 )
 
+// Rule serves Value when Predicate, or Condition for a compound rule,
+// matches the evaluation context in Env. A rule targeting UserListID instead
+// matches the users of that list. A rule has exactly one of the three.
+// Rules are evaluated in the order they appear in their revision and the
+// first enabled match wins, so reordering them changes what is served.
 type Rule struct {
-	Predicate string `json:"predicate" bson:"predicate" validate:"required"`
-	Value     string `json:"value" bson:"value" validate:"required"`
-	Env       string `json:"env" bson:"env" validate:"required"`
-	IsEnabled bool   `json:"is_enabled" bson:"is_enabled" validate:"required,boolean"`
+	ID         primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Predicate  string             `json:"predicate,omitempty" bson:"predicate,omitempty" validate:"required_without_all=Condition UserListID"`
+	Condition  *Condition         `json:"condition,omitempty" bson:"condition,omitempty"`
+	UserListID primitive.ObjectID `json:"user_list_id,omitempty" bson:"user_list_id,omitempty"`
+	Value      string             `json:"value" bson:"value" validate:"required"`
+	Env        string             `json:"env" bson:"env" validate:"required"`
+	// IsEnabled false keeps the rule in its revision without ever matching it.
+	// Rules decoded without it are enabled, see UnmarshalJSON.
+	IsEnabled bool `json:"is_enabled" bson:"is_enabled" validate:"boolean"`
+}
+
+// UnmarshalJSON enables rules that leave is_enabled out, as they were
+// before rules could be switched off. Only an explicit false disables one.
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type rule Rule
+	decoded := rule{IsEnabled: true}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = Rule(decoded)
+
+	return nil
+}
+
+// Validate checks that the rule has either a predicate, a well-formed
+// condition tree or a user list.
+func (r *Rule) Validate() error {
+	if !r.UserListID.IsZero() {
+		if r.Predicate != "" || r.Condition != nil {
+			return fmt.Errorf("%w: a rule targeting a user list can't have a predicate or a condition", ErrInvalidCondition)
+		}
+		return nil
+	}
+	if r.Condition == nil {
+		return nil
+	}
+	if r.Predicate != "" {
+		return fmt.Errorf("%w: a rule can't have both a predicate and a condition", ErrInvalidCondition)
+	}
+
+	return r.Condition.Validate()
+}
+
+// Predicates lists every predicate the rule matches on, none for a user list.
+func (r *Rule) Predicates() []string {
+	if !r.UserListID.IsZero() {
+		return nil
+	}
+	if r.Condition == nil {
+		return []string{r.Predicate}
+	}
+
+	return r.Condition.Predicates()
+}
+
+// withRuleIDs gives an id to every rule that doesn't have one yet, keeping existing ids.
+func withRuleIDs(rules []Rule) []Rule {
+	if rules == nil {
+		return nil
+	}
+
+	identified := make([]Rule, len(rules))
+	for index, rule := range rules {
+		if rule.ID.IsZero() {
+			rule.ID = primitive.NewObjectID()
+		}
+		identified[index] = rule
+	}
+
+	return identified
 }
 
 type Revision struct {
@@ -47,9 +122,18 @@ type Revision struct {
 	Status         RevisionStatus     `json:"status" bson:"status"`
 	DefaultValue   string             `json:"default_value" bson:"default_value"`
 	LastRevisionID primitive.ObjectID `json:"last_revision_id,omitempty" bson:"last_revision_id,omitempty"`
+	ApprovedBy     primitive.ObjectID `json:"approved_by,omitempty" bson:"approved_by,omitempty"`
+	ApprovedAt     primitive.DateTime `json:"approved_at,omitempty" bson:"approved_at,omitempty"`
 	Rules          []Rule
 }
 
+// Approve records userID promoting the revision to Live at approvedAt.
+func (r *Revision) Approve(userID primitive.ObjectID, approvedAt time.Time) {
+	r.Status = Live
+	r.ApprovedBy = userID
+	r.ApprovedAt = primitive.NewDateTimeFromTime(approvedAt.UTC())
+}
+
 type FlagType = string
 
 const (
@@ -59,19 +143,95 @@ const (
 	Number  FlagType = "number"
 )
 
+// LifecycleStage tells teams what a flag is meant for, independently of the
+// status of its revisions. It doesn't change what the flag serves.
+type LifecycleStage = string
+
+const (
+	Development LifecycleStage = "development"
+	InRollout   LifecycleStage = "in_rollout"
+	Stable      LifecycleStage = "stable"
+	Deprecated  LifecycleStage = "deprecated"
+)
+
+// Prerequisite requires another flag of the same organization to serve Value
+// before the dependent flag is evaluated.
+type Prerequisite struct {
+	FeatureFlagID primitive.ObjectID `json:"feature_flag_id" bson:"feature_flag_id" validate:"required"`
+	Value         string             `json:"value" bson:"value" validate:"required"`
+}
+
+// ValueConstraints limit what a flag can serve beyond its type: Enum lists
+// the only values a string or number flag takes, Min and Max bound a number
+// flag. Revisions, rollouts and overrides are checked against them on write.
+type ValueConstraints struct {
+	Enum []string `json:"enum,omitempty" bson:"enum,omitempty" validate:"max=100"`
+	Min  *float64 `json:"min,omitempty" bson:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty" bson:"max,omitempty"`
+}
+
+// IsEmpty reports whether vc doesn't limit anything, which is the case for a
+// nil vc.
+func (vc *ValueConstraints) IsEmpty() bool {
+	return vc == nil || (len(vc.Enum) == 0 && vc.Min == nil && vc.Max == nil)
+}
+
+// Override forces Value for a single end-user id, ahead of any other targeting.
+type Override struct {
+	UserID string `json:"user_id" bson:"user_id"`
+	Value  string `json:"value" bson:"value"`
+}
+
 type FeatureFlagRecord struct {
 	ID             primitive.ObjectID `json:"_id,omitempty" bson:"_id"`
 	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
 	UserID         primitive.ObjectID `json:"user_id" bson:"user_id"`
 	Version        int                `json:"version" bson:"version"`
 	Name           string             `json:"name" bson:"name"`
+	Namespace      string             `json:"namespace,omitempty" bson:"namespace,omitempty"`
+	Description    string             `json:"description,omitempty" bson:"description,omitempty"`
+	Owner          string             `json:"owner,omitempty" bson:"owner,omitempty"`
 	Type           FlagType           `json:"type" bson:"type"`
-	Revisions      []Revision         `json:"revisions" bson:"revisions"`
+	Lifecycle      LifecycleStage     `json:"lifecycle,omitempty" bson:"lifecycle,omitempty"`
+	// Tags are normalized, see NormalizeTags.
+	Tags          []string       `json:"tags,omitempty" bson:"tags,omitempty"`
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty" bson:"prerequisites,omitempty"`
+	Overrides     []Override     `json:"overrides,omitempty" bson:"overrides,omitempty"`
+	// SegmentOverrides are sorted by priority, see SortSegmentOverrides.
+	SegmentOverrides []SegmentOverride  `json:"segment_overrides,omitempty" bson:"segment_overrides,omitempty"`
+	Rollout          *Rollout           `json:"rollout,omitempty" bson:"rollout,omitempty"`
+	Guard            *RollbackGuard     `json:"guard,omitempty" bson:"guard,omitempty"`
+	Shadow           *Shadow            `json:"shadow,omitempty" bson:"shadow,omitempty"`
+	Constraints      *ValueConstraints  `json:"constraints,omitempty" bson:"constraints,omitempty"`
+	Revisions        []Revision         `json:"revisions" bson:"revisions"`
+	ArchivedAt       primitive.DateTime `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+	// LastEvaluatedAt is missing on flags no client has evaluated yet. It
+	// moves without the flag changing, so it stays out of the validators of
+	// flag lists.
+	LastEvaluatedAt primitive.DateTime `json:"last_evaluated_at,omitempty" bson:"last_evaluated_at,omitempty"`
+	// Evaluations counts every evaluation of the flag and RuleMatches those
+	// each rule served, keyed by rule id. Both are only exposed through the
+	// flag's stats.
+	Evaluations int64            `json:"-" bson:"evaluations,omitempty"`
+	RuleMatches map[string]int64 `json:"-" bson:"rule_matches,omitempty"`
+	// UpdatedBy is the user who last changed the flag's metadata or
+	// revisions, where UserID is the one who created it. Flags nobody
+	// changed since it was introduced have none.
+	UpdatedBy primitive.ObjectID `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	// SharedChangedAt moves with updated_at on every write except revision
+	// approvals, which only change the environments they target. See
+	// LastModifiedIn.
+	SharedChangedAt primitive.DateTime `json:"shared_changed_at,omitempty" bson:"shared_changed_at,omitempty"`
+	// Writes counts the writes to the flag. Unlike updated_at, which has
+	// millisecond precision, it tells apart every write, so caches derived
+	// from the flag can tell whether they are current.
+	Writes int64 `json:"writes,omitempty" bson:"writes,omitempty"`
 	storage.Timestamps
 }
 
 func NewFeatureFlagRecord(
 	name,
+	namespace,
 	defaultValue string,
 	flagType FlagType,
 	rules []Rule,
@@ -81,18 +241,22 @@ func NewFeatureFlagRecord(
 	return &FeatureFlagRecord{
 		OrganizationID: organizationID,
 		UserID:         userID,
+		UpdatedBy:      userID,
 		Version:        1,
 		Name:           name,
+		Namespace:      namespace,
 		Type:           flagType,
+		Lifecycle:      Development,
 		Revisions: []Revision{
 			{
 				ID:           primitive.NewObjectID(),
 				UserID:       userID,
 				Status:       Live,
 				DefaultValue: defaultValue,
-				Rules:        rules,
+				Rules:        withRuleIDs(rules),
 			},
 		},
+		SharedChangedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 		Timestamps: storage.Timestamps{
 			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
@@ -100,13 +264,212 @@ func NewFeatureFlagRecord(
 	}
 }
 
+const NamespaceSeparator = "/"
+
+// QualifiedName returns the flag name prefixed by its namespace, e.g. billing/new-invoice.
+func (ffr *FeatureFlagRecord) QualifiedName() string {
+	if ffr.Namespace == "" {
+		return ffr.Name
+	}
+
+	return ffr.Namespace + NamespaceSeparator + ffr.Name
+}
+
+// LifecycleStage returns the flag's lifecycle stage. Flags created before
+// stages existed are in development.
+func (ffr *FeatureFlagRecord) LifecycleStage() LifecycleStage {
+	if ffr.Lifecycle == "" {
+		return Development
+	}
+
+	return ffr.Lifecycle
+}
+
+// IsArchived reports whether the flag was retired without being deleted.
+func (ffr *FeatureFlagRecord) IsArchived() bool {
+	return ffr.ArchivedAt != 0
+}
+
+// LiveRevision returns the revision currently served, or nil when none is live.
+func (ffr *FeatureFlagRecord) LiveRevision() *Revision {
+	for index := range ffr.Revisions {
+		if ffr.Revisions[index].Status == Live {
+			return &ffr.Revisions[index]
+		}
+	}
+
+	return nil
+}
+
+// BaseDefaultValue is what the flag serves before any of its revisions was
+// approved: the default value of the revision it was created with. It is
+// false when the flag has no revision at all.
+func (ffr *FeatureFlagRecord) BaseDefaultValue() (string, bool) {
+	if len(ffr.Revisions) == 0 {
+		return "", false
+	}
+
+	return ffr.Revisions[0].DefaultValue, true
+}
+
+// FindRevision returns the revision with id, or nil when the flag has none.
+func (ffr *FeatureFlagRecord) FindRevision(id primitive.ObjectID) *Revision {
+	for index := range ffr.Revisions {
+		if ffr.Revisions[index].ID == id {
+			return &ffr.Revisions[index]
+		}
+	}
+
+	return nil
+}
+
+// EnvironmentRules returns, in order, the rules of r that target environment.
+func (r *Revision) EnvironmentRules(environment string) []Rule {
+	rules := make([]Rule, 0)
+	for _, rule := range r.Rules {
+		if rule.Env == environment {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// InheritedEnvironment is the environment whose rules of r apply in
+// environment. An environment with rules of its own, enabled or not, has
+// overridden its parents; one without inherits the rules of its closest
+// ancestor in parents that has some. It is environment itself when none has.
+func (r *Revision) InheritedEnvironment(environment string, parents map[string]string) string {
+	if r.HasRulesIn(environment) {
+		return environment
+	}
+	for _, ancestor := range EnvironmentAncestors(environment, parents) {
+		if r.HasRulesIn(ancestor) {
+			return ancestor
+		}
+	}
+
+	return environment
+}
+
+// HasRulesIn reports whether r has rules of its own in environment, enabled or
+// not.
+func (r *Revision) HasRulesIn(environment string) bool {
+	for index := range r.Rules {
+		if r.Rules[index].Env == environment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// servesSameIn reports whether r and other serve the same in environment.
+// Rule ids are ignored, since a rule sent again without its id gets a new one.
+// The rules are compared in place, as flags can have thousands of them and
+// this runs for every flag of every env request.
+func (r *Revision) servesSameIn(other *Revision, environment string) bool {
+	if r.DefaultValue != other.DefaultValue {
+		return false
+	}
+
+	index, otherIndex := r.nextRuleIn(environment, 0), other.nextRuleIn(environment, 0)
+	for index < len(r.Rules) && otherIndex < len(other.Rules) {
+		if !r.Rules[index].servesSameAs(&other.Rules[otherIndex]) {
+			return false
+		}
+		index, otherIndex = r.nextRuleIn(environment, index+1), other.nextRuleIn(environment, otherIndex+1)
+	}
+
+	return index == len(r.Rules) && otherIndex == len(other.Rules)
+}
+
+// nextRuleIn is the index of the first rule of r in environment from start
+// on, or len(r.Rules) when there's none left.
+func (r *Revision) nextRuleIn(environment string, start int) int {
+	for index := start; index < len(r.Rules); index++ {
+		if r.Rules[index].Env == environment {
+			return index
+		}
+	}
+
+	return len(r.Rules)
+}
+
+// servesSameAs reports whether r and other are the same rule but for their
+// ids: every other field is compared.
+func (r *Rule) servesSameAs(other *Rule) bool {
+	return r.Predicate == other.Predicate &&
+		r.Condition.Equal(other.Condition) &&
+		r.UserListID == other.UserListID &&
+		r.Value == other.Value &&
+		r.Env == other.Env &&
+		r.IsEnabled == other.IsEnabled
+}
+
+// LastChangedIn follows the live revision back through the revisions it
+// replaced and returns the one that made environment serve what it serves
+// now, or nil when the flag has no live revision. A revision that made
+// environment inherit from another environment, or stop inheriting, changed
+// it too.
+func (ffr *FeatureFlagRecord) LastChangedIn(environment string, parents map[string]string) *Revision {
+	changed := ffr.LiveRevision()
+	if changed == nil {
+		return nil
+	}
+
+	live := changed
+	rulesEnvironment := live.InheritedEnvironment(environment, parents)
+	for range ffr.Revisions {
+		previous := ffr.FindRevision(changed.LastRevisionID)
+		if changed.LastRevisionID.IsZero() || previous == nil ||
+			previous.InheritedEnvironment(environment, parents) != rulesEnvironment ||
+			!previous.servesSameIn(live, rulesEnvironment) {
+			break
+		}
+		changed = previous
+	}
+
+	return changed
+}
+
+// LastModifiedIn is when what the flag serves in environment last changed:
+// the later of its last change shared by every environment and the approval
+// of the revision LastChangedIn returns. Flags written before shared changes
+// were tracked fall back to updated_at.
+func (ffr *FeatureFlagRecord) LastModifiedIn(environment string, parents map[string]string) time.Time {
+	if ffr.SharedChangedAt == 0 {
+		return ffr.UpdatedAt.Time()
+	}
+
+	latest := ffr.SharedChangedAt.Time()
+	if changed := ffr.LastChangedIn(environment, parents); changed != nil && changed.ApprovedAt != 0 {
+		if approvedAt := changed.ApprovedAt.Time(); approvedAt.After(latest) {
+			latest = approvedAt
+		}
+	}
+
+	return latest
+}
+
+// SplitQualifiedName splits a possibly namespaced flag name into its namespace and name.
+// Flag names can't contain the separator, so everything before the last one is the namespace.
+func SplitQualifiedName(qualifiedName string) (string, string) {
+	index := strings.LastIndex(qualifiedName, NamespaceSeparator)
+	if index == -1 {
+		return "", qualifiedName
+	}
+
+	return qualifiedName[:index], qualifiedName[index+1:]
+}
+
 func NewRevisionRecord(defaultValue string, rules []Rule, userID primitive.ObjectID) *Revision {
 	return &Revision{
 		ID:           primitive.NewObjectID(),
 		UserID:       userID,
 		Status:       Draft,
 		DefaultValue: defaultValue,
-		Rules:        rules,
+		Rules:        withRuleIDs(rules),
 	}
 }
 
@@ -139,9 +502,59 @@ func (ffm *FeatureFlagModel) FindByID(ctx context.Context, id primitive.ObjectID
 
 var EmptyFeatureRecordList = []FeatureFlagRecord{}
 
+func (ffm *FeatureFlagModel) FindByName(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	qualifiedName string,
+) (*FeatureFlagRecord, error) {
+	namespace, name := SplitQualifiedName(qualifiedName)
+	filter := bson.D{
+		{Key: "organization_id", Value: organizationID},
+		{Key: "name", Value: name},
+		{Key: "deleted_at", Value: bson.M{
+			"$exists": false},
+		},
+	}
+	if namespace == "" {
+		filter = append(filter, bson.E{Key: "namespace", Value: bson.M{"$exists": false}})
+	} else {
+		filter = append(filter, bson.E{Key: "namespace", Value: namespace})
+	}
+
+	return ffm.FindOne(ctx, filter)
+}
+
+// organizationFlagsFilter matches the organization's flags that aren't
+// deleted and also match filter.
+func organizationFlagsFilter(organizationID primitive.ObjectID, filter bson.D) bson.D {
+	query := bson.D{
+		{Key: "organization_id", Value: organizationID},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+
+	return append(query, filter...)
+}
+
+// CountMany counts the flags FindMany pages through.
+func (ffm *FeatureFlagModel) CountMany(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	filter bson.D,
+) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = ffm.collection.CountDocuments(ctx, organizationFlagsFilter(organizationID, filter))
+		return err
+	})
+
+	return count, err
+}
+
 func (ffm *FeatureFlagModel) FindMany(
 	ctx context.Context,
 	organizationID primitive.ObjectID,
+	filter bson.D,
 	page,
 	limit int,
 ) ([]FeatureFlagRecord, error) {
@@ -150,11 +563,12 @@ func (ffm *FeatureFlagModel) FindMany(
 	findOptions.SetLimit(int64(limit))
 
 	records := make([]FeatureFlagRecord, 0)
-	cursor, err := ffm.collection.Find(ctx, bson.D{
-		{Key: "organization_id", Value: organizationID},
-		{Key: "deleted_at", Value: bson.M{
-			"$exists": false},
-		}}, findOptions)
+	var cursor *mongo.Cursor
+	err := storage.Retry(ctx, func() error {
+		var err error
+		cursor, err = ffm.collection.Find(ctx, organizationFlagsFilter(organizationID, filter), findOptions)
+		return err
+	})
 	if err != nil {
 		return EmptyFeatureRecordList, err
 	}
@@ -172,17 +586,423 @@ func (ffm *FeatureFlagModel) FindMany(
 	return records, nil
 }
 
+func organizationsFlagsFilter(organizationIDs []primitive.ObjectID, filter bson.D) bson.D {
+	query := bson.D{
+		{Key: "organization_id", Value: bson.M{"$in": organizationIDs}},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+
+	return append(query, filter...)
+}
+
+// CountManyInOrganizations counts the flags FindManyInOrganizations pages
+// through.
+func (ffm *FeatureFlagModel) CountManyInOrganizations(
+	ctx context.Context,
+	organizationIDs []primitive.ObjectID,
+	filter bson.D,
+) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = ffm.collection.CountDocuments(ctx, organizationsFlagsFilter(organizationIDs, filter))
+		return err
+	})
+
+	return count, err
+}
+
+// FindManyInOrganizations is FindMany across several organizations. Flags
+// are sorted by organization, then oldest first, so pages don't overlap.
+func (ffm *FeatureFlagModel) FindManyInOrganizations(
+	ctx context.Context,
+	organizationIDs []primitive.ObjectID,
+	filter bson.D,
+	page,
+	limit int,
+) ([]FeatureFlagRecord, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "organization_id", Value: 1}, {Key: "_id", Value: 1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	records := make([]FeatureFlagRecord, 0)
+	err := storage.Retry(ctx, func() error {
+		cursor, err := ffm.collection.Find(ctx, organizationsFlagsFilter(organizationIDs, filter), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return EmptyFeatureRecordList, err
+	}
+
+	return records, nil
+}
+
+func (ffm *FeatureFlagModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]FeatureFlagRecord, error) {
+	records := make([]FeatureFlagRecord, 0)
+	cursor, err := ffm.collection.Find(ctx, bson.D{
+		{Key: "organization_id", Value: organizationID},
+		{Key: "deleted_at", Value: bson.M{
+			"$exists": false},
+		}})
+	if err != nil {
+		return EmptyFeatureRecordList, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return EmptyFeatureRecordList, err
+	}
+
+	return records, nil
+}
+
+// FindMatching returns every flag of the organization that matches filter,
+// unlike FindMany which pages through them.
+func (ffm *FeatureFlagModel) FindMatching(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	filter bson.D,
+) ([]FeatureFlagRecord, error) {
+	records := make([]FeatureFlagRecord, 0)
+	var cursor *mongo.Cursor
+	err := storage.Retry(ctx, func() error {
+		var err error
+		cursor, err = ffm.collection.Find(ctx, organizationFlagsFilter(organizationID, filter))
+		return err
+	})
+	if err != nil {
+		return EmptyFeatureRecordList, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return EmptyFeatureRecordList, err
+	}
+
+	return records, nil
+}
+
+// FindDueRolloutRamps returns the flags whose rollout ramp has a step due at
+// now, except those of the paused organizations.
+func (ffm *FeatureFlagModel) FindDueRolloutRamps(
+	ctx context.Context,
+	now time.Time,
+	pausedOrganizationIDs []primitive.ObjectID,
+) ([]FeatureFlagRecord, error) {
+	filter := bson.D{
+		{Key: "rollout.ramp.next_step_at", Value: bson.M{
+			"$lte": primitive.NewDateTimeFromTime(now)},
+		},
+		{Key: "deleted_at", Value: bson.M{
+			"$exists": false},
+		}}
+	if len(pausedOrganizationIDs) > 0 {
+		filter = append(filter, bson.E{Key: "organization_id", Value: bson.M{"$nin": pausedOrganizationIDs}})
+	}
+
+	records := make([]FeatureFlagRecord, 0)
+	cursor, err := ffm.collection.Find(ctx, filter)
+	if err != nil {
+		return EmptyFeatureRecordList, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return EmptyFeatureRecordList, err
+	}
+
+	return records, nil
+}
+
+// SaveRolloutStep stores rollout as advanced from the step due at
+// previousStepAt. It reports false when another process already advanced it.
+func (ffm *FeatureFlagModel) SaveRolloutStep(
+	ctx context.Context,
+	id primitive.ObjectID,
+	previousStepAt primitive.DateTime,
+	rollout *Rollout,
+) (bool, error) {
+	result, err := ffm.collection.UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "rollout.ramp.next_step_at", Value: previousStepAt},
+		},
+		touch(bson.D{{Key: "$set", Value: bson.M{"rollout": rollout}}}),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// SaveRollback sets the fields of a rolled back flag, provided it is still as
+// it was read at previousVersion after writes writes. It reports false when a
+// concurrent write moved the flag on, or deleted it, in which case nothing was
+// rolled back.
+func (ffm *FeatureFlagModel) SaveRollback(
+	ctx context.Context,
+	id primitive.ObjectID,
+	previousVersion int,
+	writes int64,
+	set bson.D,
+) (bool, error) {
+	result, err := ffm.collection.UpdateOne(ctx,
+		unchangedFilter(id, previousVersion, writes),
+		touch(bson.D{{Key: "$set", Value: set}}),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// unchangedFilter matches flag id while it is still as it was read at version
+// after writes writes. The version alone isn't enough: revisions are pushed
+// without bumping it, and a rollback brings it back down.
+func unchangedFilter(id primitive.ObjectID, version int, writes int64) bson.D {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "version", Value: version},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+	if writes == 0 {
+		// Flags not written since writes were counted have none.
+		return append(filter, bson.E{Key: "writes", Value: bson.M{"$exists": false}})
+	}
+
+	return append(filter, bson.E{Key: "writes", Value: writes})
+}
+
+// DelayRolloutRamps pushes the next step of every rollout ramp of the
+// organization back by delay, so ramps pick up where they were once the
+// organization's automation is resumed. It returns how many ramps it moved.
+func (ffm *FeatureFlagModel) DelayRolloutRamps(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	delay time.Duration,
+) (int64, error) {
+	filter := organizationFlagsFilter(organizationID, bson.D{
+		{Key: "rollout.ramp.next_step_at", Value: bson.M{"$exists": true}},
+	})
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"rollout.ramp.next_step_at": bson.M{"$add": bson.A{"$rollout.ramp.next_step_at", delay.Milliseconds()}},
+		"updated_at":                "$$NOW",
+		"shared_changed_at":         "$$NOW",
+		"writes":                    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$writes", 0}}, 1}},
+	}}}}
+
+	result, err := ffm.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// FindEnvironments returns, sorted, every environment a rule of one of the
+// organization's flags targets, in any revision.
+func (ffm *FeatureFlagModel) FindEnvironments(ctx context.Context, organizationID primitive.ObjectID) ([]string, error) {
+	var values []interface{}
+	err := storage.Retry(ctx, func() error {
+		var err error
+		values, err = ffm.collection.Distinct(ctx, "revisions.rules.env", organizationFlagsFilter(organizationID, nil))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	environments := make([]string, 0, len(values))
+	for _, value := range values {
+		if environment, ok := value.(string); ok && environment != "" {
+			environments = append(environments, environment)
+		}
+	}
+	sort.Strings(environments)
+
+	return environments, nil
+}
+
+// FindDependents returns the flags that list id as one of their prerequisites.
+func (ffm *FeatureFlagModel) FindDependents(
+	ctx context.Context,
+	id primitive.ObjectID,
+) ([]FeatureFlagRecord, error) {
+	records := make([]FeatureFlagRecord, 0)
+	cursor, err := ffm.collection.Find(ctx, bson.D{
+		{Key: "prerequisites.feature_flag_id", Value: id},
+		{Key: "deleted_at", Value: bson.M{
+			"$exists": false},
+		}})
+	if err != nil {
+		return EmptyFeatureRecordList, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return EmptyFeatureRecordList, err
+	}
+
+	return records, nil
+}
+
+// DetachPrerequisite removes id from the prerequisites of every flag that references it.
+func (ffm *FeatureFlagModel) DetachPrerequisite(ctx context.Context, id primitive.ObjectID) error {
+	_, err := ffm.collection.UpdateMany(
+		ctx,
+		bson.D{{Key: "prerequisites.feature_flag_id", Value: id}},
+		touch(bson.D{{Key: "$pull", Value: bson.M{
+			"prerequisites": bson.M{"feature_flag_id": id},
+		}}}),
+	)
+
+	return err
+}
+
+// SaveLastEvaluated moves the last_evaluated_at of every flag in
+// lastEvaluated forward to the time it maps to. It leaves updated_at alone:
+// being evaluated isn't a change to the flag.
+func (ffm *FeatureFlagModel) SaveLastEvaluated(
+	ctx context.Context,
+	lastEvaluated map[primitive.ObjectID]time.Time,
+) error {
+	if len(lastEvaluated) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(lastEvaluated))
+	for id, evaluatedAt := range lastEvaluated {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: id}}).
+			SetUpdate(bson.D{{Key: "$max", Value: bson.M{
+				"last_evaluated_at": primitive.NewDateTimeFromTime(evaluatedAt),
+			}}}),
+		)
+	}
+
+	return storage.Retry(ctx, func() error {
+		_, err := ffm.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+}
+
+// EvaluationCounts is how many times a flag was evaluated and how many of
+// those evaluations each of its rules served.
+type EvaluationCounts struct {
+	Evaluations int64
+	RuleMatches map[primitive.ObjectID]int64
+	// ShadowEvaluations counts the evaluations the shadow revision
+	// ShadowRevisionID was compared in, and ShadowMismatches those where it
+	// would have served another value.
+	ShadowRevisionID  primitive.ObjectID
+	ShadowEvaluations int64
+	ShadowMismatches  int64
+}
+
+// SaveEvaluationCounts adds counts to the counters of every flag in it. Like
+// SaveLastEvaluated, it leaves updated_at alone. Shadow counts are only added
+// while the flag still shadows the revision they were counted for.
+func (ffm *FeatureFlagModel) SaveEvaluationCounts(
+	ctx context.Context,
+	counts map[primitive.ObjectID]*EvaluationCounts,
+) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(counts))
+	for id, flagCounts := range counts {
+		increments := bson.M{"evaluations": flagCounts.Evaluations}
+		for ruleID, matches := range flagCounts.RuleMatches {
+			increments["rule_matches."+ruleID.Hex()] = matches
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: id}}).
+			SetUpdate(bson.D{{Key: "$inc", Value: increments}}),
+		)
+		if flagCounts.ShadowEvaluations > 0 {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{
+					{Key: "_id", Value: id},
+					{Key: "shadow.revision_id", Value: flagCounts.ShadowRevisionID},
+				}).
+				SetUpdate(bson.D{{Key: "$inc", Value: bson.M{
+					"shadow.evaluations": flagCounts.ShadowEvaluations,
+					"shadow.mismatches":  flagCounts.ShadowMismatches,
+				}}}),
+			)
+		}
+	}
+
+	_, err := ffm.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+// touch stamps updated_at on every write, so it moves whenever the flag does
+// and read endpoints can derive their validators from it. Like stamp, it
+// counts the write in writes.
+func touch(update bson.D) bson.D {
+	return stamp(update, bson.M{"updated_at": true, "shared_changed_at": true})
+}
+
+func stamp(update bson.D, fields bson.M) bson.D {
+	stamped := make(bson.D, 0, len(update)+2)
+	stamped = append(stamped, update...)
+
+	return append(stamped,
+		bson.E{Key: "$currentDate", Value: fields},
+		bson.E{Key: "$inc", Value: bson.M{"writes": 1}},
+	)
+}
+
+// UpdateOne applies update to the flag filter matches. It reports false when
+// none does, e.g. because the flag was deleted since it was read.
 func (ffm *FeatureFlagModel) UpdateOne(
 	ctx context.Context,
 	filter,
 	update bson.D,
-) (primitive.ObjectID, error) {
-	_, err := ffm.collection.UpdateOne(ctx, filter, update)
+) (bool, error) {
+	result, err := ffm.collection.UpdateOne(ctx, filter, touch(update))
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// ApproveRevision sets the fields of a flag one of whose revisions was
+// approved, provided it is still as it was read, like SaveRollback. It leaves
+// shared_changed_at alone, so environments the revision serves the same in
+// keep their validators.
+func (ffm *FeatureFlagModel) ApproveRevision(
+	ctx context.Context,
+	id primitive.ObjectID,
+	previousVersion int,
+	writes int64,
+	set bson.D,
+) (bool, error) {
+	result, err := ffm.collection.UpdateOne(ctx,
+		unchangedFilter(id, previousVersion, writes),
+		stamp(bson.D{{Key: "$set", Value: set}}, bson.M{"updated_at": true}),
+	)
 	if err != nil {
-		return primitive.ObjectID{}, err
+		return false, err
 	}
 
-	return primitive.NilObjectID, nil
+	return result.MatchedCount == 1, nil
 }
 
 func (ffm *FeatureFlagModel) FindOne(
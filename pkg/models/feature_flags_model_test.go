@@ -0,0 +1,324 @@
+package models_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestLastChangedIn(t *testing.T) {
+	rule := func(env, value string) models.Rule {
+		return models.Rule{ID: primitive.NewObjectID(), Predicate: "plan: pro", Value: value, Env: env, IsEnabled: true}
+	}
+	revision := func(status models.RevisionStatus, last *models.Revision, rules ...models.Rule) models.Revision {
+		revision := models.Revision{ID: primitive.NewObjectID(), Status: status, DefaultValue: "false", Rules: rules}
+		if last != nil {
+			revision.LastRevisionID = last.ID
+		}
+		return revision
+	}
+
+	first := revision(models.Archived, nil, rule("dev", "true"), rule("prd", "false"))
+	// Only dev changes; prd keeps the same rule under a new id
+	second := revision(models.Archived, &first, rule("dev", "false"), rule("prd", "false"))
+	third := revision(models.Live, &second, rule("dev", "false"), rule("prd", "false"), rule("stg", "true"))
+	draft := revision(models.Draft, nil, rule("prd", "true"))
+	featureFlag := &models.FeatureFlagRecord{Revisions: []models.Revision{first, second, third, draft}}
+
+	assert.Equal(t, first.ID, featureFlag.LastChangedIn("prd", nil).ID)
+	assert.Equal(t, second.ID, featureFlag.LastChangedIn("dev", nil).ID)
+	assert.Equal(t, third.ID, featureFlag.LastChangedIn("stg", nil).ID)
+	assert.Equal(t, first.ID, featureFlag.LastChangedIn("qa", nil).ID)
+
+	// A new default value changes every environment
+	fourth := revision(models.Live, &third, rule("dev", "false"), rule("prd", "false"), rule("stg", "true"))
+	fourth.DefaultValue = "true"
+	featureFlag.Revisions[2].Status = models.Archived
+	featureFlag.Revisions = append(featureFlag.Revisions, fourth)
+	assert.Equal(t, fourth.ID, featureFlag.LastChangedIn("prd", nil).ID)
+
+	assert.Nil(t, (&models.FeatureFlagRecord{Revisions: []models.Revision{draft}}).LastChangedIn("prd", nil))
+}
+
+func TestLastChangedInFollowsInheritance(t *testing.T) {
+	parents := map[string]string{"stg": "prd"}
+	first := models.Revision{ID: primitive.NewObjectID(), Status: models.Archived, DefaultValue: "false", Rules: []models.Rule{
+		{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+		{Predicate: "plan: pro", Value: "false", Env: "stg", IsEnabled: true},
+	}}
+	// stg drops its own rule and inherits prd's, which doesn't change
+	second := models.Revision{ID: primitive.NewObjectID(), Status: models.Live, DefaultValue: "false", Rules: []models.Rule{
+		{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+	}, LastRevisionID: first.ID}
+	featureFlag := &models.FeatureFlagRecord{Revisions: []models.Revision{first, second}}
+
+	assert.Equal(t, first.ID, featureFlag.LastChangedIn("prd", parents).ID)
+	assert.Equal(t, second.ID, featureFlag.LastChangedIn("stg", parents).ID)
+}
+
+func TestLastChangedInComparesEveryRuleField(t *testing.T) {
+	// servesSameAs lists the fields of Rule one by one; a new field has to
+	// be compared there too.
+	assert.Equal(t, 7, reflect.TypeOf(models.Rule{}).NumField())
+
+	base := models.Rule{
+		Predicate: "plan: pro",
+		Condition: &models.Condition{Operator: models.OrOperator, Conditions: []models.Condition{leaf("plan: pro")}},
+		Value:     "true",
+		Env:       "prd",
+		IsEnabled: true,
+	}
+	testCases := map[string]func(rule *models.Rule){
+		"predicate":  func(rule *models.Rule) { rule.Predicate = "plan: free" },
+		"condition":  func(rule *models.Rule) { rule.Condition.Conditions[0] = leaf("plan: free") },
+		"user list":  func(rule *models.Rule) { rule.UserListID = primitive.NewObjectID() },
+		"value":      func(rule *models.Rule) { rule.Value = "false" },
+		"is enabled": func(rule *models.Rule) { rule.IsEnabled = false },
+	}
+
+	for name, change := range testCases {
+		t.Run(name, func(t *testing.T) {
+			changed := base
+			changed.Condition = &models.Condition{
+				Operator:   base.Condition.Operator,
+				Conditions: append([]models.Condition(nil), base.Condition.Conditions...),
+			}
+			change(&changed)
+
+			first := models.Revision{ID: primitive.NewObjectID(), Status: models.Archived, Rules: []models.Rule{base}}
+			second := models.Revision{
+				ID:             primitive.NewObjectID(),
+				Status:         models.Live,
+				Rules:          []models.Rule{changed},
+				LastRevisionID: first.ID,
+			}
+			featureFlag := &models.FeatureFlagRecord{Revisions: []models.Revision{first, second}}
+
+			assert.Equal(t, second.ID, featureFlag.LastChangedIn("prd", nil).ID)
+		})
+	}
+}
+
+// BenchmarkLastModifiedInManyRules only covers working out when a flag with
+// thousands of rules last changed. Evaluating its rules is measured by
+// BenchmarkEvaluateManyRules in the evaluation package.
+func BenchmarkLastModifiedInManyRules(b *testing.B) {
+	rules := make([]models.Rule, 0, 5000)
+	for index := 0; index < cap(rules); index++ {
+		env := "prd"
+		if index%2 == 0 {
+			env = "stg"
+		}
+		rules = append(rules, models.Rule{
+			ID:        primitive.NewObjectID(),
+			Predicate: fmt.Sprintf("country: C%d", index),
+			Value:     "true",
+			Env:       env,
+			IsEnabled: true,
+		})
+	}
+	approvedAt := primitive.NewDateTimeFromTime(time.Now())
+	first := models.Revision{ID: primitive.NewObjectID(), Status: models.Archived, Rules: rules, ApprovedAt: approvedAt}
+	second := models.Revision{
+		ID:             primitive.NewObjectID(),
+		Status:         models.Live,
+		Rules:          rules,
+		LastRevisionID: first.ID,
+		ApprovedAt:     approvedAt,
+	}
+	featureFlag := &models.FeatureFlagRecord{Revisions: []models.Revision{first, second}, SharedChangedAt: approvedAt}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for index := 0; index < b.N; index++ {
+		featureFlag.LastModifiedIn("prd", nil)
+	}
+}
+
+func TestLastModifiedIn(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sharedChangedAt := createdAt.Add(time.Hour)
+	approvedAt := createdAt.Add(2 * time.Hour)
+
+	first := models.Revision{ID: primitive.NewObjectID(), Status: models.Archived, DefaultValue: "false", Rules: []models.Rule{
+		{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+	}}
+	second := models.Revision{ID: primitive.NewObjectID(), Status: models.Live, DefaultValue: "false", Rules: []models.Rule{
+		{Predicate: "plan: pro", Value: "false", Env: "prd", IsEnabled: true},
+	}, LastRevisionID: first.ID}
+	second.Approve(primitive.NewObjectID(), approvedAt)
+	featureFlag := &models.FeatureFlagRecord{
+		Revisions:       []models.Revision{first, second},
+		SharedChangedAt: primitive.NewDateTimeFromTime(sharedChangedAt),
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(createdAt),
+			UpdatedAt: primitive.NewDateTimeFromTime(approvedAt),
+		},
+	}
+
+	assert.Equal(t, approvedAt, featureFlag.LastModifiedIn("prd", nil).UTC())
+	assert.Equal(t, sharedChangedAt, featureFlag.LastModifiedIn("stg", nil).UTC())
+
+	featureFlag.SharedChangedAt = 0
+	assert.Equal(t, approvedAt, featureFlag.LastModifiedIn("stg", nil).UTC())
+}
+
+func TestInheritedEnvironment(t *testing.T) {
+	revision := &models.Revision{Rules: []models.Rule{
+		{Predicate: "plan: pro", Value: "true", Env: "prd", IsEnabled: true},
+		{Predicate: "plan: pro", Value: "false", Env: "stg", IsEnabled: false},
+	}}
+	parents := map[string]string{"stg": "prd", "dev": "stg", "qa": "prd", "loop": "back", "back": "loop"}
+
+	assert.Equal(t, "prd", revision.InheritedEnvironment("prd", parents))
+	assert.Equal(t, "prd", revision.InheritedEnvironment("qa", parents))
+	assert.Equal(t, "stg", revision.InheritedEnvironment("stg", parents))
+	assert.Equal(t, "stg", revision.InheritedEnvironment("dev", parents))
+	assert.Equal(t, "loop", revision.InheritedEnvironment("loop", parents))
+	assert.Equal(t, "qa", revision.InheritedEnvironment("qa", nil))
+}
+
+func TestEnvironmentAncestors(t *testing.T) {
+	parents := map[string]string{"dev": "stg", "stg": "prd", "loop": "back", "back": "loop"}
+
+	assert.Equal(t, []string{"stg", "prd"}, models.EnvironmentAncestors("dev", parents))
+	assert.Empty(t, models.EnvironmentAncestors("prd", parents))
+	assert.Equal(t, []string{"back"}, models.EnvironmentAncestors("loop", parents))
+
+	assert.False(t, models.HasEnvironmentCycle(map[string]string{"dev": "stg", "stg": "prd", "qa": "prd"}))
+	assert.True(t, models.HasEnvironmentCycle(map[string]string{"dev": "dev"}))
+	assert.True(t, models.HasEnvironmentCycle(map[string]string{"dev": "stg", "stg": "qa", "qa": "dev"}))
+	assert.True(t, models.HasEnvironmentCycle(map[string]string{"prd": "dev", "dev": "stg", "stg": "dev"}))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := models.NormalizeTags([]string{" Team:Growth", "q3-cleanup", "team:growth", "v1.2_beta"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"q3-cleanup", "team:growth", "v1.2_beta"}, tags)
+
+	for _, invalid := range []string{"", " ", "team/growth", "-leading", "with space", strings.Repeat("a", 51)} {
+		_, err := models.NormalizeTags([]string{invalid})
+		assert.ErrorIs(t, err, models.ErrInvalidTag, invalid)
+	}
+}
+
+func TestRuleIsEnabledUnlessSaidOtherwise(t *testing.T) {
+	var rules []models.Rule
+	assert.NoError(t, json.Unmarshal([]byte(`[
+		{"predicate": "plan: pro", "value": "true", "env": "prd"},
+		{"predicate": "plan: free", "value": "true", "env": "prd", "is_enabled": false},
+		{"predicate": "plan: team", "value": "true", "env": "prd", "is_enabled": true}
+	]`), &rules))
+
+	assert.True(t, rules[0].IsEnabled)
+	assert.Equal(t, "plan: pro", rules[0].Predicate)
+	assert.False(t, rules[1].IsEnabled)
+	assert.True(t, rules[2].IsEnabled)
+}
+
+// TestSaveEvaluationCountsIsNotRepeated answers an $inc the server committed
+// with a retryable write concern error. Only the driver may send it again,
+// under the same transaction number so the server knows it already applied
+// it: a new write would count every evaluation twice.
+func TestSaveEvaluationCountsIsNotRepeated(t *testing.T) {
+	committed := bson.D{
+		{Key: "ok", Value: 1},
+		{Key: "n", Value: 1},
+		{Key: "nModified", Value: 1},
+		{Key: "writeConcernError", Value: bson.D{
+			{Key: "code", Value: 91},
+			{Key: "errmsg", Value: "interrupted at shutdown"},
+		}},
+		{Key: "errorLabels", Value: bson.A{"RetryableWriteError"}},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("committed write reported as failed", func(mt *mtest.T) {
+		mt.AddMockResponses(committed, committed, mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		err := models.NewFeatureFlagModel(mt.DB).SaveEvaluationCounts(context.Background(),
+			map[primitive.ObjectID]*models.EvaluationCounts{
+				primitive.NewObjectID(): {Evaluations: 1},
+			},
+		)
+		assert.True(mt, storage.IsTransientError(err))
+
+		transactions := make(map[string]int)
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			assert.Equal(mt, "update", event.CommandName)
+			transactions[event.Command.Lookup("txnNumber").String()]++
+		}
+		assert.Len(mt, transactions, 1)
+	})
+}
+
+func TestApproveRevisionOnlySavesFlagAsRead(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("written since it was read", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+		id := primitive.NewObjectID()
+		saved, err := models.NewFeatureFlagModel(mt.DB).ApproveRevision(context.Background(), id, 3, 7, bson.D{
+			{Key: "version", Value: 4},
+		})
+		assert.NoError(mt, err)
+		assert.False(mt, saved)
+
+		filter := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.Equal(mt, id, filter.Lookup("_id").ObjectID())
+		assert.Equal(mt, int32(3), filter.Lookup("version").Int32())
+		assert.Equal(mt, int64(7), filter.Lookup("writes").Int64())
+	})
+
+	mt.Run("never counted writes", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		saved, err := models.NewFeatureFlagModel(mt.DB).SaveRollback(context.Background(), primitive.NewObjectID(), 2, 0, bson.D{
+			{Key: "version", Value: 1},
+		})
+		assert.NoError(mt, err)
+		assert.True(mt, saved)
+
+		filter := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.False(mt, filter.Lookup("writes", "$exists").Boolean())
+	})
+}
+
+func TestUpdateOneReportsAMissingFlag(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("deleted since it was read", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+		saved, err := models.NewFeatureFlagModel(mt.DB).UpdateOne(context.Background(),
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "deleted_at", Value: bson.M{"$exists": false}},
+			},
+			bson.D{{Key: "$set", Value: bson.M{"description": "checkout"}}},
+		)
+		assert.NoError(mt, err)
+		assert.False(mt, saved)
+	})
+
+	mt.Run("found", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		saved, err := models.NewFeatureFlagModel(mt.DB).UpdateOne(context.Background(),
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}},
+			bson.D{{Key: "$set", Value: bson.M{"description": "checkout"}}},
+		)
+		assert.NoError(mt, err)
+		assert.True(mt, saved)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const OrganizationCollectionName = "organization"
@@ -21,7 +22,7 @@ type OrganizationModel struct {
 func NewOrganizationModel(db *mongo.Database) *OrganizationModel {
 	return &OrganizationModel{
 		db:         db,
-		collection: db.Collection(OrganizationCollectionName),
+		collection: storage.Collection(db, OrganizationCollectionName),
 	}
 }
 
@@ -34,6 +35,103 @@ func (om *OrganizationModel) FindByID(ctx context.Context, id primitive.ObjectID
 	return record, nil
 }
 
+func (om *OrganizationModel) FindAllByMember(
+	ctx context.Context,
+	userID primitive.ObjectID,
+) ([]OrganizationRecord, error) {
+	records := make([]OrganizationRecord, 0)
+	cursor, err := om.collection.Find(ctx, bson.D{{Key: "members.user._id", Value: userID}})
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]OrganizationRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// memberFilter matches the organizations userID belongs to, leaving out
+// deleted ones.
+func memberFilter(userID primitive.ObjectID) bson.D {
+	return bson.D{
+		{Key: "members.user._id", Value: userID},
+		{Key: "deleted_at", Value: bson.M{"$exists": false}},
+	}
+}
+
+// FindManyByMember returns a page of the organizations userID belongs to,
+// oldest first.
+func (om *OrganizationModel) FindManyByMember(
+	ctx context.Context,
+	userID primitive.ObjectID,
+	page,
+	limit int,
+) ([]OrganizationRecord, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "_id", Value: 1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	records := make([]OrganizationRecord, 0)
+	var cursor *mongo.Cursor
+	err := storage.Retry(ctx, func() error {
+		var err error
+		cursor, err = om.collection.Find(ctx, memberFilter(userID), findOptions)
+		return err
+	})
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]OrganizationRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// FindAllActiveByMember is FindAllByMember leaving out deleted
+// organizations, oldest first.
+func (om *OrganizationModel) FindAllActiveByMember(
+	ctx context.Context,
+	userID primitive.ObjectID,
+) ([]OrganizationRecord, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	records := make([]OrganizationRecord, 0)
+	err := storage.Retry(ctx, func() error {
+		cursor, err := om.collection.Find(ctx, memberFilter(userID), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return make([]OrganizationRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// CountByMember counts the organizations userID belongs to.
+func (om *OrganizationModel) CountByMember(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = om.collection.CountDocuments(ctx, memberFilter(userID))
+		return err
+	})
+
+	return count, err
+}
+
 func (om *OrganizationModel) InsertOne(ctx context.Context, record *OrganizationRecord) (primitive.ObjectID, error) {
 	record.ID = primitive.NewObjectID()
 	result, err := om.collection.InsertOne(ctx, record)
@@ -50,6 +148,135 @@ func (om *OrganizationModel) InsertOne(ctx context.Context, record *Organization
 	return objectID, nil
 }
 
+func (om *OrganizationModel) UpdateOne(ctx context.Context, filter, update bson.D) error {
+	_, err := om.collection.UpdateOne(ctx, filter, update)
+
+	return err
+}
+
+// AddMember adds member to organization id.
+func (om *OrganizationModel) AddMember(ctx context.Context, id primitive.ObjectID, member OrganizationMember) error {
+	_, err := om.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{
+			{Key: "$push", Value: bson.M{"members": member}},
+			{Key: "$currentDate", Value: bson.M{"updated_at": true}},
+		},
+	)
+
+	return err
+}
+
+// SetMemberPermissionLevel changes the level of userID in organization id.
+func (om *OrganizationModel) SetMemberPermissionLevel(
+	ctx context.Context,
+	id,
+	userID primitive.ObjectID,
+	permissionLevel PermissionLevelEnum,
+) error {
+	return storage.Retry(ctx, func() error {
+		_, err := om.collection.UpdateOne(ctx,
+			bson.D{
+				{Key: "_id", Value: id},
+				{Key: "members.user._id", Value: userID},
+			},
+			bson.D{
+				{Key: "$set", Value: bson.M{"members.$.permission_level": permissionLevel}},
+				{Key: "$currentDate", Value: bson.M{"updated_at": true}},
+			},
+		)
+		return err
+	})
+}
+
+// RemoveMember removes userID from organization id.
+func (om *OrganizationModel) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) error {
+	return storage.Retry(ctx, func() error {
+		_, err := om.collection.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: id}},
+			bson.D{
+				{Key: "$pull", Value: bson.M{"members": bson.M{"user._id": userID}}},
+				{Key: "$currentDate", Value: bson.M{"updated_at": true}},
+			},
+		)
+		return err
+	})
+}
+
+// PauseAutomation pauses the automated changes of organization id, as of
+// pausedAt, on behalf of userID. It reports false when they already are.
+func (om *OrganizationModel) PauseAutomation(
+	ctx context.Context,
+	id,
+	userID primitive.ObjectID,
+	pausedAt time.Time,
+) (bool, error) {
+	result, err := om.collection.UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "settings.automation_paused_at", Value: bson.M{"$exists": false}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "settings.automation_paused_at", Value: primitive.NewDateTimeFromTime(pausedAt)},
+			{Key: "settings.automation_paused_by", Value: userID},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// ResumeAutomation lifts the pause of organization id that started at
+// pausedAt. It reports false when another request lifted it first.
+func (om *OrganizationModel) ResumeAutomation(
+	ctx context.Context,
+	id primitive.ObjectID,
+	pausedAt primitive.DateTime,
+) (bool, error) {
+	result, err := om.collection.UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "settings.automation_paused_at", Value: pausedAt},
+		},
+		bson.D{{Key: "$unset", Value: bson.D{
+			{Key: "settings.automation_paused_at", Value: ""},
+			{Key: "settings.automation_paused_by", Value: ""},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// FindAutomationPausedIDs returns the ids of the organizations whose
+// automated changes are paused.
+func (om *OrganizationModel) FindAutomationPausedIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	var values []interface{}
+	err := storage.Retry(ctx, func() error {
+		var err error
+		values, err = om.collection.Distinct(ctx, "_id", bson.D{
+			{Key: "settings.automation_paused_at", Value: bson.M{"$exists": true}},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
 type PermissionLevelEnum = string
 
 const (
@@ -77,11 +304,55 @@ type OrganizationInvite struct {
 	Status OrganizationInviteStatus
 }
 
+// AttributeType is the expected type of an evaluation context attribute.
+type AttributeType = string
+
+const (
+	StringAttribute  AttributeType = "string"
+	NumberAttribute  AttributeType = "number"
+	BooleanAttribute AttributeType = "boolean"
+)
+
+// OrganizationLimits caps what an organization can create, for plan tiers.
+// Zero means the default limit.
+type OrganizationLimits struct {
+	MaxFeatureFlags int `json:"max_feature_flags" bson:"max_feature_flags,omitempty"`
+	MaxEnvironments int `json:"max_environments" bson:"max_environments,omitempty"`
+	MaxMembers      int `json:"max_members" bson:"max_members,omitempty"`
+}
+
+var DefaultOrganizationLimits = OrganizationLimits{
+	MaxFeatureFlags: 500,
+	MaxEnvironments: 10,
+	MaxMembers:      50,
+}
+
+// WithDefaults fills the limits left at zero, like those of organizations
+// created before limits existed, with DefaultOrganizationLimits.
+func (ol OrganizationLimits) WithDefaults() OrganizationLimits {
+	if ol.MaxFeatureFlags == 0 {
+		ol.MaxFeatureFlags = DefaultOrganizationLimits.MaxFeatureFlags
+	}
+	if ol.MaxEnvironments == 0 {
+		ol.MaxEnvironments = DefaultOrganizationLimits.MaxEnvironments
+	}
+	if ol.MaxMembers == 0 {
+		ol.MaxMembers = DefaultOrganizationLimits.MaxMembers
+	}
+
+	return ol
+}
+
 type OrganizationRecord struct {
 	ID      primitive.ObjectID   `json:"_id" bson:"_id"`
 	Name    string               `json:"name" bson:"name"`
 	Members []OrganizationMember `json:"members" bson:"members"`
 	Invites []OrganizationInvite `json:"invites" bson:"invites"`
+	// ContextSchema declares the evaluation context attributes clients are
+	// expected to send, keyed by attribute name.
+	ContextSchema map[string]AttributeType `json:"context_schema,omitempty" bson:"context_schema,omitempty"`
+	Limits        OrganizationLimits       `json:"limits" bson:"limits"`
+	Settings      OrganizationSettings     `json:"settings" bson:"settings"`
 	storage.Timestamps
 }
 
@@ -89,9 +360,74 @@ func NewOrganizationRecord(name string, members []OrganizationMember) *Organizat
 	return &OrganizationRecord{
 		Name:    name,
 		Members: members,
+		Limits:  DefaultOrganizationLimits,
 		Timestamps: storage.Timestamps{
 			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
 		},
 	}
 }
+
+// MaintenanceWindow is a period the organization plans changes in.
+type MaintenanceWindow struct {
+	Start       primitive.DateTime `json:"start" bson:"start"`
+	End         primitive.DateTime `json:"end" bson:"end"`
+	Environment string             `json:"environment,omitempty" bson:"environment,omitempty"`
+}
+
+// OrganizationSettings is the configuration admins control. Quotas aren't
+// part of it: they live in OrganizationLimits, which come with the plan.
+type OrganizationSettings struct {
+	// DefaultEnvironment is assumed when a client doesn't name one.
+	DefaultEnvironment string `json:"default_environment,omitempty" bson:"default_environment,omitempty"`
+	// RequireSeparateApprover keeps authors from approving their own
+	// revisions.
+	RequireSeparateApprover bool                `json:"require_separate_approver" bson:"require_separate_approver"`
+	MaintenanceWindows      []MaintenanceWindow `json:"maintenance_windows" bson:"maintenance_windows,omitempty"`
+	// AutomationPausedAt is set while an admin holds off the changes the
+	// system makes to flags on its own, like rollout ramp steps.
+	AutomationPausedAt primitive.DateTime `json:"automation_paused_at,omitempty" bson:"automation_paused_at,omitempty"`
+	AutomationPausedBy primitive.ObjectID `json:"automation_paused_by,omitempty" bson:"automation_paused_by,omitempty"`
+	// EnvironmentParents maps environments to the environment they inherit
+	// rules from. See Revision.InheritedEnvironment.
+	EnvironmentParents map[string]string `json:"environment_parents,omitempty" bson:"environment_parents,omitempty"`
+	// TokenIssuer and TokenAudience scope the tokens accepted for the
+	// organization. Tokens of the service's own issuer, without audience,
+	// are accepted while both are unset.
+	TokenIssuer   string `json:"token_issuer,omitempty" bson:"token_issuer,omitempty"`
+	TokenAudience string `json:"token_audience,omitempty" bson:"token_audience,omitempty"`
+}
+
+// EnvironmentAncestors returns the parents of environment in parents, closest
+// first. It stops before an environment would come back around.
+func EnvironmentAncestors(environment string, parents map[string]string) []string {
+	ancestors := make([]string, 0)
+	visited := map[string]bool{environment: true}
+	for parent, ok := parents[environment]; ok && !visited[parent]; parent, ok = parents[parent] {
+		ancestors = append(ancestors, parent)
+		visited[parent] = true
+	}
+
+	return ancestors
+}
+
+// HasEnvironmentCycle reports whether an environment of parents ends up
+// inheriting from itself.
+func HasEnvironmentCycle(parents map[string]string) bool {
+	for environment := range parents {
+		ancestors := EnvironmentAncestors(environment, parents)
+		last := environment
+		if len(ancestors) > 0 {
+			last = ancestors[len(ancestors)-1]
+		}
+		if _, ok := parents[last]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s OrganizationSettings) AutomationPaused() bool {
+	return s.AutomationPausedAt != 0
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const RevisionCommentCollectionName = "revision_comment"
+
+type RevisionCommentModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewRevisionCommentModel(db *mongo.Database) *RevisionCommentModel {
+	return &RevisionCommentModel{
+		db:         db,
+		collection: storage.Collection(db, RevisionCommentCollectionName),
+	}
+}
+
+// RevisionCommentRecord is a reviewer's note on a revision of a feature flag.
+type RevisionCommentRecord struct {
+	ID            primitive.ObjectID `json:"_id" bson:"_id"`
+	FeatureFlagID primitive.ObjectID `json:"feature_flag_id" bson:"feature_flag_id"`
+	RevisionID    primitive.ObjectID `json:"revision_id" bson:"revision_id"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Body          string             `json:"body" bson:"body"`
+	CreatedAt     primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+func NewRevisionCommentRecord(
+	featureFlagID,
+	revisionID,
+	userID primitive.ObjectID,
+	body string,
+) *RevisionCommentRecord {
+	return &RevisionCommentRecord{
+		FeatureFlagID: featureFlagID,
+		RevisionID:    revisionID,
+		UserID:        userID,
+		Body:          body,
+		CreatedAt:     primitive.NewDateTimeFromTime(time.Now().UTC()),
+	}
+}
+
+func (rcm *RevisionCommentModel) InsertOne(
+	ctx context.Context,
+	record *RevisionCommentRecord,
+) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := rcm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+func revisionFilter(featureFlagID, revisionID primitive.ObjectID) bson.D {
+	return bson.D{
+		{Key: "feature_flag_id", Value: featureFlagID},
+		{Key: "revision_id", Value: revisionID},
+	}
+}
+
+// FindManyByRevision returns a page of the comments on a revision, newest
+// first.
+func (rcm *RevisionCommentModel) FindManyByRevision(
+	ctx context.Context,
+	featureFlagID,
+	revisionID primitive.ObjectID,
+	page,
+	limit int,
+) ([]RevisionCommentRecord, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	records := make([]RevisionCommentRecord, 0)
+	var cursor *mongo.Cursor
+	err := storage.Retry(ctx, func() error {
+		var err error
+		cursor, err = rcm.collection.Find(ctx, revisionFilter(featureFlagID, revisionID), findOptions)
+		return err
+	})
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]RevisionCommentRecord, 0), err
+	}
+
+	return records, nil
+}
+
+func (rcm *RevisionCommentModel) CountByRevision(
+	ctx context.Context,
+	featureFlagID,
+	revisionID primitive.ObjectID,
+) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = rcm.collection.CountDocuments(ctx, revisionFilter(featureFlagID, revisionID))
+		return err
+	})
+
+	return count, err
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RuleChangeType = string
+
+const (
+	RuleAdded   RuleChangeType = "added"
+	RuleRemoved RuleChangeType = "removed"
+	RuleChanged RuleChangeType = "changed"
+	RuleMoved   RuleChangeType = "moved"
+)
+
+// FieldChange is a scalar revision field that differs between two revisions.
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RuleChange describes what happened to a single rule, matched by id. Before
+// is nil for added rules and After is nil for removed ones.
+type RuleChange struct {
+	RuleID primitive.ObjectID `json:"rule_id"`
+	Type   RuleChangeType     `json:"type"`
+	Before *Rule              `json:"before,omitempty"`
+	After  *Rule              `json:"after,omitempty"`
+}
+
+// RevisionDiff lists what changed between two revisions of the same flag.
+type RevisionDiff struct {
+	DefaultValue *FieldChange `json:"default_value,omitempty"`
+	Status       *FieldChange `json:"status,omitempty"`
+	Rules        []RuleChange `json:"rules,omitempty"`
+}
+
+// Empty reports whether the revisions serve the same thing.
+func (rd RevisionDiff) Empty() bool {
+	return rd.DefaultValue == nil && rd.Status == nil && len(rd.Rules) == 0
+}
+
+// DiffRevisions compares from and to. A nil from is treated as an empty
+// revision, so every rule of to is reported as added. Rules that only changed
+// position are reported as moved, since order decides which rule wins.
+func DiffRevisions(from, to *Revision) RevisionDiff {
+	if from == nil {
+		from = &Revision{}
+	}
+
+	var diff RevisionDiff
+	if from.DefaultValue != to.DefaultValue {
+		diff.DefaultValue = &FieldChange{From: from.DefaultValue, To: to.DefaultValue}
+	}
+	if from.Status != to.Status {
+		diff.Status = &FieldChange{From: from.Status, To: to.Status}
+	}
+
+	previous := make(map[primitive.ObjectID]int, len(from.Rules))
+	for index, rule := range from.Rules {
+		previous[rule.ID] = index
+	}
+
+	kept := make(map[primitive.ObjectID]bool, len(to.Rules))
+	for index := range to.Rules {
+		after := to.Rules[index]
+		kept[after.ID] = true
+
+		previousIndex, ok := previous[after.ID]
+		if !ok {
+			diff.Rules = append(diff.Rules, RuleChange{RuleID: after.ID, Type: RuleAdded, After: &after})
+			continue
+		}
+
+		before := from.Rules[previousIndex]
+		switch {
+		case !reflect.DeepEqual(before, after):
+			diff.Rules = append(diff.Rules, RuleChange{
+				RuleID: after.ID,
+				Type:   RuleChanged,
+				Before: &before,
+				After:  &after,
+			})
+		case previousIndex != index:
+			diff.Rules = append(diff.Rules, RuleChange{RuleID: after.ID, Type: RuleMoved})
+		}
+	}
+
+	for index := range from.Rules {
+		before := from.Rules[index]
+		if !kept[before.ID] {
+			diff.Rules = append(diff.Rules, RuleChange{RuleID: before.ID, Type: RuleRemoved, Before: &before})
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const MaxRolloutPercentage = 100
+
+// Rollout serves Value to Percentage percent of users when no rule matches.
+// Users are bucketed by id, so the same user keeps getting the same answer
+// and only new users are added as the percentage grows. With a Kind, the
+// context of that kind, e.g. a device, is bucketed by its key instead.
+type Rollout struct {
+	Percentage int          `json:"percentage" bson:"percentage"`
+	Value      string       `json:"value" bson:"value"`
+	Kind       string       `json:"kind,omitempty" bson:"kind,omitempty"`
+	Ramp       *RolloutRamp `json:"ramp,omitempty" bson:"ramp,omitempty"`
+}
+
+// RolloutRamp raises the rollout percentage by Step every Interval until it
+// reaches 100%.
+type RolloutRamp struct {
+	Step       int                `json:"step" bson:"step"`
+	Interval   time.Duration      `json:"interval" bson:"interval"`
+	NextStepAt primitive.DateTime `json:"next_step_at" bson:"next_step_at"`
+}
+
+// Includes reports whether key falls within the rolled out percentage of the
+// flag.
+func (r *Rollout) Includes(featureFlagID primitive.ObjectID, key string) bool {
+	return RolloutBucket(featureFlagID, key) < r.Percentage
+}
+
+// AdvanceRamp applies every ramp step due at now. It reports whether the
+// percentage changed; the ramp is dropped once the rollout is complete.
+func (r *Rollout) AdvanceRamp(now time.Time) bool {
+	if r.Ramp == nil {
+		return false
+	}
+
+	advanced := false
+	for r.Ramp != nil && !r.Ramp.NextStepAt.Time().After(now) {
+		r.Percentage += r.Ramp.Step
+		advanced = true
+
+		if r.Percentage >= MaxRolloutPercentage || r.Ramp.Interval <= 0 {
+			r.Percentage = MaxRolloutPercentage
+			r.Ramp = nil
+			break
+		}
+		r.Ramp.NextStepAt = primitive.NewDateTimeFromTime(r.Ramp.NextStepAt.Time().Add(r.Ramp.Interval))
+	}
+
+	return advanced
+}
+
+// RolloutBucket places userID in one of 100 buckets, hashed together with the
+// flag id so users aren't always in the first buckets of every flag.
+func RolloutBucket(featureFlagID primitive.ObjectID, userID string) int {
+	hash := sha1.Sum([]byte(featureFlagID.Hex() + ":" + userID))
+
+	return int(binary.BigEndian.Uint32(hash[:4]) % MaxRolloutPercentage)
+}
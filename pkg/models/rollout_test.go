@@ -0,0 +1,71 @@
+package models_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRolloutBucketIsStable(t *testing.T) {
+	featureFlagID := primitive.NewObjectID()
+
+	assert.Equal(t,
+		models.RolloutBucket(featureFlagID, "user-1"),
+		models.RolloutBucket(featureFlagID, "user-1"),
+	)
+}
+
+func TestRolloutIncludesRoughlyItsPercentage(t *testing.T) {
+	featureFlagID := primitive.NewObjectID()
+	rollout := models.Rollout{Percentage: 30}
+
+	included := 0
+	for i := 0; i < 10000; i++ {
+		if rollout.Includes(featureFlagID, strconv.Itoa(i)) {
+			included++
+		}
+	}
+
+	assert.InDelta(t, 3000, included, 300)
+}
+
+func TestAdvanceRampAppliesDueSteps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rollout := models.Rollout{
+		Percentage: 0,
+		Ramp: &models.RolloutRamp{
+			Step:       10,
+			Interval:   time.Hour,
+			NextStepAt: primitive.NewDateTimeFromTime(start),
+		},
+	}
+
+	assert.False(t, rollout.AdvanceRamp(start.Add(-time.Minute)))
+	assert.Equal(t, 0, rollout.Percentage)
+
+	// A worker that missed two steps catches up on both.
+	assert.True(t, rollout.AdvanceRamp(start.Add(time.Hour+time.Minute)))
+	assert.Equal(t, 20, rollout.Percentage)
+	assert.Equal(t, start.Add(2*time.Hour), rollout.Ramp.NextStepAt.Time().UTC())
+}
+
+func TestAdvanceRampStopsAtFullRollout(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rollout := models.Rollout{
+		Percentage: 95,
+		Ramp: &models.RolloutRamp{
+			Step:       10,
+			Interval:   time.Hour,
+			NextStepAt: primitive.NewDateTimeFromTime(start),
+		},
+	}
+
+	assert.True(t, rollout.AdvanceRamp(start.Add(24*time.Hour)))
+	assert.Equal(t, models.MaxRolloutPercentage, rollout.Percentage)
+	assert.Nil(t, rollout.Ramp)
+	assert.False(t, rollout.AdvanceRamp(start.Add(48*time.Hour)))
+}
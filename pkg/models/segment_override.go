@@ -0,0 +1,48 @@
+package models
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SegmentOverride forces Value for every user of a segment, the user list
+// UserListID. A user in several segments gets the value of the override with
+// the lowest Priority, so priorities are unique within a flag.
+type SegmentOverride struct {
+	UserListID primitive.ObjectID `json:"user_list_id" bson:"user_list_id" validate:"required"`
+	Priority   int                `json:"priority" bson:"priority" validate:"min=0"`
+	Value      string             `json:"value" bson:"value" validate:"required"`
+}
+
+// SortSegmentOverrides orders overrides by priority, the order they are
+// saved and evaluated in. It reports false when two share a priority.
+func SortSegmentOverrides(overrides []SegmentOverride) bool {
+	sort.SliceStable(overrides, func(i, j int) bool {
+		return overrides[i].Priority < overrides[j].Priority
+	})
+
+	for index := 1; index < len(overrides); index++ {
+		if overrides[index].Priority == overrides[index-1].Priority {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SegmentUserListIDs lists, without duplicates, the user lists overrides
+// target.
+func SegmentUserListIDs(overrides []SegmentOverride) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool)
+	ids := make([]primitive.ObjectID, 0, len(overrides))
+	for _, override := range overrides {
+		if seen[override.UserListID] {
+			continue
+		}
+		seen[override.UserListID] = true
+		ids = append(ids, override.UserListID)
+	}
+
+	return ids
+}
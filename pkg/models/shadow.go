@@ -0,0 +1,33 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Shadow is a draft revision evaluated alongside the live one without being
+// served, so teams can measure how often it would serve something else before
+// approving it. Evaluations counts the evaluations it was compared in since
+// StartedAt and Mismatches those where it disagreed with the served value.
+type Shadow struct {
+	RevisionID  primitive.ObjectID `json:"revision_id" bson:"revision_id"`
+	StartedBy   primitive.ObjectID `json:"started_by" bson:"started_by"`
+	StartedAt   primitive.DateTime `json:"started_at" bson:"started_at"`
+	Evaluations int64              `json:"evaluations" bson:"evaluations"`
+	Mismatches  int64              `json:"mismatches" bson:"mismatches"`
+}
+
+// ShadowRevision returns the revision evaluated in the shadow of the live
+// one, or nil when the flag has none. A shadow revision that is no longer a
+// draft, because it was approved or deleted since, isn't evaluated anymore.
+func (ffr *FeatureFlagRecord) ShadowRevision() *Revision {
+	if ffr.Shadow == nil {
+		return nil
+	}
+
+	revision := ffr.FindRevision(ffr.Shadow.RevisionID)
+	if revision == nil || revision.Status != Draft {
+		return nil
+	}
+
+	return revision
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const TestIdentityCollectionName = "test_identity"
+
+type TestIdentityModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewTestIdentityModel(db *mongo.Database) *TestIdentityModel {
+	return &TestIdentityModel{
+		db:         db,
+		collection: storage.Collection(db, TestIdentityCollectionName),
+	}
+}
+
+// TestIdentityRecord is a named persona, the attributes of one evaluation
+// context, that QA can evaluate flags against again and again.
+type TestIdentityRecord struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	Attributes     map[string]string  `json:"attributes" bson:"attributes"`
+	storage.Timestamps
+}
+
+func NewTestIdentityRecord(
+	organizationID primitive.ObjectID,
+	name string,
+	attributes map[string]string,
+) *TestIdentityRecord {
+	return &TestIdentityRecord{
+		OrganizationID: organizationID,
+		Name:           name,
+		Attributes:     attributes,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}
+}
+
+func (tim *TestIdentityModel) InsertOne(
+	ctx context.Context,
+	record *TestIdentityRecord,
+) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := tim.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+// FindByID only finds test identities of organizationID.
+func (tim *TestIdentityModel) FindByID(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (*TestIdentityRecord, error) {
+	record := new(TestIdentityRecord)
+	if err := tim.collection.FindOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	}).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (tim *TestIdentityModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]TestIdentityRecord, error) {
+	records := make([]TestIdentityRecord, 0)
+	cursor, err := tim.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]TestIdentityRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// ReplaceAttributes overwrites the name and attributes of the test identity.
+// It reports false when organizationID has no test identity id.
+func (tim *TestIdentityModel) ReplaceAttributes(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+	name string,
+	attributes map[string]string,
+) (bool, error) {
+	var result *mongo.UpdateResult
+	err := storage.Retry(ctx, func() error {
+		var err error
+		result, err = tim.collection.UpdateOne(ctx,
+			bson.D{
+				{Key: "_id", Value: id},
+				{Key: "organization_id", Value: organizationID},
+			},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "name", Value: name},
+				{Key: "attributes", Value: attributes},
+				{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+			}}},
+		)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// DeleteOne reports false when organizationID has no test identity id.
+func (tim *TestIdentityModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := tim.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const UsageCollectionName = "organization_usage"
+
+// UsageBucketSize is the span of time each usage record counts.
+const UsageBucketSize = time.Hour
+
+type UsageModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewUsageModel(db *mongo.Database) *UsageModel {
+	return &UsageModel{
+		db:         db,
+		collection: storage.Collection(db, UsageCollectionName),
+	}
+}
+
+// UsageCounts counts an organization's API calls and the evaluations it was
+// served. Latency is the total time spent answering Requests, so averages
+// can be derived from any sum of counts.
+type UsageCounts struct {
+	Requests         int64 `json:"requests" bson:"requests"`
+	ClientErrors     int64 `json:"client_errors" bson:"client_errors"`
+	ServerErrors     int64 `json:"server_errors" bson:"server_errors"`
+	LatencyMillis    int64 `json:"latency_ms" bson:"latency_ms"`
+	MaxLatencyMillis int64 `json:"max_latency_ms" bson:"max_latency_ms"`
+	Evaluations      int64 `json:"evaluations" bson:"evaluations"`
+}
+
+// Add adds other to uc.
+func (uc *UsageCounts) Add(other UsageCounts) {
+	uc.Requests += other.Requests
+	uc.ClientErrors += other.ClientErrors
+	uc.ServerErrors += other.ServerErrors
+	uc.LatencyMillis += other.LatencyMillis
+	uc.Evaluations += other.Evaluations
+	if other.MaxLatencyMillis > uc.MaxLatencyMillis {
+		uc.MaxLatencyMillis = other.MaxLatencyMillis
+	}
+}
+
+// UsageKey is the organization and the bucket, see UsageBucket, counts are
+// saved to.
+type UsageKey struct {
+	OrganizationID primitive.ObjectID
+	Bucket         time.Time
+}
+
+// UsageBucket is the start of the bucket at falls in.
+func UsageBucket(at time.Time) time.Time {
+	return at.UTC().Truncate(UsageBucketSize)
+}
+
+// UsageRecord holds the counts of one organization over one bucket.
+type UsageRecord struct {
+	ID             primitive.ObjectID `json:"-" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	Bucket         primitive.DateTime `json:"bucket" bson:"bucket"`
+	UsageCounts    `bson:",inline"`
+}
+
+// SaveUsage adds counts to the records of their buckets, creating the ones
+// that don't exist yet.
+func (um *UsageModel) SaveUsage(ctx context.Context, counts map[UsageKey]*UsageCounts) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(counts))
+	for key, bucketCounts := range counts {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{
+				{Key: "organization_id", Value: key.OrganizationID},
+				{Key: "bucket", Value: primitive.NewDateTimeFromTime(key.Bucket)},
+			}).
+			SetUpdate(bson.D{
+				{Key: "$inc", Value: bson.M{
+					"requests":      bucketCounts.Requests,
+					"client_errors": bucketCounts.ClientErrors,
+					"server_errors": bucketCounts.ServerErrors,
+					"latency_ms":    bucketCounts.LatencyMillis,
+					"evaluations":   bucketCounts.Evaluations,
+				}},
+				{Key: "$max", Value: bson.M{"max_latency_ms": bucketCounts.MaxLatencyMillis}},
+			}).
+			SetUpsert(true),
+		)
+	}
+
+	_, err := um.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+// FindSince returns the organization's records from the bucket since falls
+// in onwards, oldest first.
+func (um *UsageModel) FindSince(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	since time.Time,
+) ([]UsageRecord, error) {
+	records := make([]UsageRecord, 0)
+	err := storage.Retry(ctx, func() error {
+		cursor, err := um.collection.Find(
+			ctx,
+			bson.D{
+				{Key: "organization_id", Value: organizationID},
+				{Key: "bucket", Value: bson.M{"$gte": primitive.NewDateTimeFromTime(UsageBucket(since))}},
+			},
+			options.Find().SetSort(bson.D{{Key: "bucket", Value: 1}}),
+		)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &records)
+	})
+
+	return records, err
+}
@@ -0,0 +1,307 @@
+package models
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const UserListCollectionName = "user_list"
+
+// MaxUserListSize is how many user ids a single list can hold.
+const MaxUserListSize = 10000
+
+// ErrUserListTooLarge is returned for lists of more than MaxUserListSize ids.
+var ErrUserListTooLarge = errors.New("user list has too many user ids")
+
+type UserListModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewUserListModel(db *mongo.Database) *UserListModel {
+	return &UserListModel{
+		db:         db,
+		collection: storage.Collection(db, UserListCollectionName),
+	}
+}
+
+// UserListRecord is an explicit list of user ids rules can target, e.g. the
+// signups of a beta. Rules reference it by id rather than holding the ids, so
+// flags stay small however long the list grows. UserIDs are left out of
+// listings; Size tells how many there are.
+type UserListRecord struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	Size           int                `json:"size" bson:"size"`
+	UserIDs        []string           `json:"user_ids,omitempty" bson:"user_ids"`
+	storage.Timestamps
+}
+
+func NewUserListRecord(organizationID primitive.ObjectID, name string, userIDs []string) *UserListRecord {
+	return &UserListRecord{
+		OrganizationID: organizationID,
+		Name:           name,
+		Size:           len(userIDs),
+		UserIDs:        userIDs,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}
+}
+
+// UserSet holds the ids of a user list for constant time lookups.
+type UserSet map[string]struct{}
+
+func NewUserSet(userIDs []string) UserSet {
+	set := make(UserSet, len(userIDs))
+	for _, userID := range userIDs {
+		set[userID] = struct{}{}
+	}
+
+	return set
+}
+
+func (us UserSet) Contains(userID string) bool {
+	_, ok := us[userID]
+	return ok
+}
+
+func (ulm *UserListModel) InsertOne(ctx context.Context, record *UserListRecord) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := ulm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+// FindByID only finds user lists of organizationID.
+func (ulm *UserListModel) FindByID(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (*UserListRecord, error) {
+	record := new(UserListRecord)
+	if err := ulm.collection.FindOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	}).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// FindAllByOrganization lists the organization's user lists without their
+// user ids.
+func (ulm *UserListModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]UserListRecord, error) {
+	records := make([]UserListRecord, 0)
+	cursor, err := ulm.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().
+			SetSort(bson.D{{Key: "name", Value: 1}}).
+			SetProjection(bson.D{{Key: "user_ids", Value: 0}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]UserListRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// FindSets returns the user ids of the organization's lists among ids,
+// keyed by list id. Lists that don't exist are left out.
+func (ulm *UserListModel) FindSets(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	ids []primitive.ObjectID,
+) (map[primitive.ObjectID]UserSet, error) {
+	sets := make(map[primitive.ObjectID]UserSet, len(ids))
+	if len(ids) == 0 {
+		return sets, nil
+	}
+
+	var records []UserListRecord
+	err := storage.Retry(ctx, func() error {
+		cursor, err := ulm.collection.Find(ctx, bson.D{
+			{Key: "_id", Value: bson.M{"$in": ids}},
+			{Key: "organization_id", Value: organizationID},
+		})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range records {
+		sets[records[index].ID] = NewUserSet(records[index].UserIDs)
+	}
+
+	return sets, nil
+}
+
+// ReplaceUserIDs overwrites the name and every user id of the list. It
+// reports false when organizationID has no user list id.
+func (ulm *UserListModel) ReplaceUserIDs(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+	name string,
+	userIDs []string,
+) (bool, error) {
+	var result *mongo.UpdateResult
+	err := storage.Retry(ctx, func() error {
+		var err error
+		result, err = ulm.collection.UpdateOne(ctx,
+			bson.D{
+				{Key: "_id", Value: id},
+				{Key: "organization_id", Value: organizationID},
+			},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "name", Value: name},
+				{Key: "size", Value: len(userIDs)},
+				{Key: "user_ids", Value: userIDs},
+				{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())},
+			}}},
+		)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// DeleteOne reports false when organizationID has no user list id.
+func (ulm *UserListModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := ulm.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
+
+// CountMany counts the organization's user lists among ids.
+func (ulm *UserListModel) CountMany(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+	ids []primitive.ObjectID,
+) (int64, error) {
+	var count int64
+	err := storage.Retry(ctx, func() error {
+		var err error
+		count, err = ulm.collection.CountDocuments(ctx, bson.D{
+			{Key: "_id", Value: bson.M{"$in": ids}},
+			{Key: "organization_id", Value: organizationID},
+		})
+		return err
+	})
+
+	return count, err
+}
+
+// RuleUserListIDs lists, without duplicates, the user lists rules target.
+func RuleUserListIDs(rules []Rule) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool)
+	ids := make([]primitive.ObjectID, 0)
+	for _, rule := range rules {
+		if rule.UserListID.IsZero() || seen[rule.UserListID] {
+			continue
+		}
+		seen[rule.UserListID] = true
+		ids = append(ids, rule.UserListID)
+	}
+
+	return ids
+}
+
+// UserListIDs lists, without duplicates, the user lists the rules of every
+// revision of featureFlags and their segment overrides target.
+func UserListIDs(featureFlags []FeatureFlagRecord) []primitive.ObjectID {
+	rules := make([]Rule, 0)
+	for flagIndex := range featureFlags {
+		for revisionIndex := range featureFlags[flagIndex].Revisions {
+			rules = append(rules, featureFlags[flagIndex].Revisions[revisionIndex].Rules...)
+		}
+		for _, override := range featureFlags[flagIndex].SegmentOverrides {
+			rules = append(rules, Rule{UserListID: override.UserListID})
+		}
+	}
+
+	return RuleUserListIDs(rules)
+}
+
+// ParseUserListCSV reads user ids from the first column of a CSV file,
+// trimming them and skipping blank ones and duplicates. A first line reading
+// "user_id" is taken for a header, and a leading byte order mark, which
+// spreadsheets like to add, is dropped.
+func ParseUserListCSV(reader io.Reader) ([]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	seen := make(map[string]bool)
+	userIDs := make([]string, 0)
+	for line := 0; ; line++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		userID := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff"))
+		if userID == "" || seen[userID] || (line == 0 && userID == "user_id") {
+			continue
+		}
+		if len(userIDs) == MaxUserListSize {
+			return nil, ErrUserListTooLarge
+		}
+		seen[userID] = true
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,79 @@
+package models_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseUserListCSV(t *testing.T) {
+	userIDs, err := models.ParseUserListCSV(strings.NewReader(
+		"\ufeffuser_id,email\n user-1 ,ana@example.com\n\nuser-2\nuser-1,again@example.com\n,blank@example.com\n",
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, userIDs)
+
+	userIDs, err = models.ParseUserListCSV(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, userIDs)
+
+	_, err = models.ParseUserListCSV(strings.NewReader("user-1,\"unterminated\n"))
+	assert.Error(t, err)
+
+	var tooMany strings.Builder
+	for index := 0; index <= models.MaxUserListSize; index++ {
+		fmt.Fprintf(&tooMany, "user-%d\n", index)
+	}
+	_, err = models.ParseUserListCSV(strings.NewReader(tooMany.String()))
+	assert.ErrorIs(t, err, models.ErrUserListTooLarge)
+}
+
+func TestUserListIDs(t *testing.T) {
+	beta := primitive.NewObjectID()
+	staff := primitive.NewObjectID()
+	enterprise := primitive.NewObjectID()
+	featureFlags := []models.FeatureFlagRecord{
+		{Revisions: []models.Revision{
+			{Rules: []models.Rule{{UserListID: beta}, {Predicate: "country: BR"}}},
+			{Rules: []models.Rule{{UserListID: staff}, {UserListID: beta}}},
+		}},
+		{
+			Revisions:        []models.Revision{{Rules: []models.Rule{{UserListID: staff}}}},
+			SegmentOverrides: []models.SegmentOverride{{UserListID: enterprise}, {UserListID: beta}},
+		},
+	}
+
+	assert.Equal(t, []primitive.ObjectID{beta, staff, enterprise}, models.UserListIDs(featureFlags))
+	assert.True(t, models.NewUserSet([]string{"user-1"}).Contains("user-1"))
+	assert.False(t, models.NewUserSet([]string{"user-1"}).Contains("user-2"))
+}
+
+func TestRuleTargetingAUserList(t *testing.T) {
+	rule := models.Rule{UserListID: primitive.NewObjectID(), Value: "true", Env: "prd"}
+	assert.NoError(t, rule.Validate())
+	assert.Empty(t, rule.Predicates())
+
+	rule.Predicate = "country: BR"
+	assert.ErrorIs(t, rule.Validate(), models.ErrInvalidCondition)
+}
+
+func TestSortSegmentOverrides(t *testing.T) {
+	enterprise := primitive.NewObjectID()
+	beta := primitive.NewObjectID()
+	overrides := []models.SegmentOverride{
+		{UserListID: beta, Priority: 10, Value: "beta"},
+		{UserListID: enterprise, Priority: 0, Value: "enterprise"},
+	}
+
+	assert.True(t, models.SortSegmentOverrides(overrides))
+	assert.Equal(t, []int{0, 10}, []int{overrides[0].Priority, overrides[1].Priority})
+	assert.Equal(t, []primitive.ObjectID{enterprise, beta}, models.SegmentUserListIDs(overrides))
+
+	overrides = append(overrides, models.SegmentOverride{UserListID: beta, Priority: 10, Value: "again"})
+	assert.False(t, models.SortSegmentOverrides(overrides))
+	assert.Equal(t, []primitive.ObjectID{enterprise, beta}, models.SegmentUserListIDs(overrides))
+}
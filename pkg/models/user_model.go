@@ -23,7 +23,7 @@ type UserModel struct {
 func NewUserModel(db *mongo.Database) *UserModel {
 	return &UserModel{
 		db:         db,
-		collection: db.Collection(UserCollectionName),
+		collection: storage.Collection(db, UserCollectionName),
 	}
 }
 
@@ -36,6 +36,27 @@ func (um *UserModel) FindByID(ctx context.Context, id primitive.ObjectID) (*User
 	return record, nil
 }
 
+// FindManyByIDs returns the users of ids that exist, in no particular order.
+func (um *UserModel) FindManyByIDs(ctx context.Context, ids []primitive.ObjectID) ([]UserRecord, error) {
+	records := make([]UserRecord, 0, len(ids))
+	var cursor *mongo.Cursor
+	err := storage.Retry(ctx, func() error {
+		var err error
+		cursor, err = um.collection.Find(ctx, bson.D{{Key: "_id", Value: bson.M{"$in": ids}}})
+		return err
+	})
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]UserRecord, 0), err
+	}
+
+	return records, nil
+}
+
 func (um *UserModel) FindByEmail(ctx context.Context, email string) (*UserRecord, error) {
 	record := new(UserRecord)
 	if err := um.collection.FindOne(ctx, bson.D{{Key: "email", Value: email}}).Decode(record); err != nil {
@@ -68,7 +89,10 @@ func (um *UserModel) UpdateOne(
 ) (primitive.ObjectID, error) {
 	filter := bson.D{{Key: "_id", Value: id}}
 	update := bson.D{{Key: "$set", Value: newValues}}
-	_, err := um.collection.UpdateOne(ctx, filter, update)
+	err := storage.Retry(ctx, func() error {
+		_, err := um.collection.UpdateOne(ctx, filter, update)
+		return err
+	})
 	if err != nil {
 		return primitive.ObjectID{}, err
 	}
@@ -76,16 +100,111 @@ func (um *UserModel) UpdateOne(
 	return id, nil
 }
 
+// UpdatePassword hashes password before storing it and revokes every token
+// issued with the previous one. The previous hash is kept in the user's
+// history, which holds at most historySize of them.
+func (um *UserModel) UpdatePassword(
+	ctx context.Context,
+	user *UserRecord,
+	password string,
+	historySize int,
+) error {
+	ep, err := encryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if historySize == 0 || user.Password == "" {
+		return um.bumpTokenVersion(ctx, user.ID, bson.D{{Key: "password", Value: ep}})
+	}
+
+	return um.bumpTokenVersion(ctx, user.ID, bson.D{{Key: "password", Value: ep}}, bson.E{
+		Key: "$push", Value: bson.M{"password_history": bson.M{
+			"$each":  bson.A{user.Password},
+			"$slice": -historySize,
+		}},
+	})
+}
+
+// UpgradePasswordHash rehashes password, which must be the user's current
+// one, with the configured cost. Unlike UpdatePassword, tokens stay valid.
+func (um *UserModel) UpgradePasswordHash(ctx context.Context, id primitive.ObjectID, password string) error {
+	ep, err := encryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = um.UpdateOne(ctx, id, bson.D{{Key: "password", Value: ep}})
+	return err
+}
+
+// RevokeTokens makes every token issued to the user so far fail verification.
+func (um *UserModel) RevokeTokens(ctx context.Context, id primitive.ObjectID) error {
+	return um.bumpTokenVersion(ctx, id, bson.D{})
+}
+
+// bumpTokenVersion sets newValues and applies any other update operators
+// along with the version bump.
+func (um *UserModel) bumpTokenVersion(
+	ctx context.Context,
+	id primitive.ObjectID,
+	newValues bson.D,
+	operators ...bson.E,
+) error {
+	newValues = append(newValues, bson.E{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now().UTC())})
+	update := bson.D{
+		{Key: "$set", Value: newValues},
+		{Key: "$inc", Value: bson.M{"token_version": 1}},
+	}
+	update = append(update, operators...)
+
+	_, err := um.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+
+	return err
+}
+
 type UserRecord struct {
 	ID        primitive.ObjectID `json:"_id" bson:"_id"`
 	Email     string             `json:"email" bson:"email"`
 	SsoID     string             `json:"sso_id,omitempty" bson:"sso_id,omitempty"`
-	Password  string             `json:"password,omitempty" bson:"password,omitempty"`
+	Password  string             `json:"-" bson:"password,omitempty"`
 	FirstName string             `json:"first_name,omitempty" bson:"first_name,omitempty"`
 	LastName  string             `json:"last_name,omitempty" bson:"last_name,omitempty"`
+	// TokenVersion must match the version claim of a token for it to be accepted.
+	TokenVersion int `json:"-" bson:"token_version"`
+	// PasswordHistory holds the hashes of previous passwords, oldest first.
+	PasswordHistory []string `json:"-" bson:"password_history,omitempty"`
 	storage.Timestamps
 }
 
+// UsedPassword reports whether password is the user's current one or one of
+// their last historySize previous ones.
+func (ur *UserRecord) UsedPassword(password string, historySize int) bool {
+	hashes := []string{ur.Password}
+	if historySize > 0 {
+		history := ur.PasswordHistory
+		if len(history) > historySize {
+			history = history[len(history)-historySize:]
+		}
+		hashes = append(hashes, history...)
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedsRehash reports whether the password hash was made with a lower cost
+// than the configured one.
+func (ur *UserRecord) NeedsRehash() bool {
+	cost, err := bcrypt.Cost([]byte(ur.Password))
+	return err == nil && cost < config.BCryptCost
+}
+
 func NewUserRecord(email, password, firstName, lastName string) (*UserRecord, error) {
 	ep, err := encryptPassword(password)
 	if err != nil {
@@ -0,0 +1,48 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func hash(t *testing.T, password string, cost int) string {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	assert.NoError(t, err)
+
+	return string(hashed)
+}
+
+func TestUsedPasswordChecksCurrentAndRecentPasswords(t *testing.T) {
+	user := &models.UserRecord{
+		Password: hash(t, "current", config.BCryptCost),
+		PasswordHistory: []string{
+			hash(t, "oldest", config.BCryptCost),
+			hash(t, "older", config.BCryptCost),
+			hash(t, "old", config.BCryptCost),
+		},
+	}
+
+	assert.True(t, user.UsedPassword("current", 0))
+	assert.False(t, user.UsedPassword("old", 0))
+
+	assert.True(t, user.UsedPassword("old", 2))
+	assert.True(t, user.UsedPassword("older", 2))
+	assert.False(t, user.UsedPassword("oldest", 2))
+	assert.False(t, user.UsedPassword("brand new", 2))
+}
+
+func TestUsedPasswordIgnoresMissingPassword(t *testing.T) {
+	user := &models.UserRecord{}
+
+	assert.False(t, user.UsedPassword("", 5))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	assert.True(t, (&models.UserRecord{Password: hash(t, "secret", bcrypt.MinCost)}).NeedsRehash())
+	assert.False(t, (&models.UserRecord{Password: hash(t, "secret", config.BCryptCost)}).NeedsRehash())
+	assert.False(t, (&models.UserRecord{}).NeedsRehash())
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const WebhookCollectionName = "webhook"
+
+type WebhookModel struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewWebhookModel(db *mongo.Database) *WebhookModel {
+	return &WebhookModel{
+		db:         db,
+		collection: storage.Collection(db, WebhookCollectionName),
+	}
+}
+
+// WebhookRecord registers URL for the events of an organization. Payloads are
+// signed with Secret, which is only shown when the webhook is created. A
+// webhook without Events gets every event.
+type WebhookRecord struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organization_id" bson:"organization_id"`
+	URL            string             `json:"url" bson:"url"`
+	Secret         string             `json:"-" bson:"secret"`
+	Events         []string           `json:"events" bson:"events"`
+	storage.Timestamps
+}
+
+// NewWebhookRecord generates the secret the webhook's payloads are signed
+// with.
+func NewWebhookRecord(organizationID primitive.ObjectID, url string, events []string) (*WebhookRecord, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = make([]string, 0)
+	}
+
+	return &WebhookRecord{
+		OrganizationID: organizationID,
+		URL:            url,
+		Secret:         hex.EncodeToString(secret),
+		Events:         events,
+		Timestamps: storage.Timestamps{
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+			UpdatedAt: primitive.NewDateTimeFromTime(time.Now().UTC()),
+		},
+	}, nil
+}
+
+// Wants reports whether the webhook subscribed to event.
+func (wr *WebhookRecord) Wants(event string) bool {
+	if len(wr.Events) == 0 {
+		return true
+	}
+	for _, wanted := range wr.Events {
+		if wanted == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (wm *WebhookModel) InsertOne(ctx context.Context, record *WebhookRecord) (primitive.ObjectID, error) {
+	record.ID = primitive.NewObjectID()
+	result, err := wm.collection.InsertOne(ctx, record)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("unable to assert type of objectID")
+	}
+
+	return objectID, nil
+}
+
+func (wm *WebhookModel) FindAllByOrganization(
+	ctx context.Context,
+	organizationID primitive.ObjectID,
+) ([]WebhookRecord, error) {
+	records := make([]WebhookRecord, 0)
+	cursor, err := wm.collection.Find(
+		ctx,
+		bson.D{{Key: "organization_id", Value: organizationID}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return records, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return make([]WebhookRecord, 0), err
+	}
+
+	return records, nil
+}
+
+// DeleteOne reports false when organizationID has no webhook id.
+func (wm *WebhookModel) DeleteOne(
+	ctx context.Context,
+	organizationID,
+	id primitive.ObjectID,
+) (bool, error) {
+	result, err := wm.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organization_id", Value: organizationID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount == 1, nil
+}
@@ -0,0 +1,148 @@
+package nats
+
+import (
+	"encoding/json"
+
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	natsgo "github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// Conn is the part of a NATS connection the client uses.
+type Conn interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject string, handler natsgo.MsgHandler) (*natsgo.Subscription, error)
+	Drain() error
+}
+
+// Connect dials the server at url. It doesn't wait for the server to be up:
+// the connection keeps retrying in the background and buffers what is
+// published meanwhile, so a NATS outage never stops the service.
+func Connect(url string, logger *zap.Logger) (*natsgo.Conn, error) {
+	return natsgo.Connect(url,
+		natsgo.Name("togglelabs"),
+		natsgo.RetryOnFailedConnect(true),
+		natsgo.MaxReconnects(-1),
+		natsgo.DisconnectErrHandler(func(_ *natsgo.Conn, err error) {
+			if err != nil {
+				logger.Warn("NATS disconnected",
+					zap.String("cause", err.Error()),
+				)
+			}
+		}),
+		natsgo.ReconnectHandler(func(conn *natsgo.Conn) {
+			logger.Info("NATS reconnected",
+				zap.String("url", conn.ConnectedUrl()),
+			)
+		}),
+	)
+}
+
+// Subject is where event is published for organizationID under prefix:
+// <prefix>.<organization id>.<event>.
+func Subject(prefix, organizationID, event string) string {
+	return prefix + "." + organizationID + "." + event
+}
+
+// Client publishes flag changes and subscribes to them, under the prefix of
+// their organization.
+type Client struct {
+	conn                 Conn
+	logger               *zap.Logger
+	prefix               string
+	organizationPrefixes map[string]string
+}
+
+func NewClient(conn Conn, logger *zap.Logger, prefix string) *Client {
+	return &Client{
+		conn:   conn,
+		logger: logger,
+		prefix: prefix,
+	}
+}
+
+// WithOrganizationPrefixes uses the prefixes of prefixes, by organization id,
+// instead of the client's own for those organizations.
+func (c *Client) WithOrganizationPrefixes(prefixes map[string]string) *Client {
+	c.organizationPrefixes = prefixes
+	return c
+}
+
+// subject is where event is published for organizationID.
+func (c *Client) subject(organizationID, event string) string {
+	prefix, ok := c.organizationPrefixes[organizationID]
+	if !ok {
+		prefix = c.prefix
+	}
+
+	return Subject(prefix, organizationID, event)
+}
+
+// Publish sends a flag change to the subject of its organization. Failures
+// are logged and otherwise ignored.
+func (c *Client) Publish(payload webhooks.FeatureFlagPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+		)
+		return
+	}
+
+	subject := c.subject(payload.OrganizationID.Hex(), payload.Event)
+	if err := c.conn.Publish(subject, data); err != nil {
+		c.logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+			zap.String("subject", subject),
+		)
+	}
+}
+
+// PublishOrganizationEvent sends an organization or member change to the
+// subject of its event, so subscribers pick the events they want by subject.
+func (c *Client) PublishOrganizationEvent(payload webhooks.OrganizationPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+		)
+		return
+	}
+
+	subject := c.subject(payload.OrganizationID.Hex(), payload.Event)
+	if err := c.conn.Publish(subject, data); err != nil {
+		c.logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+			zap.String("subject", subject),
+		)
+	}
+}
+
+// SubscribeChanges calls onChange with every flag change of organizationID
+// until the connection is drained.
+func (c *Client) SubscribeChanges(organizationID string, onChange func(webhooks.FeatureFlagPayload)) error {
+	subject := c.subject(organizationID, webhooks.FeatureFlagUpdatedEvent)
+	_, err := c.conn.Subscribe(subject, func(message *natsgo.Msg) {
+		var payload webhooks.FeatureFlagPayload
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			c.logger.Error("NATS error",
+				zap.String("cause", err.Error()),
+				zap.String("subject", message.Subject),
+			)
+			return
+		}
+
+		onChange(payload)
+	})
+
+	return err
+}
+
+// Close delivers what is still buffered and closes the connection.
+func (c *Client) Close() {
+	if err := c.conn.Drain(); err != nil {
+		c.logger.Error("NATS error",
+			zap.String("cause", err.Error()),
+		)
+	}
+}
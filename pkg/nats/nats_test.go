@@ -0,0 +1,145 @@
+package nats_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/nats"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// memoryConn delivers what is published to the handlers subscribed to the
+// same subject, or fails every publish with err.
+type memoryConn struct {
+	err       error
+	published map[string][][]byte
+	handlers  map[string]natsgo.MsgHandler
+}
+
+func newMemoryConn() *memoryConn {
+	return &memoryConn{
+		published: make(map[string][][]byte),
+		handlers:  make(map[string]natsgo.MsgHandler),
+	}
+}
+
+func (mc *memoryConn) Publish(subject string, data []byte) error {
+	if mc.err != nil {
+		return mc.err
+	}
+
+	mc.published[subject] = append(mc.published[subject], data)
+	if handler, ok := mc.handlers[subject]; ok {
+		handler(&natsgo.Msg{Subject: subject, Data: data})
+	}
+	return nil
+}
+
+func (mc *memoryConn) Subscribe(subject string, handler natsgo.MsgHandler) (*natsgo.Subscription, error) {
+	mc.handlers[subject] = handler
+	return nil, nil
+}
+
+func (mc *memoryConn) Drain() error {
+	return nil
+}
+
+func TestSubjectIsScopedToTheOrganization(t *testing.T) {
+	assert.Equal(t,
+		"togglelabs.65f1c0ffee.feature_flag.updated",
+		nats.Subject("togglelabs", "65f1c0ffee", webhooks.FeatureFlagUpdatedEvent),
+	)
+}
+
+func TestPublishSendsChangesToTheOrganizationSubject(t *testing.T) {
+	conn := newMemoryConn()
+	client := nats.NewClient(conn, zap.NewNop(), "togglelabs")
+
+	organizationID := primitive.NewObjectID()
+	client.Publish(webhooks.FeatureFlagPayload{
+		Event:          webhooks.FeatureFlagUpdatedEvent,
+		OrganizationID: organizationID,
+		Name:           "checkout",
+		Version:        3,
+	})
+
+	subject := nats.Subject("togglelabs", organizationID.Hex(), webhooks.FeatureFlagUpdatedEvent)
+	assert.Len(t, conn.published[subject], 1)
+
+	var payload webhooks.FeatureFlagPayload
+	assert.NoError(t, json.Unmarshal(conn.published[subject][0], &payload))
+	assert.Equal(t, "checkout", payload.Name)
+	assert.Equal(t, 3, payload.Version)
+}
+
+func TestSubscribeChangesOnlyReceivesItsOrganization(t *testing.T) {
+	conn := newMemoryConn()
+	client := nats.NewClient(conn, zap.NewNop(), "togglelabs")
+
+	organizationID := primitive.NewObjectID()
+	received := make([]webhooks.FeatureFlagPayload, 0)
+	assert.NoError(t, client.SubscribeChanges(organizationID.Hex(), func(payload webhooks.FeatureFlagPayload) {
+		received = append(received, payload)
+	}))
+
+	client.Publish(webhooks.FeatureFlagPayload{
+		Event:          webhooks.FeatureFlagUpdatedEvent,
+		OrganizationID: primitive.NewObjectID(),
+		Name:           "someone else's",
+	})
+	client.Publish(webhooks.FeatureFlagPayload{
+		Event:          webhooks.FeatureFlagUpdatedEvent,
+		OrganizationID: organizationID,
+		Name:           "checkout",
+	})
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, "checkout", received[0].Name)
+}
+
+func TestOrganizationPrefixReplacesTheGlobalOne(t *testing.T) {
+	conn := newMemoryConn()
+	acmeID := primitive.NewObjectID()
+	client := nats.NewClient(conn, zap.NewNop(), "togglelabs").
+		WithOrganizationPrefixes(map[string]string{acmeID.Hex(): "acme.flags"})
+
+	received := make([]webhooks.FeatureFlagPayload, 0)
+	assert.NoError(t, client.SubscribeChanges(acmeID.Hex(), func(payload webhooks.FeatureFlagPayload) {
+		received = append(received, payload)
+	}))
+
+	otherID := primitive.NewObjectID()
+	for _, organizationID := range []primitive.ObjectID{acmeID, otherID} {
+		client.Publish(webhooks.FeatureFlagPayload{
+			Event:          webhooks.FeatureFlagUpdatedEvent,
+			OrganizationID: organizationID,
+		})
+	}
+
+	assert.Len(t, conn.published[nats.Subject("acme.flags", acmeID.Hex(), webhooks.FeatureFlagUpdatedEvent)], 1)
+	assert.Len(t, conn.published[nats.Subject("togglelabs", otherID.Hex(), webhooks.FeatureFlagUpdatedEvent)], 1)
+	assert.Len(t, received, 1)
+}
+
+func TestPublishLogsFailures(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	conn := newMemoryConn()
+	conn.err = errors.New("nats: connection closed")
+	client := nats.NewClient(conn, zap.New(core), "togglelabs")
+
+	client.Publish(webhooks.FeatureFlagPayload{
+		Event:          webhooks.FeatureFlagUpdatedEvent,
+		OrganizationID: primitive.NewObjectID(),
+	})
+
+	entries := logs.FilterMessage("NATS error").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+}
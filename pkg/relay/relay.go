@@ -0,0 +1,203 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+)
+
+// SyncPageSize is how many flags are requested from the central server per page.
+const SyncPageSize = 100
+
+// APIKeyHeader carries the relay's API key, as middlewares.APIKeyHeader
+// expects it.
+const APIKeyHeader = "X-API-Key"
+
+var ErrUpstreamStatus = errors.New("unexpected status from upstream")
+
+// Store holds the flags the relay serves, along with the organization's
+// environment inheritance. It is empty until the first successful sync,
+// which Synced reports.
+type Store struct {
+	mu                 sync.RWMutex
+	flags              []models.FeatureFlagRecord
+	environmentParents map[string]string
+	syncedAt           time.Time
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Flags returns a copy of the current snapshot, safe to evaluate against
+// while a sync replaces it.
+func (s *Store) Flags() []models.FeatureFlagRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]models.FeatureFlagRecord, len(s.flags))
+	copy(flags, s.flags)
+
+	return flags
+}
+
+// EnvironmentParents returns the organization's environment inheritance as
+// of the last sync. It must not be modified.
+func (s *Store) EnvironmentParents() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.environmentParents
+}
+
+func (s *Store) Synced() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !s.syncedAt.IsZero()
+}
+
+func (s *Store) replace(
+	flags []models.FeatureFlagRecord,
+	environmentParents map[string]string,
+	syncedAt time.Time,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags = flags
+	s.environmentParents = environmentParents
+	s.syncedAt = syncedAt
+}
+
+type page struct {
+	etag               string
+	flags              []models.FeatureFlagRecord
+	environmentParents map[string]string
+}
+
+// Client mirrors the flags of the organization of its API key from the
+// central server into a Store. Archived flags are listed separately upstream
+// and still serve their default value, so both listings are mirrored. Each
+// page remembers its ETag so unchanged pages are revalidated with a 304
+// instead of being transferred again.
+type Client struct {
+	httpClient  *http.Client
+	upstreamURL string
+	apiKey      string
+	store       *Store
+	listings    map[bool][]page
+}
+
+func NewClient(httpClient *http.Client, upstreamURL, apiKey string, store *Store) *Client {
+	return &Client{
+		httpClient:  httpClient,
+		upstreamURL: upstreamURL,
+		apiKey:      apiKey,
+		store:       store,
+		listings:    make(map[bool][]page),
+	}
+}
+
+// Sync fetches every page of the organization's flags and swaps the store's
+// snapshot. It reports whether anything changed since the last sync. The
+// store is left untouched when any page fails.
+func (rc *Client) Sync(ctx context.Context) (bool, error) {
+	listings := make(map[bool][]page, 2)
+	changed := !rc.store.Synced()
+	for _, archived := range []bool{false, true} {
+		pages, listingChanged, err := rc.syncListing(ctx, archived)
+		if err != nil {
+			return false, err
+		}
+		listings[archived] = pages
+		changed = changed || listingChanged
+	}
+
+	if changed {
+		flags := make([]models.FeatureFlagRecord, 0)
+		for _, archived := range []bool{false, true} {
+			for _, current := range listings[archived] {
+				flags = append(flags, current.flags...)
+			}
+		}
+		// Every page carries the inheritance; the first one is always there.
+		rc.store.replace(flags, listings[false][0].environmentParents, time.Now().UTC())
+	}
+	rc.listings = listings
+
+	return changed, nil
+}
+
+func (rc *Client) syncListing(ctx context.Context, archived bool) ([]page, bool, error) {
+	known := rc.listings[archived]
+	pages := make([]page, 0, len(known))
+	changed := false
+	for number := 1; ; number++ {
+		var previous page
+		if number <= len(known) {
+			previous = known[number-1]
+		}
+
+		current, err := rc.fetchPage(ctx, archived, number, previous)
+		if err != nil {
+			return nil, false, err
+		}
+		changed = changed || current.etag != previous.etag
+		pages = append(pages, current)
+
+		if len(current.flags) < SyncPageSize {
+			break
+		}
+	}
+
+	return pages, changed || len(pages) != len(known), nil
+}
+
+func (rc *Client) fetchPage(ctx context.Context, archived bool, number int, previous page) (page, error) {
+	url := fmt.Sprintf("%s/feature-flags?archived=%s&page=%d&page_size=%d",
+		rc.upstreamURL, strconv.FormatBool(archived), number, SyncPageSize)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return page{}, err
+	}
+	request.Header.Set(APIKeyHeader, rc.apiKey)
+	if previous.etag != "" {
+		request.Header.Set("If-None-Match", previous.etag)
+	}
+
+	response, err := rc.httpClient.Do(request)
+	if err != nil {
+		return page{}, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return previous, nil
+	case http.StatusOK:
+	default:
+		return page{}, fmt.Errorf("%w: %d for page %d", ErrUpstreamStatus, response.StatusCode, number)
+	}
+
+	var body struct {
+		Data               []models.FeatureFlagRecord `json:"data"`
+		EnvironmentParents map[string]string          `json:"environment_parents"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return page{}, err
+	}
+
+	return page{
+		etag:               response.Header.Get("ETag"),
+		flags:              body.Data,
+		environmentParents: body.EnvironmentParents,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package relay_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/relay"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// upstream serves a fixed set of flags the way the central server lists them.
+type upstream struct {
+	mu       sync.Mutex
+	active   []models.FeatureFlagRecord
+	archived []models.FeatureFlagRecord
+	parents  map[string]string
+	requests int
+	full     int
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.requests++
+	if r.URL.Path != "/feature-flags" || r.Header.Get(relay.APIKeyHeader) != "tgl_relay" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	flags := u.active
+	if r.URL.Query().Get("archived") == "true" {
+		flags = u.archived
+	}
+	body, _ := json.Marshal(map[string]any{"data": flags, "environment_parents": u.parents})
+	etag := apiutils.ETag(body)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	u.full++
+	_, _ = w.Write(body)
+}
+
+func TestClientSyncMirrorsUpstream(t *testing.T) {
+	source := &upstream{
+		active:   []models.FeatureFlagRecord{{ID: primitive.NewObjectID(), Name: "checkout"}},
+		archived: []models.FeatureFlagRecord{{ID: primitive.NewObjectID(), Name: "old-banner"}},
+	}
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	store := relay.NewStore()
+	client := relay.NewClient(server.Client(), server.URL, "tgl_relay", store)
+	assert.False(t, store.Synced())
+
+	changed, err := client.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, store.Synced())
+	assert.Len(t, store.Flags(), 2)
+	assert.Equal(t, 2, source.full)
+
+	changed, err = client.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed, "unchanged pages are revalidated")
+	assert.Equal(t, 2, source.full)
+	assert.Equal(t, 4, source.requests)
+
+	source.mu.Lock()
+	source.active = append(source.active, models.FeatureFlagRecord{ID: primitive.NewObjectID(), Name: "search"})
+	source.mu.Unlock()
+
+	changed, err = client.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, store.Flags(), 3)
+
+	// Inheritance changes are in the body, so they change the ETag too.
+	source.mu.Lock()
+	source.parents = map[string]string{"prd-eu": "prd"}
+	source.mu.Unlock()
+
+	changed, err = client.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{"prd-eu": "prd"}, store.EnvironmentParents())
+}
+
+func TestClientSyncKeepsSnapshotOnError(t *testing.T) {
+	source := &upstream{
+		active: []models.FeatureFlagRecord{{ID: primitive.NewObjectID(), Name: "checkout"}},
+	}
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	store := relay.NewStore()
+	client := relay.NewClient(server.Client(), server.URL, "tgl_relay", store)
+	_, err := client.Sync(context.Background())
+	assert.NoError(t, err)
+
+	failing := relay.NewClient(server.Client(), server.URL, "tgl_revoked", store)
+	_, err = failing.Sync(context.Background())
+	assert.ErrorIs(t, err, relay.ErrUpstreamStatus)
+	assert.Len(t, store.Flags(), 1)
+}
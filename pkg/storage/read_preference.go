@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/tag"
+)
+
+// ReadPreference builds the read preference of mode, narrowed down by the
+// tag sets and max staleness of config.MongoReadPreference unless mode is
+// primary.
+func ReadPreference(mode string) (*readpref.ReadPref, error) {
+	readPreferenceMode, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, err
+	}
+	if readPreferenceMode == readpref.PrimaryMode {
+		return readpref.Primary(), nil
+	}
+
+	opts := make([]readpref.Option, 0, 2)
+	if len(config.MongoReadPreference.TagSets) > 0 {
+		opts = append(opts, readpref.WithTagSets(tag.NewTagSetsFromMaps(config.MongoReadPreference.TagSets)...))
+	}
+	if config.MongoReadPreference.MaxStaleness > 0 {
+		opts = append(opts, readpref.WithMaxStaleness(config.MongoReadPreference.MaxStaleness))
+	}
+
+	return readpref.New(readPreferenceMode, opts...)
+}
+
+// Collection is db.Collection(name) reading with the read preference
+// configured for name, if any, and the client's otherwise. Models get their
+// collection through it so config.MongoReadPreference.Collections applies
+// to every read.
+func Collection(db *mongo.Database, name string) *mongo.Collection {
+	mode, ok := config.MongoReadPreference.Collections[name]
+	if !ok {
+		return db.Collection(name)
+	}
+
+	// The configuration was validated at startup, so this only fails for
+	// configurations built by hand, which keep the client's preference.
+	readPreference, err := ReadPreference(mode)
+	if err != nil {
+		return db.Collection(name)
+	}
+
+	return db.Collection(name, options.Collection().SetReadPreference(readPreference))
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/tag"
+)
+
+func setMongoReadPreference(t *testing.T, readPreference config.MongoReadPreferenceConfig) {
+	previous := config.MongoReadPreference
+	t.Cleanup(func() {
+		config.MongoReadPreference = previous
+	})
+	config.MongoReadPreference = readPreference
+}
+
+func TestReadPreferenceOfSecondaries(t *testing.T) {
+	setMongoReadPreference(t, config.MongoReadPreferenceConfig{
+		Mode:         config.NearestReadPreference,
+		TagSets:      []map[string]string{{"region": "eu"}, {}},
+		MaxStaleness: 2 * time.Minute,
+	})
+
+	readPreference, err := ReadPreference(config.NearestReadPreference)
+	assert.NoError(t, err)
+	assert.Equal(t, readpref.NearestMode, readPreference.Mode())
+	assert.Equal(t, []tag.Set{{{Name: "region", Value: "eu"}}, nil}, readPreference.TagSets())
+	maxStaleness, ok := readPreference.MaxStaleness()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, maxStaleness)
+}
+
+func TestReadPreferenceOfPrimaryIgnoresTags(t *testing.T) {
+	setMongoReadPreference(t, config.MongoReadPreferenceConfig{
+		Mode:    config.PrimaryReadPreference,
+		TagSets: []map[string]string{{"region": "eu"}},
+	})
+
+	readPreference, err := ReadPreference(config.PrimaryReadPreference)
+	assert.NoError(t, err)
+	assert.Equal(t, readpref.PrimaryMode, readPreference.Mode())
+	assert.Empty(t, readPreference.TagSets())
+}
+
+func TestReadPreferenceRejectsUnknownMode(t *testing.T) {
+	_, err := ReadPreference("closest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Server error codes Mongo returns while a replica set elects a new primary
+// or a node goes away. The operation can be retried as-is.
+var transientErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// RetryPolicy bounds how many times, and how far apart, an operation failing
+// with a transient error is attempted. The backoff doubles after every attempt.
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts: 3,
+	Backoff:  50 * time.Millisecond,
+}
+
+// Retry runs operation under DefaultRetryPolicy. It is only for reads and
+// writes that leave the same document however many times they are applied,
+// like a $set of fixed values or a $max: a transient error can come after the
+// server committed the write, so retrying an insert, a $push or an $inc could
+// apply it twice. Those are left to the driver's retryable writes, which the
+// server deduplicates.
+func Retry(ctx context.Context, operation func() error) error {
+	return DefaultRetryPolicy.Do(ctx, operation)
+}
+
+// Do runs operation until it succeeds, fails with a non transient error or
+// runs out of attempts. It gives up early rather than sleep past ctx's deadline,
+// returning the last error from operation.
+func (rp RetryPolicy) Do(ctx context.Context, operation func() error) error {
+	backoff := rp.Backoff
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= rp.Attempts || !IsTransientError(err) {
+			return err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// IsTransientError reports whether err is a network blip or a replica set
+// failover rather than a problem with the operation itself.
+func IsTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverError mongo.ServerError
+	if !errors.As(err, &serverError) {
+		return false
+	}
+
+	if serverError.HasErrorLabel("RetryableWriteError") || serverError.HasErrorLabel("TransientTransactionError") {
+		return true
+	}
+
+	for _, code := range transientErrorCodes {
+		if serverError.HasErrorCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
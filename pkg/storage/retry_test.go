@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errPrimarySteppedDown = mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+
+var testRetryPolicy = RetryPolicy{
+	Attempts: 3,
+	Backoff:  time.Millisecond,
+}
+
+func TestRetryRecoversFromTransientError(t *testing.T) {
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errPrimarySteppedDown
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), func() error {
+		calls++
+		return errPrimarySteppedDown
+	})
+
+	assert.Equal(t, errPrimarySteppedDown, err)
+	assert.Equal(t, testRetryPolicy.Attempts, calls)
+}
+
+func TestRetryDoesNotRetryPermanentErrors(t *testing.T) {
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), func() error {
+		calls++
+		return mongo.ErrNoDocuments
+	})
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.Equal(t, 1, calls)
+
+	duplicateKey := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+	calls = 0
+	err = testRetryPolicy.Do(context.Background(), func() error {
+		calls++
+		return duplicateKey
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryRespectsContextDeadline(t *testing.T) {
+	policy := RetryPolicy{
+		Attempts: 5,
+		Backoff:  time.Hour,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := policy.Do(ctx, func() error {
+		calls++
+		return errPrimarySteppedDown
+	})
+
+	assert.Equal(t, errPrimarySteppedDown, err)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryStopsWhenContextIsCanceled(t *testing.T) {
+	policy := RetryPolicy{
+		Attempts: 5,
+		Backoff:  time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := policy.Do(ctx, func() error {
+		calls++
+		cancel()
+		return errPrimarySteppedDown
+	})
+
+	assert.Equal(t, errPrimarySteppedDown, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(errPrimarySteppedDown))
+	assert.True(t, IsTransientError(mongo.CommandError{
+		Code:   112,
+		Labels: []string{"TransientTransactionError"},
+	}))
+	assert.False(t, IsTransientError(mongo.CommandError{Code: 2, Name: "BadValue"}))
+	assert.False(t, IsTransientError(mongo.ErrNoDocuments))
+	assert.False(t, IsTransientError(context.DeadlineExceeded))
+	assert.False(t, IsTransientError(errors.New("boom")))
+}
@@ -2,9 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"os"
 	"sync"
 
+	"github.com/Roll-Play/togglelabs/pkg/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -15,11 +17,30 @@ type MongoStorage struct {
 	init bool
 }
 
+// indexNotFoundCode is the server error for dropping an index that doesn't exist.
+const indexNotFoundCode = 27
+
 var lock = &sync.Mutex{}
 var storeSingleton *MongoStorage
 
 func newMongoStorage(ctx context.Context) (*MongoStorage, error) {
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("DATABASE_URL")))
+	readPreference, err := ReadPreference(config.MongoReadPreference.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := options.Client().
+		ApplyURI(os.Getenv("DATABASE_URL")).
+		SetMaxPoolSize(config.MongoPool.MaxPoolSize).
+		SetMinPoolSize(config.MongoPool.MinPoolSize).
+		SetConnectTimeout(config.MongoPool.ConnectTimeout).
+		SetServerSelectionTimeout(config.MongoPool.ServerSelectionTimeout).
+		SetReadPreference(readPreference).
+		// Writes Retry can't repeat safely are retried once by the driver
+		// instead, see Retry.
+		SetRetryWrites(true)
+
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +61,12 @@ func (ms *MongoStorage) Init() error {
 	}
 
 	ms.init = true
+	return EnsureIndexes(context.Background(), ms.db)
+}
+
+// EnsureIndexes creates the indexes of db the models rely on and drops those
+// they replaced.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 	indexes := []struct {
 		collection string
 		field      string
@@ -53,15 +80,111 @@ func (ms *MongoStorage) Init() error {
 				Options: options.Index().SetUnique(true),
 			},
 		},
+		{
+			// Flag names are unique among the flags that aren't deleted.
+			// Partial indexes can't filter on a missing field, so deleted_at
+			// is part of the key instead: it is null on every live flag and
+			// the deletion time on the others.
+			collection: "feature_flag",
+			field:      "organization_id,namespace,name,deleted_at",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "namespace", Value: 1},
+					{Key: "name", Value: 1},
+					{Key: "deleted_at", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		{
+			collection: "audit_log",
+			field:      "organization_id,created_at",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			},
+		},
+		{
+			collection: "audit_log",
+			field:      "organization_id,action,created_at",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "action", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			},
+		},
+		{
+			collection: "audit_log",
+			field:      "organization_id,feature_flag_id,created_at",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "feature_flag_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			},
+		},
+		{
+			collection: "audit_log",
+			field:      "organization_id,user_id,created_at",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "user_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			},
+		},
+		{
+			collection: "api_key",
+			field:      "key_hash",
+			opts: mongo.IndexModel{
+				Keys:    bson.D{{Key: "key_hash", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		{
+			collection: "organization_usage",
+			field:      "organization_id,bucket",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "bucket", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		{
+			collection: "context_sample_set",
+			field:      "organization_id,name",
+			opts: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "name", Value: 1},
+				},
+			},
+		},
 	}
 
 	for _, index := range indexes {
-		_, err := ms.db.Collection(index.collection).Indexes().CreateOne(context.Background(), index.opts)
+		_, err := db.Collection(index.collection).Indexes().CreateOne(ctx, index.opts)
 		if err != nil {
 			return err
 		}
 	}
 
+	// The unique flag name index covers what this one did.
+	_, err := db.Collection("feature_flag").Indexes().DropOne(ctx, "organization_id_1_namespace_1_name_1")
+	var commandError mongo.CommandError
+	if err != nil && !(errors.As(err, &commandError) && commandError.HasErrorCode(indexNotFoundCode)) {
+		return err
+	}
+
 	return nil
 }
 
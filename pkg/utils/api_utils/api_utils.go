@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Roll-Play/togglelabs/pkg/models"
 	"github.com/labstack/echo/v4"
@@ -14,7 +16,14 @@ import (
 )
 
 type ContextUser struct {
-	ID primitive.ObjectID
+	ID           primitive.ObjectID
+	TokenVersion int
+	// ImpersonationID is set when a platform admin is acting as the user.
+	ImpersonationID primitive.ObjectID
+	// Scope is the issuer and audience the token was stamped with.
+	Scope TokenScope
+	// ExpiresAt is when the token expires, zero when it doesn't say.
+	ExpiresAt time.Time
 }
 
 type BaseHTTPClient interface {
@@ -61,6 +70,26 @@ func GetPaginationParams(page, limit string) (int, int) {
 	return pageNumber, limitNumber
 }
 
+const MIMEApplicationYAML = "application/yaml"
+
+// IsYAMLMediaType reports whether an Accept or Content-Type header asks for YAML.
+func IsYAMLMediaType(header string) bool {
+	for _, mediaType := range []string{MIMEApplicationYAML, "application/x-yaml", "text/yaml"} {
+		if strings.Contains(header, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const MIMEApplicationJSONPatch = "application/json-patch+json"
+
+// IsJSONPatchMediaType reports whether a Content-Type header carries an RFC 6902 patch.
+func IsJSONPatchMediaType(header string) bool {
+	return strings.Contains(header, MIMEApplicationJSONPatch)
+}
+
 var ErrNotAuthenticated = errors.New("user not authenticated")
 var ErrContextUserTypeAssertion = errors.New("unable to assert type of user in context")
 var ErrReadPermissionDenied = errors.New("user does not have read permission")
@@ -80,6 +109,41 @@ func GetObjectIDFromContext(c echo.Context) (primitive.ObjectID, error) {
 	return user.ID, nil
 }
 
+// GetImpersonationIDFromContext returns the impersonation session of the
+// request, or a zero id when the user is acting on their own.
+func GetImpersonationIDFromContext(c echo.Context) primitive.ObjectID {
+	user, ok := c.Get("user").(ContextUser)
+	if !ok {
+		return primitive.NilObjectID
+	}
+
+	return user.ImpersonationID
+}
+
+// ObjectIDParam is the id in the path param name, as parsed by
+// middlewares.ObjectIDParamsMiddleware. On routes that don't declare the
+// param there it is parsed here instead, and a malformed id is the zero id,
+// which matches no document.
+func ObjectIDParam(c echo.Context, name string) primitive.ObjectID {
+	if id, ok := c.Get(objectIDParamKey(name)).(primitive.ObjectID); ok {
+		return id
+	}
+
+	id, _ := primitive.ObjectIDFromHex(c.Param(name))
+
+	return id
+}
+
+// SetObjectIDParam stores the parsed id of the path param name for
+// ObjectIDParam.
+func SetObjectIDParam(c echo.Context, name string, id primitive.ObjectID) {
+	c.Set(objectIDParamKey(name), id)
+}
+
+func objectIDParamKey(name string) string {
+	return "object_id_param:" + name
+}
+
 func HandlerErrorLogMessage(err error, c echo.Context) string {
 	return fmt.Sprintf(
 		"[Error]: {\"error\": \"%s\", \"ip\": \"%s\", \"location\": \"%s\"}",
@@ -122,3 +186,18 @@ func UserHasPermission(
 
 	return false
 }
+
+// UserPermissionLevel is the level userID holds in organization, the one
+// UserHasPermission checks against. It's false when they aren't a member.
+func UserPermissionLevel(
+	userID primitive.ObjectID,
+	organization *models.OrganizationRecord,
+) (models.PermissionLevelEnum, bool) {
+	for _, member := range organization.Members {
+		if member.User.ID == userID {
+			return member.PermissionLevel, true
+		}
+	}
+
+	return "", false
+}
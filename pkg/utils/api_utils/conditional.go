@@ -0,0 +1,48 @@
+package apiutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RevalidateCacheControl lets clients keep a copy of a read but makes them
+// check it with the server every time, which the validators keep cheap.
+const RevalidateCacheControl = "private, no-cache"
+
+// ETag derives a strong entity tag from a response body, so it changes
+// exactly when the body does.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified reports whether the request's conditional headers show the
+// client already has the representation identified by etag and lastModified.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func NotModified(request *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ifModifiedSince := request.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,55 @@
+package apiutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETagFollowsBody(t *testing.T) {
+	assert.Equal(t, apiutils.ETag([]byte("a=1")), apiutils.ETag([]byte("a=1")))
+	assert.NotEqual(t, apiutils.ETag([]byte("a=1")), apiutils.ETag([]byte("a=2")))
+}
+
+func TestNotModified(t *testing.T) {
+	etag := apiutils.ETag([]byte("a=1"))
+	lastModified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	testCases := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no conditional headers", map[string]string{}, false},
+		{"matching etag", map[string]string{"If-None-Match": etag}, true},
+		{"matching weak etag in a list", map[string]string{"If-None-Match": `"other", W/` + etag}, true},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, true},
+		{"stale etag", map[string]string{"If-None-Match": `"other"`}, false},
+		{"not modified since", map[string]string{
+			"If-Modified-Since": lastModified.Format(http.TimeFormat),
+		}, true},
+		{"modified since", map[string]string{
+			"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat),
+		}, false},
+		{"etag wins over date", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": lastModified.Format(http.TimeFormat),
+		}, false},
+		{"unparseable date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range testCase.headers {
+				request.Header.Set(key, value)
+			}
+
+			assert.Equal(t, testCase.want, apiutils.NotModified(request, etag, lastModified))
+		})
+	}
+}
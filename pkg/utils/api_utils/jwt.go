@@ -8,12 +8,93 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func CreateJWT(id primitive.ObjectID, expireAt time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iss": "togglelabs",
-		"sub": id.Hex(),
-		"exp": time.Now().Add(expireAt * time.Millisecond).Unix(),
-	})
+// TokenVersionClaim carries the user's token version. Bumping the stored
+// version revokes every token signed before.
+const TokenVersionClaim = "ver"
+
+// ImpersonationClaim carries the impersonation session a token was issued for.
+const ImpersonationClaim = "imp"
+
+// DefaultJWTIssuer is the issuer of every token unless an organization
+// configures its own, see TokenScope.
+const DefaultJWTIssuer = "togglelabs"
+
+// TokenScope is the issuer and audience a token is stamped with. The zero
+// scope is the service's own: DefaultJWTIssuer and no audience. Organizations
+// integrating with their own auth infrastructure can require a scope of their
+// own, so their tokens can't be used elsewhere and the other way around.
+type TokenScope struct {
+	Issuer   string
+	Audience string
+}
+
+// NewTokenScope returns the scope for issuer and audience, falling back to
+// DefaultJWTIssuer when issuer is empty.
+func NewTokenScope(issuer, audience string) TokenScope {
+	if issuer == "" {
+		issuer = DefaultJWTIssuer
+	}
+
+	return TokenScope{Issuer: issuer, Audience: audience}
+}
+
+// Matches reports whether a token of scope ts is accepted where expected is
+// required. Both the issuer and the audience must be the same, so a token
+// scoped to an audience is only accepted there.
+func (ts TokenScope) Matches(expected TokenScope) bool {
+	return NewTokenScope(ts.Issuer, ts.Audience) == NewTokenScope(expected.Issuer, expected.Audience)
+}
+
+// CreateJWT signs a token for id that expires after ttl, for a user whose
+// tokens were never revoked.
+func CreateJWT(id primitive.ObjectID, ttl time.Duration) (string, error) {
+	return CreateVersionedJWT(id, 0, ttl)
+}
+
+// CreateVersionedJWT signs a token for id at tokenVersion that expires after ttl.
+func CreateVersionedJWT(id primitive.ObjectID, tokenVersion int, ttl time.Duration) (string, error) {
+	return CreateScopedJWT(ContextUser{ID: id, TokenVersion: tokenVersion}, TokenScope{}, ttl)
+}
+
+// CreateScopedJWT signs a token for user stamped with scope that expires
+// after ttl. The token keeps the user's token version and impersonation
+// session, if any.
+func CreateScopedJWT(user ContextUser, scope TokenScope, ttl time.Duration) (string, error) {
+	scope = NewTokenScope(scope.Issuer, scope.Audience)
+	claims := jwt.MapClaims{
+		"iss":             scope.Issuer,
+		"sub":             user.ID.Hex(),
+		"exp":             time.Now().Add(ttl).Unix(),
+		TokenVersionClaim: user.TokenVersion,
+	}
+	if scope.Audience != "" {
+		claims["aud"] = scope.Audience
+	}
+	if !user.ImpersonationID.IsZero() {
+		claims[ImpersonationClaim] = user.ImpersonationID.Hex()
+	}
+
+	return signJWT(claims)
+}
+
+// CreateImpersonationJWT signs a token acting as id for the impersonation
+// session impersonationID. It expires after ttl like any other token, and is
+// revoked along with the user's own tokens.
+func CreateImpersonationJWT(
+	id primitive.ObjectID,
+	tokenVersion int,
+	impersonationID primitive.ObjectID,
+	ttl time.Duration,
+) (string, error) {
+	return CreateScopedJWT(ContextUser{
+		ID:              id,
+		TokenVersion:    tokenVersion,
+		ImpersonationID: impersonationID,
+	}, TokenScope{}, ttl)
+}
+
+func signJWT(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	key := os.Getenv("JWT_SECRET")
 	if key == "" {
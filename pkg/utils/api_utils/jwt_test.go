@@ -0,0 +1,51 @@
+package apiutils_test
+
+import (
+	"testing"
+	"time"
+
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateScopedJWTStampsTheScope(t *testing.T) {
+	user := apiutils.ContextUser{ID: primitive.NewObjectID(), TokenVersion: 2}
+	token, err := apiutils.CreateScopedJWT(user, apiutils.TokenScope{Audience: "billing-app"}, time.Minute)
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, _, err = new(jwt.Parser).ParseUnverified(token, claims)
+	assert.NoError(t, err)
+	assert.Equal(t, apiutils.DefaultJWTIssuer, claims["iss"])
+	assert.Equal(t, "billing-app", claims["aud"])
+	assert.Equal(t, user.ID.Hex(), claims["sub"])
+	assert.Equal(t, float64(2), claims[apiutils.TokenVersionClaim])
+	assert.NotContains(t, claims, apiutils.ImpersonationClaim)
+
+	token, err = apiutils.CreateJWT(user.ID, time.Minute)
+	assert.NoError(t, err)
+
+	claims = jwt.MapClaims{}
+	_, _, err = new(jwt.Parser).ParseUnverified(token, claims)
+	assert.NoError(t, err)
+	assert.Equal(t, apiutils.DefaultJWTIssuer, claims["iss"])
+	assert.NotContains(t, claims, "aud")
+}
+
+func TestTokenScopeMatches(t *testing.T) {
+	service := apiutils.NewTokenScope("", "")
+	billing := apiutils.NewTokenScope("https://auth.acme.test", "billing-app")
+
+	assert.True(t, service.Matches(apiutils.TokenScope{}))
+	assert.True(t, apiutils.TokenScope{Issuer: apiutils.DefaultJWTIssuer}.Matches(service))
+	assert.True(t, billing.Matches(billing))
+
+	// Scoped tokens are only accepted for their own audience, and unscoped
+	// ones aren't accepted where a scope is required.
+	assert.False(t, apiutils.NewTokenScope("https://auth.acme.test", "search-app").Matches(billing))
+	assert.False(t, apiutils.NewTokenScope("https://auth.other.test", "billing-app").Matches(billing))
+	assert.False(t, service.Matches(billing))
+	assert.False(t, billing.Matches(service))
+}
@@ -0,0 +1,56 @@
+package apiutils
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+
+	"github.com/Roll-Play/togglelabs/pkg/config"
+)
+
+var ErrPasswordMissingDigit = errors.New("password must contain at least one digit")
+var ErrPasswordMissingSymbol = errors.New("password must contain at least one symbol")
+var ErrPasswordMissingMixedCase = errors.New("password must contain both upper and lower case letters")
+
+type PasswordTooShortError struct {
+	MinLength int
+}
+
+func (e *PasswordTooShortError) Error() string {
+	return fmt.Sprintf("password must be at least %d characters long", e.MinLength)
+}
+
+// ValidatePassword returns an error describing the first requirement of policy that password fails.
+func ValidatePassword(password string, policy config.PasswordPolicy) error {
+	if len([]rune(password)) < policy.MinLength {
+		return &PasswordTooShortError{MinLength: policy.MinLength}
+	}
+
+	var hasDigit, hasSymbol, hasUpper, hasLower bool
+	for _, char := range password {
+		switch {
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireDigit && !hasDigit {
+		return ErrPasswordMissingDigit
+	}
+
+	if policy.RequireSymbol && !hasSymbol {
+		return ErrPasswordMissingSymbol
+	}
+
+	if policy.RequireMixedCase && (!hasUpper || !hasLower) {
+		return ErrPasswordMissingMixedCase
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package apiutils_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/config"
+	apiutils "github.com/Roll-Play/togglelabs/pkg/utils/api_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePasswordMinLength(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 10}
+
+	err := apiutils.ValidatePassword("short", policy)
+	assert.EqualError(t, err, "password must be at least 10 characters long")
+	assert.NoError(t, apiutils.ValidatePassword("long enough", policy))
+}
+
+func TestValidatePasswordRequireDigit(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 8, RequireDigit: true}
+
+	assert.ErrorIs(t, apiutils.ValidatePassword("no digits here", policy), apiutils.ErrPasswordMissingDigit)
+	assert.NoError(t, apiutils.ValidatePassword("digits 4 here", policy))
+}
+
+func TestValidatePasswordRequireSymbol(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 8, RequireSymbol: true}
+
+	assert.ErrorIs(t, apiutils.ValidatePassword("nosymbols", policy), apiutils.ErrPasswordMissingSymbol)
+	assert.NoError(t, apiutils.ValidatePassword("symbols!", policy))
+	assert.NoError(t, apiutils.ValidatePassword("symbols$", policy))
+}
+
+func TestValidatePasswordRequireMixedCase(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 8, RequireMixedCase: true}
+
+	assert.ErrorIs(t, apiutils.ValidatePassword("lowercase", policy), apiutils.ErrPasswordMissingMixedCase)
+	assert.ErrorIs(t, apiutils.ValidatePassword("UPPERCASE", policy), apiutils.ErrPasswordMissingMixedCase)
+	assert.NoError(t, apiutils.ValidatePassword("MixedCase", policy))
+}
+
+func TestValidatePasswordReportsFirstFailingRule(t *testing.T) {
+	policy := config.PasswordPolicy{
+		MinLength:        8,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		RequireMixedCase: true,
+	}
+
+	assert.ErrorIs(t, apiutils.ValidatePassword("password", policy), apiutils.ErrPasswordMissingDigit)
+	assert.ErrorIs(t, apiutils.ValidatePassword("password1", policy), apiutils.ErrPasswordMissingSymbol)
+	assert.ErrorIs(t, apiutils.ValidatePassword("password1!", policy), apiutils.ErrPasswordMissingMixedCase)
+	assert.NoError(t, apiutils.ValidatePassword("Password1!", policy))
+}
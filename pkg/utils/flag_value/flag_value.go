@@ -0,0 +1,155 @@
+// Package flagvalue turns the strings flag values are stored as into the
+// typed values a flag of each type serves.
+package flagvalue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+)
+
+var (
+	ErrNotBool   = errors.New("value is not a boolean")
+	ErrNotNumber = errors.New("value is not a number")
+	ErrNotJSON   = errors.New("value is not valid JSON")
+)
+
+// CoerceBool accepts what strconv.ParseBool does: 1, t, true, 0, f, false
+// and their capitalized forms.
+func CoerceBool(value string) (bool, error) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %q", ErrNotBool, value)
+	}
+
+	return parsed, nil
+}
+
+func CoerceNumber(value string) (float64, error) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrNotNumber, value)
+	}
+
+	return parsed, nil
+}
+
+// CoerceJSON decodes value into the maps, slices, strings, float64s, bools
+// and nils encoding/json produces.
+func CoerceJSON(value string) (any, error) {
+	var decoded any
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotJSON, err.Error())
+	}
+
+	return decoded, nil
+}
+
+// Coerce returns value as the type a flag of flagType serves. String flags,
+// and flags of unknown types, serve the string itself.
+func Coerce(flagType models.FlagType, value string) (any, error) {
+	switch flagType {
+	case models.Boolean:
+		return CoerceBool(value)
+	case models.Number:
+		return CoerceNumber(value)
+	case models.JSON:
+		return CoerceJSON(value)
+	}
+
+	return value, nil
+}
+
+// Valid reports whether value can be served by a flag of flagType.
+func Valid(flagType models.FlagType, value string) bool {
+	_, err := Coerce(flagType, value)
+	return err == nil
+}
+
+var (
+	ErrNotAllowed         = errors.New("value is not one of the allowed values")
+	ErrOutOfRange         = errors.New("value is out of the allowed range")
+	ErrInvalidConstraints = errors.New("invalid value constraints")
+)
+
+// Check reports why value can't be written to a flag of flagType limited by
+// constraints, which may be nil. It is stricter than Coerce: boolean flags
+// only take "true" and "false", so a typo like "True " is caught on write
+// instead of relying on coercion when the flag is served.
+func Check(flagType models.FlagType, constraints *models.ValueConstraints, value string) error {
+	if flagType == models.Boolean && value != "true" && value != "false" {
+		return fmt.Errorf("%w: %q", ErrNotBool, value)
+	}
+
+	coerced, err := Coerce(flagType, value)
+	if err != nil {
+		return err
+	}
+	if constraints == nil {
+		return nil
+	}
+
+	if flagType != models.String && flagType != models.Number {
+		return nil
+	}
+
+	if len(constraints.Enum) > 0 && !allowed(flagType, constraints.Enum, coerced) {
+		return fmt.Errorf("%w: %q", ErrNotAllowed, value)
+	}
+
+	if number, ok := coerced.(float64); ok {
+		if constraints.Min != nil && number < *constraints.Min {
+			return fmt.Errorf("%w: %q is less than %g", ErrOutOfRange, value, *constraints.Min)
+		}
+		if constraints.Max != nil && number > *constraints.Max {
+			return fmt.Errorf("%w: %q is more than %g", ErrOutOfRange, value, *constraints.Max)
+		}
+	}
+
+	return nil
+}
+
+// allowed compares coerced values, so "10" and "10.0" are the same number.
+func allowed(flagType models.FlagType, enum []string, coerced any) bool {
+	for _, candidate := range enum {
+		if parsed, err := Coerce(flagType, candidate); err == nil && parsed == coerced {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckConstraints reports whether constraints make sense for a flag of
+// flagType: an enum only limits string and number flags, a range only number
+// flags, and every enum value has to be a value of the flag's type.
+func CheckConstraints(flagType models.FlagType, constraints *models.ValueConstraints) error {
+	if constraints == nil {
+		return nil
+	}
+
+	if len(constraints.Enum) > 0 {
+		if flagType != models.String && flagType != models.Number {
+			return fmt.Errorf("%w: %s flags can't have an enum", ErrInvalidConstraints, flagType)
+		}
+		for _, value := range constraints.Enum {
+			if _, err := Coerce(flagType, value); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidConstraints, err.Error())
+			}
+		}
+	}
+
+	if constraints.Min != nil || constraints.Max != nil {
+		if flagType != models.Number {
+			return fmt.Errorf("%w: %s flags can't have a range", ErrInvalidConstraints, flagType)
+		}
+		if constraints.Min != nil && constraints.Max != nil && *constraints.Min > *constraints.Max {
+			return fmt.Errorf("%w: min is more than max", ErrInvalidConstraints)
+		}
+	}
+
+	return nil
+}
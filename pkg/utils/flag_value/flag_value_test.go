@@ -0,0 +1,135 @@
+package flagvalue_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	flagvalue "github.com/Roll-Play/togglelabs/pkg/utils/flag_value"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerceBool(t *testing.T) {
+	for value, expected := range map[string]bool{"true": true, "1": true, "FALSE": false, "f": false} {
+		coerced, err := flagvalue.CoerceBool(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, coerced, value)
+	}
+
+	_, err := flagvalue.CoerceBool("yes")
+	assert.ErrorIs(t, err, flagvalue.ErrNotBool)
+	assert.EqualError(t, err, `value is not a boolean: "yes"`)
+}
+
+func TestCoerceNumber(t *testing.T) {
+	coerced, err := flagvalue.CoerceNumber("-2.5e3")
+	assert.NoError(t, err)
+	assert.Equal(t, -2500.0, coerced)
+
+	_, err = flagvalue.CoerceNumber("ten")
+	assert.ErrorIs(t, err, flagvalue.ErrNotNumber)
+}
+
+func TestCoerceJSON(t *testing.T) {
+	coerced, err := flagvalue.CoerceJSON(`{"limit":3,"tiers":["pro"]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"limit": 3.0, "tiers": []any{"pro"}}, coerced)
+
+	_, err = flagvalue.CoerceJSON(`{"limit":`)
+	assert.ErrorIs(t, err, flagvalue.ErrNotJSON)
+}
+
+func TestCoerceByFlagType(t *testing.T) {
+	testCases := []struct {
+		flagType models.FlagType
+		value    string
+		expected any
+		err      error
+	}{
+		{models.Boolean, "true", true, nil},
+		{models.Boolean, "on", nil, flagvalue.ErrNotBool},
+		{models.Number, "42", 42.0, nil},
+		{models.Number, "", nil, flagvalue.ErrNotNumber},
+		{models.JSON, "[1]", []any{1.0}, nil},
+		{models.JSON, "nope", nil, flagvalue.ErrNotJSON},
+		{models.String, "anything goes", "anything goes", nil},
+	}
+	for _, testCase := range testCases {
+		coerced, err := flagvalue.Coerce(testCase.flagType, testCase.value)
+		if testCase.err != nil {
+			assert.ErrorIs(t, err, testCase.err, testCase.value)
+			assert.False(t, flagvalue.Valid(testCase.flagType, testCase.value))
+			continue
+		}
+
+		assert.NoError(t, err, testCase.value)
+		assert.Equal(t, testCase.expected, coerced, testCase.value)
+		assert.True(t, flagvalue.Valid(testCase.flagType, testCase.value))
+	}
+}
+
+func TestCheckOnlyTakesTrueAndFalseForBooleans(t *testing.T) {
+	assert.NoError(t, flagvalue.Check(models.Boolean, nil, "true"))
+	assert.NoError(t, flagvalue.Check(models.Boolean, nil, "false"))
+
+	for _, value := range []string{"1", "t", "TRUE", "True", " false", ""} {
+		assert.ErrorIs(t, flagvalue.Check(models.Boolean, nil, value), flagvalue.ErrNotBool, value)
+	}
+}
+
+func TestCheckEnum(t *testing.T) {
+	plans := &models.ValueConstraints{Enum: []string{"free", "pro"}}
+	assert.NoError(t, flagvalue.Check(models.String, plans, "pro"))
+	assert.ErrorIs(t, flagvalue.Check(models.String, plans, "team"), flagvalue.ErrNotAllowed)
+
+	// Numbers are compared by value, not by how they are written
+	sizes := &models.ValueConstraints{Enum: []string{"10", "20"}}
+	assert.NoError(t, flagvalue.Check(models.Number, sizes, "10.0"))
+	assert.ErrorIs(t, flagvalue.Check(models.Number, sizes, "15"), flagvalue.ErrNotAllowed)
+	assert.ErrorIs(t, flagvalue.Check(models.Number, sizes, "ten"), flagvalue.ErrNotNumber)
+}
+
+func TestCheckRange(t *testing.T) {
+	lower, upper := 1.0, 100.0
+	constraints := &models.ValueConstraints{Min: &lower, Max: &upper}
+
+	for _, value := range []string{"1", "50.5", "100"} {
+		assert.NoError(t, flagvalue.Check(models.Number, constraints, value), value)
+	}
+
+	err := flagvalue.Check(models.Number, constraints, "9999")
+	assert.ErrorIs(t, err, flagvalue.ErrOutOfRange)
+	assert.EqualError(t, err, `value is out of the allowed range: "9999" is more than 100`)
+	assert.ErrorIs(t, flagvalue.Check(models.Number, constraints, "0.5"), flagvalue.ErrOutOfRange)
+
+	// A range with a single bound only limits that side
+	constraints = &models.ValueConstraints{Max: &upper}
+	assert.NoError(t, flagvalue.Check(models.Number, constraints, "-9999"))
+}
+
+func TestCheckConstraints(t *testing.T) {
+	lower, upper := 10.0, 1.0
+	testCases := []struct {
+		flagType    models.FlagType
+		constraints *models.ValueConstraints
+		valid       bool
+	}{
+		{models.Boolean, nil, true},
+		{models.String, &models.ValueConstraints{Enum: []string{"free", "pro"}}, true},
+		{models.Number, &models.ValueConstraints{Enum: []string{"1", "2"}, Max: &lower}, true},
+		{models.Number, &models.ValueConstraints{Min: &upper, Max: &lower}, true},
+		{models.Number, &models.ValueConstraints{Min: &lower, Max: &upper}, false},
+		{models.Number, &models.ValueConstraints{Enum: []string{"one"}}, false},
+		{models.String, &models.ValueConstraints{Max: &upper}, false},
+		{models.Boolean, &models.ValueConstraints{Enum: []string{"true"}}, false},
+		{models.JSON, &models.ValueConstraints{Enum: []string{"{}"}}, false},
+	}
+	for index, testCase := range testCases {
+		err := flagvalue.CheckConstraints(testCase.flagType, testCase.constraints)
+		if testCase.valid {
+			assert.NoError(t, err, index)
+			continue
+		}
+
+		assert.ErrorIs(t, err, flagvalue.ErrInvalidConstraints, index)
+	}
+}
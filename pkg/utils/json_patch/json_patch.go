@@ -0,0 +1,418 @@
+// Package jsonpatch applies RFC 6902 JSON Patch documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+var ErrInvalidOperation = errors.New("invalid json patch operation")
+var ErrInvalidPointer = errors.New("invalid json pointer")
+var ErrPathNotFound = errors.New("json patch path not found")
+var ErrTestFailed = errors.New("json patch test failed")
+
+// Operation is a single entry of a patch. Value is kept raw so that an
+// explicit null can be told apart from a missing value.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type Patch []Operation
+
+// Decode parses a patch document. An operation naming a member twice is
+// rejected instead of keeping the last one, as RFC 6902 appendix A.13 asks.
+func Decode(body []byte) (Patch, error) {
+	var operations []json.RawMessage
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, err
+	}
+
+	patch := make(Patch, len(operations))
+	for index, raw := range operations {
+		if err := uniqueMembers(raw); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", index, err)
+		}
+		if err := json.Unmarshal(raw, &patch[index]); err != nil {
+			return nil, err
+		}
+	}
+
+	return patch, nil
+}
+
+func uniqueMembers(raw []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("%w: not an object", ErrInvalidOperation)
+	}
+
+	seen := make(map[string]bool)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := token.(string)
+		if seen[name] {
+			return fmt.Errorf("%w: repeated member %q", ErrInvalidOperation, name)
+		}
+		seen[name] = true
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Apply runs every operation against document in order and returns the
+// patched document. Nothing is returned if any operation fails.
+func (p Patch) Apply(document []byte) ([]byte, error) {
+	root, err := decodeValue(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, operation := range p {
+		root, err = operation.apply(root)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", index, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func (o Operation) apply(root interface{}) (interface{}, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case OpAdd:
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case OpRemove:
+		root, _, err := remove(root, path)
+		return root, err
+	case OpReplace:
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		return replace(root, path, value)
+	case OpMove:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if o.Path != o.From && strings.HasPrefix(o.Path, o.From+"/") {
+			return nil, fmt.Errorf("%w: can't move a value into itself", ErrInvalidOperation)
+		}
+		root, value, err := remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case OpCopy:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		value, err = deepCopy(value)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case OpTest:
+		expected, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(expected, actual) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidOperation, o.Op)
+}
+
+func (o Operation) value() (interface{}, error) {
+	if o.Value == nil {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidOperation)
+	}
+
+	return decodeValue(o.Value)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPointer, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch typed := node.(type) {
+		case map[string]interface{}:
+			child, ok := typed[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = typed[index]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+
+	return node, nil
+}
+
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch typed := parent.(type) {
+		case map[string]interface{}:
+			typed[token] = value
+			return typed, nil
+		case []interface{}:
+			if token == "-" {
+				return append(typed, value), nil
+			}
+			index, err := arrayIndex(token, len(typed))
+			if err != nil {
+				return nil, err
+			}
+			typed = append(typed, nil)
+			copy(typed[index+1:], typed[index:])
+			typed[index] = value
+			return typed, nil
+		}
+		return nil, ErrPathNotFound
+	})
+}
+
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: can't remove the whole document", ErrInvalidOperation)
+	}
+
+	var removed interface{}
+	root, err := update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch typed := parent.(type) {
+		case map[string]interface{}:
+			value, ok := typed[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			removed = value
+			delete(typed, token)
+			return typed, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = typed[index]
+			return append(typed[:index], typed[index+1:]...), nil
+		}
+		return nil, ErrPathNotFound
+	})
+
+	return root, removed, err
+}
+
+func replace(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch typed := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := typed[token]; !ok {
+				return nil, ErrPathNotFound
+			}
+			typed[token] = value
+			return typed, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			typed[index] = value
+			return typed, nil
+		}
+		return nil, ErrPathNotFound
+	})
+}
+
+// update walks down to the parent of the last token and hands it to leaf,
+// storing whatever leaf returns back into the tree. Slices may be
+// reallocated by leaf, which is why every level is re-assigned.
+func update(
+	node interface{},
+	path []string,
+	leaf func(parent interface{}, token string) (interface{}, error),
+) (interface{}, error) {
+	if len(path) == 1 {
+		return leaf(node, path[0])
+	}
+
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		child, ok := typed[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		updated, err := update(child, path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		typed[path[0]] = updated
+		return typed, nil
+	case []interface{}:
+		index, err := arrayIndex(path[0], len(typed)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(typed[index], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		typed[index] = updated
+		return typed, nil
+	}
+
+	return nil, ErrPathNotFound
+}
+
+// arrayIndex parses an array index token, which can't have leading zeros, up to max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPointer, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPointer, token)
+	}
+	if index > max {
+		return 0, ErrPathNotFound
+	}
+
+	return index, nil
+}
+
+func decodeValue(raw []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// equal compares two decoded values the way RFC 6902 section 4.6 does:
+// numbers by value, objects regardless of member order.
+func equal(a, b interface{}) bool {
+	switch typed := a.(type) {
+	case json.Number:
+		other, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okX := new(big.Rat).SetString(typed.String())
+		y, okY := new(big.Rat).SetString(other.String())
+		return okX && okY && x.Cmp(y) == 0
+	case map[string]interface{}:
+		other, ok := b.(map[string]interface{})
+		if !ok || len(typed) != len(other) {
+			return false
+		}
+		for key, value := range typed {
+			otherValue, ok := other[key]
+			if !ok || !equal(value, otherValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		other, ok := b.([]interface{})
+		if !ok || len(typed) != len(other) {
+			return false
+		}
+		for index := range typed {
+			if !equal(typed[index], other[index]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func deepCopy(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeValue(raw)
+}
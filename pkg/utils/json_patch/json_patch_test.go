@@ -0,0 +1,368 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	// Mostly taken from RFC 6902 appendix A.
+	testCases := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+	}{
+		{
+			"add object member",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			"add array element",
+			`{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			"append array element",
+			`{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": {"a": 1}}]`,
+			`{"foo": ["bar", {"a": 1}]}`,
+		},
+		{
+			"remove object member",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`,
+		},
+		{
+			"remove array element",
+			`{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`,
+		},
+		{
+			"replace value",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			"move value",
+			`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			"move array element",
+			`{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			"copy value",
+			`{"foo": {"bar": [1]}}`,
+			`[{"op": "copy", "from": "/foo/bar", "path": "/baz"}, {"op": "add", "path": "/baz/-", "value": 2}]`,
+			`{"foo": {"bar": [1]}, "baz": [1, 2]}`,
+		},
+		{
+			"test passes",
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			"escaped pointer",
+			`{"a/b": 1, "m~n": 2}`,
+			`[{"op": "replace", "path": "/a~1b", "value": 3}, {"op": "remove", "path": "/m~0n"}]`,
+			`{"a/b": 3}`,
+		},
+		{
+			"add null value",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": null}]`,
+			`{"baz": null, "foo": "bar"}`,
+		},
+		{
+			"replace whole document",
+			`{"foo": "bar"}`,
+			`[{"op": "replace", "path": "", "value": {"baz": "qux"}}]`,
+			`{"baz": "qux"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tc.patch))
+			assert.NoError(t, err)
+
+			patched, err := patch.Apply([]byte(tc.document))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(patched))
+		})
+	}
+}
+
+// TestRFC6902Conformance runs every example of RFC 6902 appendix A verbatim.
+// Examples the RFC calls errors have a nil expected document.
+func TestRFC6902Conformance(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+		err      error
+	}{
+		{
+			"A.1 adding an object member",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`,
+			nil,
+		},
+		{
+			"A.2 adding an array element",
+			`{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`,
+			nil,
+		},
+		{
+			"A.3 removing an object member",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`,
+			nil,
+		},
+		{
+			"A.4 removing an array element",
+			`{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`,
+			nil,
+		},
+		{
+			"A.5 replacing a value",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`,
+			nil,
+		},
+		{
+			"A.6 moving a value",
+			`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+			nil,
+		},
+		{
+			"A.7 moving an array element",
+			`{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`,
+			nil,
+		},
+		{
+			"A.8 testing a value: success",
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[
+				{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}
+			]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			nil,
+		},
+		{
+			"A.9 testing a value: error",
+			`{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			"",
+			ErrTestFailed,
+		},
+		{
+			"A.10 adding a nested member object",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`,
+			nil,
+		},
+		{
+			"A.11 ignoring unrecognized elements",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`,
+			nil,
+		},
+		{
+			"A.12 adding to a nonexistent target",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			"",
+			ErrPathNotFound,
+		},
+		{
+			"A.13 invalid json patch document",
+			`{"baz": "qux"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "op": "remove"}]`,
+			"",
+			ErrInvalidOperation,
+		},
+		{
+			"A.14 ~ escape ordering",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`,
+			nil,
+		},
+		{
+			"A.15 comparing strings and numbers",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": "10"}]`,
+			"",
+			ErrTestFailed,
+		},
+		{
+			"A.16 adding an array value",
+			`{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tc.patch))
+			var patched []byte
+			if err == nil {
+				patched, err = patch.Apply([]byte(tc.document))
+			}
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, patched)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(patched))
+		})
+	}
+}
+
+func TestTestComparesNumbersByValue(t *testing.T) {
+	document := []byte(`{"foo": 100, "bar": {"a": [1.0, "1"]}}`)
+
+	for _, value := range []string{`100`, `100.0`, `1e2`} {
+		patch, err := Decode([]byte(`[{"op": "test", "path": "/foo", "value": ` + value + `}]`))
+		assert.NoError(t, err)
+		_, err = patch.Apply(document)
+		assert.NoError(t, err, value)
+	}
+
+	patch, err := Decode([]byte(`[{"op": "test", "path": "/bar", "value": {"a": [1, "1"]}}]`))
+	assert.NoError(t, err)
+	_, err = patch.Apply(document)
+	assert.NoError(t, err)
+
+	patch, err = Decode([]byte(`[{"op": "test", "path": "/bar", "value": {"a": [1, 1]}}]`))
+	assert.NoError(t, err)
+	_, err = patch.Apply(document)
+	assert.ErrorIs(t, err, ErrTestFailed)
+}
+
+func TestApplyErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		patch    string
+		expected error
+	}{
+		{
+			"unknown op",
+			`{}`,
+			`[{"op": "merge", "path": "/foo"}]`,
+			ErrInvalidOperation,
+		},
+		{
+			"missing value",
+			`{}`,
+			`[{"op": "add", "path": "/foo"}]`,
+			ErrInvalidOperation,
+		},
+		{
+			"missing parent",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			ErrPathNotFound,
+		},
+		{
+			"remove missing member",
+			`{"foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			ErrPathNotFound,
+		},
+		{
+			"replace missing member",
+			`{"foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": 1}]`,
+			ErrPathNotFound,
+		},
+		{
+			"array index out of bounds",
+			`{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/2", "value": "qux"}]`,
+			ErrPathNotFound,
+		},
+		{
+			"array index with leading zero",
+			`{"foo": ["bar", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/01"}]`,
+			ErrInvalidPointer,
+		},
+		{
+			"pointer without leading slash",
+			`{"foo": "bar"}`,
+			`[{"op": "remove", "path": "foo"}]`,
+			ErrInvalidPointer,
+		},
+		{
+			"move into own child",
+			`{"foo": {"bar": 1}}`,
+			`[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`,
+			ErrInvalidOperation,
+		},
+		{
+			"test fails",
+			`{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			ErrTestFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tc.patch))
+			assert.NoError(t, err)
+
+			patched, err := patch.Apply([]byte(tc.document))
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Nil(t, patched)
+		})
+	}
+}
+
+func TestApplyIsAllOrNothing(t *testing.T) {
+	document := []byte(`{"foo": "bar"}`)
+	patch, err := Decode([]byte(`[
+		{"op": "replace", "path": "/foo", "value": "baz"},
+		{"op": "remove", "path": "/missing"}
+	]`))
+	assert.NoError(t, err)
+
+	_, err = patch.Apply(document)
+	assert.ErrorIs(t, err, ErrPathNotFound)
+	assert.JSONEq(t, `{"foo": "bar"}`, string(document))
+}
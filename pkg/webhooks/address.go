@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for webhook URLs reaching into the
+// network the service runs in, which anyone able to register a webhook
+// could otherwise probe.
+var ErrForbiddenAddress = errors.New("webhook address is loopback, private or link-local")
+
+// ValidateURL rejects URLs that aren't http(s), or whose host is a
+// forbidden address or a name for the local host. Other names are only
+// resolved when delivering, see NewClient, as they can point elsewhere by
+// then.
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ErrForbiddenAddress
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && forbiddenIP(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// NewClient returns the client deliveries should go through. It refuses to
+// connect to forbidden addresses once names are resolved, so a webhook
+// whose name later resolves to one, or that redirects to one, can't reach
+// it either. It ignores proxy settings, since only the addresses it dials
+// itself are checked.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: DeliveryTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+				return ErrForbiddenAddress
+			}
+
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport}
+}
+
+func forbiddenIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified()
+}
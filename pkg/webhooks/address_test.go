@@ -0,0 +1,54 @@
+package webhooks_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		url   string
+		valid bool
+	}{
+		{url: "https://hooks.example.com/togglelabs", valid: true},
+		{url: "http://203.0.113.10:8080/hook", valid: true},
+		{url: "ftp://hooks.example.com/togglelabs"},
+		{url: "http://localhost:8080/hook"},
+		{url: "http://api.localhost./hook"},
+		{url: "http://127.0.0.1/hook"},
+		{url: "http://[::1]/hook"},
+		{url: "http://10.0.0.5/hook"},
+		{url: "http://192.168.1.1/hook"},
+		{url: "http://169.254.169.254/latest/meta-data"},
+		{url: "http://[fe80::1]/hook"},
+		{url: "http://0.0.0.0/hook"},
+	}
+
+	for _, testCase := range testCases {
+		err := webhooks.ValidateURL(testCase.url)
+		if testCase.valid {
+			assert.NoError(t, err, testCase.url)
+		} else {
+			assert.ErrorIs(t, err, webhooks.ErrForbiddenAddress, testCase.url)
+		}
+	}
+}
+
+func TestNewClientRefusesForbiddenAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+	assert.NoError(t, err)
+
+	// The test server listens on loopback, as an internal service would.
+	_, err = webhooks.NewClient().Do(request)
+	assert.ErrorIs(t, err, webhooks.ErrForbiddenAddress)
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// EventHeader names the event of a delivery, so receivers can route it
+// before parsing the body.
+const EventHeader = "X-Togglelabs-Event"
+
+// QueuedDeliveries is how many events can wait to be delivered. Once full,
+// new events are dropped instead of piling up in memory.
+const QueuedDeliveries = 1000
+
+// DeliveryTimeout bounds each request to a webhook URL.
+const DeliveryTimeout = 10 * time.Second
+
+// DeliveryWorkers is how many requests to webhook URLs can be in flight at
+// once, so a slow receiver holds up one of them instead of every delivery.
+const DeliveryWorkers = 10
+
+// WebhookStore finds the webhooks registered by an organization.
+type WebhookStore interface {
+	FindAllByOrganization(ctx context.Context, organizationID primitive.ObjectID) ([]models.WebhookRecord, error)
+}
+
+type delivery struct {
+	organizationID primitive.ObjectID
+	event          string
+	body           []byte
+}
+
+// Dispatcher is a Publisher that POSTs every event to the webhooks of its
+// organization that subscribed to it, signed with each webhook's secret.
+// Events are delivered while Run is running, each webhook on its own by up
+// to DeliveryWorkers at a time; failed deliveries are logged and not
+// retried. Production dispatchers should use NewClient.
+type Dispatcher struct {
+	store      WebhookStore
+	client     *http.Client
+	logger     *zap.Logger
+	deliveries chan delivery
+	workers    chan struct{}
+}
+
+func NewDispatcher(store WebhookStore, client *http.Client, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:      store,
+		client:     client,
+		logger:     logger,
+		deliveries: make(chan delivery, QueuedDeliveries),
+		workers:    make(chan struct{}, DeliveryWorkers),
+	}
+}
+
+func (d *Dispatcher) Publish(payload FeatureFlagPayload) {
+	d.enqueue(payload.OrganizationID, payload.Event, payload)
+}
+
+func (d *Dispatcher) PublishOrganizationEvent(payload OrganizationPayload) {
+	if !Subscribable(payload.Event) {
+		return
+	}
+	d.enqueue(payload.OrganizationID, payload.Event, payload)
+}
+
+func (d *Dispatcher) enqueue(organizationID primitive.ObjectID, event string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		d.logger.Error("Webhook error",
+			zap.String("cause", err.Error()),
+		)
+		return
+	}
+
+	select {
+	case d.deliveries <- delivery{organizationID: organizationID, event: event, body: body}:
+	default:
+		d.logger.Warn("Webhook event dropped",
+			zap.String("event", event),
+		)
+	}
+}
+
+// Run delivers queued events until ctx is done, then waits for the
+// deliveries in flight.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.deliveries:
+			d.deliver(ctx, event, &inFlight)
+		}
+	}
+}
+
+// deliver starts a delivery of event to each webhook wanting it, waiting for
+// a free worker before each.
+func (d *Dispatcher) deliver(ctx context.Context, event delivery, inFlight *sync.WaitGroup) {
+	webhooks, err := d.store.FindAllByOrganization(ctx, event.organizationID)
+	if err != nil {
+		d.logger.Error("Webhook error",
+			zap.String("cause", err.Error()),
+			zap.String("event", event.event),
+		)
+		return
+	}
+
+	for index := range webhooks {
+		if !webhooks[index].Wants(event.event) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case d.workers <- struct{}{}:
+		}
+
+		inFlight.Add(1)
+		go func(webhook *models.WebhookRecord) {
+			defer inFlight.Done()
+			defer func() { <-d.workers }()
+
+			if err := d.post(ctx, webhook, event); err != nil {
+				d.logger.Error("Webhook error",
+					zap.String("cause", err.Error()),
+					zap.String("event", event.event),
+					zap.String("webhook_id", webhook.ID.Hex()),
+				)
+			}
+		}(&webhooks[index])
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, webhook *models.WebhookRecord, event delivery) error {
+	postCtx, cancel := context.WithTimeout(ctx, DeliveryTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(postCtx, http.MethodPost, webhook.URL, bytes.NewReader(event.body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, event.event)
+	request.Header.Set(SignatureHeader, Sign([]byte(webhook.Secret), event.body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type memoryWebhookStore map[primitive.ObjectID][]models.WebhookRecord
+
+func (mws memoryWebhookStore) FindAllByOrganization(
+	_ context.Context,
+	organizationID primitive.ObjectID,
+) ([]models.WebhookRecord, error) {
+	return mws[organizationID], nil
+}
+
+type receivedDelivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestDispatcherDeliversSubscribedEvents(t *testing.T) {
+	received := make(chan receivedDelivery, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedDelivery{
+			event:     r.Header.Get(webhooks.EventHeader),
+			signature: r.Header.Get(webhooks.SignatureHeader),
+			body:      body,
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	organizationID := primitive.NewObjectID()
+	members, err := models.NewWebhookRecord(organizationID, server.URL, []string{webhooks.MemberAddedEvent})
+	assert.NoError(t, err)
+	store := memoryWebhookStore{organizationID: {*members}}
+
+	dispatcher := webhooks.NewDispatcher(store, server.Client(), zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	userID := primitive.NewObjectID()
+	// Neither of these is delivered: the webhook isn't subscribed to the
+	// first, and the second belongs to another organization.
+	dispatcher.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationID, userID, userID, models.Collaborator, "", time.Now(),
+	))
+	dispatcher.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		primitive.NewObjectID(), userID, userID, "", models.Collaborator, time.Now(),
+	))
+	dispatcher.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationID, userID, userID, "", models.Collaborator, time.Now(),
+	))
+
+	select {
+	case delivery := <-received:
+		assert.Equal(t, webhooks.MemberAddedEvent, delivery.event)
+		valid, err := webhooks.Verify([]byte(members.Secret), delivery.body, delivery.signature)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+
+		var payload webhooks.OrganizationPayload
+		assert.NoError(t, json.Unmarshal(delivery.body, &payload))
+		assert.Equal(t, organizationID, payload.OrganizationID)
+		assert.Equal(t, userID, payload.UserID)
+		assert.Equal(t, models.Collaborator, payload.PermissionLevel)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	select {
+	case delivery := <-received:
+		t.Fatalf("unexpected delivery of %s", delivery.event)
+	default:
+	}
+}
+
+func TestDispatcherDeliversToEachWebhookOnItsOwn(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	received := make(chan string, 10)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(webhooks.EventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer fast.Close()
+
+	organizationID := primitive.NewObjectID()
+	stuck, err := models.NewWebhookRecord(organizationID, slow.URL, nil)
+	assert.NoError(t, err)
+	everything, err := models.NewWebhookRecord(organizationID, fast.URL, nil)
+	assert.NoError(t, err)
+	store := memoryWebhookStore{organizationID: {*stuck, *everything}}
+
+	dispatcher := webhooks.NewDispatcher(store, http.DefaultClient, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	userID := primitive.NewObjectID()
+	// No webhook can subscribe to it, not even those wanting every event.
+	dispatcher.PublishOrganizationEvent(webhooks.NewOrganizationCreatedPayload(&models.OrganizationRecord{
+		ID: organizationID,
+	}, time.Now()))
+	dispatcher.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationID, userID, userID, "", models.Collaborator, time.Now(),
+	))
+	dispatcher.PublishOrganizationEvent(webhooks.NewMemberPayload(
+		organizationID, userID, userID, models.Collaborator, "", time.Now(),
+	))
+
+	// Both reach the fast webhook while the slow one still holds theirs, in
+	// no particular order.
+	events := make([]string, 0, 2)
+	for len(events) < 2 {
+		select {
+		case event := <-received:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %v were delivered", events)
+		}
+	}
+	assert.ElementsMatch(t, []string{webhooks.MemberAddedEvent, webhooks.MemberRemovedEvent}, events)
+}
@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OrganizationCreatedEvent = "organization.created"
+	MemberAddedEvent         = "member.added"
+	MemberRemovedEvent       = "member.removed"
+	MemberRoleChangedEvent   = "member.role_changed"
+)
+
+// Events lists every event webhooks can subscribe to. organization.created
+// isn't one: an organization has no webhooks yet when it is created, so the
+// event only reaches the Kafka and NATS publishers.
+var Events = []string{
+	FeatureFlagUpdatedEvent,
+	MemberAddedEvent,
+	MemberRemovedEvent,
+	MemberRoleChangedEvent,
+}
+
+// Subscribable reports whether event is one of Events.
+func Subscribable(event string) bool {
+	for _, subscribable := range Events {
+		if subscribable == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OrganizationPayload describes a change to an organization or its members,
+// so provisioning systems can mirror them. UserID is the member the event is
+// about and ActorID the user who made the change; both are the creator for
+// organization.created.
+type OrganizationPayload struct {
+	Event          string             `json:"event"`
+	OrganizationID primitive.ObjectID `json:"organization_id"`
+	UserID         primitive.ObjectID `json:"user_id"`
+	ActorID        primitive.ObjectID `json:"actor_id"`
+	// PermissionLevel is the member's level after the change, empty once
+	// removed; PreviousPermissionLevel the one before, empty when added.
+	PermissionLevel         models.PermissionLevelEnum `json:"permission_level,omitempty"`
+	PreviousPermissionLevel models.PermissionLevelEnum `json:"previous_permission_level,omitempty"`
+	OccurredAt              time.Time                  `json:"occurred_at"`
+}
+
+func NewOrganizationCreatedPayload(organization *models.OrganizationRecord, occurredAt time.Time) OrganizationPayload {
+	payload := OrganizationPayload{
+		Event:          OrganizationCreatedEvent,
+		OrganizationID: organization.ID,
+		OccurredAt:     occurredAt.UTC(),
+	}
+	if len(organization.Members) > 0 {
+		creator := organization.Members[0]
+		payload.UserID = creator.User.ID
+		payload.ActorID = creator.User.ID
+		payload.PermissionLevel = creator.PermissionLevel
+	}
+
+	return payload
+}
+
+// NewMemberPayload tells which of the member events changing userID's level
+// from previous to current is: an empty previous level is an addition and an
+// empty current level a removal.
+func NewMemberPayload(
+	organizationID,
+	userID,
+	actorID primitive.ObjectID,
+	previous,
+	current models.PermissionLevelEnum,
+	occurredAt time.Time,
+) OrganizationPayload {
+	event := MemberRoleChangedEvent
+	switch {
+	case previous == "":
+		event = MemberAddedEvent
+	case current == "":
+		event = MemberRemovedEvent
+	}
+
+	return OrganizationPayload{
+		Event:                   event,
+		OrganizationID:          organizationID,
+		UserID:                  userID,
+		ActorID:                 actorID,
+		PermissionLevel:         current,
+		PreviousPermissionLevel: previous,
+		OccurredAt:              occurredAt.UTC(),
+	}
+}
@@ -0,0 +1,64 @@
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewMemberPayloadEvent(t *testing.T) {
+	organizationID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	actorID := primitive.NewObjectID()
+	occurredAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+	testCases := []struct {
+		previous models.PermissionLevelEnum
+		current  models.PermissionLevelEnum
+		event    string
+	}{
+		{"", models.Collaborator, webhooks.MemberAddedEvent},
+		{models.Collaborator, "", webhooks.MemberRemovedEvent},
+		{models.Collaborator, models.Admin, webhooks.MemberRoleChangedEvent},
+	}
+	for _, testCase := range testCases {
+		payload := webhooks.NewMemberPayload(
+			organizationID,
+			userID,
+			actorID,
+			testCase.previous,
+			testCase.current,
+			occurredAt,
+		)
+
+		assert.Equal(t, webhooks.OrganizationPayload{
+			Event:                   testCase.event,
+			OrganizationID:          organizationID,
+			UserID:                  userID,
+			ActorID:                 actorID,
+			PermissionLevel:         testCase.current,
+			PreviousPermissionLevel: testCase.previous,
+			OccurredAt:              occurredAt.UTC(),
+		}, payload)
+	}
+}
+
+func TestNewOrganizationCreatedPayload(t *testing.T) {
+	creator := models.UserRecord{ID: primitive.NewObjectID()}
+	organization := &models.OrganizationRecord{
+		ID:      primitive.NewObjectID(),
+		Members: []models.OrganizationMember{{User: creator, PermissionLevel: models.Admin}},
+	}
+
+	payload := webhooks.NewOrganizationCreatedPayload(organization, time.Now())
+
+	assert.Equal(t, webhooks.OrganizationCreatedEvent, payload.Event)
+	assert.Equal(t, organization.ID, payload.OrganizationID)
+	assert.Equal(t, creator.ID, payload.UserID)
+	assert.Equal(t, creator.ID, payload.ActorID)
+	assert.Equal(t, models.Admin, payload.PermissionLevel)
+}
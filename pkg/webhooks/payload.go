@@ -0,0 +1,88 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeatureFlagUpdatedEvent is sent whenever a flag starts serving a new revision.
+const FeatureFlagUpdatedEvent = "feature_flag.updated"
+
+// MaxRuleChanges caps the rule changes carried by a payload so flags with
+// very large rule sets don't produce unbounded requests.
+const MaxRuleChanges = 100
+
+// FeatureFlagPayload is the body of a flag change webhook. Diff compares the
+// revision that was live before the change with the one live after it.
+type FeatureFlagPayload struct {
+	Event          string              `json:"event"`
+	OrganizationID primitive.ObjectID  `json:"organization_id"`
+	FeatureFlagID  primitive.ObjectID  `json:"feature_flag_id"`
+	Name           string              `json:"name"`
+	Version        int                 `json:"version"`
+	RevisionID     primitive.ObjectID  `json:"revision_id"`
+	Diff           models.RevisionDiff `json:"diff"`
+	// OmittedRuleChanges counts the rule changes left out past MaxRuleChanges.
+	OmittedRuleChanges int       `json:"omitted_rule_changes,omitempty"`
+	OccurredAt         time.Time `json:"occurred_at"`
+}
+
+// NewFeatureFlagUpdatedPayload builds the payload for featureFlag moving from
+// the previous live revision, nil for a new flag, to current.
+func NewFeatureFlagUpdatedPayload(
+	featureFlag *models.FeatureFlagRecord,
+	previous,
+	current *models.Revision,
+	occurredAt time.Time,
+) FeatureFlagPayload {
+	diff := models.DiffRevisions(previous, current)
+
+	omitted := 0
+	if len(diff.Rules) > MaxRuleChanges {
+		omitted = len(diff.Rules) - MaxRuleChanges
+		diff.Rules = diff.Rules[:MaxRuleChanges]
+	}
+
+	return FeatureFlagPayload{
+		Event:              FeatureFlagUpdatedEvent,
+		OrganizationID:     featureFlag.OrganizationID,
+		FeatureFlagID:      featureFlag.ID,
+		Name:               featureFlag.QualifiedName(),
+		Version:            featureFlag.Version,
+		RevisionID:         current.ID,
+		Diff:               diff,
+		OmittedRuleChanges: omitted,
+		OccurredAt:         occurredAt.UTC(),
+	}
+}
+
+// Publisher delivers flag change and organization payloads. Both methods are
+// called while the request is being served, so they must not block on I/O.
+type Publisher interface {
+	Publish(payload FeatureFlagPayload)
+	PublishOrganizationEvent(payload OrganizationPayload)
+}
+
+// NopPublisher drops every payload.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(FeatureFlagPayload) {}
+
+func (NopPublisher) PublishOrganizationEvent(OrganizationPayload) {}
+
+// MultiPublisher publishes every payload to each of its publishers.
+type MultiPublisher []Publisher
+
+func (mp MultiPublisher) Publish(payload FeatureFlagPayload) {
+	for _, publisher := range mp {
+		publisher.Publish(payload)
+	}
+}
+
+func (mp MultiPublisher) PublishOrganizationEvent(payload OrganizationPayload) {
+	for _, publisher := range mp {
+		publisher.PublishOrganizationEvent(payload)
+	}
+}
@@ -0,0 +1,99 @@
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func rule(predicate, value string) models.Rule {
+	return models.Rule{
+		ID:        primitive.NewObjectID(),
+		Predicate: predicate,
+		Value:     value,
+		Env:       "prd",
+		IsEnabled: true,
+	}
+}
+
+func TestFeatureFlagUpdatedPayloadDiff(t *testing.T) {
+	kept := rule("country: br", "true")
+	changed := rule("plan: pro", "true")
+	removed := rule("beta: true", "true")
+	added := rule("plan: free", "false")
+
+	previous := &models.Revision{
+		ID:           primitive.NewObjectID(),
+		Status:       models.Archived,
+		DefaultValue: "false",
+		Rules:        []models.Rule{kept, changed, removed},
+	}
+
+	changedAfter := changed
+	changedAfter.Value = "false"
+	current := &models.Revision{
+		ID:           primitive.NewObjectID(),
+		Status:       models.Live,
+		DefaultValue: "true",
+		Rules:        []models.Rule{kept, changedAfter, added},
+	}
+
+	featureFlag := &models.FeatureFlagRecord{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: primitive.NewObjectID(),
+		Name:           "checkout",
+		Namespace:      "billing",
+		Version:        3,
+	}
+
+	occurredAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload := webhooks.NewFeatureFlagUpdatedPayload(featureFlag, previous, current, occurredAt)
+
+	assert.Equal(t, webhooks.FeatureFlagUpdatedEvent, payload.Event)
+	assert.Equal(t, "billing/checkout", payload.Name)
+	assert.Equal(t, current.ID, payload.RevisionID)
+	assert.Equal(t, occurredAt, payload.OccurredAt)
+	assert.Equal(t, &models.FieldChange{From: "false", To: "true"}, payload.Diff.DefaultValue)
+	assert.Equal(t, &models.FieldChange{From: models.Archived, To: models.Live}, payload.Diff.Status)
+	assert.Equal(t, []models.RuleChange{
+		{RuleID: changed.ID, Type: models.RuleChanged, Before: &changed, After: &changedAfter},
+		{RuleID: added.ID, Type: models.RuleAdded, After: &added},
+		{RuleID: removed.ID, Type: models.RuleRemoved, Before: &removed},
+	}, payload.Diff.Rules)
+	assert.Zero(t, payload.OmittedRuleChanges)
+}
+
+func TestFeatureFlagUpdatedPayloadReportsMovedRules(t *testing.T) {
+	first := rule("country: br", "true")
+	second := rule("plan: pro", "true")
+
+	previous := &models.Revision{Status: models.Live, Rules: []models.Rule{first, second}}
+	current := &models.Revision{Status: models.Live, Rules: []models.Rule{second, first}}
+
+	payload := webhooks.NewFeatureFlagUpdatedPayload(&models.FeatureFlagRecord{}, previous, current, time.Now())
+
+	assert.Nil(t, payload.Diff.DefaultValue)
+	assert.Nil(t, payload.Diff.Status)
+	assert.Equal(t, []models.RuleChange{
+		{RuleID: second.ID, Type: models.RuleMoved},
+		{RuleID: first.ID, Type: models.RuleMoved},
+	}, payload.Diff.Rules)
+}
+
+func TestFeatureFlagUpdatedPayloadBoundsRuleChanges(t *testing.T) {
+	rules := make([]models.Rule, webhooks.MaxRuleChanges+25)
+	for index := range rules {
+		rules[index] = rule("attr: value", "true")
+	}
+	current := &models.Revision{Status: models.Live, DefaultValue: "false", Rules: rules}
+
+	payload := webhooks.NewFeatureFlagUpdatedPayload(&models.FeatureFlagRecord{}, nil, current, time.Now())
+
+	assert.Len(t, payload.Diff.Rules, webhooks.MaxRuleChanges)
+	assert.Equal(t, 25, payload.OmittedRuleChanges)
+	assert.Equal(t, models.RuleAdded, payload.Diff.Rules[0].Type)
+}
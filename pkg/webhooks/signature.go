@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// SignatureHeader carries the signature of an outbound webhook payload.
+const SignatureHeader = "X-Togglelabs-Signature"
+
+const signaturePrefix = "sha256="
+
+var ErrMissingSignature = errors.New("missing webhook signature")
+var ErrMalformedSignature = errors.New("malformed webhook signature")
+
+// Sign returns the signature header value for body: the hex encoded
+// HMAC-SHA256 of the raw payload bytes, prefixed with the algorithm.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature against the raw request body as received, before any decoding.
+// It returns an error only when the signature can't be interpreted at all.
+func Verify(secret, body []byte, signature string) (bool, error) {
+	signature = strings.TrimSpace(signature)
+	if signature == "" {
+		return false, ErrMissingSignature
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false, ErrMalformedSignature
+	}
+
+	received, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false, ErrMalformedSignature
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hmac.Equal(received, mac.Sum(nil)), nil
+}
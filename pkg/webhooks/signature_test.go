@@ -0,0 +1,52 @@
+package webhooks_test
+
+import (
+	"testing"
+
+	"github.com/Roll-Play/togglelabs/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyAcceptsSignedPayload(t *testing.T) {
+	secret := []byte("webhook-secret")
+	body := []byte(`{"event":"feature_flag.updated"}`)
+
+	valid, err := webhooks.Verify(secret, body, webhooks.Sign(secret, body))
+
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifyRejectsTamperedPayload(t *testing.T) {
+	secret := []byte("webhook-secret")
+	signature := webhooks.Sign(secret, []byte(`{"event":"feature_flag.updated"}`))
+
+	valid, err := webhooks.Verify(secret, []byte(`{"event":"feature_flag.deleted"}`), signature)
+
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifyRejectsWrongSecret(t *testing.T) {
+	body := []byte(`{"event":"feature_flag.updated"}`)
+	signature := webhooks.Sign([]byte("webhook-secret"), body)
+
+	valid, err := webhooks.Verify([]byte("another-secret"), body, signature)
+
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifyMalformedSignature(t *testing.T) {
+	secret := []byte("webhook-secret")
+	body := []byte(`{}`)
+
+	_, err := webhooks.Verify(secret, body, "")
+	assert.ErrorIs(t, err, webhooks.ErrMissingSignature)
+
+	_, err = webhooks.Verify(secret, body, "md5=abc")
+	assert.ErrorIs(t, err, webhooks.ErrMalformedSignature)
+
+	_, err = webhooks.Verify(secret, body, "sha256=not-hex")
+	assert.ErrorIs(t, err, webhooks.ErrMalformedSignature)
+}
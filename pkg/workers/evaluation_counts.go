@@ -0,0 +1,148 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const EvaluationCountsWorkerName = "evaluation-counts"
+
+// DefaultEvaluationCountsInterval is how often evaluation counts are saved.
+const DefaultEvaluationCountsInterval = time.Minute
+
+// EvaluationCountsFinalSaveTimeout bounds the save of what's left when the
+// worker stops.
+const EvaluationCountsFinalSaveTimeout = 10 * time.Second
+
+// EvaluationCountsStore adds counts to the evaluation counters of each flag.
+type EvaluationCountsStore interface {
+	SaveEvaluationCounts(ctx context.Context, counts map[primitive.ObjectID]*models.EvaluationCounts) error
+}
+
+// EvaluationCountsWorker is an analytics sink that counts the evaluations of
+// each flag, the ones each rule served and how often a shadow revision
+// disagreed, and saves the counts every interval, so serving evaluations
+// never writes to the flags themselves.
+type EvaluationCountsWorker struct {
+	store     EvaluationCountsStore
+	logger    *zap.Logger
+	interval  time.Duration
+	heartbeat *Heartbeat
+
+	mu      sync.Mutex
+	pending map[primitive.ObjectID]*models.EvaluationCounts
+}
+
+func NewEvaluationCountsWorker(
+	store EvaluationCountsStore,
+	logger *zap.Logger,
+	registry *Registry,
+	interval time.Duration,
+) *EvaluationCountsWorker {
+	return &EvaluationCountsWorker{
+		store:     store,
+		logger:    logger,
+		interval:  interval,
+		heartbeat: registry.Register(EvaluationCountsWorkerName, interval),
+		pending:   make(map[primitive.ObjectID]*models.EvaluationCounts),
+	}
+}
+
+func (ecw *EvaluationCountsWorker) Record(evaluation analytics.Evaluation) {
+	featureFlagID, err := primitive.ObjectIDFromHex(evaluation.FeatureFlagID)
+	if err != nil {
+		return
+	}
+
+	counts := &models.EvaluationCounts{Evaluations: 1}
+	if ruleID, err := primitive.ObjectIDFromHex(evaluation.RuleID); err == nil {
+		counts.RuleMatches = map[primitive.ObjectID]int64{ruleID: 1}
+	}
+	if revisionID, err := primitive.ObjectIDFromHex(evaluation.ShadowRevisionID); err == nil {
+		counts.ShadowRevisionID = revisionID
+		counts.ShadowEvaluations = 1
+		if evaluation.ShadowValue != evaluation.Value {
+			counts.ShadowMismatches = 1
+		}
+	}
+
+	ecw.merge(map[primitive.ObjectID]*models.EvaluationCounts{featureFlagID: counts})
+}
+
+// Run saves evaluation counts every interval until ctx is done, then saves
+// whatever is left.
+func (ecw *EvaluationCountsWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(ecw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			saveCtx, cancel := context.WithTimeout(context.Background(), EvaluationCountsFinalSaveTimeout)
+			defer cancel()
+
+			ecw.save(saveCtx)
+			return
+		case <-ticker.C:
+			ecw.save(ctx)
+			ecw.heartbeat.Beat()
+		}
+	}
+}
+
+// save hands the pending counts to the store. They're added back for the next
+// run when it fails.
+func (ecw *EvaluationCountsWorker) save(ctx context.Context) {
+	ecw.mu.Lock()
+	pending := ecw.pending
+	ecw.pending = make(map[primitive.ObjectID]*models.EvaluationCounts)
+	ecw.mu.Unlock()
+
+	if err := ecw.store.SaveEvaluationCounts(ctx, pending); err != nil {
+		ecw.logger.Error("Worker error",
+			zap.String("worker", EvaluationCountsWorkerName),
+			zap.String("cause", err.Error()),
+		)
+		ecw.merge(pending)
+	}
+}
+
+// merge adds counts to the pending ones. Shadow counts of a revision the flag
+// no longer shadows are dropped for those of its new shadow revision.
+func (ecw *EvaluationCountsWorker) merge(counts map[primitive.ObjectID]*models.EvaluationCounts) {
+	ecw.mu.Lock()
+	defer ecw.mu.Unlock()
+
+	for featureFlagID, flagCounts := range counts {
+		pending, ok := ecw.pending[featureFlagID]
+		if !ok {
+			pending = &models.EvaluationCounts{}
+			ecw.pending[featureFlagID] = pending
+		}
+
+		pending.Evaluations += flagCounts.Evaluations
+		for ruleID, matches := range flagCounts.RuleMatches {
+			if pending.RuleMatches == nil {
+				pending.RuleMatches = make(map[primitive.ObjectID]int64)
+			}
+			pending.RuleMatches[ruleID] += matches
+		}
+
+		if flagCounts.ShadowEvaluations == 0 {
+			continue
+		}
+		if pending.ShadowRevisionID != flagCounts.ShadowRevisionID {
+			pending.ShadowRevisionID = flagCounts.ShadowRevisionID
+			pending.ShadowEvaluations = 0
+			pending.ShadowMismatches = 0
+		}
+		pending.ShadowEvaluations += flagCounts.ShadowEvaluations
+		pending.ShadowMismatches += flagCounts.ShadowMismatches
+	}
+}
@@ -0,0 +1,134 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryEvaluationCountsStore keeps every save, failing the first `failures` of them.
+type memoryEvaluationCountsStore struct {
+	mu       sync.Mutex
+	failures int
+	saves    []map[primitive.ObjectID]*models.EvaluationCounts
+}
+
+func (mecs *memoryEvaluationCountsStore) SaveEvaluationCounts(
+	_ context.Context,
+	counts map[primitive.ObjectID]*models.EvaluationCounts,
+) error {
+	mecs.mu.Lock()
+	defer mecs.mu.Unlock()
+
+	if mecs.failures > 0 {
+		mecs.failures--
+		return errors.New("database is down")
+	}
+
+	mecs.saves = append(mecs.saves, counts)
+	return nil
+}
+
+func TestEvaluationCountsWorkerCountsRuleMatchesPerFlag(t *testing.T) {
+	store := &memoryEvaluationCountsStore{}
+	worker := NewEvaluationCountsWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	checkout := primitive.NewObjectID()
+	search := primitive.NewObjectID()
+	proRule := primitive.NewObjectID()
+	betaRule := primitive.NewObjectID()
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), RuleID: proRule.Hex()})
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), RuleID: proRule.Hex()})
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), RuleID: betaRule.Hex()})
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex()})
+	worker.Record(analytics.Evaluation{FeatureFlagID: search.Hex()})
+	worker.Record(analytics.Evaluation{FeatureFlagID: "not an id", RuleID: proRule.Hex()})
+
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[primitive.ObjectID]*models.EvaluationCounts{{
+		checkout: {Evaluations: 4, RuleMatches: map[primitive.ObjectID]int64{proRule: 2, betaRule: 1}},
+		search:   {Evaluations: 1},
+	}}, store.saves)
+}
+
+func TestEvaluationCountsWorkerComparesShadowRevisions(t *testing.T) {
+	store := &memoryEvaluationCountsStore{}
+	worker := NewEvaluationCountsWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	checkout := primitive.NewObjectID()
+	draft := primitive.NewObjectID()
+	otherDraft := primitive.NewObjectID()
+
+	worker.Record(analytics.Evaluation{
+		FeatureFlagID:    checkout.Hex(),
+		Value:            "pix",
+		ShadowRevisionID: draft.Hex(),
+		ShadowValue:      "card",
+	})
+	worker.save(context.Background())
+
+	worker.Record(analytics.Evaluation{
+		FeatureFlagID:    checkout.Hex(),
+		Value:            "pix",
+		ShadowRevisionID: draft.Hex(),
+		ShadowValue:      "card",
+	})
+	// The shadow revision changed: counts of the previous one are dropped.
+	worker.Record(analytics.Evaluation{
+		FeatureFlagID:    checkout.Hex(),
+		Value:            "pix",
+		ShadowRevisionID: otherDraft.Hex(),
+		ShadowValue:      "pix",
+	})
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), Value: "pix"})
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[primitive.ObjectID]*models.EvaluationCounts{
+		{checkout: {Evaluations: 1, ShadowRevisionID: draft, ShadowEvaluations: 1, ShadowMismatches: 1}},
+		{checkout: {Evaluations: 3, ShadowRevisionID: otherDraft, ShadowEvaluations: 1}},
+	}, store.saves)
+}
+
+func TestEvaluationCountsWorkerRetriesFailedSaves(t *testing.T) {
+	store := &memoryEvaluationCountsStore{failures: 1}
+	worker := NewEvaluationCountsWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	checkout := primitive.NewObjectID()
+	proRule := primitive.NewObjectID()
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), RuleID: proRule.Hex()})
+	worker.save(context.Background())
+	assert.Empty(t, store.saves)
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), RuleID: proRule.Hex()})
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[primitive.ObjectID]*models.EvaluationCounts{{
+		checkout: {Evaluations: 2, RuleMatches: map[primitive.ObjectID]int64{proRule: 2}},
+	}}, store.saves)
+}
+
+func TestEvaluationCountsWorkerSavesWhenStopped(t *testing.T) {
+	store := &memoryEvaluationCountsStore{}
+	worker := NewEvaluationCountsWorker(store, zap.NewNop(), NewRegistry(), time.Hour)
+
+	checkout := primitive.NewObjectID()
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	worker.Run(ctx)
+
+	assert.Len(t, store.saves, 1)
+	assert.Contains(t, store.saves[0], checkout)
+}
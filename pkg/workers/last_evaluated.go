@@ -0,0 +1,112 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const LastEvaluatedWorkerName = "last-evaluated"
+
+// DefaultLastEvaluatedInterval is how often last evaluation times are saved.
+const DefaultLastEvaluatedInterval = time.Minute
+
+// LastEvaluatedFinalSaveTimeout bounds the save of what's left when the
+// worker stops.
+const LastEvaluatedFinalSaveTimeout = 10 * time.Second
+
+// LastEvaluatedStore saves the time each flag was last evaluated.
+type LastEvaluatedStore interface {
+	SaveLastEvaluated(ctx context.Context, lastEvaluated map[primitive.ObjectID]time.Time) error
+}
+
+// LastEvaluatedWorker is an analytics sink that remembers when each flag was
+// last evaluated and saves it every interval, so serving evaluations never
+// writes to the flags themselves.
+type LastEvaluatedWorker struct {
+	store     LastEvaluatedStore
+	logger    *zap.Logger
+	interval  time.Duration
+	heartbeat *Heartbeat
+
+	mu      sync.Mutex
+	pending map[primitive.ObjectID]time.Time
+}
+
+func NewLastEvaluatedWorker(
+	store LastEvaluatedStore,
+	logger *zap.Logger,
+	registry *Registry,
+	interval time.Duration,
+) *LastEvaluatedWorker {
+	return &LastEvaluatedWorker{
+		store:     store,
+		logger:    logger,
+		interval:  interval,
+		heartbeat: registry.Register(LastEvaluatedWorkerName, interval),
+		pending:   make(map[primitive.ObjectID]time.Time),
+	}
+}
+
+func (lew *LastEvaluatedWorker) Record(evaluation analytics.Evaluation) {
+	featureFlagID, err := primitive.ObjectIDFromHex(evaluation.FeatureFlagID)
+	if err != nil {
+		return
+	}
+
+	lew.merge(map[primitive.ObjectID]time.Time{featureFlagID: evaluation.EvaluatedAt})
+}
+
+// Run saves last evaluation times every interval until ctx is done, then
+// saves whatever is left.
+func (lew *LastEvaluatedWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(lew.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			saveCtx, cancel := context.WithTimeout(context.Background(), LastEvaluatedFinalSaveTimeout)
+			defer cancel()
+
+			lew.save(saveCtx)
+			return
+		case <-ticker.C:
+			lew.save(ctx)
+			lew.heartbeat.Beat()
+		}
+	}
+}
+
+// save hands the pending times to the store. They're kept for the next run
+// when it fails.
+func (lew *LastEvaluatedWorker) save(ctx context.Context) {
+	lew.mu.Lock()
+	pending := lew.pending
+	lew.pending = make(map[primitive.ObjectID]time.Time)
+	lew.mu.Unlock()
+
+	if err := lew.store.SaveLastEvaluated(ctx, pending); err != nil {
+		lew.logger.Error("Worker error",
+			zap.String("worker", LastEvaluatedWorkerName),
+			zap.String("cause", err.Error()),
+		)
+		lew.merge(pending)
+	}
+}
+
+// merge keeps the latest time of every flag.
+func (lew *LastEvaluatedWorker) merge(lastEvaluated map[primitive.ObjectID]time.Time) {
+	lew.mu.Lock()
+	defer lew.mu.Unlock()
+
+	for featureFlagID, evaluatedAt := range lastEvaluated {
+		if evaluatedAt.After(lew.pending[featureFlagID]) {
+			lew.pending[featureFlagID] = evaluatedAt
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryLastEvaluatedStore keeps every save, failing the first `failures` of them.
+type memoryLastEvaluatedStore struct {
+	mu       sync.Mutex
+	failures int
+	saves    []map[primitive.ObjectID]time.Time
+}
+
+func (mles *memoryLastEvaluatedStore) SaveLastEvaluated(
+	_ context.Context,
+	lastEvaluated map[primitive.ObjectID]time.Time,
+) error {
+	mles.mu.Lock()
+	defer mles.mu.Unlock()
+
+	if mles.failures > 0 {
+		mles.failures--
+		return errors.New("database is down")
+	}
+
+	mles.saves = append(mles.saves, lastEvaluated)
+	return nil
+}
+
+func TestLastEvaluatedWorkerKeepsLatestTimePerFlag(t *testing.T) {
+	store := &memoryLastEvaluatedStore{}
+	worker := NewLastEvaluatedWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	checkout := primitive.NewObjectID()
+	search := primitive.NewObjectID()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), EvaluatedAt: start.Add(time.Second)})
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), EvaluatedAt: start})
+	worker.Record(analytics.Evaluation{FeatureFlagID: search.Hex(), EvaluatedAt: start})
+	worker.Record(analytics.Evaluation{FeatureFlagID: "not an id", EvaluatedAt: start})
+
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[primitive.ObjectID]time.Time{{
+		checkout: start.Add(time.Second),
+		search:   start,
+	}}, store.saves)
+}
+
+func TestLastEvaluatedWorkerRetriesFailedSaves(t *testing.T) {
+	store := &memoryLastEvaluatedStore{failures: 1}
+	worker := NewLastEvaluatedWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	checkout := primitive.NewObjectID()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), EvaluatedAt: start})
+	worker.save(context.Background())
+	assert.Empty(t, store.saves)
+
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), EvaluatedAt: start.Add(-time.Second)})
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[primitive.ObjectID]time.Time{{checkout: start}}, store.saves)
+}
+
+func TestLastEvaluatedWorkerSavesWhenStopped(t *testing.T) {
+	store := &memoryLastEvaluatedStore{}
+	worker := NewLastEvaluatedWorker(store, zap.NewNop(), NewRegistry(), time.Hour)
+
+	checkout := primitive.NewObjectID()
+	worker.Record(analytics.Evaluation{FeatureFlagID: checkout.Hex(), EvaluatedAt: time.Now().UTC()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	worker.Run(ctx)
+
+	assert.Len(t, store.saves, 1)
+	assert.Contains(t, store.saves[0], checkout)
+}
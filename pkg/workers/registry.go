@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// StuckAfterIntervals is how many missed intervals make a worker count as stuck.
+const StuckAfterIntervals = 3
+
+// Status is a point-in-time view of a registered worker.
+type Status struct {
+	Name     string
+	Interval time.Duration
+	LastRun  time.Time
+	Stuck    bool
+}
+
+// Heartbeat is handed to a worker when it registers; the worker calls Beat
+// at the end of every run.
+type Heartbeat struct {
+	mu       sync.Mutex
+	name     string
+	interval time.Duration
+	lastRun  time.Time
+}
+
+func (h *Heartbeat) Beat() {
+	h.beatAt(time.Now().UTC())
+}
+
+func (h *Heartbeat) beatAt(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastRun = now
+}
+
+func (h *Heartbeat) status(now time.Time) Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return Status{
+		Name:     h.name,
+		Interval: h.interval,
+		LastRun:  h.lastRun,
+		Stuck:    now.Sub(h.lastRun) > StuckAfterIntervals*h.interval,
+	}
+}
+
+// Registry keeps track of the background workers running in the process.
+type Registry struct {
+	mu         sync.RWMutex
+	heartbeats map[string]*Heartbeat
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		heartbeats: make(map[string]*Heartbeat),
+	}
+}
+
+// Register adds a worker expected to run every interval. Registering counts
+// as a first beat so a worker isn't reported stuck before its first run.
+// Registering the same name twice returns the existing heartbeat.
+func (r *Registry) Register(name string, interval time.Duration) *Heartbeat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if heartbeat, ok := r.heartbeats[name]; ok {
+		return heartbeat
+	}
+
+	heartbeat := &Heartbeat{
+		name:     name,
+		interval: interval,
+		lastRun:  time.Now().UTC(),
+	}
+	r.heartbeats[name] = heartbeat
+
+	return heartbeat
+}
+
+// Statuses reports every registered worker sorted by name.
+func (r *Registry) Statuses(now time.Time) []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(r.heartbeats))
+	for _, heartbeat := range r.heartbeats {
+		statuses = append(statuses, heartbeat.status(now))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
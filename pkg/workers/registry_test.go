@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryReportsWorkersByName(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("webhook-retries", time.Minute)
+	registry.Register("expiry", time.Hour)
+
+	statuses := registry.Statuses(time.Now().UTC())
+
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "expiry", statuses[0].Name)
+	assert.Equal(t, time.Hour, statuses[0].Interval)
+	assert.Equal(t, "webhook-retries", statuses[1].Name)
+	assert.False(t, statuses[0].Stuck)
+	assert.False(t, statuses[1].Stuck)
+}
+
+func TestRegistryDetectsStuckWorkers(t *testing.T) {
+	registry := NewRegistry()
+	heartbeat := registry.Register("scheduler", time.Minute)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	heartbeat.beatAt(start)
+
+	status := registry.Statuses(start.Add(StuckAfterIntervals * time.Minute))[0]
+	assert.Equal(t, start, status.LastRun)
+	assert.False(t, status.Stuck)
+
+	status = registry.Statuses(start.Add(StuckAfterIntervals*time.Minute + time.Second))[0]
+	assert.True(t, status.Stuck)
+
+	heartbeat.beatAt(start.Add(4 * time.Minute))
+	status = registry.Statuses(start.Add(5 * time.Minute))[0]
+	assert.False(t, status.Stuck)
+}
+
+func TestRegisterTwiceReturnsSameHeartbeat(t *testing.T) {
+	registry := NewRegistry()
+
+	first := registry.Register("scheduler", time.Minute)
+	second := registry.Register("scheduler", time.Hour)
+
+	assert.Same(t, first, second)
+	assert.Len(t, registry.Statuses(time.Now().UTC()), 1)
+}
@@ -0,0 +1,84 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/relay"
+	"go.uber.org/zap"
+)
+
+const RelaySyncWorkerName = "relay-sync"
+
+// RelaySyncWorker keeps a relay's flags in step with the central server. It
+// only beats after a successful sync, so a relay that keeps failing to reach
+// the central server shows up stuck in the workers status instead of quietly
+// serving stale flags.
+type RelaySyncWorker struct {
+	client    *relay.Client
+	logger    *zap.Logger
+	interval  time.Duration
+	heartbeat *Heartbeat
+	trigger   chan struct{}
+}
+
+func NewRelaySyncWorker(
+	client *relay.Client,
+	logger *zap.Logger,
+	registry *Registry,
+	interval time.Duration,
+) *RelaySyncWorker {
+	return &RelaySyncWorker{
+		client:    client,
+		logger:    logger,
+		interval:  interval,
+		heartbeat: registry.Register(RelaySyncWorkerName, interval),
+		trigger:   make(chan struct{}, 1),
+	}
+}
+
+// Trigger asks for a sync before the next tick, e.g. when the central server
+// announces a flag change. Triggers arriving while one is pending are merged.
+func (rsw *RelaySyncWorker) Trigger() {
+	select {
+	case rsw.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run syncs right away, so the relay can serve as soon as possible, and then
+// every interval or when triggered until ctx is done.
+func (rsw *RelaySyncWorker) Run(ctx context.Context) {
+	rsw.sync(ctx)
+
+	ticker := time.NewTicker(rsw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rsw.sync(ctx)
+		case <-rsw.trigger:
+			rsw.sync(ctx)
+		}
+	}
+}
+
+func (rsw *RelaySyncWorker) sync(ctx context.Context) {
+	changed, err := rsw.client.Sync(ctx)
+	if err != nil {
+		rsw.logger.Error("Worker error",
+			zap.String("worker", RelaySyncWorkerName),
+			zap.String("cause", err.Error()),
+		)
+		return
+	}
+	if changed {
+		rsw.logger.Info("Relay synced flags",
+			zap.String("worker", RelaySyncWorkerName),
+		)
+	}
+	rsw.heartbeat.Beat()
+}
@@ -0,0 +1,39 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/relay"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRelaySyncWorkerOnlyBeatsOnSuccess(t *testing.T) {
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []any{}})
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	client := relay.NewClient(server.Client(), server.URL, "tgl_relay", relay.NewStore())
+	worker := NewRelaySyncWorker(client, zap.NewNop(), registry, time.Minute)
+	registeredAt := worker.heartbeat.status(time.Now()).LastRun
+
+	worker.sync(context.Background())
+	assert.Equal(t, registeredAt, worker.heartbeat.status(time.Now()).LastRun)
+	assert.True(t, worker.heartbeat.status(registeredAt.Add(time.Hour)).Stuck)
+
+	failing = false
+	worker.sync(context.Background())
+	assert.True(t, worker.heartbeat.status(time.Now()).LastRun.After(registeredAt))
+}
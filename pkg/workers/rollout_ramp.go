@@ -0,0 +1,110 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const RolloutRampWorkerName = "rollout-ramp"
+
+// DefaultRolloutRampInterval is how often due ramp steps are looked for.
+const DefaultRolloutRampInterval = 30 * time.Second
+
+// RolloutRampWorker raises the percentage of ramped rollouts as their steps
+// come due and records every step in the audit log. Ramps of organizations
+// whose automation is paused are left alone.
+type RolloutRampWorker struct {
+	db        *mongo.Database
+	logger    *zap.Logger
+	interval  time.Duration
+	heartbeat *Heartbeat
+}
+
+func NewRolloutRampWorker(
+	db *mongo.Database,
+	logger *zap.Logger,
+	registry *Registry,
+	interval time.Duration,
+) *RolloutRampWorker {
+	return &RolloutRampWorker{
+		db:        db,
+		logger:    logger,
+		interval:  interval,
+		heartbeat: registry.Register(RolloutRampWorkerName, interval),
+	}
+}
+
+// Run advances due ramps every interval until ctx is done.
+func (rrw *RolloutRampWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(rrw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := rrw.advance(ctx, now.UTC()); err != nil {
+				rrw.logger.Error("Worker error",
+					zap.String("worker", RolloutRampWorkerName),
+					zap.String("cause", err.Error()),
+				)
+			}
+			rrw.heartbeat.Beat()
+		}
+	}
+}
+
+func (rrw *RolloutRampWorker) advance(ctx context.Context, now time.Time) error {
+	featureFlagModel := models.NewFeatureFlagModel(rrw.db)
+	auditLogModel := models.NewAuditLogModel(rrw.db)
+
+	paused, err := models.NewOrganizationModel(rrw.db).FindAutomationPausedIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	featureFlags, err := featureFlagModel.FindDueRolloutRamps(ctx, now, paused)
+	if err != nil {
+		return err
+	}
+
+	for _, featureFlag := range featureFlags {
+		rollout := featureFlag.Rollout
+		previousStepAt := rollout.Ramp.NextStepAt
+		previousPercentage := rollout.Percentage
+		if !rollout.AdvanceRamp(now) {
+			continue
+		}
+
+		saved, err := featureFlagModel.SaveRolloutStep(ctx, featureFlag.ID, previousStepAt, rollout)
+		if err != nil {
+			return err
+		}
+		if !saved {
+			continue
+		}
+
+		_, err = auditLogModel.InsertOne(ctx, models.NewAuditEntry(
+			featureFlag.OrganizationID,
+			featureFlag.ID,
+			primitive.NilObjectID,
+			models.RolloutRampStepAction,
+			map[string]any{
+				"from":     previousPercentage,
+				"to":       rollout.Percentage,
+				"complete": rollout.Ramp == nil,
+			},
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const UsageWorkerName = "usage"
+
+// DefaultUsageInterval is how often usage counts are saved.
+const DefaultUsageInterval = time.Minute
+
+// UsageFinalSaveTimeout bounds the save of what's left when the worker stops.
+const UsageFinalSaveTimeout = 10 * time.Second
+
+// UsageStore adds counts to the usage records of each organization.
+type UsageStore interface {
+	SaveUsage(ctx context.Context, counts map[models.UsageKey]*models.UsageCounts) error
+}
+
+// UsageWorker counts, per organization and bucket, the API calls it serves
+// and the evaluations it's served, and saves the counts every interval. It's
+// both an analytics sink and the recorder of middlewares.UsageMiddleware.
+type UsageWorker struct {
+	store     UsageStore
+	logger    *zap.Logger
+	interval  time.Duration
+	heartbeat *Heartbeat
+
+	mu      sync.Mutex
+	pending map[models.UsageKey]*models.UsageCounts
+}
+
+func NewUsageWorker(
+	store UsageStore,
+	logger *zap.Logger,
+	registry *Registry,
+	interval time.Duration,
+) *UsageWorker {
+	return &UsageWorker{
+		store:     store,
+		logger:    logger,
+		interval:  interval,
+		heartbeat: registry.Register(UsageWorkerName, interval),
+		pending:   make(map[models.UsageKey]*models.UsageCounts),
+	}
+}
+
+func (uw *UsageWorker) Record(evaluation analytics.Evaluation) {
+	organizationID, err := primitive.ObjectIDFromHex(evaluation.OrganizationID)
+	if err != nil {
+		return
+	}
+
+	evaluatedAt := evaluation.EvaluatedAt
+	if evaluatedAt.IsZero() {
+		evaluatedAt = time.Now()
+	}
+
+	uw.merge(map[models.UsageKey]*models.UsageCounts{
+		{OrganizationID: organizationID, Bucket: models.UsageBucket(evaluatedAt)}: {Evaluations: 1},
+	})
+}
+
+// RecordRequest counts a call to the organization's API answered with
+// status after latency.
+func (uw *UsageWorker) RecordRequest(organizationID primitive.ObjectID, status int, latency time.Duration, at time.Time) {
+	counts := &models.UsageCounts{
+		Requests:         1,
+		LatencyMillis:    latency.Milliseconds(),
+		MaxLatencyMillis: latency.Milliseconds(),
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		counts.ServerErrors = 1
+	case status >= http.StatusBadRequest:
+		counts.ClientErrors = 1
+	}
+
+	uw.merge(map[models.UsageKey]*models.UsageCounts{
+		{OrganizationID: organizationID, Bucket: models.UsageBucket(at)}: counts,
+	})
+}
+
+// Run saves usage counts every interval until ctx is done, then saves
+// whatever is left.
+func (uw *UsageWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(uw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			saveCtx, cancel := context.WithTimeout(context.Background(), UsageFinalSaveTimeout)
+			defer cancel()
+
+			uw.save(saveCtx)
+			return
+		case <-ticker.C:
+			uw.save(ctx)
+			uw.heartbeat.Beat()
+		}
+	}
+}
+
+// save hands the pending counts to the store. They're added back for the next
+// run when it fails.
+func (uw *UsageWorker) save(ctx context.Context) {
+	uw.mu.Lock()
+	pending := uw.pending
+	uw.pending = make(map[models.UsageKey]*models.UsageCounts)
+	uw.mu.Unlock()
+
+	if err := uw.store.SaveUsage(ctx, pending); err != nil {
+		uw.logger.Error("Worker error",
+			zap.String("worker", UsageWorkerName),
+			zap.String("cause", err.Error()),
+		)
+		uw.merge(pending)
+	}
+}
+
+func (uw *UsageWorker) merge(counts map[models.UsageKey]*models.UsageCounts) {
+	uw.mu.Lock()
+	defer uw.mu.Unlock()
+
+	for key, bucketCounts := range counts {
+		pending, ok := uw.pending[key]
+		if !ok {
+			pending = &models.UsageCounts{}
+			uw.pending[key] = pending
+		}
+		pending.Add(*bucketCounts)
+	}
+}
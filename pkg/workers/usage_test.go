@@ -0,0 +1,100 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Roll-Play/togglelabs/pkg/analytics"
+	"github.com/Roll-Play/togglelabs/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryUsageStore keeps every save, failing the first `failures` of them.
+type memoryUsageStore struct {
+	mu       sync.Mutex
+	failures int
+	saves    []map[models.UsageKey]*models.UsageCounts
+}
+
+func (mus *memoryUsageStore) SaveUsage(_ context.Context, counts map[models.UsageKey]*models.UsageCounts) error {
+	mus.mu.Lock()
+	defer mus.mu.Unlock()
+
+	if mus.failures > 0 {
+		mus.failures--
+		return errors.New("database is down")
+	}
+
+	mus.saves = append(mus.saves, counts)
+	return nil
+}
+
+func TestUsageWorkerCountsPerOrganizationAndBucket(t *testing.T) {
+	store := &memoryUsageStore{}
+	worker := NewUsageWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	acme := primitive.NewObjectID()
+	globex := primitive.NewObjectID()
+	morning := time.Date(2024, 5, 1, 9, 15, 0, 0, time.UTC)
+	later := morning.Add(30 * time.Minute)
+	nextHour := morning.Add(time.Hour)
+
+	worker.RecordRequest(acme, http.StatusOK, 20*time.Millisecond, morning)
+	worker.RecordRequest(acme, http.StatusNotFound, 80*time.Millisecond, later)
+	worker.RecordRequest(acme, http.StatusServiceUnavailable, 5*time.Millisecond, nextHour)
+	worker.RecordRequest(globex, http.StatusCreated, 10*time.Millisecond, morning)
+	worker.Record(analytics.Evaluation{OrganizationID: acme.Hex(), EvaluatedAt: morning})
+	worker.Record(analytics.Evaluation{OrganizationID: acme.Hex(), EvaluatedAt: later})
+	worker.Record(analytics.Evaluation{OrganizationID: "not an id", EvaluatedAt: later})
+
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[models.UsageKey]*models.UsageCounts{{
+		{OrganizationID: acme, Bucket: models.UsageBucket(morning)}: {
+			Requests:         2,
+			ClientErrors:     1,
+			LatencyMillis:    100,
+			MaxLatencyMillis: 80,
+			Evaluations:      2,
+		},
+		{OrganizationID: acme, Bucket: models.UsageBucket(nextHour)}: {
+			Requests:         1,
+			ServerErrors:     1,
+			LatencyMillis:    5,
+			MaxLatencyMillis: 5,
+		},
+		{OrganizationID: globex, Bucket: models.UsageBucket(morning)}: {
+			Requests:         1,
+			LatencyMillis:    10,
+			MaxLatencyMillis: 10,
+		},
+	}}, store.saves)
+}
+
+func TestUsageWorkerRetriesFailedSaves(t *testing.T) {
+	store := &memoryUsageStore{failures: 1}
+	worker := NewUsageWorker(store, zap.NewNop(), NewRegistry(), time.Minute)
+
+	acme := primitive.NewObjectID()
+	at := time.Date(2024, 5, 1, 9, 15, 0, 0, time.UTC)
+	worker.RecordRequest(acme, http.StatusOK, 20*time.Millisecond, at)
+	worker.save(context.Background())
+	assert.Empty(t, store.saves)
+
+	worker.RecordRequest(acme, http.StatusOK, 40*time.Millisecond, at)
+	worker.save(context.Background())
+
+	assert.Equal(t, []map[models.UsageKey]*models.UsageCounts{{
+		{OrganizationID: acme, Bucket: models.UsageBucket(at)}: {
+			Requests:         2,
+			LatencyMillis:    60,
+			MaxLatencyMillis: 40,
+		},
+	}}, store.saves)
+}